@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// apiSignature renders an exported function's signature for api_fingerprint:
+// parameter names are dropped (renaming is not a break) and the receiver is
+// kept so value↔pointer receiver changes show up, e.g.
+// "(*Store) func(string) (int, bool)".
+func apiSignature(sig *types.Signature, recv string) string {
+	var b strings.Builder
+	if recv != "" {
+		b.WriteString("(" + recv + ") ")
+	}
+	b.WriteString("func")
+	if tps := sig.TypeParams(); tps != nil && tps.Len() > 0 {
+		parts := make([]string, tps.Len())
+		for i := range tps.Len() {
+			parts[i] = tps.At(i).Obj().Name() + " " + tps.At(i).Constraint().String()
+		}
+		b.WriteString("[" + strings.Join(parts, ", ") + "]")
+	}
+	b.WriteString("(" + strings.Join(tupleTypes(sig.Params(), sig.Variadic()), ", ") + ")")
+	switch results := tupleTypes(sig.Results(), false); len(results) {
+	case 0:
+	case 1:
+		b.WriteString(" " + results[0])
+	default:
+		b.WriteString(" (" + strings.Join(results, ", ") + ")")
+	}
+	return b.String()
+}
+
+// APIChange is one difference between the api_fingerprint tables of two CPGs.
+type APIChange struct {
+	Package  string
+	Symbol   string
+	Kind     string // function, method, type, field
+	Change   string // added, removed, changed
+	Old, New string // signatures ("" when absent)
+	Breaking bool
+}
+
+// DiffAPI compares the exported API of two CPG databases. Removed symbols and
+// changed signatures are breaking; additions are not.
+func DiffAPI(oldPath, newPath string) ([]APIChange, error) {
+	conn, err := sqlite.OpenConn(newPath, sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", newPath, err)
+	}
+	defer conn.Close()
+	if err := sqlitex.ExecuteTransient(conn, "ATTACH DATABASE ? AS old",
+		&sqlitex.ExecOptions{Args: []any{oldPath}}); err != nil {
+		return nil, fmt.Errorf("attach %s: %w", oldPath, err)
+	}
+
+	var changes []APIChange
+	err = sqlitex.Execute(conn, `
+SELECT o.package, o.symbol, o.kind, 'removed', o.signature, ''
+FROM old.api_fingerprint o
+LEFT JOIN main.api_fingerprint n ON n.package = o.package AND n.symbol = o.symbol
+WHERE n.symbol IS NULL
+UNION ALL
+SELECT o.package, o.symbol, o.kind, 'changed', o.signature, n.signature
+FROM old.api_fingerprint o
+JOIN main.api_fingerprint n ON n.package = o.package AND n.symbol = o.symbol
+WHERE n.hash != o.hash
+UNION ALL
+SELECT n.package, n.symbol, n.kind, 'added', '', n.signature
+FROM main.api_fingerprint n
+LEFT JOIN old.api_fingerprint o ON o.package = n.package AND o.symbol = n.symbol
+WHERE o.symbol IS NULL
+ORDER BY 1, 2`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			c := APIChange{
+				Package: stmt.ColumnText(0),
+				Symbol:  stmt.ColumnText(1),
+				Kind:    stmt.ColumnText(2),
+				Change:  stmt.ColumnText(3),
+				Old:     stmt.ColumnText(4),
+				New:     stmt.ColumnText(5),
+			}
+			c.Breaking = c.Change != "added"
+			changes = append(changes, c)
+			return nil
+		}})
+	if err != nil {
+		return nil, fmt.Errorf("api diff: %w", err)
+	}
+	return changes, nil
+}
+
+// runAPIDiff implements `cpg-gen api-diff <old.db> <new.db>`. It prints one
+// line per change and fails when any change is breaking.
+func runAPIDiff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: cpg-gen api-diff <old.db> <new.db>")
+	}
+	changes, err := DiffAPI(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	breaks := 0
+	for _, c := range changes {
+		mark := " "
+		if c.Breaking {
+			mark = "!"
+			breaks++
+		}
+		switch c.Change {
+		case "changed":
+			fmt.Printf("%s %-7s %s.%s: %s -> %s\n", mark, c.Change, c.Package, c.Symbol, c.Old, c.New)
+		case "removed":
+			fmt.Printf("%s %-7s %s.%s: %s\n", mark, c.Change, c.Package, c.Symbol, c.Old)
+		default:
+			fmt.Printf("%s %-7s %s.%s: %s\n", mark, c.Change, c.Package, c.Symbol, c.New)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d API changes, %d breaking\n", len(changes), breaks)
+	if breaks > 0 {
+		return fmt.Errorf("%d breaking API changes", breaks)
+	}
+	return nil
+}
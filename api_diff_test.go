@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const apiFixtureV1 = `package fixture
+
+type Config struct {
+	Name    string
+	Timeout int
+	secret  string
+}
+
+func Open(name string) (*Config, error) { helper(); return &Config{Name: name}, nil }
+
+func (c *Config) Validate() error { return nil }
+
+func helper() {}
+`
+
+func TestAPIDiffParameterAddedIsBreaking(t *testing.T) {
+	oldDB := buildTestDBFile(t, apiFixtureV1)
+	v2 := strings.Replace(apiFixtureV1, "func Open(name string)", "func Open(name string, strict bool)", 1)
+	v2 = strings.Replace(v2, "\tTimeout int\n", "\tTimeout int\n\tRetries int\n", 1)
+	newDB := buildTestDBFile(t, v2)
+
+	changes, err := DiffAPI(oldDB, newDB)
+	if err != nil {
+		t.Fatalf("DiffAPI: %v", err)
+	}
+	got := make(map[string]APIChange)
+	for _, c := range changes {
+		got[c.Symbol] = c
+	}
+	open, ok := got["Open"]
+	if !ok || open.Change != "changed" || !open.Breaking {
+		t.Fatalf("Open change = %+v, want breaking change; all changes: %+v", open, changes)
+	}
+	if open.Old != "func(string) (*example.com/fixture.Config, error)" ||
+		open.New != "func(string, bool) (*example.com/fixture.Config, error)" {
+		t.Errorf("Open signatures = %q -> %q", open.Old, open.New)
+	}
+	if r, ok := got["Config.Retries"]; !ok || r.Change != "added" || r.Breaking {
+		t.Errorf("Config.Retries change = %+v, want non-breaking addition", r)
+	}
+	if len(changes) != 2 {
+		t.Errorf("changes = %+v, want only Open and Config.Retries", changes)
+	}
+}
+
+func TestAPIFingerprintSkipsUnexported(t *testing.T) {
+	conn := buildTestDB(t, apiFixtureV1)
+	got := queryStrings(t, conn, `SELECT kind || ' ' || symbol FROM api_fingerprint ORDER BY symbol`)
+	want := []string{"type Config", "field Config.Name", "field Config.Timeout", "method Config.Validate", "function Open"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("api_fingerprint = %v, want %v", got, want)
+	}
+}
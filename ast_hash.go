@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"hash/fnv"
+)
+
+// astHash fingerprints a function's structure for clone detection: a hash of
+// its signature and body with identifiers declared inside the function
+// (receiver, parameters, locals, labels) alpha-renamed by first appearance and
+// literals reduced to their kind. Functions that differ only in local names or
+// constants hash alike; anything else (an operator, a callee, a field, a type,
+// the statement shape) changes the hash. Returns "" for bodiless functions.
+func astHash(info *types.Info, fn ast.Node, ftype *ast.FuncType, body *ast.BlockStmt) string {
+	if body == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	locals := map[types.Object]int{}
+	local := func(obj types.Object) bool {
+		if _, isPkg := obj.(*types.PkgName); isPkg {
+			return false
+		}
+		return obj.Pos() >= fn.Pos() && obj.Pos() < fn.End()
+	}
+	// ast.Inspect reports the end of each node's children with a nil call,
+	// so the hash captures nesting and not just the pre-order sequence.
+	visit := func(n ast.Node) bool {
+		if n == nil {
+			h.Write([]byte{')'})
+			return false
+		}
+		fmt.Fprintf(h, "(%T", n)
+		switch n := n.(type) {
+		case *ast.Ident:
+			obj := info.Defs[n]
+			if obj == nil {
+				obj = info.Uses[n]
+			}
+			if obj != nil && local(obj) {
+				idx, ok := locals[obj]
+				if !ok {
+					idx = len(locals)
+					locals[obj] = idx
+				}
+				fmt.Fprintf(h, " $%d", idx)
+			} else {
+				h.Write([]byte(" " + n.Name))
+			}
+		case *ast.BasicLit:
+			h.Write([]byte(" " + n.Kind.String()))
+		case *ast.BinaryExpr:
+			h.Write([]byte(" " + n.Op.String()))
+		case *ast.UnaryExpr:
+			h.Write([]byte(" " + n.Op.String()))
+		case *ast.AssignStmt:
+			h.Write([]byte(" " + n.Tok.String()))
+		case *ast.IncDecStmt:
+			h.Write([]byte(" " + n.Tok.String()))
+		case *ast.BranchStmt:
+			h.Write([]byte(" " + n.Tok.String()))
+		case *ast.RangeStmt:
+			h.Write([]byte(" " + n.Tok.String()))
+		case *ast.ChanType:
+			fmt.Fprintf(h, " %d", n.Dir)
+		case *ast.GenDecl:
+			h.Write([]byte(" " + n.Tok.String()))
+		}
+		return true
+	}
+	ast.Inspect(ftype, visit)
+	ast.Inspect(body, visit)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -3,11 +3,16 @@ package main
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 
+	"golang.org/x/tools/go/cfg"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -80,9 +85,11 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 	posLookup := NewPosLookup()
 	funcLookup := NewFuncLookup()
 	defLookup := NewDefLookup()
+	var onceGuards []onceGuard
 
 	var nodeCount, edgeCount int
-	var skippedFiles int
+	var skippedFiles, oversizedFiles int
+	ranks := initRanks(pkgs)
 
 	for _, pkg := range pkgs {
 		relPkg := modSet.RelPkg(pkg.PkgPath)
@@ -90,19 +97,29 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 		// Create package node
 		pkgID := PkgID(pkg.PkgPath)
 		cpg.AddNode(Node{
-			ID:      pkgID,
-			Kind:    "package",
-			Name:    pkg.Name,
-			Package: relPkg,
+			ID:         pkgID,
+			Kind:       "package",
+			Name:       pkg.Name,
+			Package:    relPkg,
+			Properties: map[string]any{"init_rank": ranks[pkg.PkgPath]},
 		})
 		nodeCount++
 
-		// Import edges: package → imported package (internal modules only)
-		for impPath := range pkg.Imports {
+		// Import edges: package → imported package (internal modules only).
+		// An analyzed import is initialized first: imported init_before
+		// importer.
+		// Keyed by the path as written; a vendored import's PkgPath may
+		// differ, so link by the resolved package.
+		for _, imp := range pkg.Imports {
+			impPath := imp.PkgPath
 			if modSet.IsKnownPkg(impPath) {
 				cpg.AddEdge(Edge{Source: pkgID, Target: PkgID(impPath), Kind: "imports"})
 				edgeCount++
 			}
+			if _, ok := ranks[impPath]; ok {
+				cpg.AddEdge(Edge{Source: PkgID(impPath), Target: pkgID, Kind: "init_before"})
+				edgeCount++
+			}
 		}
 
 		var initFuncIDs []string // collect init() funcs for ordering
@@ -145,10 +162,25 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 					}
 				}
 			}
+			// Language version from the module's go directive (or a //go:build
+			// go1.N downgrade): it decides per-iteration loop variables
+			if ver := pkg.TypesInfo.FileVersions[file]; ver != "" {
+				fileProps["go_version"] = ver
+			} else if pkg.Module != nil && pkg.Module.GoVersion != "" {
+				fileProps["go_version"] = "go" + pkg.Module.GoVersion
+			}
 			// Compute actual LOC from file end position
 			if file.End().IsValid() {
 				fileProps["loc"] = fset.Position(file.End()).Line
 			}
+			// -max-file-size: huge (usually generated) files keep only their
+			// file node, without source text or anything below it
+			oversized := false
+			if tf := fset.File(file.Pos()); flagMaxFileSize > 0 && tf != nil && int64(tf.Size()) > flagMaxFileSize {
+				oversized = true
+				fileProps["oversized"] = true
+				fileProps["size"] = tf.Size()
+			}
 			cpg.AddNode(Node{
 				ID:         fileID,
 				Kind:       "file",
@@ -156,11 +188,16 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 				File:       relFile,
 				Package:    relPkg,
 				EndLine:    fset.Position(file.End()).Line,
+				EndCol:     fset.Position(file.End()).Column,
 				Properties: fileProps,
 			})
 			nodeCount++
 			cpg.AddEdge(Edge{Source: pkgID, Target: fileID, Kind: "ast"})
 			edgeCount++
+			if oversized {
+				oversizedFiles++
+				continue
+			}
 
 			// Read source content for the sources table
 			if _, ok := cpg.Sources[relFile]; !ok {
@@ -184,34 +221,52 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 				source:      cpg.Sources[relFile],
 				parentStack: []string{fileID},
 				initIDs:     &initFuncIDs,
+				onceGuards:  &onceGuards,
 				scopeNodes:  make(map[string]bool),
+				writeIdents: make(map[*ast.Ident]bool),
+				storeSels:   make(map[*ast.SelectorExpr]bool),
+				unreachable: make(map[token.Pos]bool),
+				docGroups:   make(map[*ast.CommentGroup]bool),
+			}
+			if file.Doc != nil {
+				v.docGroups[file.Doc] = true
 			}
 			ast.Walk(v, file)
 
 			// Extract comments (not visited by ast.Walk — they're separate)
-			for _, cg := range file.Comments {
-				cLine, cCol := v.pos(cg.Pos())
-				if cLine == 0 {
-					continue
-				}
-				cID := StmtID(relPkg, BaseName(relFile), cLine, cCol, "comment")
-				text := cg.Text()
-				if len(text) > 200 {
-					text = text[:200] + "..."
+			if flagEmitComments {
+				for _, cg := range file.Comments {
+					cLine, cCol := v.pos(cg.Pos())
+					if cLine == 0 {
+						continue
+					}
+					cID := StmtID(relPkg, BaseName(relFile), cLine, cCol, "comment")
+					text := cg.Text()
+					if len(text) > 200 {
+						text = text[:200] + "..."
+					}
+					props := map[string]any{
+						"comment_classification": classifyComment(cg, v.docGroups[cg], cg.Pos() < file.Package),
+					}
+					if cats := suppressedCategories(cg); len(cats) > 0 {
+						props["suppresses"] = cats
+					}
+					cpg.AddNode(Node{
+						ID:         cID,
+						Kind:       "comment",
+						Name:       text,
+						File:       relFile,
+						Line:       cLine,
+						Col:        cCol,
+						EndLine:    v.endLine(cg.End()),
+						EndCol:     v.endCol(cg.End()),
+						Package:    relPkg,
+						Properties: props,
+					})
+					cpg.AddEdge(Edge{Source: fileID, Target: cID, Kind: "ast"})
+					nodeCount += 1
+					edgeCount += 1
 				}
-				cpg.AddNode(Node{
-					ID:      cID,
-					Kind:    "comment",
-					Name:    text,
-					File:    relFile,
-					Line:    cLine,
-					Col:     cCol,
-					EndLine: v.endLine(cg.End()),
-					Package: relPkg,
-				})
-				cpg.AddEdge(Edge{Source: fileID, Target: cID, Kind: "ast"})
-				nodeCount += 1
-				edgeCount += 1
 			}
 
 			nodeCount += v.nodeCount
@@ -232,8 +287,16 @@ func WalkAST(pkgs []*packages.Package, fset *token.FileSet, cpg *CPG, prog *Prog
 	// Done after all packages are walked so defLookup is fully populated.
 	hmCount := emitHasMethodEdges(pkgs, fset, defLookup, cpg)
 
-	prog.Log("Created %d nodes, %d AST edges, %d has_method edges (skipped %d generated/test files)",
-		nodeCount, edgeCount, hmCount, skippedFiles)
+	// once_guarded edges to named functions, declared anywhere in the module
+	for _, g := range onceGuards {
+		if fnID := defLookup.Get(g.fn); fnID != "" {
+			cpg.AddEdge(Edge{Source: g.callID, Target: fnID, Kind: "once_guarded"})
+			edgeCount++
+		}
+	}
+
+	prog.Log("Created %d nodes, %d AST edges, %d has_method edges (skipped %d generated/test files, %d oversized files)",
+		nodeCount, edgeCount, hmCount, skippedFiles, oversizedFiles)
 
 	return posLookup, funcLookup
 }
@@ -254,10 +317,49 @@ type astVisitor struct {
 	parentStack []string
 	// curFunc tracks the enclosing function node ID for parent_function field.
 	curFunc string
+	// curRecv and curMethod track the receiver object and node ID of the
+	// enclosing method declaration (nil/"" outside methods) for field_write edges.
+	curRecv   types.Object
+	curMethod string
+	// curResults and resultVars hold the enclosing function's result node
+	// IDs and named result objects in order; resultAssign maps each named
+	// result to its latest assignment so far, which a naked return hands back.
+	curResults   []string
+	resultVars   []types.Object
+	resultAssign map[types.Object]string
+	// writeIdents holds root identifiers of assignment/inc-dec/delete targets
+	// so visitIdent can tag those uses as writes.
+	writeIdents map[*ast.Ident]bool
+	// storeSels holds selectors that are plain assignment targets (x.f = v):
+	// the field is stored, not read, so no field_read dfg edge reaches them.
+	storeSels map[*ast.SelectorExpr]bool
+	// appendTargets maps append calls that are the single right-hand side
+	// of an assignment to the source text of their target ("_" for blank).
+	appendTargets map[*ast.CallExpr]string
+	// commaOkAsserts holds type assertions that cannot panic: the single
+	// value of a v, ok := x.(T) assignment or declaration.
+	commaOkAsserts map[*ast.TypeAssertExpr]bool
+	// selectComms holds the send statements and receive expressions that
+	// are the communication of a select case, which never blocks alone.
+	selectComms map[ast.Node]bool
+	// deadCases holds the dead_case properties of case clauses that an
+	// earlier case of their switch always pre-empts (deadSwitchCases).
+	deadCases map[*ast.CaseClause]map[string]any
+	// unreachable holds the positions of the first statement of each dead
+	// region found by markUnreachable; pendingUnreachable carries the line of
+	// such a statement until the node that represents it is emitted.
+	unreachable        map[token.Pos]bool
+	pendingUnreachable int
 	// deferIDs collects defer node IDs in source order for LIFO ordering edges.
 	deferIDs []string
 	// initIDs collects init() function node IDs for ordering.
 	initIDs *[]string
+	// docGroups holds the comment groups that document a declaration (or
+	// the package), for comment_classification.
+	docGroups map[*ast.CommentGroup]bool
+	// onceGuards collects Once.Do calls passed a named function or method
+	// value, linked once every declaration has an ID.
+	onceGuards *[]onceGuard
 	// scopeNodes tracks node IDs that introduce a new lexical scope (functions and blocks).
 	scopeNodes map[string]bool
 	nodeCount  int
@@ -287,6 +389,16 @@ func (v *astVisitor) addNodeAndEdge(n Node) {
 		}
 	}
 
+	if v.pendingUnreachable != 0 {
+		if n.Line == v.pendingUnreachable {
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["unreachable"] = true
+		}
+		v.pendingUnreachable = 0
+	}
+
 	v.cpg.AddNode(n)
 	v.nodeCount++
 
@@ -302,9 +414,10 @@ func (v *astVisitor) addNodeAndEdge(n Node) {
 
 // emitDocEdge emits a doc edge from a declaration node to its doc comment node.
 func (v *astVisitor) emitDocEdge(declID string, doc *ast.CommentGroup) {
-	if doc == nil {
+	if doc == nil || !flagEmitComments {
 		return
 	}
+	v.docGroups[doc] = true
 	cLine, cCol := v.pos(doc.Pos())
 	if cLine == 0 {
 		return
@@ -329,6 +442,14 @@ func (v *astVisitor) endLine(end token.Pos) int {
 	return v.fset.Position(end).Line
 }
 
+// endCol is the column just past a node (exclusive), as go/token reports End.
+func (v *astVisitor) endCol(end token.Pos) int {
+	if !end.IsValid() {
+		return 0
+	}
+	return v.fset.Position(end).Column
+}
+
 func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 	if node == nil {
 		// Popping back up — restore parent
@@ -338,6 +459,10 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 		return nil
 	}
 
+	if stmt, ok := node.(ast.Stmt); ok && v.unreachable[stmt.Pos()] {
+		v.pendingUnreachable, _ = v.pos(stmt.Pos())
+	}
+
 	switch n := node.(type) {
 	case *ast.FuncDecl:
 		return v.visitFuncDecl(n)
@@ -347,32 +472,61 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 		id := v.visitCallExpr(n)
 		v.parentStack = append(v.parentStack, id)
 	case *ast.IfStmt:
-		v.visitStmtWithCode(n.If, v.endLine(n.End()), "if", "if", n.Pos(), n.Body.Lbrace)
+		v.visitStmtWithCode(n.If, n.End(), "if", "if", n.Pos(), n.Body.Lbrace)
 		v.emitConditionEdge("if", n.If, n.Cond)
 	case *ast.ForStmt:
-		v.visitStmtWithCode(n.For, v.endLine(n.End()), "for", "for", n.Pos(), n.Body.Lbrace)
+		v.visitStmtWithCode(n.For, n.End(), "for", "for", n.Pos(), n.Body.Lbrace)
 		v.emitConditionEdge("for", n.For, n.Cond)
 	case *ast.RangeStmt:
-		v.visitStmtWithCode(n.Range, v.endLine(n.End()), "for", "range", n.Pos(), n.Body.Lbrace)
+		var props map[string]any
+		if code := v.codeSnippet(n.Pos(), n.Body.Lbrace, 120); code != "" {
+			props = map[string]any{"code": code}
+		}
+		if tv, ok := v.pkg.TypesInfo.Types[n.X]; ok {
+			if over := rangeOver(tv.Type); over != "" {
+				if props == nil {
+					props = make(map[string]any)
+				}
+				props["range_over"] = over
+			}
+		}
+		v.visitStmtProps(n.Range, n.End(), "for", "range", props)
+		v.visitRangeVars(n)
 	case *ast.SwitchStmt:
-		v.visitStmtWithCode(n.Switch, v.endLine(n.End()), "switch", "switch", n.Pos(), n.Body.Lbrace)
+		v.visitStmtWithCode(n.Switch, n.End(), "switch", "switch", n.Pos(), n.Body.Lbrace)
 		v.emitConditionEdge("switch", n.Switch, n.Tag)
+		for cc, props := range deadSwitchCases(v.pkg.TypesInfo, v.fset, n) {
+			if v.deadCases == nil {
+				v.deadCases = make(map[*ast.CaseClause]map[string]any)
+			}
+			v.deadCases[cc] = props
+		}
 	case *ast.TypeSwitchStmt:
-		v.visitStmtWithCode(n.Switch, v.endLine(n.End()), "switch", "type switch", n.Pos(), n.Body.Lbrace)
+		v.visitStmtWithCode(n.Switch, n.End(), "switch", "type switch", n.Pos(), n.Body.Lbrace)
 	case *ast.SelectStmt:
-		v.visitStmt(n.Select, v.endLine(n.End()), "select", "select")
+		v.visitStmt(n.Select, n.End(), "select", "select")
 	case *ast.CaseClause:
-		v.visitStmt(n.Case, v.endLine(n.End()), "case", "case")
+		v.visitStmtProps(n.Case, n.End(), "case", "case", v.deadCases[n])
 	case *ast.CommClause:
-		v.visitStmt(n.Case, v.endLine(n.End()), "case", "comm case")
+		v.markSelectComm(n.Comm)
+		v.visitStmt(n.Case, n.End(), "case", "comm case")
 	case *ast.ReturnStmt:
-		v.visitStmtWithCode(n.Return, v.endLine(n.End()), "return", "return", n.Pos(), n.End())
+		v.visitStmtWithCode(n.Return, n.End(), "return", "return", n.Pos(), n.End())
+		v.emitReturns(n)
 	case *ast.AssignStmt:
+		if len(n.Lhs) == 2 && len(n.Rhs) == 1 {
+			v.markCommaOk(n.Rhs[0])
+		}
+		if len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+			v.markAppendTarget(n.Lhs[0], n.Rhs[0])
+		}
 		v.visitAssign(n)
+	case *ast.ExprStmt:
+		v.parentStack = append(v.parentStack, v.currentParent()) // balance push
 	case *ast.GoStmt:
 		v.visitGoStmt(n)
 	case *ast.DeferStmt:
-		v.visitStmt(n.Defer, v.endLine(n.End()), "defer", "defer")
+		v.visitStmt(n.Defer, n.End(), "defer", "defer")
 		// Track defers for LIFO ordering
 		line, col := v.pos(n.Defer)
 		if line > 0 {
@@ -380,9 +534,9 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 		}
 	case *ast.SendStmt:
 		line, col := v.pos(n.Arrow)
-		v.visitStmtAt(line, col, v.endLine(n.End()), "send", "send")
+		v.visitStmtAtProps(line, col, n.End(), "send", "send", v.selectCommProps(n))
 	case *ast.BranchStmt:
-		v.visitStmt(n.TokPos, v.endLine(n.End()), "branch", n.Tok.String())
+		v.visitStmt(n.TokPos, n.End(), "branch", n.Tok.String())
 		// branch_target edge: break/continue/goto with label → labeled statement
 		if n.Label != nil {
 			if obj := v.pkg.TypesInfo.Uses[n.Label]; obj != nil {
@@ -412,6 +566,11 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 	case *ast.BlockStmt:
 		v.visitBlock(n)
 	case *ast.GenDecl:
+		for _, spec := range n.Specs {
+			if vs, ok := spec.(*ast.ValueSpec); ok && len(vs.Names) == 2 && len(vs.Values) == 1 {
+				v.markCommaOk(vs.Values[0])
+			}
+		}
 		v.visitGenDecl(n)
 		v.parentStack = append(v.parentStack, v.currentParent()) // balance push for pop in Visit(nil)
 	case *ast.TypeSpec:
@@ -430,22 +589,40 @@ func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
 		id := v.visitSelectorExpr(n)
 		v.parentStack = append(v.parentStack, id)
 	case *ast.UnaryExpr:
-		v.visitExpr(n.OpPos, n.Op.String(), "unary_expr")
+		var props map[string]any
+		if n.Op == token.ARROW {
+			props = v.selectCommProps(n)
+		}
+		v.visitExprProps(n.OpPos, n.Op.String(), "unary_expr", props)
 	case *ast.BinaryExpr:
-		v.visitExpr(n.OpPos, n.Op.String(), "binary_expr")
+		var props map[string]any
+		if sentinel := v.errorSentinelComparison(n); sentinel != "" {
+			props = map[string]any{"error_sentinel": sentinel}
+		} else if v.nilComparison(n) {
+			props = map[string]any{"nil_check": true}
+		}
+		v.visitExprProps(n.OpPos, n.Op.String(), "binary_expr", props)
 	case *ast.IndexExpr:
-		v.visitExpr(n.Lbrack, "index", "index_expr")
+		v.visitExprProps(n.Lbrack, "index", "index_expr", v.constIndexProps(n))
 	case *ast.SliceExpr:
 		v.visitExpr(n.Lbrack, "slice", "slice_expr")
 	case *ast.TypeAssertExpr:
-		v.visitExpr(n.Lparen, "type_assert", "type_assert_expr")
+		// x.(type) in a type switch never panics either
+		var props map[string]any
+		if n.Type != nil && !v.commaOkAsserts[n] {
+			props = map[string]any{"unchecked": true}
+		}
+		v.visitExprProps(n.Lparen, "type_assert", "type_assert_expr", props)
 	case *ast.KeyValueExpr:
 		v.visitExpr(n.Colon, "key_value", "key_value_expr")
 	case *ast.ImportSpec:
 		v.visitImportSpec(n)
 		return nil // leaf node
 	case *ast.IncDecStmt:
-		v.visitStmt(n.TokPos, v.endLine(n.End()), "inc_dec", n.Tok.String())
+		v.visitStmt(n.TokPos, n.End(), "inc_dec", n.Tok.String())
+		line, col := v.pos(n.TokPos)
+		v.emitFieldWrites(StmtID(v.relPkg, BaseName(v.relFile), line, col, "inc_dec"), []ast.Expr{n.X})
+		v.markWriteTarget(n.X)
 	default:
 		v.parentStack = append(v.parentStack, v.currentParent()) // balance push
 	}
@@ -458,9 +635,13 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 	el := v.endLine(n.End())
 
 	var recv, name string
+	var recvObj types.Object
 	name = n.Name.Name
 	if n.Recv != nil && len(n.Recv.List) > 0 {
 		recv = exprTypeName(n.Recv.List[0].Type)
+		if names := n.Recv.List[0].Names; len(names) > 0 && names[0].Name != "_" {
+			recvObj = v.pkg.TypesInfo.Defs[names[0]]
+		}
 	}
 
 	funcID := FuncID(v.relPkg, recv, name, BaseName(v.relFile), line, col)
@@ -486,6 +667,7 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 		Line:     line,
 		Col:      col,
 		EndLine:  el,
+		EndCol:   v.endCol(n.End()),
 		TypeInfo: typeInfo,
 		Properties: map[string]any{
 			"full_name": fullName,
@@ -495,6 +677,15 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 	if recv != "" {
 		node.Properties["receiver"] = recv
 	}
+	if recvObj != nil && n.Body != nil {
+		escapes, addressed := v.receiverUsage(n.Body, recvObj)
+		if escapes {
+			node.Properties["receiver_escapes"] = true
+		}
+		if addressed {
+			node.Properties["receiver_addressed"] = true
+		}
+	}
 	if n.Type.TypeParams != nil && n.Type.TypeParams.NumFields() > 0 {
 		node.Properties["generic"] = true
 	}
@@ -508,6 +699,10 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 				}
 			}
 		}
+		if sig, ok := obj.Type().(*types.Signature); ok && token.IsExported(name) &&
+			(recv == "" || token.IsExported(strings.TrimPrefix(recv, "*"))) {
+			node.Properties["api_signature"] = apiSignature(sig, recv)
+		}
 		if sig, ok := obj.Type().(*types.Signature); ok && sig.Results() != nil {
 			for i := range sig.Results().Len() {
 				rt := sig.Results().At(i).Type()
@@ -524,6 +719,9 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 	if sig := v.codeSnippet(n.Pos(), n.Type.End(), 200); sig != "" {
 		node.Properties["code"] = sig
 	}
+	if hash := astHash(v.pkg.TypesInfo, n, n.Type, n.Body); hash != "" {
+		node.Properties["ast_hash"] = hash
+	}
 	v.addNodeAndEdge(node)
 	v.emitDocEdge(funcID, n.Doc)
 
@@ -548,6 +746,11 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 	v.parentStack = append(v.parentStack, funcID)
 	prevFunc := v.curFunc
 	v.curFunc = funcID
+	prevRecv, prevMethod := v.curRecv, v.curMethod
+	v.curRecv, v.curMethod = recvObj, ""
+	if recv != "" {
+		v.curMethod = funcID
+	}
 
 	// Visit type parameters (generics)
 	if n.Type.TypeParams != nil {
@@ -557,19 +760,19 @@ func (v *astVisitor) visitFuncDecl(n *ast.FuncDecl) ast.Visitor {
 	if n.Type.Params != nil {
 		v.visitFieldList(n.Type.Params, "parameter")
 	}
-	if n.Type.Results != nil {
-		v.visitFieldList(n.Type.Results, "result")
-	}
+	defer v.enterResults(n.Type.Results)()
 
 	// Visit body
 	prevDefers := v.deferIDs
 	v.deferIDs = nil
 	if n.Body != nil {
+		v.markUnreachable(n.Body)
 		ast.Walk(v, n.Body)
 	}
 	v.emitDeferOrdering()
 	v.deferIDs = prevDefers
 
+	v.curRecv, v.curMethod = prevRecv, prevMethod
 	v.curFunc = prevFunc
 	v.parentStack = v.parentStack[:len(v.parentStack)-1]
 	return nil // we handled children manually
@@ -588,6 +791,7 @@ func (v *astVisitor) visitFuncLit(n *ast.FuncLit) ast.Visitor {
 		Line:    line,
 		Col:     col,
 		EndLine: el,
+		EndCol:  v.endCol(n.End()),
 	}
 	v.addNodeAndEdge(node)
 
@@ -601,12 +805,11 @@ func (v *astVisitor) visitFuncLit(n *ast.FuncLit) ast.Visitor {
 	if n.Type.Params != nil {
 		v.visitFieldList(n.Type.Params, "parameter")
 	}
-	if n.Type.Results != nil {
-		v.visitFieldList(n.Type.Results, "result")
-	}
+	defer v.enterResults(n.Type.Results)()
 	prevDefers := v.deferIDs
 	v.deferIDs = nil
 	if n.Body != nil {
+		v.markUnreachable(n.Body)
 		ast.Walk(v, n.Body)
 	}
 	v.emitDeferOrdering()
@@ -617,6 +820,54 @@ func (v *astVisitor) visitFuncLit(n *ast.FuncLit) ast.Visitor {
 	return nil
 }
 
+// callKind classifies a call expression by what it invokes: "func" (a
+// declared function), "method" (a concrete method, including T.M(x)
+// method expressions), "interface" (an interface method), "builtin"
+// (len, append, make, ...), "conversion" (T(x), not a call at run time) or
+// "func_value" (a func-typed variable, field, result or literal).
+func (v *astVisitor) callKind(n *ast.CallExpr) string {
+	info := v.pkg.TypesInfo
+	if tv, ok := info.Types[n.Fun]; ok {
+		if tv.IsType() {
+			return "conversion"
+		}
+		if tv.IsBuiltin() {
+			return "builtin"
+		}
+	}
+	fun := ast.Unparen(n.Fun)
+	// Explicit instantiation: f[int](x) calls f
+	switch ix := fun.(type) {
+	case *ast.IndexExpr:
+		fun = ix.X
+	case *ast.IndexListExpr:
+		fun = ix.X
+	}
+	var ident *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		if sel, ok := info.Selections[f]; ok {
+			switch sel.Kind() {
+			case types.MethodVal, types.MethodExpr:
+				if types.IsInterface(sel.Recv()) {
+					return "interface"
+				}
+				return "method"
+			}
+			return "func_value" // func-typed struct field
+		}
+		ident = f.Sel // package-qualified
+	}
+	if ident != nil {
+		if _, ok := info.Uses[ident].(*types.Func); ok {
+			return "func"
+		}
+	}
+	return "func_value"
+}
+
 func (v *astVisitor) visitCallExpr(n *ast.CallExpr) string {
 	line, col := v.pos(n.Lparen)
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "call")
@@ -662,13 +913,19 @@ func (v *astVisitor) visitCallExpr(n *ast.CallExpr) string {
 
 	props := map[string]any{
 		"dispatch_type": dispatchType,
+		"call_kind":     v.callKind(n),
 	}
 	if code := v.codeSnippet(n.Fun.Pos(), n.Rparen+1, 120); code != "" {
 		props["code"] = code
 	}
+	if target, ok := v.appendTargets[n]; ok {
+		props["assigned_to"] = target
+	}
+	v.addMakeSizes(n, props)
 	// Detect sync primitive calls via receiver type
+	var syncKind string
 	if sel, ok := n.Fun.(*ast.SelectorExpr); ok {
-		if syncKind := v.detectSyncPrimitive(sel); syncKind != "" {
+		if syncKind = v.detectSyncPrimitive(sel); syncKind != "" {
 			props["sync_kind"] = syncKind
 		}
 	}
@@ -694,6 +951,7 @@ func (v *astVisitor) visitCallExpr(n *ast.CallExpr) string {
 		Line:       line,
 		Col:        col,
 		EndLine:    v.endLine(n.End()),
+		EndCol:     v.endCol(n.End()),
 		TypeInfo:   typeInfo,
 		Properties: props,
 	})
@@ -723,12 +981,34 @@ func (v *astVisitor) visitCallExpr(n *ast.CallExpr) string {
 	// Error wrapping: fmt.Errorf with %w wraps an error argument
 	v.emitErrorWrapEdge(id, callee, n.Args)
 
+	// once.Do(f): call → the function it runs at most once
+	if syncKind == "once_do" && len(n.Args) == 1 {
+		v.emitOnceGuarded(id, n.Args[0])
+	}
+
+	// delete(m, k) and clear(x) mutate their first argument
+	if fun, ok := n.Fun.(*ast.Ident); ok && len(n.Args) > 0 {
+		if b, ok := v.pkg.TypesInfo.Uses[fun].(*types.Builtin); ok && (b.Name() == "delete" || b.Name() == "clear") {
+			v.markWriteTarget(n.Args[0])
+		}
+	}
+
 	return id
 }
 
 // visitStmtWithCode creates a statement node with an optional code snippet.
 // codeStart/codeEnd define the range for the snippet (pass invalid Pos to skip).
-func (v *astVisitor) visitStmtWithCode(p token.Pos, el int, kind, name string, codeStart, codeEnd token.Pos) {
+func (v *astVisitor) visitStmtWithCode(p, end token.Pos, kind, name string, codeStart, codeEnd token.Pos) {
+	var props map[string]any
+	if code := v.codeSnippet(codeStart, codeEnd, 120); code != "" {
+		props = map[string]any{"code": code}
+	}
+	v.visitStmtProps(p, end, kind, name, props)
+}
+
+// visitStmtProps emits a statement node with initial properties and pushes
+// it onto the parent stack.
+func (v *astVisitor) visitStmtProps(p, end token.Pos, kind, name string, props map[string]any) {
 	line, col := v.pos(p)
 	if line == 0 {
 		v.parentStack = append(v.parentStack, v.currentParent()) // balance push
@@ -736,29 +1016,30 @@ func (v *astVisitor) visitStmtWithCode(p token.Pos, el int, kind, name string, c
 	}
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, kind)
 
-	var props map[string]any
-	if code := v.codeSnippet(codeStart, codeEnd, 120); code != "" {
-		props = map[string]any{"code": code}
-	}
-
 	v.addNodeAndEdge(Node{
 		ID:         id,
 		Kind:       kind,
 		Name:       name,
 		Line:       line,
 		Col:        col,
-		EndLine:    el,
+		EndLine:    v.endLine(end),
+		EndCol:     v.endCol(end),
 		Properties: props,
 	})
 
 	v.parentStack = append(v.parentStack, id)
 }
 
-func (v *astVisitor) visitStmt(p token.Pos, el int, kind, name string) {
-	v.visitStmtWithCode(p, el, kind, name, 0, 0)
+func (v *astVisitor) visitStmt(p, end token.Pos, kind, name string) {
+	v.visitStmtWithCode(p, end, kind, name, 0, 0)
 }
 
-func (v *astVisitor) visitStmtAt(line, col, el int, kind, name string) {
+func (v *astVisitor) visitStmtAt(line, col int, end token.Pos, kind, name string) {
+	v.visitStmtAtProps(line, col, end, kind, name, nil)
+}
+
+// visitStmtAtProps is visitStmtAt with initial node properties.
+func (v *astVisitor) visitStmtAtProps(line, col int, end token.Pos, kind, name string, props map[string]any) {
 	if line == 0 {
 		v.parentStack = append(v.parentStack, v.currentParent()) // balance push
 		return
@@ -766,12 +1047,14 @@ func (v *astVisitor) visitStmtAt(line, col, el int, kind, name string) {
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, kind)
 
 	v.addNodeAndEdge(Node{
-		ID:      id,
-		Kind:    kind,
-		Name:    name,
-		Line:    line,
-		Col:     col,
-		EndLine: el,
+		ID:         id,
+		Kind:       kind,
+		Name:       name,
+		Line:       line,
+		Col:        col,
+		EndLine:    v.endLine(end),
+		EndCol:     v.endCol(end),
+		Properties: props,
 	})
 
 	v.parentStack = append(v.parentStack, id)
@@ -779,6 +1062,11 @@ func (v *astVisitor) visitStmtAt(line, col, el int, kind, name string) {
 
 // visitExpr creates a node for expression types and pushes onto parent stack.
 func (v *astVisitor) visitExpr(p token.Pos, name, kind string) {
+	v.visitExprProps(p, name, kind, nil)
+}
+
+// visitExprProps is visitExpr with initial node properties.
+func (v *astVisitor) visitExprProps(p token.Pos, name, kind string, props map[string]any) {
 	line, col := v.pos(p)
 	if line == 0 {
 		v.parentStack = append(v.parentStack, v.currentParent())
@@ -786,15 +1074,209 @@ func (v *astVisitor) visitExpr(p token.Pos, name, kind string) {
 	}
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, kind)
 	v.addNodeAndEdge(Node{
-		ID:   id,
-		Kind: kind,
-		Name: name,
-		Line: line,
-		Col:  col,
+		ID:         id,
+		Kind:       kind,
+		Name:       name,
+		Line:       line,
+		Col:        col,
+		Properties: props,
 	})
 	v.parentStack = append(v.parentStack, id)
 }
 
+// constInt returns the value of e when it is an integer constant expression
+// (folded by the type checker, so 2*n for a constant n counts).
+func (v *astVisitor) constInt(e ast.Expr) (int64, bool) {
+	tv, ok := v.pkg.TypesInfo.Types[e]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	val := constant.ToInt(tv.Value)
+	if val.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(val)
+}
+
+// constIndexProps returns the const_index property of a constant index into
+// a slice, for index_out_of_range_const; nil for any other index expression.
+// The compiler already rejects constant indexes out of an array's range, but
+// a slice's length is only known at run time.
+func (v *astVisitor) constIndexProps(n *ast.IndexExpr) map[string]any {
+	idx, ok := v.constInt(n.Index)
+	if !ok {
+		return nil
+	}
+	tv, ok := v.pkg.TypesInfo.Types[n.X]
+	if !ok {
+		return nil
+	}
+	if _, ok := tv.Type.Underlying().(*types.Slice); !ok {
+		return nil
+	}
+	return map[string]any{"const_index": idx}
+}
+
+// sliceLitProps returns the slice_len property of a slice literal, its
+// length (one past the highest index, keyed elements included), for
+// index_out_of_range_const; nil for any other literal or a non-constant key.
+func (v *astVisitor) sliceLitProps(n *ast.CompositeLit) map[string]any {
+	tv, ok := v.pkg.TypesInfo.Types[n]
+	if !ok {
+		return nil
+	}
+	if _, ok := tv.Type.Underlying().(*types.Slice); !ok {
+		return nil
+	}
+	var length, next int64
+	for _, elt := range n.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if next, ok = v.constInt(kv.Key); !ok {
+				return nil
+			}
+		}
+		next++
+		length = max(length, next)
+	}
+	return map[string]any{"slice_len": length}
+}
+
+// addMakeSizes records a constant capacity argument of a make call as
+// make_cap, for invalid_make_size. The compiler rejects constant negative
+// sizes and a constant capacity below the length, so only an explicit zero
+// capacity is left to report.
+func (v *astVisitor) addMakeSizes(n *ast.CallExpr, props map[string]any) {
+	id, ok := ast.Unparen(n.Fun).(*ast.Ident)
+	if !ok || len(n.Args) < 3 {
+		return
+	}
+	if _, ok := v.pkg.TypesInfo.Uses[id].(*types.Builtin); !ok || id.Name != "make" {
+		return
+	}
+	if size, ok := v.constInt(n.Args[2]); ok {
+		props["make_cap"] = size
+	}
+}
+
+// markSelectComm records the channel operation of a select case: the send
+// statement, or the receive in case <-ch, case v := <-ch and case v, ok = <-ch.
+func (v *astVisitor) markSelectComm(comm ast.Stmt) {
+	var op ast.Node
+	switch c := comm.(type) {
+	case *ast.SendStmt:
+		op = c
+	case *ast.ExprStmt:
+		op = ast.Unparen(c.X)
+	case *ast.AssignStmt:
+		if len(c.Rhs) == 1 {
+			op = ast.Unparen(c.Rhs[0])
+		}
+	}
+	if op == nil {
+		return
+	}
+	if v.selectComms == nil {
+		v.selectComms = make(map[ast.Node]bool)
+	}
+	v.selectComms[op] = true
+}
+
+// selectCommProps marks a send or receive that is a select case's
+// communication with in_select.
+func (v *astVisitor) selectCommProps(op ast.Node) map[string]any {
+	if v.selectComms[op] {
+		return map[string]any{"in_select": true}
+	}
+	return nil
+}
+
+// markCommaOk records e as the comma-ok operand of a two-value assignment or
+// declaration when it is a type assertion.
+func (v *astVisitor) markCommaOk(e ast.Expr) {
+	ta, ok := ast.Unparen(e).(*ast.TypeAssertExpr)
+	if !ok {
+		return
+	}
+	if v.commaOkAsserts == nil {
+		v.commaOkAsserts = make(map[*ast.TypeAssertExpr]bool)
+	}
+	v.commaOkAsserts[ta] = true
+}
+
+// markAppendTarget records lhs as the target of a builtin append call
+// assigned to it, so lost_append can tell s = append(s, x) from
+// t = append(s, x) and _ = append(s, x).
+func (v *astVisitor) markAppendTarget(lhs, rhs ast.Expr) {
+	call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	if tv, ok := v.pkg.TypesInfo.Types[call.Fun]; !ok || !tv.IsBuiltin() {
+		return
+	}
+	if ident, ok := ast.Unparen(call.Fun).(*ast.Ident); !ok || ident.Name != "append" {
+		return
+	}
+	if v.appendTargets == nil {
+		v.appendTargets = make(map[*ast.CallExpr]string)
+	}
+	v.appendTargets[call] = types.ExprString(lhs)
+}
+
+// errorSentinelComparison returns the sentinel (e.g. "io.EOF") when n compares
+// an error value against a package-level error variable with == or !=, which
+// misses wrapped errors; errors.Is is the fix. Comparisons to nil never match.
+func (v *astVisitor) errorSentinelComparison(n *ast.BinaryExpr) string {
+	if n.Op != token.EQL && n.Op != token.NEQ {
+		return ""
+	}
+	info := v.pkg.TypesInfo
+	sentinel := func(e ast.Expr) *types.Var {
+		var id *ast.Ident
+		switch e := ast.Unparen(e).(type) {
+		case *ast.Ident:
+			id = e
+		case *ast.SelectorExpr:
+			id = e.Sel
+		default:
+			return nil
+		}
+		obj, ok := info.Uses[id].(*types.Var)
+		if !ok || obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+			return nil
+		}
+		if !types.Implements(obj.Type(), errorInterface) {
+			return nil
+		}
+		return obj
+	}
+	isError := func(e ast.Expr) bool {
+		tv, ok := info.Types[e]
+		return ok && !tv.IsNil() && tv.Type != nil && types.Implements(tv.Type, errorInterface)
+	}
+	for _, pair := range [][2]ast.Expr{{n.X, n.Y}, {n.Y, n.X}} {
+		if obj := sentinel(pair[0]); obj != nil && isError(pair[1]) {
+			return obj.Pkg().Name() + "." + obj.Name()
+		}
+	}
+	return ""
+}
+
+// nilComparison reports whether n is x == nil or x != nil.
+func (v *astVisitor) nilComparison(n *ast.BinaryExpr) bool {
+	if n.Op != token.EQL && n.Op != token.NEQ {
+		return false
+	}
+	isNil := func(e ast.Expr) bool {
+		tv, ok := v.pkg.TypesInfo.Types[e]
+		return ok && tv.IsNil()
+	}
+	return isNil(n.X) != isNil(n.Y)
+}
+
+// errorInterface is the predeclared error type's method set.
+var errorInterface = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
 // visitBlock creates a block node and emits next_sibling edges between
 // consecutive statements in the block's statement list.
 func (v *astVisitor) visitBlock(n *ast.BlockStmt) {
@@ -812,6 +1294,7 @@ func (v *astVisitor) visitBlock(n *ast.BlockStmt) {
 		Line:    line,
 		Col:     col,
 		EndLine: el,
+		EndCol:  v.endCol(n.End()),
 	})
 	// Register as scope boundary and emit scope edge to nearest enclosing scope
 	v.scopeNodes[id] = true
@@ -923,6 +1406,7 @@ func (v *astVisitor) visitGoStmt(n *ast.GoStmt) {
 		Line:    line,
 		Col:     col,
 		EndLine: v.endLine(n.End()),
+		EndCol:  v.endCol(n.End()),
 	})
 	v.parentStack = append(v.parentStack, id)
 
@@ -957,9 +1441,29 @@ func (v *astVisitor) visitAssign(n *ast.AssignStmt) {
 		Line:       line,
 		Col:        col,
 		EndLine:    v.endLine(n.End()),
+		EndCol:     v.endCol(n.End()),
 		Properties: props,
 	})
 
+	// field_write edges for stores into struct fields (x.f = ..., x.f += ...)
+	v.emitFieldWrites(id, n.Lhs)
+	for _, lhs := range n.Lhs {
+		if ident, ok := lhs.(*ast.Ident); ok {
+			obj := v.pkg.TypesInfo.Uses[ident]
+			if _, named := v.resultAssign[obj]; named {
+				v.resultAssign[obj] = id
+			}
+		}
+	}
+	if n.Tok != token.DEFINE {
+		for _, lhs := range n.Lhs {
+			v.markWriteTarget(lhs)
+			if sel, ok := ast.Unparen(lhs).(*ast.SelectorExpr); ok && n.Tok == token.ASSIGN {
+				v.storeSels[sel] = true
+			}
+		}
+	}
+
 	// For short variable declarations, create local variable nodes
 	if n.Tok == token.DEFINE {
 		for i, lhs := range n.Lhs {
@@ -998,9 +1502,77 @@ func (v *astVisitor) visitAssign(n *ast.AssignStmt) {
 	v.parentStack = append(v.parentStack, id)
 }
 
+// visitRangeVars creates local nodes for the key/value variables a
+// "for k, v := range" declares, as children of the loop node, so closures
+// capturing them get capture edges like any other local.
+func (v *astVisitor) visitRangeVars(n *ast.RangeStmt) {
+	if n.Tok != token.DEFINE {
+		return
+	}
+	for _, expr := range []ast.Expr{n.Key, n.Value} {
+		ident, ok := expr.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			continue
+		}
+		line, col := v.pos(ident.Pos())
+		id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "local")
+
+		var typeInfo string
+		if obj := v.pkg.TypesInfo.Defs[ident]; obj != nil {
+			typeInfo = obj.Type().String()
+			v.defLookup.Set(obj, id)
+		}
+
+		v.addNodeAndEdge(Node{
+			ID:         id,
+			Kind:       "local",
+			Name:       ident.Name,
+			Line:       line,
+			Col:        col,
+			TypeInfo:   typeInfo,
+			Properties: map[string]any{"decl": "range"},
+		})
+	}
+}
+
+// rangeOver classifies the type a range statement iterates: map, slice,
+// array, string, chan, int or func (range-over-func iterators).
+func rangeOver(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Map:
+		return "map"
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Pointer:
+		if _, ok := u.Elem().Underlying().(*types.Array); ok {
+			return "array"
+		}
+	case *types.Chan:
+		return "chan"
+	case *types.Signature:
+		return "func"
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return "string"
+		case u.Info()&types.IsInteger != 0:
+			return "int"
+		}
+	}
+	return ""
+}
+
 func (v *astVisitor) visitGenDecl(n *ast.GenDecl) {
 	switch n.Tok { //nolint:exhaustive // only VAR/CONST/TYPE are relevant
 	case token.VAR, token.CONST:
+		// Package-level declarations are global_var / global_const nodes,
+		// the targets of ref edges from every use
+		kind := "local"
+		if v.curFunc == "" {
+			kind = "global_" + n.Tok.String()
+		}
 		for _, spec := range n.Specs {
 			vs, ok := spec.(*ast.ValueSpec)
 			if !ok {
@@ -1011,25 +1583,29 @@ func (v *astVisitor) visitGenDecl(n *ast.GenDecl) {
 					continue
 				}
 				line, col := v.pos(name.Pos())
-				id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "local")
+				id := StmtID(v.relPkg, BaseName(v.relFile), line, col, kind)
 
+				props := map[string]any{
+					"decl":     n.Tok.String(),
+					"exported": token.IsExported(name.Name),
+				}
 				var typeInfo string
 				if obj := v.pkg.TypesInfo.Defs[name]; obj != nil {
 					typeInfo = obj.Type().String()
 					v.defLookup.Set(obj, id)
+					if isOnceType(obj.Type()) {
+						props["sync_kind"] = "once"
+					}
 				}
 
 				v.addNodeAndEdge(Node{
-					ID:       id,
-					Kind:     "local",
-					Name:     name.Name,
-					Line:     line,
-					Col:      col,
-					TypeInfo: typeInfo,
-					Properties: map[string]any{
-						"decl":     n.Tok.String(),
-						"exported": token.IsExported(name.Name),
-					},
+					ID:         id,
+					Kind:       kind,
+					Name:       name.Name,
+					Line:       line,
+					Col:        col,
+					TypeInfo:   typeInfo,
+					Properties: props,
 				})
 				// Initializer edge: var/const → RHS expression
 				if i < len(vs.Values) {
@@ -1083,6 +1659,15 @@ func (v *astVisitor) visitTypeSpec(n *ast.TypeSpec) {
 	if n.TypeParams != nil && n.TypeParams.NumFields() > 0 {
 		props["generic"] = true
 	}
+	// Structs are fingerprinted per exported field; everything else by its
+	// underlying type (interface method sets, alias targets, basic kinds).
+	if obj := v.pkg.TypesInfo.Defs[n.Name]; obj != nil && token.IsExported(n.Name.Name) {
+		if typeKind == "struct" {
+			props["api_signature"] = "struct"
+		} else {
+			props["api_signature"] = obj.Type().Underlying().String()
+		}
+	}
 
 	v.addNodeAndEdge(Node{
 		ID:         id,
@@ -1091,6 +1676,7 @@ func (v *astVisitor) visitTypeSpec(n *ast.TypeSpec) {
 		Line:       line,
 		Col:        col,
 		EndLine:    el,
+		EndCol:     v.endCol(n.End()),
 		TypeInfo:   typeInfo,
 		Properties: props,
 	})
@@ -1187,6 +1773,9 @@ func (v *astVisitor) visitField(field *ast.Field) {
 	props := map[string]any{
 		"exported": token.IsExported(name),
 	}
+	if tv, ok := v.pkg.TypesInfo.Types[field.Type]; ok && isOnceType(tv.Type) {
+		props["sync_kind"] = "once"
+	}
 	if field.Tag != nil && field.Tag.Value != "" {
 		// Raw tag includes backticks; strip them for the property
 		tag := field.Tag.Value
@@ -1194,6 +1783,9 @@ func (v *astVisitor) visitField(field *ast.Field) {
 			tag = tag[1 : len(tag)-1]
 		}
 		props["tag"] = tag
+		if tags := parseStructTag(tag); len(tags) > 0 {
+			props["tags"] = tags
+		}
 	}
 	if len(field.Names) == 0 {
 		props["embedded"] = true
@@ -1211,7 +1803,10 @@ func (v *astVisitor) visitField(field *ast.Field) {
 	v.emitDocEdge(id, field.Doc)
 }
 
-func (v *astVisitor) visitFieldList(fl *ast.FieldList, kind string) {
+// visitFieldList emits one node per parameter/result/type parameter and
+// returns their IDs in declaration order.
+func (v *astVisitor) visitFieldList(fl *ast.FieldList, kind string) []string {
+	var ids []string
 	for _, field := range fl.List {
 		var typeInfo string
 		var props map[string]any
@@ -1236,6 +1831,18 @@ func (v *astVisitor) visitFieldList(fl *ast.FieldList, kind string) {
 					}
 					props["context_param"] = true
 				}
+				if v.pkg.TypesSizes != nil && sizeKnown(tv.Type) {
+					if props == nil {
+						props = map[string]any{}
+					}
+					props["type_size"] = v.pkg.TypesSizes.Sizeof(tv.Type)
+				}
+				if _, ok := tv.Type.Underlying().(*types.Struct); ok {
+					if props == nil {
+						props = map[string]any{}
+					}
+					props["struct_value"] = true
+				}
 			}
 		}
 
@@ -1252,6 +1859,7 @@ func (v *astVisitor) visitFieldList(fl *ast.FieldList, kind string) {
 				TypeInfo:   typeInfo,
 				Properties: props,
 			})
+			ids = append(ids, id)
 			continue
 		}
 
@@ -1268,25 +1876,111 @@ func (v *astVisitor) visitFieldList(fl *ast.FieldList, kind string) {
 				Properties: props,
 			})
 			v.defLookup.Set(v.pkg.TypesInfo.Defs[name], id)
+			ids = append(ids, id)
 		}
 	}
+	return ids
+}
+
+// enterResults makes results the enclosing function's result list for
+// returns edges and returns a func that restores the previous one.
+func (v *astVisitor) enterResults(results *ast.FieldList) func() {
+	prevResults, prevVars, prevAssign := v.curResults, v.resultVars, v.resultAssign
+	v.curResults, v.resultVars, v.resultAssign = nil, nil, nil
+	if results != nil {
+		v.curResults = v.visitFieldList(results, "result")
+		v.resultAssign = map[types.Object]string{}
+		for _, field := range results.List {
+			for _, name := range field.Names {
+				obj := v.pkg.TypesInfo.Defs[name]
+				v.resultVars = append(v.resultVars, obj)
+				if obj != nil {
+					v.resultAssign[obj] = ""
+				}
+			}
+		}
+	}
+	return func() { v.curResults, v.resultVars, v.resultAssign = prevResults, prevVars, prevAssign }
+}
+
+// emitReturns links each returned value to the enclosing function's
+// positional result node. A single multi-value call feeds every result; a
+// naked return hands back each named result's latest assignment. Values
+// without a node of their own (nil, builtins) use the return statement.
+func (v *astVisitor) emitReturns(n *ast.ReturnStmt) {
+	if len(v.curResults) == 0 {
+		return
+	}
+	line, col := v.pos(n.Return)
+	retID := StmtID(v.relPkg, BaseName(v.relFile), line, col, "return")
+	emit := func(src string, i int) {
+		if src == "" || i >= len(v.curResults) {
+			return
+		}
+		v.cpg.AddEdge(Edge{
+			Source: src, Target: v.curResults[i], Kind: "returns",
+			Properties: map[string]any{"index": i},
+		})
+		v.edgeCount++
+	}
+
+	if len(n.Results) == 0 {
+		for i, obj := range v.resultVars {
+			emit(v.resultAssign[obj], i)
+		}
+		return
+	}
+	if len(n.Results) == 1 && len(v.curResults) > 1 {
+		for i := range v.curResults {
+			emit(v.returnValueID(n.Results[0], retID), i)
+		}
+		return
+	}
+	for i, r := range n.Results {
+		emit(v.returnValueID(r, retID), i)
+	}
+}
+
+// returnValueID is exprNodeID for a returned expression, falling back to the
+// return statement for expressions the walk emits no node for.
+func (v *astVisitor) returnValueID(e ast.Expr, retID string) string {
+	if id, ok := ast.Unparen(e).(*ast.Ident); ok {
+		switch v.pkg.TypesInfo.Uses[id].(type) {
+		case *types.Var, *types.Func, *types.Const, *types.TypeName:
+		default:
+			return retID
+		}
+	}
+	if id := v.exprNodeID(e); id != "" {
+		return id
+	}
+	return retID
 }
 
 func (v *astVisitor) visitCompositeLit(n *ast.CompositeLit) string {
 	line, col := v.pos(n.Lbrace)
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "composite_lit")
 
-	var typeName string
+	var typeName, typeInfo string
 	if n.Type != nil {
 		typeName = exprTypeName(n.Type)
 	}
+	if tv, ok := v.pkg.TypesInfo.Types[n]; ok {
+		typeInfo = tv.Type.String()
+	}
 
+	props := v.unkeyedStructProps(n)
+	if props == nil {
+		props = v.sliceLitProps(n)
+	}
 	v.addNodeAndEdge(Node{
-		ID:   id,
-		Kind: "composite_lit",
-		Name: typeName,
-		Line: line,
-		Col:  col,
+		ID:         id,
+		Kind:       "composite_lit",
+		Name:       typeName,
+		Line:       line,
+		Col:        col,
+		TypeInfo:   typeInfo,
+		Properties: props,
 	})
 
 	// eval_type: composite literal → type declaration
@@ -1295,6 +1989,41 @@ func (v *astVisitor) visitCompositeLit(n *ast.CompositeLit) string {
 	return id
 }
 
+// unkeyedStructProps returns the unkeyed, struct_fields and (for a type
+// declared in another package) type_package properties of a literal of a
+// named struct type with positional elements, for unkeyed_struct_literal; nil
+// for any other literal. Anonymous structs (table-driven test cases) are
+// left out: nothing else depends on their field order.
+func (v *astVisitor) unkeyedStructProps(n *ast.CompositeLit) map[string]any {
+	if len(n.Elts) == 0 {
+		return nil
+	}
+	if _, ok := n.Elts[0].(*ast.KeyValueExpr); ok {
+		return nil
+	}
+	tv, ok := v.pkg.TypesInfo.Types[n]
+	if !ok {
+		return nil
+	}
+	t := tv.Type
+	if p, ok := t.(*types.Pointer); ok { // elided &T in []*T{{...}}
+		t = p.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	props := map[string]any{"unkeyed": true, "struct_fields": st.NumFields()}
+	if pkg := named.Obj().Pkg(); pkg != nil && pkg != v.pkg.Types {
+		props["type_package"] = pkg.Path()
+	}
+	return props
+}
+
 func (v *astVisitor) visitBasicLit(n *ast.BasicLit) {
 	line, col := v.pos(n.Pos())
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "literal")
@@ -1334,13 +2063,19 @@ func (v *astVisitor) visitIdent(n *ast.Ident) {
 	line, col := v.pos(n.Pos())
 	id := StmtID(v.relPkg, BaseName(v.relFile), line, col, "identifier")
 
+	var props map[string]any
+	if v.writeIdents[n] {
+		props = map[string]any{"write": true}
+	}
+
 	v.addNodeAndEdge(Node{
-		ID:       id,
-		Kind:     "identifier",
-		Name:     n.Name,
-		Line:     line,
-		Col:      col,
-		TypeInfo: obj.Type().String(),
+		ID:         id,
+		Kind:       "identifier",
+		Name:       n.Name,
+		Line:       line,
+		Col:        col,
+		TypeInfo:   obj.Type().String(),
+		Properties: props,
 	})
 
 	// eval_type: identifier → type declaration
@@ -1409,6 +2144,19 @@ func (v *astVisitor) visitSelectorExpr(n *ast.SelectorExpr) string {
 	// eval_type: selector → type declaration
 	v.emitEvalType(id, n)
 
+	// Field reads chain data flow through each dereference: in a.b.c the
+	// base a flows into a.b and a.b into a.b.c, so taint on a reaches the
+	// final value (and, via the call's receiver flow, a.b.c.Method()).
+	if props["selection_kind"] == "field_val" && !v.storeSels[n] {
+		if baseID := v.exprNodeID(n.X); baseID != "" {
+			v.cpg.AddEdge(Edge{
+				Source: baseID, Target: id, Kind: "dfg",
+				Properties: map[string]any{"field_read": true, "field": n.Sel.Name, "confidence": "precise"},
+			})
+			v.edgeCount++
+		}
+	}
+
 	// REF edge: selector → field/method declaration
 	if obj := v.pkg.TypesInfo.Uses[n.Sel]; obj != nil {
 		if declID := v.defLookup.Get(obj); declID != "" {
@@ -1518,6 +2266,168 @@ func (v *astVisitor) emitFmtErrorfWrapEdges(callID string, args []ast.Expr) {
 	}
 }
 
+// emitFieldWrites emits field_write edges from an assignment or inc/dec statement
+// to the declarations of the struct fields stored into by its left-hand sides.
+// Writes rooted at the enclosing method's receiver carry via_receiver and the
+// method ID; writes that reach the field through a pointer, slice, or map
+// (and are therefore visible to other copies) carry shared.
+func (v *astVisitor) emitFieldWrites(stmtID string, lhs []ast.Expr) {
+	for _, expr := range lhs {
+		field, root, shared := v.fieldWriteTarget(expr)
+		if field == nil {
+			continue
+		}
+		fieldID := v.fieldNodeID(field)
+		if fieldID == "" {
+			continue
+		}
+		props := map[string]any{"field": field.Name()}
+		if root != nil && root == v.curRecv {
+			props["via_receiver"] = true
+			props["method"] = v.curMethod
+		}
+		if shared {
+			props["shared"] = true
+		}
+		v.cpg.AddEdge(Edge{Source: stmtID, Target: fieldID, Kind: "field_write", Properties: props})
+		v.edgeCount++
+	}
+}
+
+// markWriteTarget records the root identifier of an assignable expression
+// (x, x[i], x.f, *x) so its identifier node is tagged as a write.
+func (v *astVisitor) markWriteTarget(expr ast.Expr) {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			v.writeIdents[e] = true
+			return
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return
+		}
+	}
+}
+
+// fieldWriteTarget walks an assignable expression (x.a.b, x.arr[i].f, (*p).f)
+// down to its root variable. It returns the outermost struct field written,
+// the root object, and whether the path dereferences anything other than the
+// root itself. Returns a nil field for non-field targets.
+func (v *astVisitor) fieldWriteTarget(expr ast.Expr) (field *types.Var, root types.Object, shared bool) {
+	info := v.pkg.TypesInfo
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			sel, ok := info.Selections[e]
+			if !ok || sel.Kind() != types.FieldVal {
+				return nil, nil, false
+			}
+			if field == nil {
+				field, _ = sel.Obj().(*types.Var)
+			}
+			if sel.Indirect() && !isPointerIdent(info, e.X) {
+				shared = true
+			}
+			expr = e.X
+		case *ast.IndexExpr:
+			if tv, ok := info.Types[e.X]; !ok || !isArrayType(tv.Type) {
+				shared = true
+			}
+			expr = e.X
+		case *ast.StarExpr:
+			if _, ok := ast.Unparen(e.X).(*ast.Ident); !ok {
+				shared = true
+			}
+			expr = e.X
+		case *ast.Ident:
+			if field == nil {
+				return nil, nil, false
+			}
+			return field, info.Uses[e], shared
+		default:
+			// Call results, composite literals, etc.: no named root.
+			return field, nil, true
+		}
+	}
+}
+
+// fieldNodeID resolves the field node ID for a struct field object, falling
+// back to predicting it from the declaration position when the declaring file
+// has not been walked yet.
+func (v *astVisitor) fieldNodeID(field *types.Var) string {
+	field = field.Origin()
+	if id := v.defLookup.Get(field); id != "" {
+		return id
+	}
+	if field.Pkg() == nil || !modSet.IsKnownPkg(field.Pkg().Path()) || !field.Pos().IsValid() {
+		return ""
+	}
+	pos := v.fset.Position(field.Pos())
+	relFile := modSet.RelFile(pos.Filename)
+	if relFile == "" {
+		return ""
+	}
+	return StmtID(modSet.RelPkg(field.Pkg().Path()), BaseName(relFile), pos.Line, pos.Column, "field")
+}
+
+// receiverUsage scans a method body for uses of the receiver beyond field
+// access. escapes reports a bare use of the receiver value (returned, passed,
+// compared, dereferenced, or copied); addressed reports that the address of a
+// receiver field is taken or a pointer-receiver method is invoked on the
+// receiver or one of its fields, both of which need pointer identity.
+func (v *astVisitor) receiverUsage(body *ast.BlockStmt, recv types.Object) (escapes, addressed bool) {
+	info := v.pkg.TypesInfo
+	selected := make(map[*ast.Ident]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := ast.Unparen(e.X).(*ast.Ident); ok {
+				selected[id] = true
+			}
+			sel, ok := info.Selections[e]
+			if !ok || sel.Kind() != types.MethodVal {
+				break
+			}
+			sig, ok := sel.Obj().Type().(*types.Signature)
+			if !ok || sig.Recv() == nil {
+				break
+			}
+			if _, ptr := sig.Recv().Type().(*types.Pointer); ptr && v.rootedAt(e.X, recv) {
+				addressed = true
+			}
+		case *ast.UnaryExpr:
+			if e.Op == token.AND && v.rootedAt(e.X, recv) {
+				addressed = true
+			}
+		case *ast.Ident:
+			if !selected[e] && info.Uses[e] == recv {
+				escapes = true
+			}
+		}
+		return true
+	})
+	return escapes, addressed
+}
+
+// rootedAt reports whether expr is obj itself or a field path on obj that
+// does not pass through a shared indirection.
+func (v *astVisitor) rootedAt(expr ast.Expr, obj types.Object) bool {
+	if id, ok := ast.Unparen(expr).(*ast.Ident); ok {
+		return v.pkg.TypesInfo.Uses[id] == obj
+	}
+	_, root, shared := v.fieldWriteTarget(expr)
+	return root == obj && !shared
+}
+
 // emitEvalType emits an eval_type edge from a node to its resolved type declaration,
 // if the expression's type is a named type from the analyzed modules.
 func (v *astVisitor) emitEvalType(nodeID string, expr ast.Expr) {
@@ -1621,6 +2531,42 @@ func resolveCalleeName(call *ast.CallExpr) string {
 	return "?"
 }
 
+// initRanks returns the position of each package of pkgs in the program's
+// initialization order, as the Go 1.21+ toolchain fixes it: repeatedly the
+// package with the smallest import path whose imports are all initialized.
+// Imports outside pkgs are ignored; they initialize before any of them.
+func initRanks(pkgs []*packages.Package) map[string]int {
+	pending := make(map[string]*packages.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		pending[pkg.PkgPath] = pkg
+	}
+	ranks := make(map[string]int, len(pkgs))
+	for len(pending) > 0 {
+		next := ""
+		for path, pkg := range pending {
+			if next != "" && path >= next {
+				continue
+			}
+			ready := true
+			for _, imp := range pkg.Imports {
+				if _, waiting := pending[imp.PkgPath]; waiting {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = path
+			}
+		}
+		if next == "" {
+			break // import cycle; cannot type-check, so nothing to order
+		}
+		ranks[next] = len(ranks)
+		delete(pending, next)
+	}
+	return ranks
+}
+
 // emitHasMethodEdges iterates all named types in analyzed packages and emits
 // has_method edges from each type_decl to its method function nodes.
 // Uses the type checker's method sets so we catch both value and pointer receivers.
@@ -1675,6 +2621,106 @@ func emitHasMethodEdges(pkgs []*packages.Package, _ *token.FileSet, defLookup *D
 	return count
 }
 
+// todoMarker matches a TODO/FIXME/XXX/HACK marker word in comment text.
+var todoMarker = regexp.MustCompile(`\b(TODO|FIXME|XXX|HACK)\b`)
+
+// classifyComment returns the comment_classification of cg: directive
+// (//go:..., //nolint, //cpg:ignore, //line), license (a copyright or license
+// header before the package clause), todo (a TODO/FIXME/XXX/HACK marker), doc
+// (documents a declaration or the package) or other, in that precedence.
+func classifyComment(cg *ast.CommentGroup, isDoc, beforePackage bool) string {
+	directive := true
+	for _, c := range cg.List {
+		if !strings.HasPrefix(c.Text, "//go:") && !strings.HasPrefix(c.Text, "//line ") &&
+			!strings.HasPrefix(c.Text, "//nolint") && !strings.HasPrefix(c.Text, "//cpg:") {
+			directive = false
+			break
+		}
+	}
+	text := cg.Text()
+	switch {
+	case directive:
+		return "directive"
+	case beforePackage && !isDoc && (strings.Contains(text, "Copyright") || strings.Contains(text, "License") ||
+		strings.Contains(text, "SPDX-License-Identifier")):
+		return "license"
+	case todoMarker.MatchString(text):
+		return "todo"
+	case isDoc:
+		return "doc"
+	}
+	return "other"
+}
+
+// suppressedCategories returns the finding categories silenced by the
+// //cpg:ignore and //nolint directives in cg: "//cpg:ignore complexity,god_function"
+// or "//nolint:complexity". A directive without categories (or nolint:all)
+// silences every category and yields "*". Text after the category list is
+// a free-form reason.
+func suppressedCategories(cg *ast.CommentGroup) []string {
+	var cats []string
+	for _, c := range cg.List {
+		var list string
+		switch {
+		case c.Text == "//cpg:ignore" || strings.HasPrefix(c.Text, "//cpg:ignore "):
+			list, _, _ = strings.Cut(strings.TrimSpace(strings.TrimPrefix(c.Text, "//cpg:ignore")), " ")
+		case c.Text == "//nolint" || strings.HasPrefix(c.Text, "//nolint "):
+		case strings.HasPrefix(c.Text, "//nolint:"):
+			list, _, _ = strings.Cut(strings.TrimPrefix(c.Text, "//nolint:"), " ")
+		default:
+			continue
+		}
+		if list == "" || list == "all" {
+			cats = append(cats, "*")
+			continue
+		}
+		for _, cat := range strings.Split(list, ",") {
+			if cat != "" && !slices.Contains(cats, cat) {
+				cats = append(cats, cat)
+			}
+		}
+	}
+	return cats
+}
+
+// parseStructTag splits a conventional struct tag into key → value, reading
+// each value with reflect.StructTag.Lookup. Parsing stops at the first
+// malformed pair, as reflect does.
+func parseStructTag(tag string) map[string]string {
+	st := reflect.StructTag(tag)
+	var tags map[string]string
+	for rest := tag; ; {
+		rest = strings.TrimLeft(rest, " ")
+		i := 0
+		for i < len(rest) && rest[i] > ' ' && rest[i] != ':' && rest[i] != '"' && rest[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(rest) || rest[i] != ':' || rest[i+1] != '"' {
+			return tags
+		}
+		key := rest[:i]
+		rest = rest[i+1:]
+		// Skip the quoted value, honoring escapes
+		i = 1
+		for i < len(rest) && rest[i] != '"' {
+			if rest[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(rest) {
+			return tags
+		}
+		rest = rest[i+1:]
+		if value, ok := st.Lookup(key); ok {
+			if tags == nil {
+				tags = map[string]string{}
+			}
+			tags[key] = value
+		}
+	}
+}
+
 // exprTypeName extracts a human-readable name from a type expression.
 func exprTypeName(expr ast.Expr) string {
 	switch t := expr.(type) {
@@ -1722,6 +2768,48 @@ func isMutableType(t types.Type) bool {
 	return false
 }
 
+// sizeKnown reports whether types.Sizes can size t: not a type parameter or
+// invalid type, nor a struct or array containing one by value (generic
+// signatures before instantiation).
+func sizeKnown(t types.Type) bool {
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Kind() != types.Invalid
+	case *types.Array:
+		return sizeKnown(u.Elem())
+	case *types.Struct:
+		for i := range u.NumFields() {
+			if !sizeKnown(u.Field(i).Type()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isArrayType returns true if t is a fixed-size array (copied by value).
+func isArrayType(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Array)
+	return ok
+}
+
+// isPointerIdent returns true if expr is a plain identifier of pointer type.
+func isPointerIdent(info *types.Info, expr ast.Expr) bool {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	obj := info.Uses[id]
+	if obj == nil {
+		return false
+	}
+	_, ptr := obj.Type().Underlying().(*types.Pointer)
+	return ptr
+}
+
 // isContextType returns true if the type is context.Context.
 func isContextType(t types.Type) bool {
 	named, ok := t.(*types.Named)
@@ -1732,6 +2820,47 @@ func isContextType(t types.Type) bool {
 	return obj != nil && obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
 }
 
+// isOnceType reports whether t is sync.Once or *sync.Once.
+func isOnceType(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "sync" && obj.Name() == "Once"
+}
+
+// onceGuard is a Once.Do call whose argument names a function or method.
+type onceGuard struct {
+	callID string
+	fn     types.Object
+}
+
+// emitOnceGuarded links a Once.Do call to the function it guards: a function
+// literal argument directly, a named function or method value once WalkAST
+// has seen every declaration.
+func (v *astVisitor) emitOnceGuarded(callID string, arg ast.Expr) {
+	var ident *ast.Ident
+	switch a := ast.Unparen(arg).(type) {
+	case *ast.FuncLit:
+		v.cpg.AddEdge(Edge{Source: callID, Target: v.exprNodeID(a), Kind: "once_guarded"})
+		v.edgeCount++
+		return
+	case *ast.Ident:
+		ident = a
+	case *ast.SelectorExpr:
+		ident = a.Sel
+	default:
+		return
+	}
+	if fn, ok := v.pkg.TypesInfo.Uses[ident].(*types.Func); ok {
+		*v.onceGuards = append(*v.onceGuards, onceGuard{callID: callID, fn: fn})
+	}
+}
+
 // isNilableType returns true if a type can be nil
 // (pointer, slice, map, channel, interface, or function).
 func isNilableType(t types.Type) bool {
@@ -1812,3 +2941,55 @@ func (v *astVisitor) detectSyncPrimitive(sel *ast.SelectorExpr) string {
 	}
 	return ""
 }
+
+// markUnreachable builds the statement-level CFG of a function body and
+// records the first statement of every dead region: a non-live block that is
+// not itself only entered from another dead, non-empty block (so the
+// statements following a dead if/for are not reported again).
+// Calls to panic, os.Exit, log.Fatal* and runtime.Goexit end a block.
+func (v *astVisitor) markUnreachable(body *ast.BlockStmt) {
+	g := cfg.New(body, v.mayReturn)
+	fromDead := make(map[*cfg.Block]bool)
+	for _, b := range g.Blocks {
+		if !b.Live && len(b.Nodes) > 0 {
+			for _, succ := range b.Succs {
+				fromDead[succ] = true
+			}
+		}
+	}
+	for _, b := range g.Blocks {
+		if b.Live || fromDead[b] {
+			continue
+		}
+		for _, n := range b.Nodes {
+			if stmt, ok := n.(ast.Stmt); ok {
+				v.unreachable[stmt.Pos()] = true
+				break
+			}
+		}
+	}
+}
+
+// mayReturn reports whether a call can return to its caller (for cfg.New).
+func (v *astVisitor) mayReturn(call *ast.CallExpr) bool {
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		if b, ok := v.pkg.TypesInfo.Uses[fun].(*types.Builtin); ok && b.Name() == "panic" {
+			return false
+		}
+	case *ast.SelectorExpr:
+		fn, ok := v.pkg.TypesInfo.Uses[fun.Sel].(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			break
+		}
+		switch fn.Pkg().Path() {
+		case "os":
+			return fn.Name() != "Exit"
+		case "log":
+			return !strings.HasPrefix(fn.Name(), "Fatal")
+		case "runtime":
+			return fn.Name() != "Goexit"
+		}
+	}
+	return true
+}
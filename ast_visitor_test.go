@@ -0,0 +1,370 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func TestReturnsEdges(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Pair(a int) (int, error) {
+	return a, nil
+}
+
+func Named(n int) (out int, err error) {
+	out = n * 2
+	return
+}
+
+func Both() (int, error) { return Pair(1) }
+`)
+	got := queryStrings(t, conn,
+		`SELECT fn.name || ':' || src.kind || '->' || json_extract(e.properties, '$.index')
+		 FROM edges e
+		 JOIN nodes src ON src.id = e.source
+		 JOIN nodes r ON r.id = e.target AND r.kind = 'result'
+		 JOIN nodes fn ON fn.id = r.parent_function
+		 WHERE e.kind = 'returns'
+		 ORDER BY fn.name, json_extract(e.properties, '$.index')`)
+	want := "Both:call->0,Both:call->1,Named:assign->0,Pair:identifier->0,Pair:return->1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("returns edges = %v, want %s", got, want)
+	}
+}
+
+func TestCallKinds(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "strings"
+
+type MyType int
+
+func (m MyType) Double() MyType { return m * 2 }
+
+type Doubler interface{ Double() MyType }
+
+func helper(x int) int { return x }
+
+func Use(xs []int, d Doubler, f func(int) int) int {
+	n := len(xs)
+	m := MyType(n)
+	_ = strings.TrimSpace("x")
+	_ = m.Double()
+	_ = d.Double()
+	return helper(f(n))
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT json_extract(properties, '$.code') || '=' || json_extract(properties, '$.call_kind')
+		 FROM nodes WHERE kind = 'call' AND line BETWEEN 14 AND 19 ORDER BY line, col`)
+	want := `len(xs)=builtin,MyType(n)=conversion,strings.TrimSpace("x")=func,m.Double()=method,` +
+		`d.Double()=interface,helper(f(n))=func,f(n)=func_value`
+	if strings.Join(got, ",") != want {
+		t.Errorf("call kinds = %v, want %s", got, want)
+	}
+
+	edges := queryStrings(t, conn,
+		`SELECT DISTINCT callee.name || '=' || json_extract(e.properties, '$.call_kind')
+		 FROM edges e JOIN nodes caller ON caller.id = e.source JOIN nodes callee ON callee.id = e.target
+		 WHERE e.kind = 'call' AND caller.name = 'Use' ORDER BY callee.name`)
+	if strings.Join(edges, ",") != "MyType.Double=method,TrimSpace=func,helper=func" {
+		t.Errorf("call edge kinds = %v", edges)
+	}
+}
+
+func TestASTHash(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "strings"
+
+func Sum(xs []int) int {
+	if len(xs) == 0 {
+		return 0
+	}
+	total := 0
+	for _, x := range xs {
+		if x > 0 {
+			total += x
+		}
+	}
+	return total
+}
+
+func Add(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	acc := 0
+	for _, v := range values {
+		if v > 1 {
+			acc += v
+		}
+	}
+	return acc
+}
+
+func Product(xs []int) int {
+	if len(xs) == 0 {
+		return 0
+	}
+	total := 1
+	for _, x := range xs {
+		if x > 0 {
+			total *= x
+		}
+	}
+	return total
+}
+
+func Upper(s string) string { return strings.ToUpper(s) }
+
+func Lower(s string) string { return strings.ToLower(s) }
+
+func Run() int { return Sum(nil) + Add(nil) + Product(nil) + len(Upper("a")+Lower("b")) }
+`)
+	hash := map[string]string{}
+	for _, row := range queryStrings(t, conn, `SELECT name || '=' || COALESCE(json_extract(properties, '$.ast_hash'), '') FROM nodes WHERE kind = 'function' AND file IS NOT NULL`) {
+		name, h, _ := strings.Cut(row, "=")
+		if h == "" {
+			t.Errorf("%s has no ast_hash", name)
+		}
+		hash[name] = h
+	}
+	if hash["Sum"] != hash["Add"] {
+		t.Errorf("Sum and Add differ only in names and a literal but hash %s vs %s", hash["Sum"], hash["Add"])
+	}
+	if hash["Sum"] == hash["Product"] {
+		t.Errorf("Sum and Product (+= vs *=) share hash %s", hash["Sum"])
+	}
+	if hash["Upper"] == hash["Lower"] {
+		t.Errorf("Upper and Lower (different callees) share hash %s", hash["Upper"])
+	}
+
+	got := queryStrings(t, conn, `SELECT f.message FROM findings f WHERE f.category = 'similar_function'`)
+	if strings.Join(got, ",") != "Sum is a structural clone of Add (2 functions share this AST, loc=12)" {
+		t.Errorf("similar_function = %v", got)
+	}
+}
+
+func TestEndCol(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Twice(n int) int { return n * 2 }
+
+func Run() int {
+	return Twice(3)
+}
+`)
+	got := queryStrings(t, conn, `SELECT kind || ' ' || line || ':' || col || '-' || end_line || ':' || end_col FROM nodes
+		WHERE kind IN ('function', 'call', 'return') AND file IS NOT NULL ORDER BY line, col`)
+	want := "function 3:1-3:39,return 3:25-3:37,function 5:1-7:2,return 6:2-6:17,call 6:14-6:17"
+	if strings.Join(got, ",") != want {
+		t.Errorf("spans = %v, want %s", got, want)
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	prev := flagMaxFileSize
+	t.Cleanup(func() { flagMaxFileSize = prev })
+	flagMaxFileSize = 4096
+
+	table := "package fixture\n\nvar Table = []string{\n" + strings.Repeat("\t\"0123456789abcdef0123456789abcdef\",\n", 200) + "}\n\nfunc Lookup(i int) string { return Table[i] }\n"
+	cpg := buildTestCPGFiles(t, map[string]string{
+		"fixture.go": "package fixture\n\nfunc Get() string { return Lookup(0) }\n",
+		"bindata.go": table,
+	})
+
+	var kinds []string
+	for _, n := range cpg.Nodes {
+		if n.File != "bindata.go" {
+			continue
+		}
+		kinds = append(kinds, n.Kind)
+		if n.Kind == "file" && n.Properties["oversized"] != true {
+			t.Errorf("bindata.go file node not marked oversized: %v", n.Properties)
+		}
+	}
+	if strings.Join(kinds, ",") != "file" {
+		t.Errorf("bindata.go nodes = %v, want only the file node", kinds)
+	}
+	if _, ok := cpg.Sources["bindata.go"]; ok {
+		t.Error("oversized file's source was read")
+	}
+	if _, ok := cpg.Sources["fixture.go"]; !ok {
+		t.Error("small file's source missing")
+	}
+	writeTestDB(t, cpg)
+}
+
+func TestEmitComments(t *testing.T) {
+	src := `// Copyright 2026 The Fixture Authors. Licensed under the MIT License.
+
+// Package fixture is a test.
+package fixture
+
+// Get returns the answer.
+func Get() int {
+	// TODO: compute it
+	return 42 //nolint:magic
+}
+
+// FIXME: unused
+var x = 1
+`
+	conn := buildTestDB(t, src)
+	got := queryStrings(t, conn, `SELECT line || ' ' || json_extract(properties, '$.comment_classification')
+		FROM nodes WHERE kind = 'comment' ORDER BY line`)
+	want := "1 license,3 doc,6 doc,8 todo,9 directive,12 todo"
+	if strings.Join(got, ",") != want {
+		t.Errorf("classifications = %v, want %s", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT json_extract(details, '$.count') FROM findings WHERE category = 'todo_comment'`)
+	if strings.Join(got, ",") != "2" {
+		t.Errorf("todo_comment counts = %v, want [2]", got)
+	}
+
+	prev := flagEmitComments
+	t.Cleanup(func() { flagEmitComments = prev })
+	flagEmitComments = false
+
+	cpg := buildTestCPG(t, src)
+	for _, n := range cpg.Nodes {
+		if n.Kind == "comment" {
+			t.Errorf("comment node %s emitted with -emit-comments=false", n.ID)
+		}
+	}
+	for _, e := range cpg.Edges {
+		if e.Kind == "doc" {
+			t.Errorf("doc edge %s -> %s emitted with -emit-comments=false", e.Source, e.Target)
+		}
+	}
+	writeTestDB(t, cpg)
+}
+
+func TestInitBefore(t *testing.T) {
+	cpg := buildTestCPGFiles(t, map[string]string{
+		"a/a.go": "package a\n\nimport \"example.com/fixture/b\"\n\nvar Ready bool\n\nfunc init() { Ready = b.Ready }\n",
+		"b/b.go": "package b\n\nvar Ready bool\n\nfunc init() { Ready = true }\n",
+		"c/c.go": "package c\n\nfunc init() {}\n",
+	})
+	var edges []string
+	for _, e := range cpg.Edges {
+		if e.Kind == "init_before" {
+			edges = append(edges, e.Source+"->"+e.Target)
+		}
+	}
+	if strings.Join(edges, ",") != "pkg::b->pkg::a" {
+		t.Errorf("init_before edges = %v, want [pkg::b->pkg::a]", edges)
+	}
+	ranks := map[string]any{}
+	for _, n := range cpg.Nodes {
+		if n.Kind == "package" {
+			ranks[n.Name] = n.Properties["init_rank"]
+		}
+	}
+	// b before its importer a; c (no imports) sorts after b by import path.
+	if ranks["b"] != 0 || ranks["a"] != 1 || ranks["c"] != 2 {
+		t.Errorf("init ranks = %v, want b:0 a:1 c:2", ranks)
+	}
+}
+
+func TestGlobalDeclNodes(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+const limit = 3
+
+var hits int
+
+func Record() {
+	if hits < limit {
+		hits++
+	}
+}
+
+func Reset() {
+	var n int
+	hits = n
+}
+`)
+	got := queryStrings(t, conn, `SELECT kind || ':' || name FROM nodes
+		WHERE kind IN ('global_var', 'global_const', 'local') ORDER BY line`)
+	if want := "global_const:limit,global_var:hits,local:n"; strings.Join(got, ",") != want {
+		t.Errorf("declaration nodes = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT DISTINCT f.name FROM nodes g
+		JOIN edges e ON e.target = g.id AND e.kind = 'ref'
+		JOIN nodes u ON u.id = e.source
+		JOIN nodes f ON f.id = u.parent_function
+		WHERE g.kind = 'global_var' AND g.name = 'hits' ORDER BY f.name`)
+	if want := "Record,Reset"; strings.Join(got, ",") != want {
+		t.Errorf("functions referencing hits = %v, want [%s]", got, want)
+	}
+	var block string
+	for _, id := range queryStrings(t, conn, `SELECT b.id FROM nodes b JOIN nodes f ON f.id = b.parent_function
+		WHERE b.kind = 'block' AND f.name = 'Reset'`) {
+		block = id
+	}
+	var scopeSQL string
+	for _, q := range queryStrings(t, conn, `SELECT sql FROM queries WHERE name = 'scope_variables'`) {
+		scopeSQL = q
+	}
+	var inScope []string
+	err := sqlitex.Execute(conn, scopeSQL, &sqlitex.ExecOptions{
+		Named: map[string]any{":block_id": block},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			inScope = append(inScope, stmt.GetText("name"))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("scope_variables: %v", err)
+	}
+	if want := "limit,hits,n"; strings.Join(inScope, ",") != want {
+		t.Errorf("scope_variables in Reset = %v, want [%s]", inScope, want)
+	}
+}
+
+func TestOnceGuarded(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "sync"
+
+type Cache struct {
+	once sync.Once
+	data map[string]int
+}
+
+func (c *Cache) Get(k string) int {
+	c.once.Do(func() {
+		c.data = map[string]int{}
+	})
+	return c.data[k]
+}
+
+var setupOnce sync.Once
+
+func Setup() { setupOnce.Do(load) }
+
+func load() {}
+`)
+	got := queryStrings(t, conn,
+		`SELECT target.kind || ':' || target.name FROM edges e
+		 JOIN nodes target ON target.id = e.target
+		 WHERE e.kind = 'once_guarded' ORDER BY target.name`)
+	if want := "function:func literal,function:load"; strings.Join(got, ",") != want {
+		t.Errorf("once_guarded targets = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn,
+		`SELECT kind || ':' || name FROM nodes
+		 WHERE json_extract(properties, '$.sync_kind') = 'once' ORDER BY name`)
+	if want := "field:once,global_var:setupOnce"; strings.Join(got, ",") != want {
+		t.Errorf("sync_kind=once nodes = %v, want [%s]", got, want)
+	}
+	if n := queryStrings(t, conn, `SELECT lazy_init_count FROM go_pattern_summary`); len(n) != 1 || n[0] != "2" {
+		t.Errorf("go_pattern_summary lazy_init_count = %v, want [2]", n)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Bundle entry names, in the order WriteBundle emits them.
+const (
+	bundleDB       = "cpg.db"
+	bundleSARIF    = "findings.sarif"
+	bundleReport   = "report.txt"
+	bundleManifest = "manifest.json"
+)
+
+// bundleFinding is one findings row as exported into a bundle.
+type bundleFinding struct {
+	Category, Severity, File, Message string
+	Line                              int64
+}
+
+// WriteBundleFile writes the bundle for dbPath to path, or to stdout when
+// path is "-".
+func WriteBundleFile(path, dbPath string, prog *Progress) error {
+	if path == "-" {
+		return WriteBundle(os.Stdout, dbPath, prog)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	if err := WriteBundle(f, dbPath, prog); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	return nil
+}
+
+// WriteBundle streams a tar of a finished CPG database to w: the database
+// itself plus SARIF, plain-text and JSON exports derived from it in the same
+// pass. The database is copied straight from disk; the exports are small and
+// are rendered in memory because tar headers need their sizes up front.
+func WriteBundle(w io.Writer, dbPath string, prog *Progress) error {
+	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	if err != nil {
+		return fmt.Errorf("bundle: open %s: %w", dbPath, err)
+	}
+	defer conn.Close()
+
+	var findings []bundleFinding
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT category, severity, COALESCE(file, ''), COALESCE(line, 0), message
+		 FROM findings ORDER BY id`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			findings = append(findings, bundleFinding{
+				Category: stmt.ColumnText(0),
+				Severity: stmt.ColumnText(1),
+				File:     stmt.ColumnText(2),
+				Line:     stmt.ColumnInt64(3),
+				Message:  stmt.ColumnText(4),
+			})
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("bundle: findings: %w", err)
+	}
+
+	manifest, err := bundleManifestJSON(conn)
+	if err != nil {
+		return err
+	}
+	sarif, err := findingsSARIF(findings)
+	if err != nil {
+		return err
+	}
+	report, err := bundleReportText(conn, findings)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tw := tar.NewWriter(w)
+	db, err := os.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	defer db.Close()
+	info, err := db.Stat()
+	if err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: bundleDB, Mode: 0o644, Size: info.Size(), ModTime: now}); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	if _, err := io.Copy(tw, db); err != nil {
+		return fmt.Errorf("bundle: %s: %w", bundleDB, err)
+	}
+	for _, e := range []struct {
+		name string
+		data []byte
+	}{
+		{bundleSARIF, sarif},
+		{bundleReport, report},
+		{bundleManifest, manifest},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.data)), ModTime: now}); err != nil {
+			return fmt.Errorf("bundle: %w", err)
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return fmt.Errorf("bundle: %s: %w", e.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	prog.Log("Bundle: %d findings, %d MB database", len(findings), info.Size()/(1024*1024))
+	return nil
+}
+
+// sarifLevel maps a findings severity onto a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error", "warning":
+		return severity
+	}
+	return "note"
+}
+
+// findingsSARIF renders findings as a SARIF 2.1.0 log with one rule per
+// category.
+func findingsSARIF(findings []bundleFinding) ([]byte, error) {
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *struct {
+				StartLine int64 `json:"startLine"`
+			} `json:"region,omitempty"`
+		} `json:"physicalLocation"`
+	}
+	type message struct {
+		Text string `json:"text"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations,omitempty"`
+	}
+	type rule struct {
+		ID string `json:"id"`
+	}
+
+	seen := map[string]bool{}
+	rules := []rule{}
+	results := []result{}
+	for _, f := range findings {
+		if !seen[f.Category] {
+			seen[f.Category] = true
+			rules = append(rules, rule{ID: f.Category})
+		}
+		r := result{RuleID: f.Category, Level: sarifLevel(f.Severity), Message: message{f.Message}}
+		if f.File != "" {
+			var loc location
+			loc.PhysicalLocation.ArtifactLocation.URI = f.File
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &struct {
+					StartLine int64 `json:"startLine"`
+				}{f.Line}
+			}
+			r.Locations = []location{loc}
+		}
+		results = append(results, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	log := map[string]any{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":    "cpg-gen",
+				"version": generatorVersion,
+				"rules":   rules,
+			}},
+			"results": results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("bundle: sarif: %w", err)
+	}
+	return data, nil
+}
+
+// bundleManifestJSON exports cpg_manifest as JSON.
+func bundleManifestJSON(conn *sqlite.Conn) ([]byte, error) {
+	type object struct {
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		RowCount *int64 `json:"row_count"`
+	}
+	objects := []object{}
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT object_type, name, row_count FROM cpg_manifest ORDER BY name`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			o := object{Type: stmt.ColumnText(0), Name: stmt.ColumnText(1)}
+			if stmt.ColumnType(2) != sqlite.TypeNull {
+				n := stmt.ColumnInt64(2)
+				o.RowCount = &n
+			}
+			objects = append(objects, o)
+			return nil
+		}}); err != nil {
+		return nil, fmt.Errorf("bundle: manifest: %w", err)
+	}
+	data, err := json.MarshalIndent(map[string]any{
+		"generator":         "cpg-gen",
+		"generator_version": generatorVersion,
+		"objects":           objects,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("bundle: manifest: %w", err)
+	}
+	return data, nil
+}
+
+// bundleReportText renders a short human-readable summary: graph size and
+// findings per category and severity.
+func bundleReportText(conn *sqlite.Conn, findings []bundleFinding) ([]byte, error) {
+	var nodes, edges int64
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT (SELECT COUNT(*) FROM nodes), (SELECT COUNT(*) FROM edges)`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			nodes, edges = stmt.ColumnInt64(0), stmt.ColumnInt64(1)
+			return nil
+		}}); err != nil {
+		return nil, fmt.Errorf("bundle: report: %w", err)
+	}
+
+	bySeverity := map[string]int{}
+	byCategory := map[string]int{}
+	for _, f := range findings {
+		bySeverity[f.Severity]++
+		byCategory[f.Category]++
+	}
+	cats := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		cats = append(cats, c)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		if byCategory[cats[i]] != byCategory[cats[j]] {
+			return byCategory[cats[i]] > byCategory[cats[j]]
+		}
+		return cats[i] < cats[j]
+	})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "cpg-gen %s report\n\n", generatorVersion)
+	fmt.Fprintf(&b, "Nodes:    %d\nEdges:    %d\nFindings: %d (%d error, %d warning, %d info)\n",
+		nodes, edges, len(findings), bySeverity["error"], bySeverity["warning"], bySeverity["info"])
+	if len(cats) > 0 {
+		b.WriteString("\nFindings by category:\n")
+		for _, c := range cats {
+			fmt.Fprintf(&b, "  %-40s %d\n", c, byCategory[c])
+		}
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	src := `package fixture
+
+import (
+	"errors"
+	"io"
+)
+
+func IsEOF(err error) bool {
+	return err == io.EOF
+}
+
+var errOther = errors.New("other")
+
+func Done(err error) bool {
+	return IsEOF(err) || errors.Is(err, errOther)
+}
+`
+	dbPath := buildTestDBFile(t, src)
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, dbPath, NewProgress(false)); err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+
+	entries := map[string][]byte{}
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", hdr.Name, err)
+		}
+		names = append(names, hdr.Name)
+		entries[hdr.Name] = data
+	}
+	if got := strings.Join(names, ","); got != "cpg.db,findings.sarif,report.txt,manifest.json" {
+		t.Fatalf("entries = %s", got)
+	}
+
+	want, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(entries["cpg.db"], want) {
+		t.Errorf("cpg.db differs from the written database (%d vs %d bytes)", len(entries["cpg.db"]), len(want))
+	}
+
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(entries["findings.sarif"], &sarif); err != nil {
+		t.Fatalf("findings.sarif: %v", err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || sarif.Runs[0].Tool.Driver.Name != "cpg-gen" {
+		t.Fatalf("unexpected SARIF header: %+v", sarif)
+	}
+	found := false
+	for _, r := range sarif.Runs[0].Results {
+		if r.RuleID == "error_equality_comparison" {
+			found = r.Level == "warning" && len(r.Locations) == 1 &&
+				r.Locations[0].PhysicalLocation.Region.StartLine == 9
+		}
+	}
+	if !found {
+		t.Errorf("SARIF lacks error_equality_comparison warning at line 9: %+v", sarif.Runs[0].Results)
+	}
+
+	var manifest struct {
+		GeneratorVersion string `json:"generator_version"`
+		Objects          []struct {
+			Type     string `json:"type"`
+			Name     string `json:"name"`
+			RowCount *int64 `json:"row_count"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(entries["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if manifest.GeneratorVersion != generatorVersion {
+		t.Errorf("manifest generator_version = %q", manifest.GeneratorVersion)
+	}
+	hasNodes := false
+	for _, o := range manifest.Objects {
+		if o.Name == "nodes" && o.Type == "table" && o.RowCount != nil && *o.RowCount > 0 {
+			hasNodes = true
+		}
+	}
+	if !hasNodes {
+		t.Errorf("manifest lacks a populated nodes table")
+	}
+
+	if !strings.Contains(string(entries["report.txt"]), "error_equality_comparison") {
+		t.Errorf("report.txt lacks the finding category:\n%s", entries["report.txt"])
+	}
+}
@@ -1,31 +1,52 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"go/token"
+	"go/types"
+	"path/filepath"
 
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/ssa"
 )
 
 // BuildCallGraph constructs a VTA call graph and emits call/call_site edges.
+// VTA itself runs to completion; ctx is checked before it and while its edges
+// are visited.
 func BuildCallGraph(
+	ctx context.Context,
 	ssaResult *SSAResult,
 	fset *token.FileSet,
 	posLookup *PosLookup,
 	funcLookup *FuncLookup,
 	cpg *CPG,
 	prog *Progress,
-) {
+) error {
+	if !emitFilter.AnyEdge(callPhaseEdges...) {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("call graph: %w", err)
+	}
 	prog.Log("Building VTA call graph...")
 
 	cg := vta.CallGraph(ssaResult.AllFuncs, nil)
-	cg.DeleteSyntheticNodes()
+	if flagScope != "" {
+		deleteWrapperNodes(cg)
+	} else {
+		cg.DeleteSyntheticNodes()
+	}
 
 	var callEdges, callSiteEdges, paramInEdges, paramOutEdges, callToReturnEdges int
-	var vtaTotal, vtaProm, vtaMatched, stubCount int
+	var vtaTotal, vtaProm, vtaMatched, stubCount, internalStubCount int
 	stubs := make(map[string]bool) // track created stub nodes
 
-	_ = callgraph.GraphVisitEdges(cg, func(edge *callgraph.Edge) error {
+	err := callgraph.GraphVisitEdges(cg, func(edge *callgraph.Edge) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		caller := edge.Caller.Func
 		callee := edge.Callee.Func
 
@@ -57,18 +78,38 @@ func BuildCallGraph(
 				return nil
 			}
 			pkgPath := callee.Pkg.Pkg.Path()
+			internal := modSet.IsInternalDep(pkgPath)
 			stubID := "ext::" + callee.String()
+			if internal {
+				stubID = "int::" + callee.String()
+			}
 			if !stubs[stubID] {
+				props := map[string]any{
+					"external":  !internal,
+					"full_name": callee.String(),
+					"params":    tupleTypes(callee.Signature.Params(), callee.Signature.Variadic()),
+					"results":   tupleTypes(callee.Signature.Results(), false),
+					"variadic":  callee.Signature.Variadic(),
+				}
+				if internal {
+					// First-party dependency: keep enough to find the source.
+					props["internal"] = true
+					if pos := fset.Position(callee.Pos()); pos.IsValid() {
+						props["source_file"] = filepath.Base(pos.Filename)
+						props["source_line"] = pos.Line
+					}
+					if callee.Signature.Recv() != nil {
+						props["receiver"] = callee.Signature.Recv().Type().String()
+					}
+					internalStubCount++
+				}
 				cpg.AddNode(Node{
-					ID:       stubID,
-					Kind:     "function",
-					Name:     callee.Name(),
-					Package:  modSet.RelPkg(pkgPath),
-					TypeInfo: callee.Signature.String(),
-					Properties: map[string]any{
-						"external":  true,
-						"full_name": callee.String(),
-					},
+					ID:         stubID,
+					Kind:       "function",
+					Name:       callee.Name(),
+					Package:    modSet.RelPkg(pkgPath),
+					TypeInfo:   callee.Signature.String(),
+					Properties: props,
 				})
 				stubs[stubID] = true
 				stubCount++
@@ -85,6 +126,9 @@ func BuildCallGraph(
 		if edge.Site != nil && edge.Site.Common().IsInvoke() {
 			props["dynamic"] = true
 		}
+		if edge.Site != nil {
+			props["call_kind"] = ssaCallKind(edge.Site.Common())
+		}
 
 		// Emit function→function call edge
 		cpg.AddEdge(Edge{
@@ -110,11 +154,17 @@ func BuildCallGraph(
 			siteID = posLookup.Get(relFile, p.Line, p.Column)
 		}
 		if siteID != "" {
+			// The site's basic block lets SQL ask whether the call is
+			// control-dependent (cdg) on a branch.
+			siteProps := map[string]any{"block": BlockID(callerID, edge.Site.Block().Index)}
+			for k, v := range props {
+				siteProps[k] = v
+			}
 			cpg.AddEdge(Edge{
 				Source:     siteID,
 				Target:     calleeID,
 				Kind:       "call_site",
-				Properties: props,
+				Properties: siteProps,
 			})
 			callSiteEdges++
 		}
@@ -186,9 +236,78 @@ func BuildCallGraph(
 
 		return nil
 	})
+	if err != nil {
+		return fmt.Errorf("call graph: %w", err)
+	}
 
-	prog.Log("VTA: %d total edges, %d known-module pairs, %d matched to AST, %d external stubs", vtaTotal, vtaProm, vtaMatched, stubCount)
+	prog.Log("VTA: %d total edges, %d known-module pairs, %d matched to AST, %d external stubs (%d internal-prefix)", vtaTotal, vtaProm, vtaMatched, stubCount, internalStubCount)
 	prog.Log("Created %d call, %d call_site, %d param_in, %d param_out, %d call_to_return edges", callEdges, callSiteEdges, paramInEdges, paramOutEdges, callToReturnEdges)
+	return nil
+}
+
+// deleteWrapperNodes is callgraph.Graph.DeleteSyntheticNodes for -scope
+// loads: it splices out wrappers and other synthetic functions but keeps
+// the imported functions known only from export data, which have no syntax
+// either yet are the real callees that get stubs.
+func deleteWrapperNodes(g *callgraph.Graph) {
+	edges := make(map[callgraph.Edge]bool)
+	for _, cgn := range g.Nodes {
+		for _, e := range cgn.Out {
+			edges[*e] = true
+		}
+	}
+	for fn, cgn := range g.Nodes {
+		if cgn == g.Root || fn.Syntax() != nil || fn.Synthetic == "from type information" ||
+			(fn.Pkg != nil && fn.Pkg.Func("init") == fn) {
+			continue
+		}
+		for _, in := range cgn.In {
+			for _, out := range cgn.Out {
+				e := callgraph.Edge{Caller: in.Caller, Site: in.Site, Callee: out.Callee}
+				if !edges[e] {
+					callgraph.AddEdge(in.Caller, in.Site, out.Callee)
+					edges[e] = true
+				}
+			}
+		}
+		g.DeleteNode(cgn)
+	}
+}
+
+// tupleTypes returns the type strings of a parameter or result tuple,
+// e.g. ["string", "...any"] for fmt.Sprintf's params. The final variadic
+// parameter is rendered with a "..." prefix instead of as a slice.
+func tupleTypes(t *types.Tuple, variadic bool) []string {
+	out := make([]string, t.Len())
+	for i := range t.Len() {
+		typ := t.At(i).Type()
+		if slice, ok := typ.(*types.Slice); ok && variadic && i == t.Len()-1 {
+			out[i] = "..." + slice.Elem().String()
+			continue
+		}
+		out[i] = typ.String()
+	}
+	return out
+}
+
+// ssaCallKind is the call_kind of a call graph edge, matching the call
+// node's: "interface" for invokes, "func"/"method" for static callees and
+// "func_value" for calls through closures and func values. Builtins and
+// conversions never reach the call graph.
+func ssaCallKind(c *ssa.CallCommon) string {
+	if c.IsInvoke() {
+		return "interface"
+	}
+	if fn := c.StaticCallee(); fn != nil {
+		if _, isClosure := c.Value.(*ssa.MakeClosure); isClosure || fn.Parent() != nil {
+			return "func_value"
+		}
+		if fn.Signature.Recv() != nil {
+			return "method"
+		}
+		return "func"
+	}
+	return "func_value"
 }
 
 // ComputeFanInOut calculates fan-in, fan-out, and recursion from the call graph edges.
@@ -21,6 +21,9 @@ func ExtractCDG(
 	cpg *CPG,
 	prog *Progress,
 ) {
+	if !emitFilter.AnyEdge(cdgPhaseEdges...) {
+		return
+	}
 	prog.Log("Extracting CDG (control dependence)...")
 
 	var cdgEdges, domEdges, pdomEdges, cdgFuncs int
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+const (
+	// pageRankDamping is the probability of following a call rather than
+	// jumping to a random function.
+	pageRankDamping = 0.85
+	// pageRankIterations caps the power iteration; it usually converges to
+	// pageRankTolerance (L1 change per step) well before.
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+	// betweennessSources bounds the breadth-first searches behind betweenness:
+	// larger call graphs use this many evenly spaced sources and scale up.
+	betweennessSources = 512
+)
+
+// pageRank returns the PageRank of each node of the directed graph given as
+// adjacency lists over node indices. Rank of nodes without out-edges is
+// spread evenly, so the ranks always sum to 1.
+func pageRank(succs [][]int) []float64 {
+	n := len(succs)
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for range pageRankIterations {
+		dangling := 0.0
+		for i, out := range succs {
+			if len(out) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, out := range succs {
+			share := pageRankDamping * rank[i] / float64(len(out))
+			for _, j := range out {
+				next[j] += share
+			}
+		}
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// betweenness returns the betweenness centrality of each node (Brandes'
+// algorithm for unweighted directed graphs), normalized by (n-1)(n-2): the
+// fraction of shortest paths between other nodes that pass through it. With
+// more than betweennessSources nodes it is estimated from evenly spaced
+// sources.
+func betweenness(succs [][]int) []float64 {
+	n := len(succs)
+	bc := make([]float64, n)
+	if n < 3 {
+		return bc
+	}
+	step := 1
+	if n > betweennessSources {
+		step = n / betweennessSources
+	}
+	sigma := make([]float64, n)
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	var stack, queue []int
+	sources := 0
+	for s := 0; s < n; s += step {
+		sources++
+		for i := range n {
+			sigma[i], dist[i], delta[i], preds[i] = 0, -1, 0, preds[i][:0]
+		}
+		sigma[s], dist[s] = 1, 0
+		stack, queue = stack[:0], append(queue[:0], s)
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range succs[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				bc[w] += delta[w]
+			}
+		}
+	}
+	scale := float64(n) / float64(sources) / float64((n-1)*(n-2))
+	for i := range bc {
+		bc[i] *= scale
+	}
+	return bc
+}
+
+// createCallGraphCentrality stores the PageRank and betweenness of every
+// module function in the call graph (call_graph_centrality) and reports the
+// top 1% by PageRank that also lie on paths between other functions as
+// central_function findings: load-bearing code that fan-in and fan-out alone
+// under-rate. ext:: and int:: stubs take part in the ranking but get no rows.
+func createCallGraphCentrality(conn *sqlite.Conn, prog *Progress) error {
+	if err := sqlitex.ExecuteScript(conn, `
+CREATE TABLE call_graph_centrality (
+    function_id TEXT PRIMARY KEY,
+    pagerank REAL NOT NULL,
+    betweenness REAL NOT NULL
+);`, nil); err != nil {
+		return fmt.Errorf("centrality DDL: %w", err)
+	}
+
+	index := make(map[string]int)
+	var ids []string
+	var edges [][2]string
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT DISTINCT e.source, e.target FROM edges e
+		 JOIN nodes s ON s.id = e.source AND s.kind = 'function'
+		 JOIN nodes t ON t.id = e.target AND t.kind = 'function'
+		 WHERE e.kind = 'call' AND e.source != e.target
+		 ORDER BY e.source, e.target`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			edges = append(edges, [2]string{stmt.ColumnText(0), stmt.ColumnText(1)})
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("centrality: %w", err)
+	}
+	for _, e := range edges {
+		for _, id := range e {
+			if _, ok := index[id]; !ok {
+				index[id] = len(ids)
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		prog.Log("Call graph centrality: no call edges")
+		return nil
+	}
+	succs := make([][]int, len(ids))
+	for _, e := range edges {
+		succs[index[e[0]]] = append(succs[index[e[0]]], index[e[1]])
+	}
+
+	pr := pageRank(succs)
+	bc := betweenness(succs)
+
+	stmt, err := conn.Prepare(`INSERT INTO call_graph_centrality (function_id, pagerank, betweenness)
+		SELECT ?1, ?2, ?3 WHERE EXISTS (SELECT 1 FROM nodes WHERE id = ?1 AND file IS NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("centrality: %w", err)
+	}
+	for i, id := range ids {
+		stmt.BindText(1, id)
+		stmt.BindFloat(2, pr[i])
+		stmt.BindFloat(3, bc[i])
+		if _, err := stmt.Step(); err != nil {
+			return fmt.Errorf("centrality insert: %w", err)
+		}
+		if err := stmt.Reset(); err != nil {
+			return fmt.Errorf("centrality insert: %w", err)
+		}
+	}
+
+	// Top 1% (at least one function) of the module functions by PageRank,
+	// ties broken by ID
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Or(cmp.Compare(pr[b], pr[a]), cmp.Compare(ids[a], ids[b]))
+	})
+	top := max(1, (len(ids)+99)/100)
+	if excludeFindings["central_function"] {
+		top = 0
+	}
+
+	var central int
+	for _, i := range order {
+		if top == 0 {
+			break
+		}
+		if bc[i] == 0 {
+			continue
+		}
+		if err := sqlitex.Execute(conn, `
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'central_function', 'info', n.id, n.file, n.line,
+    n.name || ' is central to the call graph (PageRank ' || printf('%.4f', c.pagerank) ||
+      ', betweenness ' || printf('%.4f', c.betweenness) || ')',
+    json_object('pagerank', c.pagerank, 'betweenness', c.betweenness, 'package', n.package)
+  FROM call_graph_centrality c JOIN nodes n ON n.id = c.function_id
+  WHERE c.function_id = ?`,
+			&sqlitex.ExecOptions{Args: []any{ids[i]}}); err != nil {
+			return fmt.Errorf("central_function findings: %w", err)
+		}
+		if conn.Changes() > 0 {
+			central++
+			top--
+		}
+	}
+
+	prog.Log("Call graph centrality: %d functions, %d central", len(ids), central)
+	return nil
+}
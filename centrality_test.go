@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCallGraphCentralityHub(t *testing.T) {
+	// Every caller reaches every leaf only through hub.
+	conn := buildTestDB(t, `package fixture
+
+func a() { hub() }
+func b() { hub() }
+func c() { hub() }
+
+func hub() { x(); y(); z() }
+
+func x() {}
+func y() {}
+func z() {}
+
+func Run() { a(); b(); c() }
+`)
+	got := queryStrings(t, conn, `SELECT n.name FROM call_graph_centrality c
+		JOIN nodes n ON n.id = c.function_id ORDER BY c.betweenness DESC, n.name LIMIT 1`)
+	if len(got) != 1 || got[0] != "hub" {
+		t.Errorf("highest betweenness = %v, want [hub]", got)
+	}
+	got = queryStrings(t, conn, `SELECT n.name FROM findings f
+		JOIN nodes n ON n.id = f.node_id WHERE f.category = 'central_function'`)
+	if len(got) != 1 || got[0] != "hub" {
+		t.Errorf("central_function findings = %v, want [hub]", got)
+	}
+}
+
+func TestPageRankHub(t *testing.T) {
+	// 0, 1 and 2 call 3; 3 calls 4 and 5.
+	succs := [][]int{{3}, {3}, {3}, {4, 5}, nil, nil}
+	pr := pageRank(succs)
+	for i, r := range pr {
+		if i != 3 && r >= pr[3] {
+			t.Errorf("pagerank[%d] = %f >= hub's %f", i, r, pr[3])
+		}
+	}
+	bc := betweenness(succs)
+	// 3 lies on the 6 shortest paths {0,1,2} -> {4,5}, out of (n-1)(n-2) = 20 pairs.
+	if math.Abs(bc[3]-6.0/20) > 1e-12 {
+		t.Errorf("betweenness[3] = %f, want %f", bc[3], 6.0/20)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// CoverBlock is one line of a `go test -coverprofile` file, with the file
+// already mapped to its CPG-relative path.
+type CoverBlock struct {
+	File                string
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt, Count      int
+}
+
+// ParseCoverProfile reads a Go coverage profile. Blocks for files outside the
+// analyzed modules are dropped; duplicate blocks (from -coverpkg runs across
+// several packages) are merged keeping the highest count.
+func ParseCoverProfile(path string) ([]CoverBlock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open coverage profile: %w", err)
+	}
+	defer f.Close()
+
+	type blockKey struct {
+		file                                 string
+		startLine, startCol, endLine, endCol int
+	}
+	index := make(map[blockKey]int)
+	var blocks []CoverBlock
+
+	sc := bufio.NewScanner(f)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.col,line.col numStmt count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("coverage profile line %d: missing ':'", lineNo)
+		}
+		var b CoverBlock
+		if _, err := fmt.Sscanf(line[colon+1:], "%d.%d,%d.%d %d %d",
+			&b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.NumStmt, &b.Count); err != nil {
+			return nil, fmt.Errorf("coverage profile line %d: %w", lineNo, err)
+		}
+		b.File = modSet.RelFileFromImport(line[:colon])
+		if b.File == "" {
+			continue
+		}
+		k := blockKey{b.File, b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+		if i, ok := index[k]; ok {
+			blocks[i].Count = max(blocks[i].Count, b.Count)
+			continue
+		}
+		index[k] = len(blocks)
+		blocks = append(blocks, b)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read coverage profile: %w", err)
+	}
+	return blocks, nil
+}
+
+// coverStmtKinds are the node kinds that get a covered property.
+var coverStmtKinds = map[string]bool{
+	"assign": true, "call": true, "return": true, "if": true, "for": true,
+	"switch": true, "select": true, "case": true, "go": true, "defer": true,
+	"send": true, "branch": true, "inc_dec": true, "local": true, "label": true,
+}
+
+// ApplyCoverage annotates function nodes with covered_statements,
+// total_statements and coverage_ratio (summed over the profile blocks that
+// start inside the function), and statement nodes inside a profile block with
+// covered=true/false.
+func ApplyCoverage(cpg *CPG, blocks []CoverBlock, prog *Progress) {
+	byFile := make(map[string][]CoverBlock)
+	for _, b := range blocks {
+		byFile[b.File] = append(byFile[b.File], b)
+	}
+
+	var funcs, stmts int
+	for i := range cpg.Nodes {
+		n := &cpg.Nodes[i]
+		fileBlocks := byFile[n.File]
+		if len(fileBlocks) == 0 || n.Line == 0 {
+			continue
+		}
+		switch {
+		case n.Kind == "function" && n.EndLine > 0:
+			var covered, total int
+			for _, b := range fileBlocks {
+				if b.StartLine >= n.Line && b.StartLine <= n.EndLine {
+					total += b.NumStmt
+					if b.Count > 0 {
+						covered += b.NumStmt
+					}
+				}
+			}
+			if total == 0 {
+				continue
+			}
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["covered_statements"] = covered
+			n.Properties["total_statements"] = total
+			n.Properties["coverage_ratio"] = math.Round(float64(covered)/float64(total)*1000) / 1000
+			funcs++
+		case coverStmtKinds[n.Kind] && n.ParentFunction != "":
+			// Innermost enclosing block wins (blocks do not overlap in
+			// practice, but -coverpkg merges can produce nested ones).
+			var hit *CoverBlock
+			for j := range fileBlocks {
+				b := &fileBlocks[j]
+				if coverContains(b, n.Line, n.Col) && (hit == nil || coverContains(hit, b.StartLine, b.StartCol)) {
+					hit = b
+				}
+			}
+			if hit == nil {
+				continue
+			}
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["covered"] = hit.Count > 0
+			stmts++
+		}
+	}
+	prog.Log("Coverage: annotated %d functions, %d statements from %d profile blocks", funcs, stmts, len(blocks))
+}
+
+// coverContains reports whether line:col falls inside b.
+func coverContains(b *CoverBlock, line, col int) bool {
+	if line < b.StartLine || line > b.EndLine {
+		return false
+	}
+	if line == b.StartLine && col < b.StartCol {
+		return false
+	}
+	if line == b.EndLine && col >= b.EndCol {
+		return false
+	}
+	return true
+}
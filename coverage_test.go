@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+)
+
+func TestCoverageOverlay(t *testing.T) {
+	src := `package fixture
+
+func Classify(n int) int {
+	if n == 1 {
+		return 1
+	}
+	if n == 2 {
+		return 2
+	}
+	if n == 3 {
+		return 3
+	}
+	if n == 4 {
+		return 4
+	}
+	if n == 5 {
+		return 5
+	}
+	if n == 6 {
+		return 6
+	}
+	if n == 7 {
+		return 7
+	}
+	if n == 8 {
+		return 8
+	}
+	if n == 9 {
+		return 9
+	}
+	if n == 10 {
+		return 10
+	}
+	return helper(n)
+}
+
+func helper(n int) int {
+	return n * 2
+}
+`
+	cpg := buildTestCPG(t, src)
+
+	// Only the first if (condition + body) and helper ran.
+	profile := strings.Join([]string{
+		"mode: set",
+		"example.com/fixture/fixture.go:3.26,4.12 1 1",
+		"example.com/fixture/fixture.go:4.12,6.3 1 1",
+		"example.com/fixture/fixture.go:7.2,7.12 1 0",
+		"example.com/fixture/fixture.go:7.12,9.3 1 0",
+		"example.com/fixture/fixture.go:34.2,34.18 1 0",
+		"example.com/fixture/fixture.go:37.24,39.2 1 1",
+		"example.com/other/other.go:1.1,2.2 1 1",
+		"",
+	}, "\n")
+	path := filepath.Join(t.TempDir(), "cover.out")
+	if err := os.WriteFile(path, []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := ParseCoverProfile(path)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(blocks) != 6 {
+		t.Fatalf("got %d blocks, want 6 (foreign module dropped)", len(blocks))
+	}
+	ApplyCoverage(cpg, blocks, NewProgress(false))
+
+	conn, err := sqlite.OpenConn(writeTestDB(t, cpg), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn, `SELECT name || ':' || json_extract(properties, '$.covered_statements') || '/' ||
+		json_extract(properties, '$.total_statements') FROM nodes
+		WHERE kind = 'function' AND name IN ('Classify', 'helper') ORDER BY name`)
+	if strings.Join(got, ",") != "Classify:2/5,helper:1/1" {
+		t.Errorf("function coverage = %v", got)
+	}
+
+	covered := queryStrings(t, conn, `SELECT line || '=' || json_extract(properties, '$.covered') FROM nodes
+		WHERE kind = 'return' AND line IN (5, 8) ORDER BY line`)
+	if strings.Join(covered, ",") != "5=1,8=0" {
+		t.Errorf("return statement coverage = %v", covered)
+	}
+
+	findings := queryStrings(t, conn, `SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id
+		WHERE f.category = 'untested_complex_function'`)
+	if len(findings) != 1 || findings[0] != "Classify" {
+		t.Errorf("untested_complex_function findings = %v, want [Classify]", findings)
+	}
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"zombiezen.com/go/sqlite"
@@ -11,67 +19,200 @@ import (
 
 const batchSize = 50000
 
+// leaderboardLimit is the -top-n flag, set by main before WriteDB: rows kept
+// per dashboard leaderboard (0 = built-in limits).
+var leaderboardLimit = 0
+
+// godTypeFields and godTypeMethods are the -god-type-fields and
+// -god-type-methods flags, set by main before WriteDB: a struct with more
+// fields and more methods than these is reported as a god_type.
+var (
+	godTypeFields  = 20
+	godTypeMethods = 15
+)
+
+// taintMaxHops is the -taint-max-hops flag, set by main before WriteDB: steps
+// the taint BFS (taint_flow_state) follows from a source.
+var taintMaxHops = 8
+
+// taintHopsWarn is the -taint-max-hops above which main warns about runtime.
+const taintHopsWarn = 32
+
+// onlyFindings is the -only-findings flag, set by main before WriteDB: skip
+// the dashboard, graph intelligence, navigation, SCIP and communication /
+// session type passes, which add tables for viewers but no findings except
+// those listed in findingsSkippedByOnlyFindings.
+var onlyFindings = false
+
+// severityMap is the -severity-map file, loaded by main before WriteDB:
+// finding category → the severity to report it with (nil = as generated).
+var severityMap map[string]string
+
+// excludeFindings is the -exclude-findings flag, set by main before WriteDB:
+// finding categories never inserted into findings (nil = keep all).
+var excludeFindings map[string]bool
+
+// findingSeverities are the severities a finding, or a -severity-map entry,
+// may have.
+var findingSeverities = []string{"info", "warning", "error"}
+
+// externalDFG is the -external-dfg flag, set by main before WriteDB: how
+// many of inferHeuristicDFG's steps run, see externalDFGLevels.
+var externalDFG = "fallback"
+
+// externalDFGLevels maps -external-dfg values to the last inferHeuristicDFG
+// step they keep: none adds no edges through ext::/int:: calls, precise only
+// flow_semantics argument→result flows, heuristic also the modelled side
+// effects, fallback also the all-arguments→result guess.
+var externalDFGLevels = map[string]int{"none": 0, "precise": 1, "heuristic": 2, "fallback": 3}
+
+// findingsSkippedByOnlyFindings are the finding categories produced by a
+// pass that -only-findings skips (createGraphIntelligence); every other
+// category only needs the graph, metrics and taint passes that still run.
+var findingsSkippedByOnlyFindings = []string{"long_param_list", "god_package", "god_type", "high_coupling", "central_function"}
+
 // WriteDB writes the CPG to a SQLite database file.
 func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []GitFileHistory, validate bool, prog *Progress) error {
+	return WriteDBContext(context.Background(), path, cpg, escapeResults, gitHistory, validate, prog)
+}
+
+// WriteDBContext is WriteDB with cancellation: once ctx is done the running
+// SQLite statement is interrupted and the write fails; the partial file is
+// left for the caller to remove.
+func WriteDBContext(ctx context.Context, path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []GitFileHistory, validate bool, prog *Progress) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sink, err := NewSQLiteSink(path, escapeResults, gitHistory, validate, prog)
+	if err != nil {
+		return err
+	}
+	sink.conn.SetInterrupt(ctx.Done())
+	if err := WriteCPG(sink, cpg); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("write db: %w", ctx.Err())
+		}
+		return err
+	}
+	return nil
+}
+
+// SQLiteSink is the CPGSink behind WriteDB: it bulk-inserts the graph in one
+// transaction and, on Finalize, builds indexes and every derived table.
+type SQLiteSink struct {
+	path          string
+	conn          *sqlite.Conn
+	endTx         func(*error)
+	escapeResults []EscapeResult
+	gitHistory    []GitFileHistory
+	validate      bool
+	prog          *Progress
+}
+
+// NewSQLiteSink creates (replacing) the database at path, creates the base
+// tables and opens the bulk-insert transaction.
+func NewSQLiteSink(path string, escapeResults []EscapeResult, gitHistory []GitFileHistory, validate bool, prog *Progress) (*SQLiteSink, error) {
 	prog.Log("Writing SQLite to %s ...", path)
 
 	_ = os.Remove(path) // ignore if doesn't exist
 
 	conn, err := sqlite.OpenConn(path, sqlite.OpenCreate, sqlite.OpenReadWrite, sqlite.OpenWAL)
 	if err != nil {
-		return fmt.Errorf("open sqlite: %w", err)
+		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
-	defer func() { _ = conn.Close() }()
 
 	// Performance pragmas
-	if err := sqlitex.ExecuteTransient(conn, "PRAGMA synchronous = NORMAL", nil); err != nil {
-		return err
-	}
-	if err := sqlitex.ExecuteTransient(conn, "PRAGMA temp_store = MEMORY", nil); err != nil {
-		return err
-	}
-	if err := sqlitex.ExecuteTransient(conn, "PRAGMA mmap_size = 268435456", nil); err != nil {
-		return err
-	}
-	if err := sqlitex.ExecuteTransient(conn, "PRAGMA cache_size = -64000", nil); err != nil {
-		return err
-	}
-	if err := sqlitex.ExecuteTransient(conn, "PRAGMA journal_mode = WAL", nil); err != nil {
-		return err
+	for _, pragma := range []string{
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA temp_store = MEMORY",
+		"PRAGMA mmap_size = 268435456",
+		"PRAGMA cache_size = -64000",
+		"PRAGMA journal_mode = WAL",
+	} {
+		if err := sqlitex.ExecuteTransient(conn, pragma, nil); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
 	}
 
 	// Create tables without indexes (deferred creation for speed)
 	if err := createTables(conn); err != nil {
-		return err
+		_ = conn.Close()
+		return nil, err
 	}
 
 	// Bulk insert in a transaction
 	endFn, err := sqlitex.ImmediateTransaction(conn)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		_ = conn.Close()
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 
-	if err := insertNodes(conn, cpg.Nodes, prog); err != nil {
-		endFn(&err)
-		return err
+	return &SQLiteSink{
+		path:          path,
+		conn:          conn,
+		endTx:         endFn,
+		escapeResults: escapeResults,
+		gitHistory:    gitHistory,
+		validate:      validate,
+		prog:          prog,
+	}, nil
+}
+
+// abort rolls back the bulk-insert transaction and closes the connection.
+func (s *SQLiteSink) abort(err error) error {
+	s.endTx(&err)
+	_ = s.conn.Close()
+	return err
+}
+
+func (s *SQLiteSink) WriteNodes(nodes []Node) error {
+	if err := insertNodes(s.conn, nodes, s.prog); err != nil {
+		return s.abort(err)
 	}
-	if err := insertEdges(conn, cpg.Edges, prog); err != nil {
-		endFn(&err)
-		return err
+	return nil
+}
+
+func (s *SQLiteSink) WriteEdges(edges []Edge) error {
+	if err := insertEdges(s.conn, edges, s.prog); err != nil {
+		return s.abort(err)
 	}
-	if err := insertSources(conn, cpg.Sources, prog); err != nil {
-		endFn(&err)
-		return err
+	return nil
+}
+
+func (s *SQLiteSink) WriteSources(sources map[string]string) error {
+	if err := insertSources(s.conn, sources, s.prog); err != nil {
+		return s.abort(err)
 	}
-	if err := insertMetrics(conn, cpg.Metrics, prog); err != nil {
-		endFn(&err)
-		return err
+	return nil
+}
+
+func (s *SQLiteSink) WriteMetrics(metrics map[string]*Metrics) error {
+	if err := insertMetrics(s.conn, metrics, s.prog); err != nil {
+		return s.abort(err)
 	}
+	return nil
+}
 
-	endFn(&err)
+// Finalize commits the bulk insert, runs all post-processing passes and
+// closes the database.
+func (s *SQLiteSink) Finalize() error {
+	defer func() { _ = s.conn.Close() }()
+
+	var err error
+	s.endTx(&err)
 	if err != nil {
 		return fmt.Errorf("commit: %w", err)
 	}
+	return finalizeDB(s.conn, s.path, s.escapeResults, s.gitHistory, s.validate, s.prog)
+}
+
+// finalizeDB builds indexes, derived tables, findings and documentation on top
+// of the base nodes/edges/sources/metrics tables.
+func finalizeDB(conn *sqlite.Conn, path string, escapeResults []EscapeResult, gitHistory []GitFileHistory, validate bool, prog *Progress) error {
+	if err := createModules(conn); err != nil {
+		return err
+	}
 
 	// Create flow semantics table for stdlib data-flow modeling
 	prog.Log("Building flow semantics model...")
@@ -79,76 +220,16 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 		return err
 	}
 
-	// Heuristic DFG for external calls using flow semantics
-	prog.Log("Inferring DFG for external calls...")
-
-	// Step 1: Precise DFG for functions WITH custom semantics (arg→return)
-	var preciseDFG, fallbackDFG, sideEffectDFG int
-	if err := sqlitex.ExecuteTransient(conn,
-		`INSERT OR IGNORE INTO edges (source, target, kind, properties)
-		 SELECT DISTINCT arg_e.target, site_e.source, 'dfg', '{"heuristic":true}'
-		 FROM edges site_e
-		 JOIN nodes callee ON site_e.target = callee.id
-		 JOIN flow_semantics fs ON callee.package = fs.package AND callee.name = fs.func_name
-		   AND fs.flow_to LIKE 'return:%'
-		 JOIN edges arg_e ON arg_e.source = site_e.source AND arg_e.kind = 'argument'
-		 WHERE site_e.kind = 'call_site'
-		   AND callee.id LIKE 'ext::%'
-		   AND (fs.flow_from = 'arg:*'
-		        OR fs.flow_from = 'arg:' || json_extract(arg_e.properties, '$.index'))`,
-		&sqlitex.ExecOptions{
-			ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
-		}); err != nil {
-		return fmt.Errorf("precise heuristic dfg: %w", err)
-	}
-	preciseDFG = conn.Changes()
-
-	// Step 2: Side-effect flows: arg→arg (e.g., json.Unmarshal: bytes→target)
-	if err := sqlitex.ExecuteTransient(conn,
-		`INSERT OR IGNORE INTO edges (source, target, kind, properties)
-		 SELECT DISTINCT src_arg.target, dst_arg.target, 'dfg', '{"heuristic":true,"side_effect":true}'
-		 FROM edges site_e
-		 JOIN nodes callee ON site_e.target = callee.id
-		 JOIN flow_semantics fs ON callee.package = fs.package AND callee.name = fs.func_name
-		   AND fs.flow_from LIKE 'arg:%' AND fs.flow_to LIKE 'arg:%'
-		 JOIN edges src_arg ON src_arg.source = site_e.source AND src_arg.kind = 'argument'
-		   AND (fs.flow_from = 'arg:*'
-		        OR fs.flow_from = 'arg:' || json_extract(src_arg.properties, '$.index'))
-		 JOIN edges dst_arg ON dst_arg.source = site_e.source AND dst_arg.kind = 'argument'
-		   AND fs.flow_to = 'arg:' || json_extract(dst_arg.properties, '$.index')
-		 WHERE site_e.kind = 'call_site'
-		   AND callee.id LIKE 'ext::%'`,
-		&sqlitex.ExecOptions{
-			ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
-		}); err != nil {
-		return fmt.Errorf("side-effect heuristic dfg: %w", err)
-	}
-	sideEffectDFG = conn.Changes()
-
-	// Step 3: Fallback: all args→return for functions WITHOUT custom semantics
-	if err := sqlitex.ExecuteTransient(conn,
-		`INSERT OR IGNORE INTO edges (source, target, kind, properties)
-		 SELECT DISTINCT arg_e.target, site_e.source, 'dfg', '{"heuristic":true}'
-		 FROM edges site_e
-		 JOIN nodes callee ON site_e.target = callee.id
-		 JOIN edges arg_e ON arg_e.source = site_e.source AND arg_e.kind = 'argument'
-		 WHERE site_e.kind = 'call_site'
-		   AND callee.id LIKE 'ext::%'
-		   AND NOT EXISTS (
-		     SELECT 1 FROM flow_semantics fs
-		     WHERE callee.package = fs.package AND callee.name = fs.func_name
-		   )`,
-		&sqlitex.ExecOptions{
-			ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
-		}); err != nil {
-		return fmt.Errorf("fallback heuristic dfg: %w", err)
+	// Signatures of ext::/int:: stubs so the heuristic DFG knows real arity
+	if err := createExternalSignatures(conn, prog); err != nil {
+		return err
 	}
-	fallbackDFG = conn.Changes()
 
-	totalDFG := preciseDFG + sideEffectDFG + fallbackDFG
-	if totalDFG > 0 {
-		prog.Log("Created %d heuristic DFG edges (%d precise, %d side-effect, %d fallback)",
-			totalDFG, preciseDFG, sideEffectDFG, fallbackDFG)
+	// Heuristic DFG for external calls using flow semantics
+	if emitFilter.Edge("dfg") && externalDFGLevels[externalDFG] > 0 {
+		if err := inferHeuristicDFG(conn, prog); err != nil {
+			return err
+		}
 	}
 
 	// Clean up orphan edges before indexing
@@ -188,6 +269,11 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 		return err
 	}
 
+	prog.Log("Creating findings table...")
+	if err := createFindingsTable(conn); err != nil {
+		return err
+	}
+
 	// Pre-built analysis views and example queries
 	prog.Log("Creating analysis views...")
 	if err := createAnalysisViews(conn); err != nil {
@@ -226,6 +312,12 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 		return err
 	}
 
+	// Receiver correctness: value-receiver mutations, needless pointer receivers
+	prog.Log("Checking method receivers...")
+	if err := createReceiverAnalysis(conn, prog); err != nil {
+		return err
+	}
+
 	// Run ANALYZE before dashboard queries — without statistics, the query planner
 	// has no row counts and picks catastrophically bad plans on 445k+ row tables
 	prog.Log("Running ANALYZE for query planner...")
@@ -233,22 +325,40 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 		return err
 	}
 
-	// Pre-computed dashboard data for easy chart rendering
-	prog.Log("Building dashboard data...")
-	if err := createDashboardData(conn, prog); err != nil {
-		return err
-	}
+	if onlyFindings {
+		prog.Log("Skipping dashboard, navigation and communication tables (-only-findings; no %s findings)",
+			strings.Join(findingsSkippedByOnlyFindings, "/"))
+	} else {
+		// Pre-computed dashboard data for easy chart rendering
+		prog.Log("Building dashboard data...")
+		if err := createDashboardData(conn, prog); err != nil {
+			return err
+		}
 
-	// Graph intelligence: top-N tables, cross-package coupling, error chains
-	prog.Log("Building graph intelligence...")
-	if err := createGraphIntelligence(conn, prog); err != nil {
-		return err
-	}
+		// Graph intelligence: top-N tables, cross-package coupling, error chains
+		prog.Log("Building graph intelligence...")
+		if err := createGraphIntelligence(conn, leaderboardLimit, prog); err != nil {
+			return err
+		}
 
-	// File-level analysis and dependency graph data for visualization
-	prog.Log("Building file and dependency analysis...")
-	if err := createFileAndDepAnalysis(conn, prog); err != nil {
-		return err
+		// PageRank and betweenness over the call graph
+		prog.Log("Computing call graph centrality...")
+		if err := createCallGraphCentrality(conn, prog); err != nil {
+			return err
+		}
+
+		// Functions each main package links in, via calls
+		prog.Log("Computing binary footprints...")
+		if err := createBinaryFootprint(conn, prog); err != nil {
+			return err
+		}
+
+		// File-level analysis and dependency graph data for visualization
+		// (reads the dashboard and package_coupling tables above)
+		prog.Log("Building file and dependency analysis...")
+		if err := createFileAndDepAnalysis(conn, prog); err != nil {
+			return err
+		}
 	}
 
 	// Type system analysis: hierarchy, implementation map, method resolution
@@ -258,9 +368,11 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 	}
 
 	// Code navigation aids and pattern summaries
-	prog.Log("Building navigation and patterns...")
-	if err := createNavigationAndPatterns(conn, prog); err != nil {
-		return err
+	if !onlyFindings {
+		prog.Log("Building navigation and patterns...")
+		if err := createNavigationAndPatterns(conn, prog); err != nil {
+			return err
+		}
 	}
 
 	// Schema documentation: self-describing DB for interview candidates
@@ -289,21 +401,107 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 		return err
 	}
 
+	// Maps/slices shared between a goroutine closure and its launcher
+	prog.Log("Detecting unsynchronized shared containers...")
+	if err := createConcurrentAccess(conn, prog); err != nil {
+		return err
+	}
+
+	// Variables each goroutine closure captures, by value or by reference
+	prog.Log("Recording goroutine captures...")
+	if err := createGoroutineCaptures(conn, prog); err != nil {
+		return err
+	}
+
+	// go statements ↔ wg.Wait, unawaited goroutines
+	prog.Log("Linking goroutines to WaitGroups...")
+	if err := createGoroutineJoins(conn, prog); err != nil {
+		return err
+	}
+
 	// SCIP-style cross-repository symbol identifiers
-	prog.Log("Building SCIP symbol index...")
-	if err := createSCIPSymbols(conn, prog); err != nil {
+	if !onlyFindings {
+		prog.Log("Building SCIP symbol index...")
+		if err := createSCIPSymbols(conn, prog); err != nil {
+			return err
+		}
+	}
+
+	prog.Log("Fingerprinting public API...")
+	if err := createAPIFingerprint(conn, prog); err != nil {
+		return err
+	}
+
+	if !onlyFindings {
+		// Communication patterns: Honda session types, protocol detection, duality
+		prog.Log("Building communication patterns...")
+		if err := createCommunicationPatterns(conn, prog); err != nil {
+			return err
+		}
+
+		// Honda 2008 corrections: subtyping, acyclic deps, association relation
+		prog.Log("Applying Honda 2008 corrections (Scalas & Yoshida 2019, Yoshida & Hou 2024)...")
+		if err := createSessionTypeCorrections(conn, prog); err != nil {
+			return err
+		}
+	}
+
+	// SQL passes above derive edges too (waitgroup_member, ...); honor -emit-edges
+	if kinds := emitFilter.SortedEdges(); kinds != nil {
+		list, _ := json.Marshal(kinds)
+		if err := sqlitex.ExecuteTransient(conn,
+			`DELETE FROM edges WHERE kind NOT IN (SELECT value FROM json_each(?))`,
+			&sqlitex.ExecOptions{Args: []any{string(list)}}); err != nil {
+			return fmt.Errorf("emit-edges filter: %w", err)
+		}
+		if n := conn.Changes(); n > 0 {
+			prog.Log("Dropped %d derived edges excluded by -emit-edges", n)
+		}
+	}
+
+	// -exclude-findings: leftover rows, and queries and views with nothing to
+	// read (after the last INSERT INTO findings and INSERT INTO queries)
+	if len(excludeFindings) > 0 {
+		if err := applyExcludedFindings(conn, prog); err != nil {
+			return err
+		}
+	}
+
+	// -severity-map overrides (after the last INSERT INTO findings)
+	if len(severityMap) > 0 {
+		prog.Log("Remapping finding severities...")
+		if err := applySeverityMap(conn, severityMap, prog); err != nil {
+			return err
+		}
+	}
+
+	// //cpg:ignore and //nolint comments (after the last INSERT INTO findings)
+	prog.Log("Applying suppression comments...")
+	if err := applySuppressions(conn, prog); err != nil {
 		return err
 	}
 
-	// Communication patterns: Honda session types, protocol detection, duality
-	prog.Log("Building communication patterns...")
-	if err := createCommunicationPatterns(conn, prog); err != nil {
+	// dashboard_findings_summary again, now that every pass has added its
+	// findings, severities are final and suppressed findings are marked
+	if !onlyFindings {
+		if err := sqlitex.ExecuteScript(conn, `
+DELETE FROM dashboard_findings_summary;
+INSERT INTO dashboard_findings_summary
+  SELECT category, MAX(severity), COUNT(*)
+  FROM findings WHERE suppressed = 0 GROUP BY category ORDER BY COUNT(*) DESC;`, nil); err != nil {
+			return fmt.Errorf("findings summary: %w", err)
+		}
+	}
+
+	// Parameter declarations for every saved query (after the last INSERT
+	// INTO queries)
+	if err := createQueryParams(conn); err != nil {
 		return err
 	}
 
-	// Honda 2008 corrections: subtyping, acyclic deps, association relation
-	prog.Log("Applying Honda 2008 corrections (Scalas & Yoshida 2019, Yoshida & Hou 2024)...")
-	if err := createSessionTypeCorrections(conn, prog); err != nil {
+	// Table/view inventory with row counts (last, so it sees everything)
+	prog.Log("Writing manifest...")
+	if err := createManifest(conn, prog); err != nil {
 		return err
 	}
 
@@ -323,6 +521,119 @@ func WriteDB(path string, cpg *CPG, escapeResults []EscapeResult, gitHistory []G
 	return nil
 }
 
+// createModules records the analyzed modules; nodes.module holds the mod_path
+// of the module each node comes from.
+func createModules(conn *sqlite.Conn) error {
+	if err := sqlitex.ExecuteTransient(conn, `
+CREATE TABLE modules (
+    prefix TEXT PRIMARY KEY,
+    mod_path TEXT NOT NULL UNIQUE,
+    dir TEXT NOT NULL
+)`, nil); err != nil {
+		return fmt.Errorf("modules table: %w", err)
+	}
+	for _, m := range modSet.Dirs() {
+		if err := sqlitex.Execute(conn, `INSERT OR IGNORE INTO modules (prefix, mod_path, dir) VALUES (?, ?, ?)`,
+			&sqlitex.ExecOptions{Args: []any{m.Prefix, m.ModPath, m.Dir}}); err != nil {
+			return fmt.Errorf("insert module %s: %w", m.ModPath, err)
+		}
+	}
+	return nil
+}
+
+// inferHeuristicDFG adds dfg edges through ext::/int:: callees, which have no
+// SSA bodies, from flow_semantics or an all-arguments→result fallback. The
+// edges' confidence records which: "heuristic" for modelled flows, "fallback"
+// for the guess (SSA and field-read edges are "precise"). -external-dfg
+// stops it after an earlier step.
+func inferHeuristicDFG(conn *sqlite.Conn, prog *Progress) error {
+	prog.Log("Inferring DFG for external calls (-external-dfg %s)...", externalDFG)
+	level := externalDFGLevels[externalDFG]
+
+	// Step 1: Precise DFG for functions WITH custom semantics (arg→return).
+	// A method call's receiver edge acts as argument "recv" (index -1).
+	var preciseDFG, fallbackDFG, sideEffectDFG int
+	if err := sqlitex.ExecuteTransient(conn,
+		`INSERT OR IGNORE INTO edges (source, target, kind, properties)
+		 SELECT DISTINCT arg_e.target, site_e.source, 'dfg', '{"heuristic":true,"confidence":"heuristic"}'
+		 FROM edges site_e
+		 JOIN nodes callee ON site_e.target = callee.id
+		 JOIN flow_semantics fs ON callee.package = fs.package AND callee.name = fs.func_name
+		   AND fs.flow_to LIKE 'return:%'
+		 JOIN edges arg_e ON arg_e.source = site_e.source AND arg_e.kind IN ('argument', 'receiver')
+		 WHERE site_e.kind = 'call_site'
+		   AND (callee.id LIKE 'ext::%' OR callee.id LIKE 'int::%')
+		   AND ((fs.flow_from = 'arg:*' AND arg_e.kind = 'argument')
+		        OR fs.flow_from = `+flowEndpointSQL("arg_e")+`)`,
+		&sqlitex.ExecOptions{
+			ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
+		}); err != nil {
+		return fmt.Errorf("precise heuristic dfg: %w", err)
+	}
+	preciseDFG = conn.Changes()
+
+	if level >= 2 {
+		// Step 2: Side-effect flows: arg→arg (e.g., json.Unmarshal: bytes→target),
+		// arg→recv (e.g., Builder.WriteString) and recv→arg (e.g., Reader.Read)
+		if err := sqlitex.ExecuteTransient(conn,
+			`INSERT OR IGNORE INTO edges (source, target, kind, properties)
+			 SELECT DISTINCT src_arg.target, dst_arg.target, 'dfg', '{"heuristic":true,"side_effect":true,"confidence":"heuristic"}'
+			 FROM edges site_e
+			 JOIN nodes callee ON site_e.target = callee.id
+			 JOIN flow_semantics fs ON callee.package = fs.package AND callee.name = fs.func_name
+			   AND (fs.flow_from LIKE 'arg:%' OR fs.flow_from = 'recv')
+			   AND (fs.flow_to LIKE 'arg:%' OR fs.flow_to = 'recv')
+			 JOIN edges src_arg ON src_arg.source = site_e.source AND src_arg.kind IN ('argument', 'receiver')
+			   AND ((fs.flow_from = 'arg:*' AND src_arg.kind = 'argument')
+			        OR fs.flow_from = `+flowEndpointSQL("src_arg")+`)
+			 JOIN edges dst_arg ON dst_arg.source = site_e.source AND dst_arg.kind IN ('argument', 'receiver')
+			   AND fs.flow_to = `+flowEndpointSQL("dst_arg")+`
+			 WHERE site_e.kind = 'call_site'
+			   AND (callee.id LIKE 'ext::%' OR callee.id LIKE 'int::%')
+			   AND src_arg.target != dst_arg.target`,
+			&sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
+			}); err != nil {
+			return fmt.Errorf("side-effect heuristic dfg: %w", err)
+		}
+		sideEffectDFG = conn.Changes()
+	}
+
+	if level >= 3 {
+		// Step 3: Fallback: all args (and the receiver)→return for functions
+		// WITHOUT custom semantics
+		if err := sqlitex.ExecuteTransient(conn,
+			`INSERT OR IGNORE INTO edges (source, target, kind, properties)
+			 SELECT DISTINCT arg_e.target, site_e.source, 'dfg', '{"heuristic":true,"confidence":"fallback"}'
+			 FROM edges site_e
+			 JOIN nodes callee ON site_e.target = callee.id
+			 JOIN edges arg_e ON arg_e.source = site_e.source AND arg_e.kind IN ('argument', 'receiver')
+			 WHERE site_e.kind = 'call_site'
+			   AND (callee.id LIKE 'ext::%' OR callee.id LIKE 'int::%')
+			   AND NOT EXISTS (
+			     SELECT 1 FROM flow_semantics fs
+			     WHERE callee.package = fs.package AND callee.name = fs.func_name
+			   )
+			   AND NOT EXISTS (
+			     SELECT 1 FROM external_signatures es
+			     WHERE es.id = callee.id AND es.num_results = 0
+			   )`,
+			&sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
+			}); err != nil {
+			return fmt.Errorf("fallback heuristic dfg: %w", err)
+		}
+		fallbackDFG = conn.Changes()
+	}
+
+	totalDFG := preciseDFG + sideEffectDFG + fallbackDFG
+	if totalDFG > 0 {
+		prog.Log("Created %d heuristic DFG edges (%d precise, %d side-effect, %d fallback)",
+			totalDFG, preciseDFG, sideEffectDFG, fallbackDFG)
+	}
+	return nil
+}
+
 func createTables(conn *sqlite.Conn) error {
 	ddl := `
 CREATE TABLE nodes (
@@ -336,7 +647,9 @@ CREATE TABLE nodes (
     package TEXT,
     parent_function TEXT,
     type_info TEXT,
-    properties TEXT
+    properties TEXT,
+    module TEXT,
+    end_col INTEGER
 );
 
 CREATE TABLE edges (
@@ -358,7 +671,8 @@ CREATE TABLE metrics (
     fan_in INTEGER,
     fan_out INTEGER,
     loc INTEGER,
-    num_params INTEGER
+    num_params INTEGER,
+    path_count INTEGER
 );
 `
 	return sqlitex.ExecuteScript(conn, ddl, nil)
@@ -369,6 +683,7 @@ func createIndexes(conn *sqlite.Conn) error {
 CREATE INDEX idx_nodes_kind ON nodes(kind);
 CREATE INDEX idx_nodes_package ON nodes(package);
 CREATE INDEX idx_nodes_file ON nodes(file);
+CREATE INDEX idx_nodes_module ON nodes(module);
 CREATE INDEX idx_nodes_parent ON nodes(parent_function);
 CREATE INDEX idx_edges_source ON edges(source, kind);
 CREATE INDEX idx_edges_target ON edges(target, kind);
@@ -377,14 +692,28 @@ CREATE INDEX idx_edges_kind ON edges(kind);
 	return sqlitex.ExecuteScript(conn, indexes, nil)
 }
 
+// sortedOrder returns the indices 0..n-1 ordered by cmp, leaving the caller's
+// slice as it is. The base tables are inserted in this order so their rowids
+// (and everything derived from them) do not depend on walk or map order.
+func sortedOrder(n int, cmp func(i, j int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, cmp)
+	return order
+}
+
 func insertNodes(conn *sqlite.Conn, nodes []Node, prog *Progress) error {
-	stmt, err := conn.Prepare(`INSERT OR IGNORE INTO nodes (id, kind, name, file, line, col, end_line, package, parent_function, type_info, properties) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	stmt, err := conn.Prepare(`INSERT OR IGNORE INTO nodes (id, kind, name, file, line, col, end_line, package, parent_function, type_info, properties, module, end_col) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return fmt.Errorf("prepare node insert: %w", err)
 	}
 	defer func() { _ = stmt.Finalize() }()
 
-	for i, n := range nodes {
+	order := sortedOrder(len(nodes), func(i, j int) int { return strings.Compare(nodes[i].ID, nodes[j].ID) })
+	for i, idx := range order {
+		n := &nodes[idx]
 		stmt.BindText(1, n.ID)
 		stmt.BindText(2, n.Kind)
 		stmt.BindText(3, n.Name)
@@ -396,6 +725,8 @@ func insertNodes(conn *sqlite.Conn, nodes []Node, prog *Progress) error {
 		bindTextOrNull(stmt, 9, n.ParentFunction)
 		bindTextOrNull(stmt, 10, n.TypeInfo)
 		bindTextOrNull(stmt, 11, PropsJSON(n.Properties))
+		bindTextOrNull(stmt, 12, modSet.ModuleOf(*n))
+		bindIntOrNull(stmt, 13, n.EndCol)
 
 		if _, err := stmt.Step(); err != nil {
 			return fmt.Errorf("insert node %s: %w", n.ID, err)
@@ -418,7 +749,15 @@ func insertEdges(conn *sqlite.Conn, edges []Edge, prog *Progress) error {
 	}
 	defer func() { _ = stmt.Finalize() }()
 
-	for i, e := range edges {
+	order := sortedOrder(len(edges), func(i, j int) int {
+		a, b := &edges[i], &edges[j]
+		if c := cmp.Or(strings.Compare(a.Source, b.Source), strings.Compare(a.Target, b.Target), strings.Compare(a.Kind, b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(PropsJSON(a.Properties), PropsJSON(b.Properties))
+	})
+	for i, idx := range order {
+		e := &edges[idx]
 		stmt.BindText(1, e.Source)
 		stmt.BindText(2, e.Target)
 		stmt.BindText(3, e.Kind)
@@ -445,7 +784,8 @@ func insertSources(conn *sqlite.Conn, sources map[string]string, prog *Progress)
 	}
 	defer func() { _ = stmt.Finalize() }()
 
-	for file, content := range sources {
+	for _, file := range slices.Sorted(maps.Keys(sources)) {
+		content := sources[file]
 		stmt.BindText(1, file)
 		stmt.BindText(2, content)
 		// Extract package from file path: first directory component
@@ -463,19 +803,25 @@ func insertSources(conn *sqlite.Conn, sources map[string]string, prog *Progress)
 }
 
 func insertMetrics(conn *sqlite.Conn, metrics map[string]*Metrics, prog *Progress) error {
-	stmt, err := conn.Prepare(`INSERT OR IGNORE INTO metrics (function_id, cyclomatic_complexity, fan_in, fan_out, loc, num_params) VALUES (?, ?, ?, ?, ?, ?)`)
+	stmt, err := conn.Prepare(`INSERT OR IGNORE INTO metrics (function_id, cyclomatic_complexity, fan_in, fan_out, loc, num_params, path_count) VALUES (?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return fmt.Errorf("prepare metrics insert: %w", err)
 	}
 	defer func() { _ = stmt.Finalize() }()
 
-	for _, m := range metrics {
+	for _, id := range slices.Sorted(maps.Keys(metrics)) {
+		m := metrics[id]
 		stmt.BindText(1, m.FunctionID)
 		stmt.BindInt64(2, int64(m.CyclomaticComplexity))
 		stmt.BindInt64(3, int64(m.FanIn))
 		stmt.BindInt64(4, int64(m.FanOut))
 		stmt.BindInt64(5, int64(m.LOC))
 		stmt.BindInt64(6, int64(m.NumParams))
+		if m.PathCount > 0 {
+			stmt.BindInt64(7, int64(m.PathCount))
+		} else {
+			stmt.BindNull(7)
+		}
 
 		if _, err := stmt.Step(); err != nil {
 			return fmt.Errorf("insert metric %s: %w", m.FunctionID, err)
@@ -622,6 +968,215 @@ CREATE INDEX idx_edge_props_key_value ON edge_properties(key, value);
 	return sqlitex.ExecuteScript(conn, ddl, nil)
 }
 
+// createFindingsTable creates the findings table every analysis pass inserts
+// into.
+func createFindingsTable(conn *sqlite.Conn) error {
+	return sqlitex.ExecuteScript(conn, `
+-- Pre-computed findings for the viewer
+CREATE TABLE findings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    node_id TEXT,
+    file TEXT,
+    line INTEGER,
+    message TEXT NOT NULL,
+    details TEXT,
+    suppressed INTEGER NOT NULL DEFAULT 0
+);`, nil)
+}
+
+// selectedCategory matches the literal category a findings INSERT selects
+// first (SELECT 'dead_code', 'warning', ...).
+var selectedCategory = regexp.MustCompile(`(?i)\bSELECT\s+'([a-z0-9_]+)'\s*,`)
+
+// skipsFindings reports whether query, one SQL statement, is an INSERT INTO
+// findings whose every category is excluded by -exclude-findings, so running
+// it would be wasted work. A statement choosing its category at run time
+// (CASE ...) is never skipped; applyExcludedFindings deletes its rows.
+func skipsFindings(query string) bool {
+	if len(excludeFindings) == 0 {
+		return false
+	}
+	var code []string
+	for _, line := range strings.Split(query, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			code = append(code, line)
+		}
+	}
+	if len(code) == 0 || !strings.HasPrefix(code[0], "INSERT INTO findings") {
+		return false
+	}
+	cats := selectedCategory.FindAllStringSubmatch(strings.Join(code, "\n"), -1)
+	if len(cats) == 0 {
+		return false
+	}
+	for _, m := range cats {
+		if !excludeFindings[m[1]] {
+			return false
+		}
+	}
+	return true
+}
+
+// execFindingsScript is sqlitex.ExecuteScript for the analysis passes: with
+// -exclude-findings it skips the INSERT INTO findings statements that would
+// only add excluded categories (skipsFindings).
+func execFindingsScript(conn *sqlite.Conn, script string) (err error) {
+	defer sqlitex.Save(conn)(&err)
+	for {
+		script = strings.TrimSpace(script)
+		if script == "" {
+			return nil
+		}
+		stmt, trailing, err := conn.PrepareTransient(script)
+		if err != nil {
+			return err
+		}
+		query := script[:len(script)-trailing]
+		script = script[len(query):]
+		if stmt == nil || skipsFindings(query) {
+			if stmt != nil {
+				stmt.Finalize()
+			}
+			continue
+		}
+		for {
+			hasRow, err := stmt.Step()
+			if err != nil {
+				stmt.Finalize()
+				return err
+			}
+			if !hasRow {
+				break
+			}
+		}
+		if err := stmt.Finalize(); err != nil {
+			return err
+		}
+	}
+}
+
+// insertFindings runs query, an INSERT INTO findings of category, and
+// returns how many findings it added. With category excluded by
+// -exclude-findings it runs nothing and returns 0.
+func insertFindings(conn *sqlite.Conn, category, query string, opts *sqlitex.ExecOptions) (int, error) {
+	if excludeFindings[category] {
+		return 0, nil
+	}
+	if err := sqlitex.ExecuteTransient(conn, query, opts); err != nil {
+		return 0, err
+	}
+	return conn.Changes(), nil
+}
+
+// findingCategoryFilter matches a category = '...' or category IN ('...')
+// condition in a saved query or view.
+var findingCategoryFilter = regexp.MustCompile(`(?i)\bcategory\s*(?:=\s*'([a-z0-9_]+)'|IN\s*\(([^)]*)\))`)
+
+// servesOnlyExcluded reports whether code, a saved query or view, filters
+// findings to categories that are all excluded by -exclude-findings: it can
+// only ever return nothing.
+func servesOnlyExcluded(code string) bool {
+	var cats []string
+	for _, m := range findingCategoryFilter.FindAllStringSubmatch(code, -1) {
+		if m[1] != "" {
+			cats = append(cats, m[1])
+			continue
+		}
+		for _, c := range strings.Split(m[2], ",") {
+			c = strings.TrimSpace(c)
+			if len(c) < 2 || c[0] != '\'' || c[len(c)-1] != '\'' {
+				return false // a subquery, not a list of categories
+			}
+			cats = append(cats, c[1:len(c)-1])
+		}
+	}
+	if len(cats) == 0 {
+		return false
+	}
+	for _, c := range cats {
+		if !excludeFindings[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyExcludedFindings finishes -exclude-findings after the last INSERT INTO
+// findings and queries: it warns about categories no pass produces (a typo
+// would otherwise silently exclude nothing), deletes excluded rows that
+// passes with a computed category added, and drops the saved queries and
+// views that only read excluded categories, with their schema_docs rows.
+func applyExcludedFindings(conn *sqlite.Conn, prog *Progress) error {
+	known := make(map[string]bool)
+	if err := sqlitex.ExecuteTransient(conn, `SELECT name FROM schema_docs WHERE category = 'finding'`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			known[stmt.ColumnText(0)] = true
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("exclude findings: %w", err)
+	}
+	for _, cat := range slices.Sorted(maps.Keys(excludeFindings)) {
+		if !known[cat] {
+			prog.Log("Warning: -exclude-findings category %q is not a finding category", cat)
+		}
+	}
+
+	list, err := json.Marshal(slices.Sorted(maps.Keys(excludeFindings)))
+	if err != nil {
+		return fmt.Errorf("exclude findings: %w", err)
+	}
+	if err := sqlitex.ExecuteTransient(conn, `DELETE FROM findings WHERE category IN (SELECT value FROM json_each(?))`,
+		&sqlitex.ExecOptions{Args: []any{string(list)}}); err != nil {
+		return fmt.Errorf("exclude findings: %w", err)
+	}
+
+	var queries, views []string
+	if err := sqlitex.ExecuteTransient(conn, `
+SELECT 'query', name, sql FROM queries
+UNION ALL
+SELECT 'view', name, sql FROM sqlite_master WHERE type = 'view'`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			if servesOnlyExcluded(stmt.ColumnText(2)) {
+				if stmt.ColumnText(0) == "query" {
+					queries = append(queries, stmt.ColumnText(1))
+				} else {
+					views = append(views, stmt.ColumnText(1))
+				}
+			}
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("exclude findings: %w", err)
+	}
+	for _, name := range queries {
+		if err := sqlitex.ExecuteTransient(conn, `DELETE FROM queries WHERE name = ?`,
+			&sqlitex.ExecOptions{Args: []any{name}}); err != nil {
+			return fmt.Errorf("exclude findings: %w", err)
+		}
+	}
+	for _, name := range views {
+		if err := sqlitex.ExecuteTransient(conn, `DROP VIEW "`+name+`"`, nil); err != nil {
+			return fmt.Errorf("exclude findings: %w", err)
+		}
+	}
+	for category, names := range map[string][]string{"query": queries, "view": views} {
+		b, err := json.Marshal(names)
+		if err != nil {
+			return fmt.Errorf("exclude findings: %w", err)
+		}
+		if err := sqlitex.ExecuteTransient(conn,
+			`DELETE FROM schema_docs WHERE category = ? AND name IN (SELECT value FROM json_each(?))`,
+			&sqlitex.ExecOptions{Args: []any{category, string(b)}}); err != nil {
+			return fmt.Errorf("exclude findings: %w", err)
+		}
+	}
+	if n := len(queries) + len(views); n > 0 {
+		prog.Log("Dropped %d saved queries and views that only read excluded findings", n)
+	}
+	return nil
+}
+
 // createAnalysisViews creates SQL views and a queries table for program analysis.
 func createAnalysisViews(conn *sqlite.Conn) error {
 	ddl := `
@@ -660,6 +1215,25 @@ CREATE VIEW v_data_flow AS
   JOIN nodes n2 ON e.target = n2.id
   WHERE e.kind = 'dfg';
 
+-- Data flow restricted to precise (SSA-derived and field-read) edges
+CREATE VIEW v_precise_data_flow AS
+  SELECT
+    e.source AS def_id,
+    n1.name AS def_name,
+    n1.kind AS def_kind,
+    n1.file AS def_file,
+    n1.line AS def_line,
+    e.target AS use_id,
+    n2.name AS use_name,
+    n2.kind AS use_kind,
+    n2.file AS use_file,
+    n2.line AS use_line
+  FROM edges e
+  JOIN nodes n1 ON e.source = n1.id
+  JOIN nodes n2 ON e.target = n2.id
+  WHERE e.kind = 'dfg'
+    AND COALESCE(json_extract(e.properties, '$.confidence'), 'precise') = 'precise';
+
 -- Function summary with metrics and call counts
 CREATE VIEW v_function_summary AS
   SELECT
@@ -735,24 +1309,12 @@ CREATE VIEW v_function_io AS
     p.name AS io_name,
     p.kind AS io_kind,
     p.type_info AS io_type,
-    json_extract(p.properties, '$.mutable') AS is_mutable,
-    json_extract(p.properties, '$.nullable') AS is_nullable
-  FROM nodes f
-  JOIN edges e ON e.source = f.id AND e.kind = 'ast'
-  JOIN nodes p ON p.id = e.target AND p.kind IN ('parameter', 'result')
-  WHERE f.kind = 'function';
-
--- Pre-computed findings for the viewer
-CREATE TABLE findings (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    category TEXT NOT NULL,
-    severity TEXT NOT NULL,
-    node_id TEXT,
-    file TEXT,
-    line INTEGER,
-    message TEXT NOT NULL,
-    details TEXT
-);
+    json_extract(p.properties, '$.mutable') AS is_mutable,
+    json_extract(p.properties, '$.nullable') AS is_nullable
+  FROM nodes f
+  JOIN edges e ON e.source = f.id AND e.kind = 'ast'
+  JOIN nodes p ON p.id = e.target AND p.kind IN ('parameter', 'result')
+  WHERE f.kind = 'function';
 
 -- High complexity functions
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
@@ -762,6 +1324,16 @@ INSERT INTO findings (category, severity, node_id, file, line, message, details)
   FROM nodes n JOIN metrics m ON n.id = m.function_id
   WHERE m.cyclomatic_complexity >= 15;
 
+-- Path explosion: acyclic CFG paths grow multiplicatively with sequential
+-- branches, which cyclomatic complexity (additive) hides
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'path_explosion', 'warning', n.id, n.file, n.line,
+    n.name || ' has ' || CASE WHEN m.path_count >= 1000000000 THEN '1000000000+' ELSE m.path_count END ||
+      ' acyclic paths (cyclomatic complexity ' || m.cyclomatic_complexity || ')',
+    json_object('path_count', m.path_count, 'complexity', m.cyclomatic_complexity, 'package', n.package)
+  FROM nodes n JOIN metrics m ON n.id = m.function_id
+  WHERE m.path_count >= 1000;
+
 -- Very large functions
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
   SELECT 'size', 'info', n.id, n.file, n.line,
@@ -847,25 +1419,31 @@ CREATE TABLE queries (
 
 INSERT INTO queries (name, description, sql) VALUES
 ('backward_slice',
- 'Backward program slice: find all nodes that contribute to a given node via data flow',
+ 'Backward program slice: find all nodes that contribute to a given node via data flow; :min_confidence (precise, heuristic, fallback; default fallback = all) drops less certain dfg edges',
  'WITH RECURSIVE slice(id, depth) AS (
   SELECT :node_id, 0
   UNION
   SELECT e.source, s.depth + 1
   FROM slice s JOIN edges e ON e.target = s.id
-  WHERE e.kind IN (''dfg'', ''param_in'') AND s.depth < 20
+  WHERE e.kind IN (''dfg'', ''param_in'', ''returns'') AND s.depth < 20
+    AND (e.kind != ''dfg'' OR
+      CASE json_extract(e.properties, ''$.confidence'') WHEN ''fallback'' THEN 1 WHEN ''heuristic'' THEN 2 ELSE 3 END >=
+      CASE :min_confidence WHEN ''precise'' THEN 3 WHEN ''heuristic'' THEN 2 ELSE 1 END)
 )
 SELECT DISTINCT n.* FROM slice s JOIN nodes n ON n.id = s.id ORDER BY n.file, n.line');
 
 INSERT INTO queries (name, description, sql) VALUES
 ('forward_slice',
- 'Forward program slice: find all nodes affected by a given node via data flow',
+ 'Forward program slice: find all nodes affected by a given node via data flow; :min_confidence (precise, heuristic, fallback; default fallback = all) drops less certain dfg edges',
  'WITH RECURSIVE slice(id, depth) AS (
   SELECT :node_id, 0
   UNION
   SELECT e.target, s.depth + 1
   FROM slice s JOIN edges e ON e.source = s.id
-  WHERE e.kind IN (''dfg'', ''param_out'') AND s.depth < 20
+  WHERE e.kind IN (''dfg'', ''param_out'', ''returns'') AND s.depth < 20
+    AND (e.kind != ''dfg'' OR
+      CASE json_extract(e.properties, ''$.confidence'') WHEN ''fallback'' THEN 1 WHEN ''heuristic'' THEN 2 ELSE 3 END >=
+      CASE :min_confidence WHEN ''precise'' THEN 3 WHEN ''heuristic'' THEN 2 ELSE 1 END)
 )
 SELECT DISTINCT n.* FROM slice s JOIN nodes n ON n.id = s.id ORDER BY n.file, n.line');
 
@@ -897,9 +1475,19 @@ SELECT DISTINCT n.id, n.name, n.package, c.depth
 FROM callers c JOIN nodes n ON n.id = c.id
 WHERE n.kind = ''function'' ORDER BY c.depth, n.name');
 
+INSERT INTO queries (name, description, sql) VALUES
+('global_users',
+ 'Uses of a package-level var or const (global_var/global_const node) and the functions they sit in',
+ 'SELECT u.id, u.file, u.line, f.id AS function_id, f.name AS function_name, f.package
+FROM edges e
+JOIN nodes u ON u.id = e.source
+LEFT JOIN nodes f ON f.id = u.parent_function
+WHERE e.target = :global_id AND e.kind = ''ref''
+ORDER BY u.file, u.line');
+
 INSERT INTO queries (name, description, sql) VALUES
 ('scope_variables',
- 'All variables visible at a given scope (block), walking the scope chain',
+ 'All variables visible at a given scope (block), walking the scope chain, plus the package-level vars and consts',
  'WITH RECURSIVE scope_chain(id) AS (
   SELECT :block_id
   UNION
@@ -909,7 +1497,11 @@ SELECT n.* FROM scope_chain sc
 JOIN edges e ON e.source = sc.id AND e.kind = ''ast''
 JOIN nodes n ON n.id = e.target
 WHERE n.kind IN (''local'', ''parameter'', ''result'')
-ORDER BY n.file, n.line');
+UNION ALL
+SELECT g.* FROM nodes b
+JOIN nodes g ON g.package = b.package
+WHERE b.id = :block_id AND g.kind IN (''global_var'', ''global_const'')
+ORDER BY file, line');
 
 INSERT INTO queries (name, description, sql) VALUES
 ('interface_implementors',
@@ -1028,13 +1620,16 @@ INSERT INTO queries (name, description, sql) VALUES
  'Parameters and return values for a function (use v_function_io view)',
  'SELECT * FROM v_function_io WHERE function_id = :function_id ORDER BY io_kind DESC, io_name');
 `
-	return sqlitex.ExecuteScript(conn, ddl, nil)
+	return execFindingsScript(conn, ddl)
 }
 
 // computeEOG creates Evaluation Order Graph edges within call expressions.
 // For a call f(a, b, c), Go evaluates arguments left-to-right: a → b → c → f().
 // EOG edges connect consecutive arguments and the last argument to the call node.
 func computeEOG(conn *sqlite.Conn, prog *Progress) error {
+	if !emitFilter.Edge("eog") {
+		return nil
+	}
 	// Step 1: Connect consecutive arguments (arg[i] → arg[i+1])
 	if err := sqlitex.ExecuteTransient(conn,
 		`INSERT OR IGNORE INTO edges (source, target, kind, properties)
@@ -1114,18 +1709,44 @@ CREATE VIEW v_error_handling AS
   LEFT JOIN metrics m ON m.function_id = n.id
   WHERE n.kind = 'function';
 
--- Unused exports: exported functions with zero callers from other packages
+-- Unused exports: exported functions nothing outside their own body calls or references
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
 SELECT 'unused_export', 'info', n.id, n.file, n.line,
-  'exported ' || n.name || ' has no callers from other packages',
+  'exported ' || n.name || ' has no callers',
   json_object('name', n.name, 'package', n.package)
 FROM nodes n
 WHERE n.kind = 'function' AND n.name GLOB '[A-Z]*'
   AND n.package IS NOT NULL AND n.package NOT LIKE 'cmd/%'
   AND NOT EXISTS (
     SELECT 1 FROM edges e
-    JOIN nodes caller ON e.source = caller.id AND caller.package != n.package
-    WHERE e.target = n.id AND e.kind = 'call'
+    JOIN nodes u ON e.source = u.id
+    WHERE e.target = n.id AND e.kind IN ('call', 'ref')
+      AND u.id != n.id AND COALESCE(u.parent_function, '') != n.id
+  );
+
+-- Over-exported symbols: exported functions, methods and types used inside
+-- their package but never from another one. Methods that satisfy an
+-- interface method must stay exported and are skipped.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+SELECT 'should_be_unexported', 'info', n.id, n.file, n.line,
+  'exported ' || n.name || ' is only used inside package ' || n.package,
+  json_object('name', n.name, 'kind', n.kind, 'package', n.package)
+FROM nodes n
+WHERE n.kind IN ('function', 'type_decl') AND n.name GLOB '[A-Z]*'
+  AND n.package IS NOT NULL AND n.package NOT LIKE 'cmd/%'
+  AND EXISTS (
+    SELECT 1 FROM edges e
+    JOIN nodes u ON e.source = u.id AND u.package = n.package
+    WHERE e.target = n.id AND e.kind IN ('call', 'ref')
+      AND u.id != n.id AND COALESCE(u.parent_function, '') != n.id
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM edges e
+    JOIN nodes u ON e.source = u.id AND u.package != n.package
+    WHERE e.target = n.id AND e.kind IN ('call', 'ref')
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM edges e WHERE e.source = n.id AND e.kind = 'satisfies_method'
   );
 
 -- Long parameter lists (> 5 params)
@@ -1175,14 +1796,34 @@ WHERE fn.kind = 'function'
     WHERE np.key = 'sync_kind' AND np.value LIKE 'mutex_%'
   );
 
--- Deeply recursive functions: function calls itself (directly)
+-- Unbounded recursion: a direct self-call whose basic block is not control
+-- dependent on any branch runs on every invocation, so nothing stops the
+-- recursion short of a stack overflow.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+SELECT 'unbounded_recursion', 'warning', c.id, c.file, c.line,
+  n.name || ' calls itself unconditionally (no base-case branch guards the recursive call)',
+  json_object('function_id', n.id, 'package', n.package)
+FROM nodes n
+JOIN nodes c ON c.parent_function = n.id
+JOIN edges cs ON cs.source = c.id AND cs.target = n.id AND cs.kind = 'call_site'
+JOIN edge_properties bp ON bp.source = cs.source AND bp.target = cs.target
+  AND bp.edge_kind = 'call_site' AND bp.key = 'block'
+WHERE n.kind = 'function'
+  AND NOT EXISTS (SELECT 1 FROM edges d WHERE d.target = bp.value AND d.kind = 'cdg')
+GROUP BY n.id;
+
+-- Deeply recursive functions: function calls itself (directly); the
+-- unbounded ones are reported above instead
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
 SELECT 'recursive', 'info', n.id, n.file, n.line,
   n.name || ' calls itself directly',
   json_object('package', n.package)
 FROM nodes n
 JOIN edges e ON e.source = n.id AND e.target = n.id AND e.kind = 'call'
-WHERE n.kind = 'function';
+WHERE n.kind = 'function'
+  AND n.id NOT IN (
+    SELECT json_extract(details, '$.function_id') FROM findings WHERE category = 'unbounded_recursion'
+  );
 
 -- Additional queries
 INSERT INTO queries (name, description, sql) VALUES
@@ -1265,14 +1906,14 @@ INSERT INTO queries (name, description, sql) VALUES
     AND EXISTS (SELECT 1 FROM edges e WHERE e.target = n.id AND e.source = :function_b AND e.kind = ''call'')
   ORDER BY n.package, n.name');
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return err
 	}
 
 	// Count new findings
 	var count int64
 	_ = sqlitex.ExecuteTransient(conn,
-		`SELECT COUNT(*) FROM findings WHERE category IN ('unused_export','long_param_list','god_function','interface_coupling','concurrency_risk','recursive')`,
+		`SELECT COUNT(*) FROM findings WHERE category IN ('unused_export','should_be_unexported','long_param_list','god_function','interface_coupling','concurrency_risk','recursive','unbounded_recursion')`,
 		&sqlitex.ExecOptions{
 			ResultFunc: func(stmt *sqlite.Stmt) error {
 				count = stmt.ColumnInt64(0)
@@ -1366,8 +2007,16 @@ func applyEscapeAnalysis(conn *sqlite.Conn, results []EscapeResult, prog *Progre
 	return nil
 }
 
+// flowEndpointSQL renders the flow_semantics endpoint name ('arg:N' or 'recv')
+// of an argument/receiver edge aliased as alias.
+func flowEndpointSQL(alias string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s.kind = 'receiver' THEN 'recv'
+		     ELSE 'arg:' || json_extract(%[1]s.properties, '$.index') END`, alias)
+}
+
 // createFlowSemantics builds a table describing how data flows through known
 // stdlib functions. Used by the heuristic DFG to create precise data-flow edges.
+// Endpoints are 'arg:N', 'arg:*', 'return:N' and 'recv' (a method's receiver).
 func createFlowSemantics(conn *sqlite.Conn) error {
 	ddl := `
 CREATE TABLE flow_semantics (
@@ -1459,6 +2108,19 @@ INSERT INTO flow_semantics (package, func_name, flow_from, flow_to, description)
 ('bytes', 'Contains', 'arg:0', 'return:0', 'Bytes checked for containment'),
 ('bytes', 'Replace', 'arg:0', 'return:0', 'Source bytes flow to result'),
 
+-- Builders/buffers: written data accumulates in the receiver
+('strings', 'WriteString', 'arg:0', 'recv', 'String appended to Builder'),
+('strings', 'Write', 'arg:0', 'recv', 'Bytes appended to Builder'),
+('strings', 'String', 'recv', 'return:0', 'Builder contents flow to string'),
+('bytes', 'WriteString', 'arg:0', 'recv', 'String appended to Buffer'),
+('bytes', 'Write', 'arg:0', 'recv', 'Bytes appended to Buffer'),
+('bytes', 'String', 'recv', 'return:0', 'Buffer contents flow to string'),
+('bytes', 'Bytes', 'recv', 'return:0', 'Buffer contents flow to bytes'),
+
+-- URLs: parsed components carry the receiver's data
+('net/url', 'Query', 'recv', 'return:0', 'URL query flows to values'),
+('net/url', 'String', 'recv', 'return:0', 'URL flows to string'),
+
 -- Errors
 ('errors', 'New', 'arg:0', 'return:0', 'Message flows to error'),
 ('errors', 'Unwrap', 'arg:0', 'return:0', 'Wrapped error flows to inner error'),
@@ -1472,6 +2134,39 @@ CREATE INDEX idx_flow_sem_pkg ON flow_semantics(package, func_name);
 	return sqlitex.ExecuteScript(conn, ddl, nil)
 }
 
+// createExternalSignatures materializes the go/types signatures recorded on
+// ext:: and int:: stub nodes (params/results/variadic properties) into a
+// queryable table.
+func createExternalSignatures(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+CREATE TABLE external_signatures (
+    id TEXT PRIMARY KEY,
+    package TEXT,
+    name TEXT NOT NULL,
+    params TEXT NOT NULL,
+    results TEXT NOT NULL,
+    num_params INTEGER NOT NULL,
+    num_results INTEGER NOT NULL,
+    variadic INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO external_signatures (id, package, name, params, results, num_params, num_results, variadic)
+  SELECT id, package, name,
+    COALESCE(json_extract(properties, '$.params'), '[]'),
+    COALESCE(json_extract(properties, '$.results'), '[]'),
+    COALESCE(json_array_length(properties, '$.params'), 0),
+    COALESCE(json_array_length(properties, '$.results'), 0),
+    COALESCE(json_extract(properties, '$.variadic'), 0)
+  FROM nodes
+  WHERE (id LIKE 'ext::%' OR id LIKE 'int::%') AND kind = 'function';
+`
+	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+		return fmt.Errorf("external signatures: %w", err)
+	}
+	prog.Log("Recorded %d external signatures", conn.Changes())
+	return nil
+}
+
 // createTaintModel builds a security-oriented taint specification table and
 // annotates call nodes that target known sources, sinks, barriers, or propagators.
 func createTaintModel(conn *sqlite.Conn) error {
@@ -1572,6 +2267,18 @@ JOIN nodes callee ON callee.id = cse.target
 JOIN taint_specs ts ON callee.package = ts.package AND callee.name = ts.func_name
 WHERE c.kind = 'call';
 
+-- Remote calls: database/sql queries and statements and net/http client
+-- requests, each a round trip to another process
+INSERT INTO node_properties (node_id, key, value)
+SELECT DISTINCT c.id, 'remote_call', CASE callee.package WHEN 'database/sql' THEN 'db' ELSE 'http' END
+FROM nodes c
+JOIN edges cse ON cse.source = c.id AND cse.kind = 'call_site'
+JOIN nodes callee ON callee.id = cse.target
+WHERE c.kind = 'call' AND callee.id GLOB 'ext::*'
+  AND ((callee.package = 'database/sql'
+        AND callee.name IN ('Query', 'QueryContext', 'QueryRow', 'QueryRowContext', 'Exec', 'ExecContext'))
+    OR (callee.package = 'net/http' AND callee.name IN ('Get', 'Head', 'Post', 'PostForm', 'Do')));
+
 -- Findings: functions containing both sources and sinks
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
 SELECT 'taint_hotspot', 'warning', fn.id, fn.file, fn.line,
@@ -1591,7 +2298,7 @@ WHERE src_role.key = 'taint_role' AND src_role.value = 'source'
   AND src.parent_function IS NOT NULL
 GROUP BY fn.id;
 `
-	return sqlitex.ExecuteScript(conn, ddl, nil)
+	return execFindingsScript(conn, ddl)
 }
 
 // createSchemaDocs creates a self-documenting table describing the CPG schema,
@@ -1613,6 +2320,8 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('node_kind', 'parameter', 'Function parameter', NULL),
 ('node_kind', 'result', 'Function return value', NULL),
 ('node_kind', 'local', 'Local variable (short decl or var)', NULL),
+('node_kind', 'global_var', 'Package-level var; uses are ref edges to it', NULL),
+('node_kind', 'global_const', 'Package-level const; uses are ref edges to it', NULL),
 ('node_kind', 'call', 'Function/method call expression', NULL),
 ('node_kind', 'literal', 'Literal value (string, int, bool)', NULL),
 ('node_kind', 'identifier', 'Variable/const/type reference', NULL),
@@ -1646,37 +2355,66 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('edge_kind', 'cdg', 'Control dependence: block depends on branch', NULL),
 ('edge_kind', 'dom', 'Dominator tree edge', NULL),
 ('edge_kind', 'pdom', 'Post-dominator tree edge', NULL),
-('edge_kind', 'dfg', 'Data flow: definition→use (intra-procedural)', 'Properties: {"heuristic":true} for external calls'),
-('edge_kind', 'call', 'Caller function→callee function', 'Properties: {"dynamic":true} for interface dispatch'),
-('edge_kind', 'call_site', 'Call AST node→callee function', NULL),
+('edge_kind', 'dfg', 'Data flow: definition→use (intra-procedural); field reads chain base→selector through a.b.c', 'Properties: confidence = precise (SSA, field reads), heuristic (flow_semantics model) or fallback (all arguments→result guess); {"heuristic":true} for external calls, {"field_read":true,"field":"Header"} for field reads'),
+('edge_kind', 'call', 'Caller function→callee function', 'Properties: {"dynamic":true} for interface dispatch, call_kind func/method/interface/func_value'),
+('edge_kind', 'call_site', 'Call AST node→callee function', 'Properties: call_kind as on call edges; block = ID of the SSA basic_block holding the call'),
 ('edge_kind', 'param_in', 'Actual argument→formal parameter (inter-procedural)', 'Properties: {"index": N}'),
 ('edge_kind', 'param_out', 'Callee function→call site (return value flow)', NULL),
-('edge_kind', 'implements', 'Concrete type→interface it implements', NULL),
+('edge_kind', 'returns', 'Returned expression→positional result node {index}; a naked return links named results'' latest assignments, nil/builtins link from the return statement', NULL),
+('edge_kind', 'implements', 'Concrete type→interface it implements; {"via":"type_param"} when only established by a generic instantiation (target may be an ext:: stub)', NULL),
 ('edge_kind', 'embeds', 'Struct→embedded type', NULL),
 ('edge_kind', 'alias_of', 'Type alias→aliased type', NULL),
 ('edge_kind', 'satisfies_method', 'Concrete method→interface method it satisfies', NULL),
 ('edge_kind', 'has_method', 'Type declaration→its method functions', NULL),
+('edge_kind', 'once_guarded', 'sync.Once.Do call→function or closure it runs at most once', NULL),
+('edge_kind', 'init_before', 'Imported package→importing package: the import''s init() functions and package variables are initialized first (see package init_rank)', NULL),
 ('edge_kind', 'scope', 'Block→enclosing scope (lexical scoping)', NULL),
 ('edge_kind', 'ref', 'Identifier→its definition', NULL),
 ('edge_kind', 'eval_type', 'Expression→its type declaration', NULL),
 ('edge_kind', 'argument', 'Call→argument expression', 'Properties: {"index": N}'),
-('edge_kind', 'receiver', 'Method call→receiver expression', NULL),
+('edge_kind', 'receiver', 'Method call→receiver expression (flow_semantics endpoint ''recv'')', NULL),
 ('edge_kind', 'doc', 'Declaration→its doc comment', NULL),
 ('edge_kind', 'initializer', 'Variable→its initializing expression', NULL),
 ('edge_kind', 'next_sibling', 'Statement→next statement (sequential order)', NULL),
 ('edge_kind', 'branch_target', 'Branch statement→target label', NULL),
 ('edge_kind', 'error_wrap', 'Error wrapping: fmt.Errorf %%w or errors.Join → wrapped error', NULL),
 ('edge_kind', 'capture', 'Closure→captured variable from outer scope', NULL),
-('edge_kind', 'eog', 'Evaluation order: arg[i]→arg[i+1] within call', NULL);
+('edge_kind', 'eog', 'Evaluation order: arg[i]→arg[i+1] within call', NULL),
+('edge_kind', 'field_write', 'Assignment/inc-dec→struct field it stores into', 'Properties: {"field":"count","via_receiver":true,"method":"<func id>","shared":true}'),
+('edge_kind', 'error_recovery', 'recover() in a deferred closure→named result it assigns', NULL);
 
 -- Node properties (on JSON properties column)
 INSERT INTO schema_docs (category, name, description, example) VALUES
 ('node_property', 'receiver', 'Receiver type for methods', '*Manager'),
+('node_property', 'receiver_escapes', 'Method uses its receiver as a bare value (returned, passed, compared)', 'true'),
+('node_property', 'receiver_addressed', 'Method takes a receiver field address or calls a pointer method on it', 'true'),
+('node_property', 'recovers_to_error', 'Deferred recover() assigns a named result (panic converted to error)', 'true'),
+('node_property', 'const_index', 'Index expression into a slice: the constant value of its index', '5'),
+('node_property', 'slice_len', 'Slice composite literal: its length (one past the highest index)', '3'),
+('node_property', 'make_cap', 'make() call: constant capacity argument', '16'),
+('node_property', 'range_over', 'Range statement (for node): what it iterates; map, slice, array, string, chan, int or func', 'map'),
+('node_property', 'unkeyed', 'composite_lit of a named struct type with positional elements; with struct_fields (its field count) and, for a type of another package, type_package (import path)', 'true'),
+('node_property', 'nil_check', 'binary_expr comparing a value with nil (== or !=)', 'true'),
+('node_property', 'in_select', 'Channel send (send node) or receive (unary_expr <-) that is the communication of a select case', 'true'),
+('node_property', 'unchecked', 'Type assertion in single-value form (not v, ok := x.(T) or a type switch); panics on mismatch', 'true'),
+('node_property', 'comment_classification', 'Comment kind: directive (//go:, //nolint, //cpg:ignore, //line), license (header before the package clause), todo, doc (documents a declaration or the package) or other', 'todo'),
+('node_property', 'panic_type', 'panic() call raising a value of a package-local named type', 'example.com/m/parser.parseError'),
+('node_property', 'recovers_type', 'recover() call whose result is asserted (r.(T) or type switch) to these types', '["example.com/m/parser.parseError"]'),
+('node_property', 'coverage_ratio', 'Fraction of the function''s statements covered by the -coverage profile', '0.75'),
+('node_property', 'covered_statements', 'Covered statement count from the -coverage profile (with total_statements)', '12'),
+('node_property', 'covered', 'Statement lies in an executed block of the -coverage profile', 'true'),
+('node_property', 'unreachable', 'First statement of a dead region in the statement-level CFG', 'true'),
+('node_property', 'api_signature', 'Exported func/method/type signature used by api_fingerprint (param names dropped)', '(*Store) func(string) (int, bool)'),
 ('node_property', 'generic', 'Function or type has type parameters', 'true'),
 ('node_property', 'external', 'External stub node (not in analyzed code)', 'true'),
 ('node_property', 'snippet', 'Code snippet for the node', 'if err != nil {'),
 ('node_property', 'nesting_depth', 'Depth of control structure nesting', '5'),
+('node_property', 'init_rank', 'Package: position in program initialization order (0 first) among analyzed packages; imports first, ties by import path', '0'),
+('node_property', 'type_size', 'Parameter: size in bytes of its type (go/types Sizes for the target platform); struct_value is true for non-pointer structs', '168'),
+('node_property', 'dead_case', 'Case never selected because an earlier case matches first: duplicate or subsumed (with dead_value and covered_by_line)', 'duplicate'),
 ('node_property', 'is_generated', 'File is generated (.pb.go)', 'true'),
+('node_property', 'oversized', 'File above -max-file-size: only its file node exists (no source text or child nodes); size holds its byte count', 'true'),
+('node_property', 'go_version', 'File language version from the go.mod go directive (or a //go:build go1.N line)', 'go1.21'),
 ('node_property', 'returns_error', 'Function returns error type', 'true'),
 ('node_property', 'returns_nilable', 'Function returns pointer/slice/map/chan', 'true'),
 ('node_property', 'nullable', 'Parameter accepts nil (pointer/slice/map/chan/interface)', 'true'),
@@ -1684,23 +2422,31 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('node_property', 'has_context', 'Function has context.Context as first param', 'true'),
 ('node_property', 'context_param', 'Parameter is context.Context', 'true'),
 ('node_property', 'context_derivation', 'Call derives new context', 'WithCancel'),
-('node_property', 'sync_kind', 'Call is sync primitive', 'mutex_lock'),
-('node_property', 'struct_tag', 'Struct field tag', 'json:"name,omitempty"'),
+('node_property', 'call_kind', 'Call node form: func, method, interface, builtin (len/append/make), conversion (T(x), not a runtime call) or func_value', 'builtin'),
+('node_property', 'ast_hash', 'Function fingerprint: FNV-64 of the signature and body AST with local identifiers alpha-renamed and literals reduced to their kind; equal hashes are clones', '9f2c4b1a7e3d5c60'),
+('node_property', 'assigned_to', 'Builtin append call that is the single right-hand side of an assignment: the target expression (_ for the blank identifier)', 't'),
+('node_property', 'sync_kind', 'Call is sync primitive (once_do for Once.Do); on fields and vars of type sync.Once or *sync.Once, once', 'mutex_lock'),
+('node_property', 'write', 'Identifier is the root of an assignment, inc/dec, or delete/clear target', 'true'),
+('node_property', 'tag', 'Struct field tag (raw, without backticks)', 'json:"name,omitempty"'),
+('node_property', 'tags', 'Struct field tag parsed per key (see struct_tags)', '{"json":"name,omitempty"}'),
 ('node_property', 'inlineable', 'Function can be inlined by compiler', 'true'),
 ('node_property', 'heap_escapes', 'Variable escapes to heap (GC pressure)', 'true/false'),
 ('node_property', 'taint_role', 'Security taint classification', 'source/sink/barrier/propagator'),
-('node_property', 'taint_category', 'Taint category detail', 'http_input, sql_injection');
+('node_property', 'taint_category', 'Taint category detail', 'http_input, sql_injection'),
+('node_property', 'remote_call', 'Call (node_properties) to a database/sql query or statement (db) or a net/http client request (http)', 'db');
 
 -- Tables
 INSERT INTO schema_docs (category, name, description, example) VALUES
-('table', 'nodes', 'All CPG nodes (AST + SSA)', 'SELECT * FROM nodes WHERE kind=''function'' AND package=''scrape'''),
+('table', 'nodes', 'All CPG nodes (AST + SSA); module is the mod_path of the analyzed module the node comes from (NULL for ext::/int:: stubs); end_col is the exclusive end column of functions, statements, calls, blocks and type declarations', 'SELECT * FROM nodes WHERE kind=''function'' AND package=''scrape'''),
+('table', 'modules', 'Analyzed modules: node ID/file prefix (empty for the primary module), module path and directory', 'SELECT c.name, d.name FROM edges e JOIN nodes c ON c.id = e.source JOIN nodes d ON d.id = e.target JOIN modules mc ON mc.mod_path = c.module JOIN modules md ON md.mod_path = d.module WHERE e.kind = ''call'' AND mc.prefix = ''adapter'' AND md.prefix = '''''),
 ('table', 'edges', 'All CPG edges (AST, CFG, DFG, call, type)', 'SELECT * FROM edges WHERE kind=''call'' AND source=:func_id'),
 ('table', 'sources', 'Source file contents', 'SELECT content FROM sources WHERE file=''scrape/manager.go'''),
-('table', 'metrics', 'Function-level metrics', 'SELECT * FROM metrics ORDER BY cyclomatic_complexity DESC'),
+('table', 'metrics', 'Function-level metrics; path_count is acyclic CFG entry→exit paths (loop bodies counted once, capped at 1e9)', 'SELECT * FROM metrics ORDER BY cyclomatic_complexity DESC'),
 ('table', 'findings', 'Pre-computed analysis findings', 'SELECT * FROM findings WHERE category=''complexity'''),
 ('table', 'queries', 'Parameterized CTE queries for analysis', 'SELECT name, description FROM queries'),
 ('table', 'taint_specs', 'Security taint model: known sources/sinks/barriers', 'SELECT * FROM taint_specs WHERE role=''sink'''),
 ('table', 'flow_semantics', 'Data flow semantics for stdlib functions', 'SELECT * FROM flow_semantics WHERE package=''fmt'''),
+('table', 'external_signatures', 'go/types signatures of ext:: and int:: stub callees (params/results as JSON arrays)', 'SELECT * FROM external_signatures WHERE id = ''ext::fmt.Sprintf'''),
 ('table', 'node_properties', 'Vertical property table (extracted from JSON)', 'SELECT * FROM node_properties WHERE key=''receiver'''),
 ('table', 'edge_properties', 'Vertical edge property table', 'SELECT * FROM edge_properties WHERE key=''dynamic'''),
 ('table', 'stats_overview', 'Summary statistics for the entire CPG', 'SELECT * FROM stats_overview'),
@@ -1711,6 +2457,7 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 INSERT INTO schema_docs (category, name, description, example) VALUES
 ('view', 'v_call_graph', 'Flattened call graph with names', 'SELECT * FROM v_call_graph WHERE caller_package=''scrape'''),
 ('view', 'v_data_flow', 'DFG edges with file/line context', NULL),
+('view', 'v_precise_data_flow', 'v_data_flow restricted to precise (SSA-derived and field-read) edges', NULL),
 ('view', 'v_function_summary', 'Per-function metrics + call counts', 'SELECT * FROM v_function_summary ORDER BY complexity DESC'),
 ('view', 'v_type_hierarchy', 'Implements/embeds/alias relationships', NULL),
 ('view', 'v_package_deps', 'Aggregated cross-package call edges', NULL),
@@ -1721,10 +2468,13 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('view', 'v_error_handling', 'Error-returning functions with metrics', NULL),
 ('view', 'v_package_stability', 'Package stability metrics: afferent/efferent coupling, instability index, abstractness', NULL),
 ('view', 'v_control_flow_profile', 'Control flow breakdown per function: if/for/switch/select/return/defer/go counts', NULL),
+('finding', 'path_explosion', 'Function with 1000+ acyclic CFG paths (sequential branches multiply; see metrics.path_count)', NULL),
 ('finding', 'risk_score', 'Composite bug-risk score combining complexity, LOC, fan-in, fan-out', NULL),
 ('finding', 'dead_code', 'Internal functions with zero callers (unreachable code)', NULL),
+('finding', 'untested_complex_function', 'Cyclomatic complexity >= 10 with under 50% statement coverage (needs -coverage)', NULL),
+('finding', 'main_sequence_outlier', 'Package far from the main sequence: |I + A - 1| > 0.7 (zone of pain or uselessness)', NULL),
 ('finding', 'interface_bloat', 'Interfaces with 5+ methods (Go idiom prefers small interfaces)', NULL),
-('finding', 'similar_function', 'Structurally similar function pairs (potential clones)', NULL),
+('finding', 'similar_function', 'Structural clones: functions of 10+ lines with the same ast_hash; details.twin_id is the first function of the clone group', NULL),
 ('query', 'dependency_depth', 'Package dependency depth from leaf packages', NULL),
 ('query', 'function_risk_ranking', 'Top 50 riskiest functions by composite score', NULL),
 ('query', 'package_stability', 'Package instability and abstractness metrics', NULL),
@@ -1733,10 +2483,44 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('view', 'v_package_cohesion', 'Package cohesion: ratio of internal vs external calls', NULL),
 ('view', 'v_concurrency_profile', 'Per-package concurrency usage: goroutines, channels, sync', NULL),
 ('view', 'v_package_impact', 'Transitive package impact: packages affected by changes', NULL),
+('view', 'v_handler_reach', 'HTTP handlers and the functions each reaches within three calls (depth 0 = the handler)', NULL),
 ('finding', 'missing_context_first', 'Functions with context.Context not as first parameter', NULL),
 ('finding', 'large_return', 'Functions returning 4+ values', NULL),
 ('finding', 'bool_params', 'Functions with 2+ boolean parameters (boolean blindness)', NULL),
 ('finding', 'panic_call', 'Functions that call panic() directly', NULL),
+('finding', 'library_terminates_process', 'os.Exit, log.Fatal* or panic called outside package main and cmd/*', NULL),
+('finding', 'context_not_checked_in_loop', 'Loop in a context-taking function with no ctx.Done()/ctx.Err() check in its body', NULL),
+('finding', 'context_background_in_leaf', 'context.Background()/TODO() called in a function that already takes a context.Context (the incoming ctx is not propagated)', NULL),
+('finding', 'query_in_loop', 'Database query or HTTP request (remote_call) in a loop body, a round trip per iteration (N+1)', NULL),
+('finding', 'deferred_close_in_loop', 'Deferred Close/Unlock/RUnlock/Release/Rollback/Stop call inside a loop; it runs at function return, holding each iteration''s resource until then', NULL),
+('finding', 'goroutine_captures_loop_var', 'go statement in a loop whose closure captures the loop variable, in a file before Go 1.22 (per-loop variables)', NULL),
+('finding', 'lost_append', 'Result of append(s, ...) assigned to _, or to another variable while s is read again afterwards without the new elements', NULL),
+('finding', 'any_parameter', 'Parameter typed exactly any/interface{} (not variadic ...any; error-returning helpers that call reflect are exempt)', NULL),
+('finding', 'large_value_param', 'Struct parameter over 128 bytes (type_size) passed by value to a function with fan-in >= 5', NULL),
+('finding', 'duplicate_switch_case', 'Switch case an earlier case always pre-empts: a repeated constant value, or in a tagless switch a range comparison inside an earlier one (n > 100 after n > 10)', NULL),
+('finding', 'map_with_lock', 'Struct map field next to a sync.Mutex/RWMutex field that its methods lock around; advisory sync.Map candidate if read-heavy', NULL),
+('finding', 'blocking_channel_op', 'Channel send or receive outside a select in an HTTP handler or within three calls of one; warning when the function has no context', NULL),
+('finding', 'sleep_in_handler', 'time.Sleep in an HTTP handler (a function taking http.ResponseWriter, *http.Request) or within three calls of one', NULL),
+('finding', 'panic_recover_control_flow', 'recover() asserting a package-local type that another function in the package panics with; deliberate panic-based control flow', NULL),
+('finding', 'map_range_order_dependence', 'Range over a map appends to a slice that the function then returns unsorted; the result order is random', NULL),
+('finding', 'goroutine_in_init', 'go statement in init() or in a function literal a package-level var initializer calls; starts before main configures flags or logging', NULL),
+('finding', 'goroutine_panic_no_recover', 'go statement whose body, or a function it calls, calls panic() with no deferred recover() in the goroutine; crashes the process', NULL),
+('finding', 'unchecked_type_assertion', 'Single-value type assertion x.(T), which panics on mismatch, outside a comma-ok assignment or type switch', NULL),
+('finding', 'index_out_of_range_const', 'Constant (type-checker folded) index not below the length of the slice literal that reaches it over a dfg edge; panics at run time', NULL),
+('finding', 'invalid_make_size', 'make() with an explicit zero capacity: any larger length panics, and make([]T, 0) says the same', NULL),
+('finding', 'unkeyed_struct_literal', 'Literal of a named struct type with positional elements; warning when the type is from another package (two-field standard library types exempt)', NULL),
+('finding', 'redundant_nil_check', 'if condition comparing with nil a value whose dfg definition is make, new or a composite literal, so never nil; always true (!=) or false (==)', NULL),
+('finding', 'error_string_style', 'errors.New/fmt.Errorf message literal starting with a capital letter (not an acronym) or ending with . or !; error strings get wrapped into longer messages', NULL),
+('finding', 'todo_comment', 'Package with comments classified todo (TODO, FIXME, XXX or HACK markers); details.count holds how many', NULL),
+('finding', 'lock_order_inversion', 'Cycle of up to six mutexes (by field or variable declaration), each locked while holding the previous by one of at least two functions; details.cycle lists the locks, details.function_ids the function per step. Can deadlock', NULL),
+('finding', 'lock_without_unlock', 'mu.Lock()/RLock() with no matching Unlock()/RUnlock() (direct, deferred or in a closure) in the same function', NULL),
+('finding', 'http_client_no_timeout', 'http.Get/Head/Post/PostForm or http.DefaultClient call, or http.Client literal with no Timeout field; a stalled server hangs the request', NULL),
+('finding', 'ticker_not_stopped', 'time.NewTicker/NewTimer result with no Stop() call in its function that is not returned, passed on or stored', NULL),
+('finding', 'response_body_not_closed', 'http.Get/Post/Head/PostForm or Client.Do response whose fields are read with no deferred resp.Body.Close()', NULL),
+('finding', 'error_equality_comparison', 'Error compared with ==/!= against a sentinel error variable; errors.Is also matches wrapped errors', NULL),
+('finding', 'unreachable_code', 'Statement with no path from function entry (after return/panic/os.Exit)', NULL),
+('finding', 'value_receiver_mutation', 'Value-receiver method assigns a receiver field (write is lost)', NULL),
+('finding', 'pointer_receiver_could_be_value', 'Pointer-receiver method on a type whose methods never mutate or need receiver identity', NULL),
 ('query', 'package_cohesion', 'Package cohesion analysis', NULL),
 ('query', 'concurrency_profile', 'Per-package concurrency usage', NULL),
 ('query', 'package_impact', 'Transitive package impact analysis', NULL),
@@ -1745,7 +2529,7 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('query', 'type_usage', 'Functions that reference a given type in their signatures', NULL),
 ('table', 'dashboard_complexity_distribution', 'Complexity histogram buckets for chart rendering', NULL),
 ('table', 'dashboard_package_treemap', 'Per-package LOC + complexity for treemap visualization', NULL),
-('table', 'dashboard_findings_summary', 'Finding category counts for bar chart', NULL),
+('table', 'dashboard_findings_summary', 'Finding category counts for bar chart (suppressed findings not counted)', NULL),
 ('table', 'dashboard_edge_distribution', 'Edge type distribution for pie/donut chart', NULL),
 ('table', 'dashboard_node_distribution', 'Node type distribution for pie/donut chart', NULL),
 ('table', 'dashboard_complexity_vs_loc', 'Scatter plot data: complexity vs LOC per function', NULL),
@@ -1754,14 +2538,22 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('table', 'dashboard_hotspots', 'Functions ranked by combined hotspot score (complexity + fan-in + findings)', 'SELECT * FROM dashboard_hotspots ORDER BY hotspot_score DESC LIMIT 20'),
 ('table', 'package_coupling', 'Cross-package call coupling matrix (source→target, count)', 'SELECT * FROM package_coupling ORDER BY call_count DESC LIMIT 20'),
 ('table', 'error_chains', 'Functions involved in error wrapping/propagation chains', 'SELECT * FROM error_chains WHERE error_wraps > 0 ORDER BY error_wraps DESC'),
+('table', 'hot_call_paths', 'Shortest call path between each ordered pair of the top hotspots (the first -top-n, default 50): from_id, to_id, hop_count, path', 'SELECT path FROM hot_call_paths WHERE from_id = :start AND to_id = :end'),
+('table', 'call_graph_centrality', 'PageRank and betweenness (fraction of shortest call paths through it; sampled on large graphs) of each module function in the call graph', 'SELECT * FROM call_graph_centrality ORDER BY pagerank DESC LIMIT 20'),
 ('finding', 'long_param_list', 'Functions with more than 5 parameters', NULL),
+('finding', 'recursive', 'Functions that call themselves directly, with the recursive call under a branch', NULL),
+('finding', 'unbounded_recursion', 'Direct self-call not control dependent (cdg) on any branch: no base case can stop it', NULL),
 ('finding', 'god_package', 'Packages with more than 50 functions', NULL),
+('finding', 'god_type', 'Struct types with more than -god-type-fields fields and more than -god-type-methods methods (defaults 20/15)', NULL),
 ('finding', 'high_coupling', 'Packages depending on more than 10 other packages', NULL),
+('finding', 'central_function', 'Top 1% of functions by call-graph PageRank that also lie on shortest call paths between others (betweenness > 0)', NULL),
 ('query', 'hotspot_analysis', 'Find functions with combined high complexity, fan-in, and findings', NULL),
 ('query', 'package_coupling_matrix', 'Aggregated cross-package call coupling matrix', NULL),
 ('query', 'error_propagation', 'Functions in error wrapping chains', NULL),
 ('query', 'top_functions_by_metric', 'Top 50 functions by complexity, LOC, fan-in, or fan-out', NULL),
 ('query', 'package_coupling_degree', 'Packages ranked by number of coupled packages', NULL),
+('query', 'hot_call_path', 'Precomputed shortest call path between two top hotspots (:start, :end function IDs) from hot_call_paths', NULL),
+('table', 'binary_footprint', 'Per main package: module functions reachable from main/init over call edges (including devirtualized calls and nested function literals) and their summed LOC; a binary-size proxy', 'SELECT * FROM binary_footprint ORDER BY reachable_loc DESC'),
 ('query', 'call_chain_pathfinder', 'Find all call paths between two functions (recursive CTE, up to 6 hops)', NULL),
 ('table', 'dashboard_file_heatmap', 'Per-file complexity/LOC/findings for code heatmap rendering', 'SELECT * FROM dashboard_file_heatmap ORDER BY hotspot_score DESC LIMIT 20'),
 ('table', 'dashboard_package_graph', 'Internal package dependency graph (source→target, weight) for force-directed viz', 'SELECT * FROM dashboard_package_graph ORDER BY weight DESC LIMIT 20'),
@@ -1772,18 +2564,23 @@ INSERT INTO schema_docs (category, name, description, example) VALUES
 ('table', 'type_impl_map', 'Interface→concrete type implementation mapping with method counts', 'SELECT * FROM type_impl_map ORDER BY interface_name LIMIT 20'),
 ('table', 'type_hierarchy', 'Type embedding hierarchy (parent→embedded child)', 'SELECT * FROM type_hierarchy WHERE embedded_id IS NOT NULL LIMIT 20'),
 ('table', 'type_method_set', 'Methods per type with complexity and LOC', 'SELECT * FROM type_method_set ORDER BY type_name, method_name LIMIT 20'),
+('table', 'method_resolution', 'Promoted method set of embedding types: the method a selector resolves to (NULL if ambiguous), its embedding depth and the shadowed same-named methods (JSON array of IDs)', 'SELECT * FROM method_resolution WHERE shadowed_ids IS NOT NULL'),
 ('finding', 'large_interface', 'Interfaces with more than 10 methods (overly broad contract)', NULL),
 ('finding', 'orphan_type', 'Types with no implements/embeds/method edges', NULL),
+('table', 'struct_tags', 'Struct tags per field and key: tag_name (NULL keeps the field name) and comma-separated options', 'SELECT * FROM struct_tags WHERE tag_key = ''json'' LIMIT 20'),
+('finding', 'json_tag_missing', 'Exported struct field without a json tag in a package whose other fields use json tags', NULL),
 ('query', 'interface_map', 'Concrete types implementing a given interface', NULL),
 ('query', 'type_hierarchy_tree', 'Type embedding tree for a given type', NULL),
 ('query', 'method_set', 'Complete method set for a type', NULL),
 ('query', 'largest_interfaces', 'Interfaces ranked by method count', NULL),
 ('query', 'most_implemented', 'Interfaces with the most implementations', NULL),
 ('table', 'symbol_index', 'All named declarations for quick symbol search', 'SELECT * FROM symbol_index WHERE name LIKE ''Manager%'' LIMIT 10'),
+('table', 'symbol_fts', 'FTS5 trigram index over symbol_index names for ranked prefix/substring search', 'SELECT s.* FROM symbol_fts f JOIN symbol_index s ON s.rowid = f.rowid WHERE symbol_fts MATCH ''name:"Mana"'' ORDER BY bm25(symbol_fts) LIMIT 10'),
 ('table', 'file_outline', 'Hierarchical file structure for sidebar tree', 'SELECT * FROM file_outline WHERE file = ''scrape/manager.go'' ORDER BY line'),
 ('table', 'xrefs', 'Definition→usage cross-reference table for go-to-definition and find-all-references', 'SELECT * FROM xrefs WHERE def_name = ''Manager'' LIMIT 10'),
-('table', 'go_pattern_summary', 'Go-specific construct counts per package (goroutines, channels, errors, etc.)', 'SELECT * FROM go_pattern_summary ORDER BY goroutine_count DESC LIMIT 10'),
+('table', 'go_pattern_summary', 'Go-specific construct counts per package (goroutines, channels, errors, any_param_count = any_parameter findings, lazy_init_count = sync.Once.Do calls, etc.)', 'SELECT * FROM go_pattern_summary ORDER BY goroutine_count DESC LIMIT 10'),
 ('query', 'symbol_search', 'Search symbols by name (supports LIKE patterns)', NULL),
+('query', 'symbol_fts_search', 'Ranked prefix/substring symbol search via symbol_fts', NULL),
 ('query', 'file_outline_query', 'Get hierarchical outline of a file', NULL),
 ('query', 'xref_lookup', 'Find all usages of a symbol', NULL),
 ('query', 'go_patterns', 'Go-specific construct usage per package', NULL);
@@ -1896,7 +2693,7 @@ INSERT INTO findings (category, severity, node_id, file, line, message, details)
     2.0 * CAST(m.loc AS REAL) / MAX(maxes.max_loc, 1) +
     1.0 * CAST(m.fan_in AS REAL) / MAX(maxes.max_fi, 1) +
     1.0 * CAST(m.fan_out AS REAL) / MAX(maxes.max_fo, 1)
-  ) DESC
+  ) DESC, m.function_id
   LIMIT 200;
 
 -- Dead code: internal functions with zero callers that aren't entry points
@@ -1915,7 +2712,42 @@ INSERT INTO findings (category, severity, node_id, file, line, message, details)
     AND n.name NOT LIKE '%Example%'
     AND n.package IS NOT NULL
     AND n.package NOT LIKE 'cmd/%'
-    AND n.id NOT LIKE 'ext::%';
+    AND n.id NOT LIKE 'ext::%' AND n.id NOT LIKE 'int::%';
+-- Main-sequence outliers: D = |I + A - 1| > 0.7. Below the line (I + A < 1)
+-- is the zone of pain (stable and concrete: hard to change, many dependents);
+-- above it the zone of uselessness (abstract and unstable: nobody depends on it).
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'main_sequence_outlier', 'info', p.id, NULL, NULL,
+    'package ' || s.package || ' is in the ' ||
+      CASE WHEN s.instability + s.abstractness < 1.0 THEN 'zone of pain' ELSE 'zone of uselessness' END ||
+      ' (D=' || ROUND(ABS(s.instability + s.abstractness - 1.0), 3) || ')',
+    json_object(
+      'package', s.package,
+      'zone', CASE WHEN s.instability + s.abstractness < 1.0 THEN 'pain' ELSE 'uselessness' END,
+      'distance', ROUND(ABS(s.instability + s.abstractness - 1.0), 3),
+      'instability', s.instability,
+      'abstractness', s.abstractness,
+      'afferent_coupling', s.afferent_coupling,
+      'efferent_coupling', s.efferent_coupling)
+  FROM v_package_stability s
+  JOIN nodes p ON p.kind = 'package' AND p.package = s.package
+  WHERE ABS(s.instability + s.abstractness - 1.0) > 0.7;
+
+-- Untested complex functions: only present when built with -coverage
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'untested_complex_function', 'warning', n.id, n.file, n.line,
+    n.name || ' has complexity ' || m.cyclomatic_complexity || ' but ' ||
+      CAST(ROUND(json_extract(n.properties, '$.coverage_ratio') * 100) AS INTEGER) || '% statement coverage',
+    json_object('complexity', m.cyclomatic_complexity,
+      'coverage_ratio', json_extract(n.properties, '$.coverage_ratio'),
+      'covered_statements', json_extract(n.properties, '$.covered_statements'),
+      'total_statements', json_extract(n.properties, '$.total_statements'),
+      'package', n.package)
+  FROM nodes n
+  JOIN metrics m ON m.function_id = n.id
+  WHERE n.kind = 'function'
+    AND m.cyclomatic_complexity >= 10
+    AND json_extract(n.properties, '$.coverage_ratio') < 0.5;
 
 -- Interface bloat: interfaces with many methods (Go prefers small interfaces)
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
@@ -1934,24 +2766,32 @@ INSERT INTO findings (category, severity, node_id, file, line, message, details)
   ) sub
   JOIN nodes n ON n.id = sub.type_id;
 
--- Similar functions: structural clones (same complexity + similar param count + similar LOC)
+-- Similar functions: structural clones, i.e. functions of 10+ lines with the
+-- same ast_hash (AST with local names and literals normalized). Each clone
+-- points at the first function of its group rather than at every twin.
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
-  SELECT 'similar_function', 'info', m1.function_id, n1.file, n1.line,
-    n1.name || ' is structurally similar to ' || n2.name ||
-    ' (cc=' || m1.cyclomatic_complexity || ', loc≈' || m1.loc || '/' || m2.loc || ')',
-    json_object('twin_id', m2.function_id, 'twin_name', n2.name,
-                'complexity', m1.cyclomatic_complexity,
-                'loc_a', m1.loc, 'loc_b', m2.loc,
-                'package_a', n1.package, 'package_b', n2.package)
-  FROM metrics m1
-  JOIN metrics m2 ON m1.function_id < m2.function_id
-    AND m1.cyclomatic_complexity = m2.cyclomatic_complexity
-    AND m1.num_params = m2.num_params
-    AND ABS(m1.loc - m2.loc) <= 3
-  JOIN nodes n1 ON n1.id = m1.function_id
-  JOIN nodes n2 ON n2.id = m2.function_id
-  WHERE m1.cyclomatic_complexity >= 5 AND m1.loc >= 15
-    AND n1.package != n2.package;
+  WITH hashed AS (
+    SELECT n.id, n.name, n.file, n.line, n.package, m.loc, m.cyclomatic_complexity AS cc,
+      json_extract(n.properties, '$.ast_hash') AS hash
+    FROM nodes n
+    JOIN metrics m ON m.function_id = n.id
+    WHERE n.kind = 'function' AND m.loc >= 10
+      AND json_extract(n.properties, '$.ast_hash') IS NOT NULL
+  ),
+  groups AS (
+    SELECT hash, MIN(id) AS first_id, COUNT(*) AS size
+    FROM hashed GROUP BY hash HAVING COUNT(*) > 1
+  )
+  SELECT 'similar_function', 'info', c.id, c.file, c.line,
+    c.name || ' is a structural clone of ' || f.name ||
+    ' (' || g.size || ' functions share this AST, loc=' || c.loc || ')',
+    json_object('twin_id', f.id, 'twin_name', f.name, 'ast_hash', c.hash, 'clone_count', g.size,
+                'complexity', c.cc,
+                'loc_a', c.loc, 'loc_b', f.loc,
+                'package_a', c.package, 'package_b', f.package)
+  FROM groups g
+  JOIN hashed c ON c.hash = g.hash AND c.id != g.first_id
+  JOIN hashed f ON f.id = g.first_id;
 
 -- Additional queries
 INSERT INTO queries (name, description, sql) VALUES
@@ -2005,25 +2845,23 @@ INSERT INTO queries (name, description, sql) VALUES
 
 INSERT INTO queries (name, description, sql) VALUES
 ('similar_functions',
- 'Find structural clones of a function (same complexity and parameter count)',
+ 'Find structural clones of a function (same ast_hash: identical AST up to local names and literals)',
  'SELECT n2.id, n2.name, n2.package, n2.file, n2.line,
     m2.cyclomatic_complexity AS complexity, m2.loc, m2.num_params
-  FROM metrics m1
-  JOIN metrics m2 ON m1.function_id != m2.function_id
-    AND m1.cyclomatic_complexity = m2.cyclomatic_complexity
-    AND m1.num_params = m2.num_params
-    AND ABS(m1.loc - m2.loc) <= 5
-  JOIN nodes n2 ON n2.id = m2.function_id
-  WHERE m1.function_id = :function_id
-  ORDER BY ABS(m1.loc - m2.loc), n2.package, n2.name');
+  FROM nodes n1
+  JOIN nodes n2 ON n2.kind = ''function'' AND n2.id != n1.id
+    AND json_extract(n2.properties, ''$.ast_hash'') = json_extract(n1.properties, ''$.ast_hash'')
+  LEFT JOIN metrics m2 ON m2.function_id = n2.id
+  WHERE n1.id = :function_id
+  ORDER BY n2.package, n2.name');
 
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return err
 	}
 
 	// Count new findings
-	var riskCount, deadCount, bloatCount, simCount int64
+	var riskCount, deadCount, bloatCount, simCount, outlierCount, untestedCount int64
 	for _, pair := range []struct {
 		cat  string
 		dest *int64
@@ -2032,6 +2870,8 @@ INSERT INTO queries (name, description, sql) VALUES
 		{"dead_code", &deadCount},
 		{"interface_bloat", &bloatCount},
 		{"similar_function", &simCount},
+		{"main_sequence_outlier", &outlierCount},
+		{"untested_complex_function", &untestedCount},
 	} {
 		cat := pair.cat
 		_ = sqlitex.ExecuteTransient(conn,
@@ -2045,8 +2885,8 @@ INSERT INTO queries (name, description, sql) VALUES
 			})
 	}
 
-	prog.Log("Advanced: %d risk scores, %d dead code, %d interface bloat, %d similar pairs, 2 views, 5 queries",
-		riskCount, deadCount, bloatCount, simCount)
+	prog.Log("Advanced: %d risk scores, %d dead code, %d interface bloat, %d similar pairs, %d main-sequence outliers, %d untested complex, 2 views, 5 queries",
+		riskCount, deadCount, bloatCount, simCount, outlierCount, untestedCount)
 	return nil
 }
 
@@ -2118,6 +2958,35 @@ CREATE VIEW v_package_impact AS
   FROM impact
   GROUP BY pkg;
 
+-- Handler reach: HTTP handlers, functions (declared or literal) taking
+-- (http.ResponseWriter, *http.Request), and every function each reaches
+-- within three call edges, at depth 0 for the handler itself
+CREATE VIEW v_handler_reach AS
+  WITH RECURSIVE handlers AS (
+    SELECT fn.id
+    FROM nodes fn
+    WHERE fn.kind = 'function'
+      AND EXISTS (
+        SELECT 1 FROM edges e JOIN nodes p ON p.id = e.target
+        WHERE e.source = fn.id AND e.kind = 'ast' AND p.kind = 'parameter'
+          AND p.type_info = 'net/http.ResponseWriter'
+      )
+      AND EXISTS (
+        SELECT 1 FROM edges e JOIN nodes p ON p.id = e.target
+        WHERE e.source = fn.id AND e.kind = 'ast' AND p.kind = 'parameter'
+          AND p.type_info = '*net/http.Request'
+      )
+  ),
+  reach(handler_id, fn_id, depth) AS (
+    SELECT id, id, 0 FROM handlers
+    UNION
+    SELECT r.handler_id, e.target, r.depth + 1
+    FROM reach r
+    JOIN edges e ON e.source = r.fn_id AND e.kind = 'call'
+    WHERE r.depth < 3
+  )
+  SELECT handler_id, fn_id AS function_id, depth FROM reach;
+
 -- Context.Context compliance: functions with 2+ params that don't take context first
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
   SELECT 'missing_context_first', 'info', n.id, n.file, n.line,
@@ -2179,6 +3048,860 @@ INSERT INTO findings (category, severity, node_id, file, line, message, details)
       WHERE c.kind = 'call' AND c.parent_function = fn.id AND c.name = 'panic'
     );
 
+-- Library code that ends the process: os.Exit / log.Fatal* / panic outside
+-- package main and cmd/*. Package name comes from the package node, since the
+-- module root's relative package is also rendered as 'main'.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'library_terminates_process', 'warning', fn.id, c.file, c.line,
+    fn.name || ' calls ' || c.name || ' in library package ' || fn.package,
+    json_object('call', c.name, 'call_id', c.id, 'package', fn.package)
+  FROM nodes c
+  JOIN nodes fn ON fn.id = c.parent_function AND fn.kind = 'function'
+  JOIN nodes p ON p.kind = 'package' AND p.package = fn.package
+  WHERE c.kind = 'call'
+    AND (c.name IN ('os.Exit', 'panic') OR c.name GLOB 'log.Fatal*')
+    AND p.name != 'main'
+    AND fn.package NOT LIKE 'cmd/%' AND fn.package != 'cmd';
+
+-- Unreachable code: first statement of each dead region in the statement-level
+-- CFG (after return/panic/os.Exit/log.Fatal*, or after if/else that all exit).
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'unreachable_code', 'warning', s.id, s.file, s.line,
+    'unreachable ' || s.kind || ' in ' || COALESCE(fn.name, 'function'),
+    json_object('function_id', s.parent_function, 'kind', s.kind)
+  FROM nodes s
+  LEFT JOIN nodes fn ON fn.id = s.parent_function
+  WHERE json_extract(s.properties, '$.unreachable') = 1;
+
+-- Context not checked in loops: a loop in a context-taking function with no
+-- ctx.Done()/ctx.Err() call anywhere in its body. Loops nested in an already
+-- flagged loop are not reported again.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH loops AS (
+    SELECT l.id, l.parent_function AS fn_id, l.file, l.line, l.end_line,
+      EXISTS (
+        SELECT 1 FROM nodes c
+        WHERE c.parent_function = l.parent_function AND c.kind = 'call'
+          AND c.file = l.file AND c.line BETWEEN l.line AND l.end_line
+          AND (c.name LIKE '%.Done' OR c.name LIKE '%.Err')
+      ) AS checked
+    FROM nodes l
+    JOIN node_properties np ON np.node_id = l.parent_function
+      AND np.key = 'has_context' AND np.value = '1'
+    WHERE l.kind = 'for' AND l.end_line IS NOT NULL
+  )
+  SELECT 'context_not_checked_in_loop', 'info', l.id, l.file, l.line,
+    'loop in ' || fn.name || ' never checks ctx.Done() or ctx.Err()',
+    json_object('function_id', l.fn_id, 'end_line', l.end_line, 'package', fn.package)
+  FROM loops l
+  JOIN nodes fn ON fn.id = l.fn_id
+  WHERE l.checked = 0
+    AND NOT EXISTS (
+      SELECT 1 FROM loops o
+      WHERE o.fn_id = l.fn_id AND o.id != l.id AND o.checked = 0
+        AND o.line <= l.line AND o.end_line >= l.end_line
+    );
+
+-- Background context in a leaf: a function that receives ctx but starts a
+-- fresh context.Background()/TODO() cuts its callees off from the caller's
+-- cancellation and deadline; pass ctx (or context.WithoutCancel(ctx)) on.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'context_background_in_leaf', 'info', c.id, c.file, c.line,
+    fn.name || ' receives a context.Context but calls ' || c.name || '(); propagate the incoming ctx',
+    json_object('function_id', fn.id, 'call', c.name, 'package', fn.package)
+  FROM nodes c
+  JOIN nodes fn ON fn.id = c.parent_function
+  JOIN node_properties np ON np.node_id = fn.id
+    AND np.key = 'has_context' AND np.value = '1'
+  WHERE c.kind = 'call' AND c.name IN ('context.Background', 'context.TODO');
+
+-- Error equality: err == io.EOF misses wrapped errors (the AST walk tags the
+-- binary_expr with the sentinel it compares against)
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'error_equality_comparison', 'warning', b.id, b.file, b.line,
+    'error compared with ' || b.name || ' ' || json_extract(b.properties, '$.error_sentinel') ||
+      '; use errors.Is to match wrapped errors',
+    json_object('sentinel', json_extract(b.properties, '$.error_sentinel'), 'operator', b.name,
+      'function_id', b.parent_function)
+  FROM nodes b
+  WHERE b.kind = 'binary_expr' AND json_extract(b.properties, '$.error_sentinel') IS NOT NULL;
+
+-- Response bodies never closed: resp from http.Get/Post/Head/PostForm or
+-- Client.Do whose fields are read, with no resp.Body.Close() under a defer.
+-- A response returned or passed to another call may be closed there.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH resp AS (
+    SELECT c.id AS call_id, c.name AS call_name, c.file, c.line, c.parent_function AS fn_id, d.id AS var_id, d.name AS var_name
+    FROM nodes c
+    JOIN nodes d ON d.parent_function = c.parent_function AND d.file = c.file AND d.line = c.line
+      AND d.kind = 'local' AND d.type_info = '*net/http.Response'
+    WHERE c.kind = 'call' AND c.type_info LIKE 'func(%) (%*net/http.Response, %error)'
+      AND (c.name LIKE '%.Get' OR c.name LIKE '%.Post' OR c.name LIKE '%.Head'
+           OR c.name LIKE '%.PostForm' OR c.name LIKE '%.Do')
+  ),
+  uses AS (
+    SELECT r.var_id, i.id AS ident_id, e_ast.source AS parent_id
+    FROM resp r
+    JOIN edges e_ref ON e_ref.target = r.var_id AND e_ref.kind = 'ref'
+    JOIN nodes i ON i.id = e_ref.source AND i.kind = 'identifier'
+    JOIN edges e_ast ON e_ast.target = i.id AND e_ast.kind = 'ast'
+  )
+  SELECT 'response_body_not_closed', 'warning', r.call_id, r.file, r.line,
+    r.var_name || ' from ' || r.call_name || ' is used but ' || r.var_name || '.Body is never closed; add defer ' ||
+      r.var_name || '.Body.Close()',
+    json_object('function_id', r.fn_id, 'variable', r.var_name, 'call', r.call_name)
+  FROM resp r
+  WHERE EXISTS (
+      SELECT 1 FROM uses u JOIN nodes s ON s.id = u.parent_id
+      WHERE u.var_id = r.var_id AND s.kind = 'selector'
+        AND json_extract(s.properties, '$.selection_kind') = 'field_val'
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM nodes df
+      JOIN nodes cl ON cl.kind = 'call' AND cl.file = df.file
+        AND cl.line BETWEEN df.line AND COALESCE(df.end_line, df.line)
+        AND json_extract(cl.properties, '$.code') = r.var_name || '.Body.Close()'
+      WHERE df.kind = 'defer' AND df.parent_function = r.fn_id
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM uses u JOIN edges e
+        ON (e.source = u.ident_id AND e.kind = 'returns') OR (e.target = u.ident_id AND e.kind = 'argument')
+      WHERE u.var_id = r.var_id
+    );
+
+-- Tickers and timers never stopped: t from time.NewTicker/NewTimer with no
+-- t.Stop() anywhere in its function (deferred, direct, or in a closure). A
+-- ticker returned, passed to another call, or stored by an assignment or
+-- composite literal may be stopped elsewhere and is skipped.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH tick AS (
+    SELECT c.id AS call_id, c.name AS call_name, c.file, c.line, c.parent_function AS fn_id,
+      d.id AS var_id, d.name AS var_name,
+      CASE c.name WHEN 'time.NewTicker' THEN 'ticker' ELSE 'timer' END AS what
+    FROM nodes c
+    JOIN nodes d ON d.parent_function = c.parent_function AND d.file = c.file AND d.line = c.line
+      AND d.kind = 'local' AND d.type_info IN ('*time.Ticker', '*time.Timer')
+    WHERE c.kind = 'call' AND c.name IN ('time.NewTicker', 'time.NewTimer')
+  ),
+  uses AS (
+    SELECT t.var_id, i.id AS ident_id, e_ast.source AS parent_id
+    FROM tick t
+    JOIN edges e_ref ON e_ref.target = t.var_id AND e_ref.kind = 'ref'
+    JOIN nodes i ON i.id = e_ref.source AND i.kind = 'identifier'
+    JOIN edges e_ast ON e_ast.target = i.id AND e_ast.kind = 'ast'
+  )
+  SELECT 'ticker_not_stopped', 'warning', t.call_id, t.file, t.line,
+    t.what || ' ' || t.var_name || ' from ' || t.call_name || ' is never stopped; add defer ' || t.var_name || '.Stop()',
+    json_object('function_id', t.fn_id, 'variable', t.var_name, 'call', t.call_name)
+  FROM tick t
+  JOIN nodes fn ON fn.id = t.fn_id
+  WHERE NOT EXISTS (
+      SELECT 1 FROM nodes cl
+      WHERE cl.kind = 'call' AND cl.file = fn.file
+        AND cl.line BETWEEN fn.line AND COALESCE(fn.end_line, fn.line)
+        AND json_extract(cl.properties, '$.code') = t.var_name || '.Stop()'
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM uses u JOIN edges e
+        ON (e.source = u.ident_id AND e.kind = 'returns') OR (e.target = u.ident_id AND e.kind = 'argument')
+      WHERE u.var_id = t.var_id
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM uses u JOIN nodes p ON p.id = u.parent_id
+      WHERE u.var_id = t.var_id AND p.kind IN ('assign', 'key_value_expr', 'composite_lit')
+    );
+
+-- HTTP requests without a timeout: http.Get/Head/Post/PostForm and
+-- http.DefaultClient calls use a client with no Timeout, as does an
+-- http.Client literal with no Timeout key; a stalled server hangs the caller.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'http_client_no_timeout', 'warning', c.id, c.file, c.line,
+    c.name || ' uses http.DefaultClient, which has no timeout; use an http.Client with Timeout set',
+    json_object('function_id', c.parent_function, 'call', c.name)
+  FROM nodes c
+  WHERE c.kind = 'call'
+    AND (c.name IN ('http.Get', 'http.Head', 'http.Post', 'http.PostForm') OR c.name LIKE 'http.DefaultClient.%');
+
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'http_client_no_timeout', 'warning', cl.id, cl.file, cl.line,
+    'http.Client literal without a Timeout; requests through it can hang forever',
+    json_object('function_id', cl.parent_function, 'type', cl.type_info)
+  FROM nodes cl
+  WHERE cl.kind = 'composite_lit' AND cl.type_info = 'net/http.Client'
+    AND NOT EXISTS (
+      SELECT 1 FROM edges a
+      JOIN nodes kv ON kv.id = a.target AND kv.kind = 'key_value_expr'
+      JOIN edges ak ON ak.source = kv.id AND ak.kind = 'ast'
+      JOIN nodes k ON k.id = ak.target AND k.kind = 'identifier' AND k.name = 'Timeout'
+      WHERE a.source = cl.id AND a.kind = 'ast'
+    );
+
+-- Goroutines capturing a loop variable: before Go 1.22 every iteration shares
+-- one variable, so the goroutine may see a later value. The loop variable is
+-- declared on the for line; the file's go_version comes from go.mod.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT DISTINCT 'goroutine_captures_loop_var', 'warning', g.id, g.file, g.line,
+    'goroutine captures loop variable ' || v.name || ', shared by all iterations before Go 1.22; pass it as an argument',
+    json_object('function_id', g.parent_function, 'variable', v.name, 'loop_id', l.id,
+      'go_version', json_extract(f.properties, '$.go_version'))
+  FROM nodes g
+  JOIN edges sp ON sp.source = g.id AND sp.kind = 'spawn'
+  JOIN edges c ON c.source = sp.target AND c.kind = 'capture'
+  JOIN nodes v ON v.id = c.target AND v.parent_function = g.parent_function
+  JOIN nodes l ON l.kind = 'for' AND l.parent_function = g.parent_function AND l.file = g.file
+    AND l.line = v.line AND g.line BETWEEN l.line AND l.end_line
+  JOIN nodes f ON f.kind = 'file' AND f.file = g.file
+  WHERE g.kind = 'go'
+    AND json_extract(f.properties, '$.go_version') LIKE 'go1.%'
+    AND CAST(substr(json_extract(f.properties, '$.go_version'), 5) AS INTEGER) < 22;
+
+-- Deferred close in loop: a deferred Close/Unlock-like call inside a loop
+-- runs only when the function returns, so every iteration's file, lock or
+-- transaction stays held until then. The defer's own call is matched by
+-- method name; a defer in a func literal called per iteration (the fix) has
+-- that literal as parent_function and is not inside the loop. Reported
+-- once per defer, with its innermost loop.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'deferred_close_in_loop', 'warning', d.id, d.file, d.line,
+    'deferred ' || c.name || '() in a loop in ' || fn.name ||
+      ' runs only when ' || fn.name || ' returns, holding every iteration''s resource until then; release it in the loop body or move the body into a function',
+    json_object('function_id', fn.id, 'loop_id', l.id, 'call', c.name, 'package', fn.package)
+  FROM nodes d
+  JOIN edges e ON e.source = d.id AND e.kind = 'ast'
+  JOIN nodes c ON c.id = e.target AND c.kind = 'call'
+  JOIN nodes l ON l.kind = 'for' AND l.parent_function = d.parent_function AND l.file = d.file
+    AND d.line BETWEEN l.line AND l.end_line
+  JOIN nodes fn ON fn.id = d.parent_function
+  WHERE d.kind = 'defer'
+    AND (c.name GLOB '*.Close' OR c.name GLOB '*.Unlock' OR c.name GLOB '*.RUnlock'
+      OR c.name GLOB '*.Release' OR c.name GLOB '*.Rollback' OR c.name GLOB '*.Stop')
+    AND l.line = (
+      SELECT MAX(o.line) FROM nodes o
+      WHERE o.kind = 'for' AND o.parent_function = d.parent_function AND o.file = d.file
+        AND d.line BETWEEN o.line AND o.end_line
+    );
+
+-- Query in loop: a remote call (remote_call node property: a database query
+-- or HTTP request) in a loop body pays a round trip per iteration, the N+1
+-- pattern; batch it into one query or request. The call's ast parent chain
+-- is followed up to its function; a for node reached from its body block is
+-- an enclosing loop, while calls in the loop header (range ... db.Query(...))
+-- reach it from elsewhere and run once. Reported once per call, with its
+-- innermost loop.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH RECURSIVE ancestors(call_id, remote, id, child_id, depth) AS (
+    SELECT c.id, rc.value, c.id, NULL, 0
+    FROM node_properties rc
+    JOIN nodes c ON c.id = rc.node_id
+    WHERE rc.key = 'remote_call'
+    UNION ALL
+    SELECT a.call_id, a.remote, e.source, a.id, a.depth + 1
+    FROM ancestors a
+    JOIN edges e ON e.target = a.id AND e.kind = 'ast'
+    JOIN nodes p ON p.id = e.source
+    WHERE p.kind != 'function'
+  ),
+  loop_calls AS (
+    SELECT a.call_id, a.id AS loop_id, a.remote, a.depth
+    FROM ancestors a
+    JOIN nodes l ON l.id = a.id AND l.kind = 'for'
+    JOIN nodes b ON b.id = a.child_id AND b.kind = 'block'
+  )
+  SELECT 'query_in_loop', 'info', c.id, c.file, c.line,
+    CASE lc.remote WHEN 'db' THEN 'database query ' ELSE 'HTTP request ' END || c.name || ' in a loop in ' || fn.name ||
+      ' runs once per iteration (N+1); batch it outside the loop',
+    json_object('function_id', fn.id, 'loop_id', lc.loop_id, 'call', c.name, 'remote_call', lc.remote, 'package', fn.package)
+  FROM loop_calls lc
+  JOIN nodes c ON c.id = lc.call_id
+  JOIN nodes fn ON fn.id = c.parent_function
+  WHERE lc.depth = (SELECT MIN(o.depth) FROM loop_calls o WHERE o.call_id = lc.call_id);
+
+-- Lock without unlock: mu.Lock() with no matching mu.Unlock() in the same
+-- function, called directly, deferred, or inside a closure it defines.
+-- Receivers are matched on the call text; helpers whose name contains Lock
+-- or starts with lock (lockAll, withLock, RLockShard), which lock on behalf
+-- of their caller, are skipped. Block and clock don't count.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH locks AS (
+    SELECT c.id, c.file, c.line, c.parent_function AS fn_id, json_extract(c.properties, '$.code') AS code,
+      json_extract(c.properties, '$.sync_kind') AS kind,
+      CASE json_extract(c.properties, '$.sync_kind')
+        WHEN 'rwmutex_rlock' THEN 'rwmutex_runlock'
+        WHEN 'rwmutex_lock' THEN 'rwmutex_unlock'
+        ELSE 'mutex_unlock' END AS unlock_kind
+    FROM nodes c
+    WHERE c.kind = 'call'
+      AND json_extract(c.properties, '$.sync_kind') IN ('mutex_lock', 'rwmutex_lock', 'rwmutex_rlock')
+      AND json_extract(c.properties, '$.code') LIKE '%Lock()'
+  ),
+  unlocks AS (
+    SELECT c.parent_function AS fn_id, json_extract(c.properties, '$.sync_kind') AS kind,
+      json_extract(c.properties, '$.code') AS code
+    FROM nodes c
+    WHERE c.kind = 'call'
+      AND json_extract(c.properties, '$.sync_kind') IN ('mutex_unlock', 'rwmutex_unlock', 'rwmutex_runlock')
+    UNION ALL
+    SELECT f.parent_function, json_extract(c.properties, '$.sync_kind'), json_extract(c.properties, '$.code')
+    FROM nodes c
+    JOIN nodes f ON f.id = c.parent_function AND f.kind = 'function' AND f.parent_function IS NOT NULL
+    WHERE c.kind = 'call'
+      AND json_extract(c.properties, '$.sync_kind') IN ('mutex_unlock', 'rwmutex_unlock', 'rwmutex_runlock')
+  )
+  SELECT 'lock_without_unlock', 'warning', l.id, l.file, l.line,
+    l.code || ' in ' || fn.name || ' has no matching ' ||
+      replace(replace(l.code, 'RLock()', 'RUnlock()'), '.Lock()', '.Unlock()') || ' (direct or deferred)',
+    json_object('function_id', l.fn_id, 'sync_kind', l.kind, 'call', l.code)
+  FROM locks l
+  JOIN nodes fn ON fn.id = l.fn_id
+  WHERE fn.name NOT GLOB '*Lock*' AND fn.name NOT GLOB 'lock*' AND fn.name NOT GLOB '*.lock*'
+    AND NOT EXISTS (
+      SELECT 1 FROM unlocks u
+      WHERE u.fn_id = l.fn_id AND u.kind = l.unlock_kind
+        AND u.code = replace(replace(l.code, 'RLock()', 'RUnlock()'), '.Lock()', '.Unlock()')
+    );
+
+-- Lock order inversion: a cycle in the lock-order graph, where an edge A -> B
+-- means some function locks B while holding A (A was locked earlier and not
+-- unlocked in between; a deferred unlock holds it to the end). Functions
+-- following the cycle's edges can each hold the lock the next waits for. A
+-- lock is identified by the declaration its receiver refers to (the mu field
+-- of T, a package-level mutex); positions order the calls, so a lock released
+-- on one branch counts as released on all. Cycles of up to six locks are
+-- found, reported once from their smallest lock id, and need at least two
+-- functions: a single function taking two locks in both orders is another
+-- finding's business.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH RECURSIVE lock_calls AS (
+    SELECT c.parent_function AS fn_id, c.line, c.col, r.target AS lock_id,
+      json_extract(c.properties, '$.sync_kind') IN ('mutex_lock', 'rwmutex_lock') AS acquire
+    FROM nodes c
+    JOIN edges rv ON rv.source = c.id AND rv.kind = 'receiver'
+    JOIN edges r ON r.source = rv.target AND r.kind = 'ref'
+    WHERE c.kind = 'call'
+      AND json_extract(c.properties, '$.sync_kind') IN ('mutex_lock', 'rwmutex_lock', 'mutex_unlock', 'rwmutex_unlock')
+      AND NOT EXISTS (SELECT 1 FROM edges d JOIN nodes dn ON dn.id = d.source AND dn.kind = 'defer'
+                      WHERE d.target = c.id AND d.kind = 'ast')
+  ),
+  orders AS (
+    SELECT a.lock_id AS first_id, b.lock_id AS second_id,
+      MIN(a.fn_id) AS fn_id, COUNT(DISTINCT a.fn_id) AS fns
+    FROM lock_calls a
+    JOIN lock_calls b ON b.fn_id = a.fn_id AND b.acquire AND b.lock_id != a.lock_id
+      AND (b.line > a.line OR (b.line = a.line AND b.col > a.col))
+    WHERE a.acquire
+      AND NOT EXISTS (
+        SELECT 1 FROM lock_calls u
+        WHERE u.fn_id = a.fn_id AND NOT u.acquire AND u.lock_id = a.lock_id
+          AND (u.line > a.line OR (u.line = a.line AND u.col > a.col))
+          AND (u.line < b.line OR (u.line = b.line AND u.col < b.col))
+      )
+    GROUP BY a.lock_id, b.lock_id
+  ),
+  walk (start_id, last_id, locks, fn_ids, first_fn, shared, depth) AS (
+    SELECT first_id, second_id, json_array(first_id, second_id), json_array(fn_id), fn_id, fns > 1, 1
+    FROM orders
+    WHERE second_id > first_id
+    UNION ALL
+    SELECT w.start_id, o.second_id, json_insert(w.locks, '$[#]', o.second_id),
+      json_insert(w.fn_ids, '$[#]', o.fn_id), w.first_fn,
+      w.shared OR o.fns > 1 OR o.fn_id != w.first_fn, w.depth + 1
+    FROM walk w
+    JOIN orders o ON o.first_id = w.last_id
+    WHERE w.last_id != w.start_id AND w.depth < 6
+      AND o.second_id >= w.start_id
+      AND (o.second_id = w.start_id
+        OR NOT EXISTS (SELECT 1 FROM json_each(w.locks) l WHERE l.value = o.second_id))
+  ),
+  cycles AS (
+    SELECT locks, fn_ids FROM walk
+    WHERE last_id = start_id AND shared
+  ),
+  lock_names AS (
+    SELECT d.id, COALESCE(t.name || '.', '') || d.name AS name
+    FROM nodes d
+    LEFT JOIN edges e ON e.target = d.id AND e.kind = 'ast' AND d.kind = 'field'
+    LEFT JOIN nodes t ON t.id = e.source AND t.kind = 'type_decl'
+    WHERE d.id IN (SELECT lock_id FROM lock_calls)
+  )
+  SELECT 'lock_order_inversion', 'warning', f1.id, f1.file, f1.line,
+    'locks taken in a cycle (' || (SELECT group_concat(n.name, ' -> ') FROM (
+        SELECT ln.name FROM json_each(c.locks) l JOIN lock_names ln ON ln.id = l.value ORDER BY l.key) n) ||
+      ') by ' || (SELECT replace(group_concat(DISTINCT fn.name), ',', ', ') FROM json_each(c.fn_ids) l JOIN nodes fn ON fn.id = l.value) ||
+      '; each can hold the lock the next waits for, so together they can deadlock',
+    json_object('function_id', f1.id, 'other_function_id', f2.id,
+      'first_lock', la.name, 'second_lock', lb.name,
+      'first_lock_id', json_extract(c.locks, '$[0]'), 'second_lock_id', json_extract(c.locks, '$[1]'),
+      'cycle', (SELECT json_group_array(n.name) FROM (
+        SELECT ln.name FROM json_each(c.locks) l JOIN lock_names ln ON ln.id = l.value ORDER BY l.key) n),
+      'function_ids', json(c.fn_ids))
+  FROM cycles c
+  JOIN nodes f1 ON f1.id = json_extract(c.fn_ids, '$[0]')
+  JOIN nodes f2 ON f2.id = json_extract(c.fn_ids, '$[1]')
+  JOIN lock_names la ON la.id = json_extract(c.locks, '$[0]')
+  JOIN lock_names lb ON lb.id = json_extract(c.locks, '$[1]');
+
+-- Lost append: append's result is the extended slice. _ = append(s, x)
+-- throws the new elements away; t = append(s, x) followed by a read of s
+-- leaves that read without them, unless s is assigned in between (s = t is
+-- the fix). Only an identifier s is tracked past the call, in source order.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH appends AS (
+    SELECT c.id, c.file, c.line, c.end_line, c.end_col, c.parent_function,
+      COALESCE(json_extract(c.properties, '$.code'), 'append') AS code,
+      json_extract(c.properties, '$.assigned_to') AS target,
+      b.name AS base, r.target AS base_decl
+    FROM nodes c
+    LEFT JOIN edges ae ON ae.source = c.id AND ae.kind = 'argument' AND json_extract(ae.properties, '$.index') = 0
+    LEFT JOIN nodes b ON b.id = ae.target AND b.kind = 'identifier'
+    LEFT JOIN edges r ON r.source = b.id AND r.kind = 'ref'
+    WHERE c.kind = 'call' AND c.name = 'append'
+      AND json_extract(c.properties, '$.call_kind') = 'builtin'
+      AND json_extract(c.properties, '$.assigned_to') IS NOT NULL
+  )
+  SELECT 'lost_append', 'warning', a.id, a.file, a.line,
+    CASE WHEN a.target = '_'
+      THEN 'result of ' || a.code || ' is discarded; assign it back (s = append(s, ...))'
+      ELSE 'result of ' || a.code || ' goes to ' || a.target || ' but ' || a.base ||
+        ' is read again afterwards without the new elements; assign it back (' || a.base || ' = ' || a.target || ')'
+    END,
+    json_object('function_id', a.parent_function, 'call', a.code, 'assigned_to', a.target)
+  FROM appends a
+  WHERE a.target = '_'
+    OR (a.target != a.base AND EXISTS (
+      SELECT 1 FROM edges r
+      JOIN nodes u ON u.id = r.source AND u.kind = 'identifier'
+      WHERE r.target = a.base_decl AND r.kind = 'ref'
+        AND u.parent_function = a.parent_function
+        AND json_extract(u.properties, '$.write') IS NULL
+        AND (u.line, u.col) >= (a.end_line, a.end_col)
+        AND NOT EXISTS (
+          SELECT 1 FROM edges wr
+          JOIN nodes w ON w.id = wr.source AND w.kind = 'identifier'
+          WHERE wr.target = a.base_decl AND wr.kind = 'ref'
+            AND w.parent_function = a.parent_function
+            AND json_extract(w.properties, '$.write') = 1
+            AND (w.line, w.col) >= (a.end_line, a.end_col) AND (w.line, w.col) < (u.line, u.col)
+        )
+    ));
+
+-- Map range order dependence: a range over a map appends to a slice declared
+-- outside the loop, and the function returns that slice without sorting it
+-- (sort.* or slices.Sort*), so callers see a randomized order.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH map_appends AS (
+    SELECT DISTINCT f.id AS for_id, f.file, f.line, f.package, f.parent_function AS fn_id, ref.target AS slice_id
+    FROM nodes f
+    JOIN nodes c ON c.kind = 'call' AND c.name = 'append' AND c.parent_function = f.parent_function
+      AND c.file = f.file AND c.line BETWEEN f.line AND f.end_line
+    JOIN edges a ON a.source = c.id AND a.kind = 'argument'
+      AND CAST(json_extract(a.properties, '$.index') AS INTEGER) = 0
+    JOIN edges ref ON ref.source = a.target AND ref.kind = 'ref'
+    JOIN nodes decl ON decl.id = ref.target AND decl.kind IN ('local', 'parameter')
+      AND NOT (decl.file = f.file AND decl.line BETWEEN f.line AND f.end_line)
+    WHERE f.kind = 'for' AND json_extract(f.properties, '$.range_over') = 'map'
+  )
+  SELECT 'map_range_order_dependence', 'info', ma.for_id, ma.file, ma.line,
+    fn.name || ' returns ' || decl.name || ', appended to in map iteration order, without sorting it',
+    json_object('function_id', ma.fn_id, 'slice_id', ma.slice_id, 'package', ma.package)
+  FROM map_appends ma
+  JOIN nodes fn ON fn.id = ma.fn_id
+  JOIN nodes decl ON decl.id = ma.slice_id
+  WHERE EXISTS (
+      SELECT 1 FROM nodes r
+      JOIN edges ra ON ra.source = r.id AND ra.kind = 'ast'
+      JOIN edges rr ON rr.source = ra.target AND rr.kind = 'ref' AND rr.target = ma.slice_id
+      WHERE r.kind = 'return' AND r.parent_function = ma.fn_id
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM nodes s
+      JOIN edges sa ON sa.source = s.id AND sa.kind = 'argument'
+      JOIN edges sr ON sr.source = sa.target AND sr.kind = 'ref' AND sr.target = ma.slice_id
+      WHERE s.kind = 'call' AND s.parent_function = ma.fn_id
+        AND (s.name LIKE 'sort.%' OR s.name LIKE 'slices.Sort%')
+    );
+
+-- Goroutine in init: a go statement in an init function, or in a function
+-- literal run by a package-level var initializer, starts before main can
+-- configure flags or logging. Literals climb to the function declaring them;
+-- one with no enclosing function belongs to a package-level initializer, and
+-- runs there only when the initializer calls it (var x = func() {...}()); a
+-- literal merely assigned (var h = func() {...}) or passed as an argument
+-- (var x = run(func() {...})) runs when its holder calls it.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH RECURSIVE owner(go_id, fn_id) AS (
+    SELECT g.id, g.parent_function FROM nodes g WHERE g.kind = 'go'
+    UNION ALL
+    SELECT o.go_id, lit.parent_function
+    FROM owner o JOIN nodes lit ON lit.id = o.fn_id
+    WHERE lit.kind = 'function' AND lit.name = 'func literal' AND lit.parent_function IS NOT NULL
+  ),
+  roots AS (
+    SELECT o.go_id, fn.id AS fn_id, fn.name
+    FROM owner o JOIN nodes fn ON fn.id = o.fn_id AND fn.kind = 'function'
+    WHERE (fn.name = 'init' AND json_extract(fn.properties, '$.receiver') IS NULL)
+      OR (fn.name = 'func literal' AND fn.parent_function IS NULL
+        AND EXISTS (SELECT 1 FROM edges e JOIN nodes c ON c.id = e.source AND c.kind = 'call'
+                    WHERE e.target = fn.id AND e.kind = 'ast'
+                      AND NOT EXISTS (SELECT 1 FROM edges a
+                                      WHERE a.source = c.id AND a.target = fn.id AND a.kind = 'argument')))
+  )
+  SELECT 'goroutine_in_init', 'warning', g.id, g.file, g.line,
+    CASE WHEN r.name = 'init' THEN 'goroutine started in init()'
+      ELSE 'goroutine started by a package-level var initializer' END ||
+      ' runs before main can configure flags or logging',
+    json_object('function_id', r.fn_id, 'package', g.package)
+  FROM roots r
+  JOIN nodes g ON g.id = r.go_id;
+
+-- Goroutine panic without recover: an unrecovered panic in any goroutine
+-- crashes the process. The goroutine body is the spawned closure or named
+-- function (SSA puts go/defer call sites on the statement); it can panic
+-- when it, or a function it calls (one level), calls panic() directly. Only
+-- a deferred call counts as recovering, to a closure (defer func() {
+-- recover() }()) or a function (defer handleCrash()) that calls recover()
+-- itself: anywhere else recover() returns nil and stops nothing.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH bodies AS (
+    SELECT g.id AS go_id, g.file, g.line, g.parent_function AS fn_id, body.id AS body_id
+    FROM nodes g
+    JOIN edges sp ON sp.source = g.id AND sp.kind = 'spawn'
+    JOIN nodes body ON body.id = sp.target AND body.kind = 'function'
+    WHERE g.kind = 'go'
+    UNION
+    SELECT g.id, g.file, g.line, g.parent_function, body.id
+    FROM nodes g
+    JOIN edges cs ON cs.source = g.id AND cs.kind = 'call_site'
+    JOIN nodes body ON body.id = cs.target AND body.kind = 'function' AND body.file IS NOT NULL
+    WHERE g.kind = 'go'
+  ),
+  panickers AS (
+    SELECT DISTINCT parent_function AS fn_id FROM nodes WHERE kind = 'call' AND name = 'panic'
+  ),
+  recoverers AS (
+    SELECT DISTINCT parent_function AS fn_id FROM nodes WHERE kind = 'call' AND name = 'recover'
+  ),
+  deferred_recovers AS (
+    SELECT DISTINCT d.parent_function AS fn_id
+    FROM nodes d
+    JOIN edges cs ON cs.source = d.id AND cs.kind = 'call_site'
+    JOIN recoverers rc ON rc.fn_id = cs.target
+    WHERE d.kind = 'defer'
+  ),
+  callees AS (
+    SELECT DISTINCT c.parent_function AS fn_id, cs.target AS callee_id
+    FROM nodes c
+    JOIN edges cs ON cs.source = c.id AND cs.kind = 'call_site'
+    WHERE c.kind IN ('call', 'defer')
+  ),
+  risky AS (
+    SELECT b.go_id, b.file, b.line, b.fn_id, b.body_id, b.body_id AS panic_fn
+    FROM bodies b JOIN panickers p ON p.fn_id = b.body_id
+    UNION
+    SELECT b.go_id, b.file, b.line, b.fn_id, b.body_id, ce.callee_id
+    FROM bodies b
+    JOIN callees ce ON ce.fn_id = b.body_id
+    JOIN panickers p ON p.fn_id = ce.callee_id
+  )
+  SELECT 'goroutine_panic_no_recover', 'error', r.go_id, r.file, r.line,
+    'goroutine started in ' || fn.name || ' can panic (' || pf.name || ' calls panic) with no deferred recover; the panic crashes the process',
+    json_object('function_id', r.fn_id, 'goroutine_body_id', r.body_id, 'panic_function_id', r.panic_fn)
+  FROM risky r
+  JOIN nodes fn ON fn.id = r.fn_id
+  JOIN nodes pf ON pf.id = r.panic_fn
+  WHERE r.body_id NOT IN (SELECT fn_id FROM deferred_recovers)
+  GROUP BY r.go_id;
+
+-- Panic/recover as control flow: a recover() whose result is asserted to a
+-- package-local type that another function of the same package panics with,
+-- as parsers do to unwind from deep recursion. The owner of a recover in a
+-- deferred closure is the function declaring the closure.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH panics AS (
+    SELECT p.package, p.parent_function AS fn_id, json_extract(p.properties, '$.panic_type') AS typ
+    FROM nodes p
+    WHERE json_extract(p.properties, '$.panic_type') IS NOT NULL
+  ),
+  recovers AS (
+    SELECT r.id, r.file, r.line, r.package, t.value AS typ,
+      COALESCE(cl.parent_function, cl.id) AS owner_id, cl.id AS closure_id
+    FROM nodes r
+    JOIN json_each(r.properties, '$.recovers_type') t
+    JOIN nodes cl ON cl.id = r.parent_function
+    WHERE json_extract(r.properties, '$.recovers_type') IS NOT NULL
+  )
+  SELECT 'panic_recover_control_flow', 'info', rc.id, rc.file, rc.line,
+    owner.name || ' recovers ' || rc.typ || ' panicked by ' || COUNT(DISTINCT p.fn_id) || ' other function(s) in package ' || rc.package || '; panic-based control flow should be intentional',
+    json_object('type', rc.typ, 'function_id', rc.owner_id, 'panic_functions', COUNT(DISTINCT p.fn_id), 'package', rc.package)
+  FROM recovers rc
+  JOIN panics p ON p.package = rc.package AND p.typ = rc.typ
+    AND p.fn_id NOT IN (rc.owner_id, rc.closure_id)
+  JOIN nodes owner ON owner.id = rc.owner_id
+  GROUP BY rc.id, rc.typ;
+
+-- Unchecked type assertion: single-value x.(T) panics when x holds another
+-- type; the comma-ok form (or a type switch) handles the mismatch.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'unchecked_type_assertion', 'warning', ta.id, ta.file, ta.line,
+    'type assertion in ' || COALESCE(fn.name, 'package scope') || ' panics on mismatch; use the v, ok := x.(T) form',
+    json_object('function_id', ta.parent_function, 'package', ta.package)
+  FROM nodes ta
+  LEFT JOIN nodes fn ON fn.id = ta.parent_function
+  WHERE ta.kind = 'type_assert_expr' AND json_extract(ta.properties, '$.unchecked') = 1;
+
+-- Constant index out of range: a constant index (folded by the type checker)
+-- into a slice whose only reaching definition, over a dfg edge, is a slice
+-- literal too short for it. The compiler rejects this for arrays but not for
+-- slices, where it panics at run time. A slice several definitions reach
+-- (appended to on a branch) goes through an SSA phi and is left alone.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'index_out_of_range_const', 'error', ix.id, ix.file, ix.line,
+    'constant index ' || json_extract(ix.properties, '$.const_index') || ' out of range for slice literal of length ' ||
+      json_extract(lit.properties, '$.slice_len') || ' in ' || COALESCE(fn.name, 'package scope'),
+    json_object('index', json_extract(ix.properties, '$.const_index'), 'length', json_extract(lit.properties, '$.slice_len'),
+      'literal_id', lit.id, 'function_id', ix.parent_function, 'package', ix.package)
+  FROM nodes ix
+  JOIN edges d ON d.target = ix.id AND d.kind = 'dfg'
+  JOIN nodes lit ON lit.id = d.source AND lit.kind = 'composite_lit'
+  LEFT JOIN nodes fn ON fn.id = ix.parent_function
+  WHERE ix.kind = 'index_expr' AND json_extract(ix.properties, '$.const_index') IS NOT NULL
+    AND json_extract(ix.properties, '$.const_index') >= json_extract(lit.properties, '$.slice_len');
+
+-- Invalid make size: an explicit zero capacity. make([]T, 0, 0) is just
+-- make([]T, 0), and any larger length panics. Constant negative sizes and a
+-- constant capacity below the length don't compile.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'invalid_make_size', 'warning', c.id, c.file, c.line,
+    'make in ' || COALESCE(fn.name, 'package scope') || ' has an explicit zero capacity',
+    json_object('cap', json_extract(c.properties, '$.make_cap'), 'function_id', c.parent_function, 'package', c.package)
+  FROM nodes c
+  LEFT JOIN nodes fn ON fn.id = c.parent_function
+  WHERE c.kind = 'call' AND c.name = 'make'
+    AND json_extract(c.properties, '$.make_cap') = 0;
+
+-- Redundant nil check: an if whose condition (or an operand of its && / ||
+-- condition) compares x with nil, where x's reaching definition, over a dfg
+-- edge, is a builtin make/new or a composite literal, none of which is ever
+-- nil. A value several definitions reach goes through an SSA phi, which has
+-- no position and so no dfg edge, so such checks are left alone.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT DISTINCT 'redundant_nil_check', 'info', cmp.id, cmp.file, cmp.line,
+    'nil check in ' || COALESCE(fn.name, 'package scope') || ' is always ' ||
+      CASE cmp.name WHEN '!=' THEN 'true' ELSE 'false' END || ': the value comes from ' ||
+      CASE src.kind WHEN 'call' THEN src.name || '(), which' ELSE 'a composite literal, which' END || ' is never nil',
+    json_object('function_id', cmp.parent_function, 'if_id', i.id, 'source_id', src.id,
+      'operator', cmp.name, 'package', cmp.package)
+  FROM nodes i
+  JOIN edges ce ON ce.source = i.id AND ce.kind = 'condition'
+  JOIN nodes cond ON cond.id = ce.target
+  JOIN nodes cmp ON cmp.kind = 'binary_expr' AND json_extract(cmp.properties, '$.nil_check') = 1
+    AND (cmp.id = cond.id OR (cond.kind = 'binary_expr' AND cond.name IN ('&&', '||')
+      AND EXISTS (SELECT 1 FROM edges a WHERE a.source = cond.id AND a.target = cmp.id AND a.kind = 'ast')))
+  JOIN edges d ON d.target = cmp.id AND d.kind = 'dfg'
+  JOIN nodes src ON src.id = d.source
+  LEFT JOIN nodes fn ON fn.id = cmp.parent_function
+  WHERE i.kind = 'if'
+    AND ((src.kind = 'call' AND src.name IN ('make', 'new') AND json_extract(src.properties, '$.call_kind') = 'builtin')
+      OR src.kind = 'composite_lit');
+
+-- Unkeyed struct literal: a named struct type's literal with positional
+-- elements (no key_value_expr child) silently changes meaning, or stops
+-- compiling, when the struct's fields are reordered or added. A warning for
+-- types of another package, whose authors can change them without seeing the
+-- literal; two-field standard library types (dot-free import path, like
+-- image.Point) are left alone.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'unkeyed_struct_literal',
+    CASE WHEN json_extract(cl.properties, '$.type_package') IS NOT NULL THEN 'warning' ELSE 'info' END,
+    cl.id, cl.file, cl.line,
+    'literal of ' || COALESCE(NULLIF(cl.name, ''), cl.type_info) || ' (' || json_extract(cl.properties, '$.struct_fields') ||
+      ' fields) in ' || COALESCE(fn.name, 'package scope') || ' sets fields by position; name them (Field: value)',
+    json_object('type', cl.type_info, 'type_package', json_extract(cl.properties, '$.type_package'),
+      'struct_fields', json_extract(cl.properties, '$.struct_fields'),
+      'function_id', cl.parent_function, 'package', cl.package)
+  FROM nodes cl
+  LEFT JOIN nodes fn ON fn.id = cl.parent_function
+  WHERE cl.kind = 'composite_lit' AND json_extract(cl.properties, '$.unkeyed') = 1
+    AND NOT EXISTS (SELECT 1 FROM edges e JOIN nodes k ON k.id = e.target
+                    WHERE e.source = cl.id AND e.kind = 'ast' AND k.kind = 'key_value_expr')
+    AND NOT (COALESCE(json_extract(cl.properties, '$.type_package'), '.') NOT LIKE '%.%'
+      AND json_extract(cl.properties, '$.struct_fields') <= 2);
+
+-- Error string style: the message literal of errors.New/fmt.Errorf should
+-- start lowercase and carry no trailing punctuation, since it is usually
+-- wrapped into a longer message. Literal names keep their quotes and are cut
+-- at 50 characters, so only untruncated literals are checked for an ending;
+-- an initial capital followed by another capital (HTTP, ID) is an acronym.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'error_string_style', 'info', lit.id, lit.file, lit.line,
+    c.name || ' message ' || lit.name || CASE
+      WHEN substr(lit.name, 2, 1) GLOB '[A-Z]' AND substr(lit.name, 3, 1) GLOB '[a-z]'
+        THEN ' starts with a capital letter'
+      ELSE ' ends with punctuation' END || '; error strings should be lowercase without trailing punctuation',
+    json_object('call_id', c.id, 'function_id', c.parent_function, 'package', c.package)
+  FROM nodes c
+  JOIN edges e ON e.source = c.id AND e.kind = 'argument'
+    AND CAST(json_extract(e.properties, '$.index') AS INTEGER) = 0
+  JOIN nodes lit ON lit.id = e.target AND lit.kind = 'literal'
+    AND json_extract(lit.properties, '$.literal_kind') = 'STRING'
+  WHERE c.kind = 'call' AND c.name IN ('errors.New', 'fmt.Errorf')
+    AND ((substr(lit.name, 2, 1) GLOB '[A-Z]' AND substr(lit.name, 3, 1) GLOB '[a-z]')
+      OR (substr(lit.name, -2, 1) IN ('.', '!') AND substr(lit.name, -1) IN ('"', char(96))));
+
+-- TODO comments: one finding per package counting the comments classified
+-- todo (TODO, FIXME, XXX or HACK), anchored at the package node, or at the
+-- first such comment when the package has none.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'todo_comment', 'info', COALESCE(p.id, MIN(c.id)),
+    COALESCE(p.file, MIN(c.file)), COALESCE(p.line, MIN(c.line)),
+    c.package || ' has ' || COUNT(*) || ' TODO/FIXME comment(s)',
+    json_object('count', COUNT(*), 'package', c.package)
+  FROM nodes c
+  LEFT JOIN nodes p ON p.kind = 'package' AND p.package = c.package
+  WHERE c.kind = 'comment' AND json_extract(c.properties, '$.comment_classification') = 'todo'
+  GROUP BY c.package;
+
+-- Sleep in handler: time.Sleep parks the goroutine serving the request.
+-- The sleep may sit in the handler or in a function it reaches within three
+-- call edges (v_handler_reach). Reported once per sleep call, at the nearest
+-- handler.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH sleeps AS (
+    SELECT c.id AS call_id, c.file, c.line, r.handler_id, r.depth
+    FROM v_handler_reach r
+    JOIN nodes c ON c.parent_function = r.function_id AND c.kind = 'call' AND c.name = 'time.Sleep'
+  )
+  SELECT 'sleep_in_handler', 'info', s.call_id, s.file, s.line,
+    CASE WHEN MIN(s.depth) = 0
+      THEN 'time.Sleep in HTTP handler ' || h.name || ' blocks the request goroutine'
+      ELSE 'time.Sleep reachable from HTTP handler ' || h.name || ' (' || MIN(s.depth) || ' calls deep) blocks the request goroutine'
+    END,
+    json_object('handler_id', s.handler_id, 'depth', MIN(s.depth))
+  FROM sleeps s
+  JOIN nodes h ON h.id = s.handler_id
+  GROUP BY s.call_id;
+
+-- Blocking channel op: a send or receive outside a select blocks until the
+-- other side is ready, holding the request goroutine. Handlers and the
+-- functions they reach come from v_handler_reach, as for sleep_in_handler;
+-- receives from ctx.Done() and time.After are the cancellation or timeout
+-- themselves and are skipped. Warning when the function has no context to
+-- cancel by (no context parameter and no call yielding one, such as
+-- r.Context()).
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH ops AS (
+    SELECT o.id AS op_id, o.file, o.line, o.parent_function AS fn_id,
+      CASE o.kind WHEN 'send' THEN 'send' ELSE 'receive' END AS op,
+      r.handler_id, r.depth
+    FROM v_handler_reach r
+    JOIN nodes o ON o.parent_function = r.function_id
+    WHERE (o.kind = 'send' OR (o.kind = 'unary_expr' AND o.name = '<-'))
+      AND json_extract(o.properties, '$.in_select') IS NULL
+      AND NOT EXISTS (
+        SELECT 1 FROM edges e JOIN nodes c ON c.id = e.target
+        WHERE e.source = o.id AND e.kind = 'ast' AND c.kind = 'call'
+          AND (c.name LIKE '%.Done' OR c.name = 'time.After')
+      )
+  )
+  SELECT 'blocking_channel_op',
+    CASE WHEN json_extract(fn.properties, '$.has_context') = 1
+           OR EXISTS (
+             SELECT 1 FROM nodes c
+             WHERE c.parent_function = fn.id AND c.kind = 'call'
+               AND (c.type_info LIKE '%) context.Context' OR c.type_info LIKE '%context.CancelFunc)')
+           )
+      THEN 'info' ELSE 'warning' END,
+    o.op_id, o.file, o.line,
+    'channel ' || o.op || ' outside select in ' || fn.name ||
+      CASE WHEN MIN(o.depth) = 0
+        THEN ' (HTTP handler)'
+        ELSE ', reachable from HTTP handler ' || h.name || ' (' || MIN(o.depth) || ' calls deep),'
+      END || ' blocks the request goroutine until the other side is ready',
+    json_object('op', o.op, 'function_id', fn.id, 'handler_id', o.handler_id, 'depth', MIN(o.depth))
+  FROM ops o
+  JOIN nodes fn ON fn.id = o.fn_id
+  JOIN nodes h ON h.id = o.handler_id
+  GROUP BY o.op_id;
+
+-- any parameter: a parameter typed exactly any/interface{} erases the
+-- static type. Variadic ...any (Printf-style) is []any and never matches;
+-- error-returning reflective helpers (Unmarshal(data, v any) error and the
+-- like, which call into reflect) are skipped too.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'any_parameter', 'info', p.id, p.file, p.line,
+    'parameter ' || p.name || ' of ' || fn.name || ' is ' || p.type_info || '; a concrete type or type parameter keeps type safety',
+    json_object('function_id', fn.id, 'package', fn.package)
+  FROM nodes fn
+  JOIN edges e ON e.source = fn.id AND e.kind = 'ast'
+  JOIN nodes p ON p.id = e.target AND p.kind = 'parameter'
+  WHERE fn.kind = 'function'
+    AND p.type_info IN ('any', 'interface{}')
+    AND NOT (
+      (fn.type_info LIKE '%) error' OR fn.type_info LIKE '%, error)')
+      AND EXISTS (
+        SELECT 1 FROM nodes c
+        WHERE c.parent_function = fn.id AND c.kind = 'call' AND c.name LIKE 'reflect.%'
+      )
+    );
+
+-- Large value parameter: a struct over 128 bytes passed by value is copied
+-- on every call; on a function with 5+ callers a pointer avoids the copies.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'large_value_param', 'info', p.id, p.file, p.line,
+    'parameter ' || p.name || ' of ' || fn.name || ' copies a ' || json_extract(p.properties, '$.type_size') || '-byte ' || p.type_info || ' on each of its ' || m.fan_in || ' callers'' calls; pass a pointer',
+    json_object('function_id', fn.id, 'type_size', json_extract(p.properties, '$.type_size'), 'fan_in', m.fan_in)
+  FROM nodes fn
+  JOIN metrics m ON m.function_id = fn.id
+  JOIN edges e ON e.source = fn.id AND e.kind = 'ast'
+  JOIN nodes p ON p.id = e.target AND p.kind = 'parameter'
+  WHERE fn.kind = 'function' AND m.fan_in >= 5
+    AND json_extract(p.properties, '$.struct_value') = 1
+    AND json_extract(p.properties, '$.type_size') > 128;
+
+-- Dead switch case: the AST walk marks a case whose value an earlier case
+-- always matches first (a repeated constant, or a range inside an earlier
+-- range in a tagless switch).
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'duplicate_switch_case', 'warning', c.id, c.file, c.line,
+    CASE json_extract(c.properties, '$.dead_case')
+      WHEN 'duplicate' THEN 'case ' || json_extract(c.properties, '$.dead_value') || ' repeats the value of the case on line '
+      ELSE 'case ' || json_extract(c.properties, '$.dead_value') || ' is contained in the case on line '
+    END || json_extract(c.properties, '$.covered_by_line') || ' and can never be selected',
+    json_object('kind', json_extract(c.properties, '$.dead_case'),
+                'value', json_extract(c.properties, '$.dead_value'),
+                'covered_by_line', json_extract(c.properties, '$.covered_by_line'),
+                'function_id', c.parent_function, 'package', c.package)
+  FROM nodes c
+  WHERE c.kind = 'case' AND json_extract(c.properties, '$.dead_case') IS NOT NULL;
+
+-- Map with lock: a struct's map field sits beside a sync.Mutex/RWMutex field
+-- and a method of the struct locks and then touches the map. Advisory: for
+-- read-mostly maps with disjoint keys sync.Map avoids the lock contention.
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'map_with_lock', 'info', mf.id, mf.file, mf.line,
+    t.name || '.' || mf.name || ' is a map guarded by ' || t.name || '.' || mx.name || ' in ' ||
+      COUNT(DISTINCT hm.target) || ' method(s); consider sync.Map if it is read-heavy',
+    json_object('type_id', t.id, 'mutex_field', mx.name, 'mutex_type', mx.type_info,
+                'guarded_methods', COUNT(DISTINCT hm.target), 'package', t.package)
+  FROM nodes t
+  JOIN edges fm ON fm.source = t.id AND fm.kind = 'ast'
+  JOIN nodes mf ON mf.id = fm.target AND mf.kind = 'field' AND mf.type_info LIKE 'map[%'
+  JOIN edges fx ON fx.source = t.id AND fx.kind = 'ast'
+  JOIN nodes mx ON mx.id = fx.target AND mx.kind = 'field'
+    AND mx.type_info IN ('sync.Mutex', 'sync.RWMutex', '*sync.Mutex', '*sync.RWMutex')
+  JOIN edges hm ON hm.source = t.id AND hm.kind = 'has_method'
+  WHERE t.kind = 'type_decl' AND json_extract(t.properties, '$.type_kind') = 'struct'
+    AND EXISTS (
+      SELECT 1 FROM nodes l
+      WHERE l.parent_function = hm.target AND l.kind = 'call'
+        AND json_extract(l.properties, '$.sync_kind') IN ('mutex_lock', 'rwmutex_lock', 'rwmutex_rlock')
+    )
+    AND EXISTS (
+      SELECT 1 FROM edges r JOIN nodes s ON s.id = r.source
+      WHERE r.target = mf.id AND r.kind = 'ref'
+        AND s.kind = 'selector' AND s.parent_function = hm.target
+    )
+  GROUP BY mf.id, mx.id;
+
 -- Additional queries
 INSERT INTO queries (name, description, sql) VALUES
 ('package_cohesion',
@@ -2236,12 +3959,12 @@ INSERT INTO queries (name, description, sql) VALUES
          ))
   ORDER BY n.package, n.name');
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return err
 	}
 
 	// Count findings
-	var ctxCount, retCount, boolCount, panicCount int64
+	var ctxCount, retCount, boolCount, panicCount, terminateCount, unreachableCount, loopCtxCount, errEqCount, bodyCount, tickerCount, loopVarCount, deferLoopCount, loopQueryCount, lockCount, goPanicCount, appendCount, sleepCount, chanBlockCount, anyParamCount, largeParamCount, mapLockCount, bgCtxCount, deadCaseCount, httpTimeoutCount, panicFlowCount, lockOrderCount, mapOrderCount, initGoCount, uncheckedAssertCount, constIndexCount, makeSizeCount, nilCheckCount, unkeyedCount, errStyleCount, todoCount int64
 	for _, pair := range []struct {
 		cat  string
 		dest *int64
@@ -2250,6 +3973,37 @@ INSERT INTO queries (name, description, sql) VALUES
 		{"large_return", &retCount},
 		{"bool_params", &boolCount},
 		{"panic_call", &panicCount},
+		{"library_terminates_process", &terminateCount},
+		{"unreachable_code", &unreachableCount},
+		{"context_not_checked_in_loop", &loopCtxCount},
+		{"context_background_in_leaf", &bgCtxCount},
+		{"error_equality_comparison", &errEqCount},
+		{"response_body_not_closed", &bodyCount},
+		{"ticker_not_stopped", &tickerCount},
+		{"goroutine_captures_loop_var", &loopVarCount},
+		{"deferred_close_in_loop", &deferLoopCount},
+		{"query_in_loop", &loopQueryCount},
+		{"lock_without_unlock", &lockCount},
+		{"lock_order_inversion", &lockOrderCount},
+		{"map_range_order_dependence", &mapOrderCount},
+		{"goroutine_in_init", &initGoCount},
+		{"goroutine_panic_no_recover", &goPanicCount},
+		{"lost_append", &appendCount},
+		{"sleep_in_handler", &sleepCount},
+		{"blocking_channel_op", &chanBlockCount},
+		{"any_parameter", &anyParamCount},
+		{"large_value_param", &largeParamCount},
+		{"map_with_lock", &mapLockCount},
+		{"duplicate_switch_case", &deadCaseCount},
+		{"http_client_no_timeout", &httpTimeoutCount},
+		{"panic_recover_control_flow", &panicFlowCount},
+		{"unchecked_type_assertion", &uncheckedAssertCount},
+		{"index_out_of_range_const", &constIndexCount},
+		{"invalid_make_size", &makeSizeCount},
+		{"redundant_nil_check", &nilCheckCount},
+		{"unkeyed_struct_literal", &unkeyedCount},
+		{"error_string_style", &errStyleCount},
+		{"todo_comment", &todoCount},
 	} {
 		cat := pair.cat
 		_ = sqlitex.ExecuteTransient(conn,
@@ -2263,8 +4017,95 @@ INSERT INTO queries (name, description, sql) VALUES
 			})
 	}
 
-	prog.Log("Patterns: %d missing-ctx-first, %d large-return, %d bool-params, %d panic-calls, 3 views, 6 queries",
-		ctxCount, retCount, boolCount, panicCount)
+	prog.Log("Patterns: %d missing-ctx-first, %d large-return, %d bool-params, %d panic-calls, %d library-terminates, %d unreachable, %d unchecked-ctx loops, %d error ==, %d unclosed bodies, %d unstopped tickers, %d loop-var captures, %d deferred closes in loops, %d queries in loops, %d locks without unlock, %d lock order inversions, %d map-order-dependent results, %d goroutines in init, %d unrecovered goroutine panics, %d lost appends, %d sleeps in handlers, %d blocking channel ops, %d any params, %d large value params, %d locked maps, %d background contexts in ctx functions, %d dead switch cases, %d HTTP calls without timeout, %d panic-based control flows, %d unchecked type assertions, %d constant indexes out of range, %d invalid make sizes, %d redundant nil checks, %d unkeyed struct literals, %d error string style issues, %d packages with TODOs, 3 views, 6 queries",
+		ctxCount, retCount, boolCount, panicCount, terminateCount, unreachableCount, loopCtxCount, errEqCount, bodyCount, tickerCount, loopVarCount, deferLoopCount, loopQueryCount, lockCount, lockOrderCount, mapOrderCount, initGoCount, goPanicCount, appendCount, sleepCount, chanBlockCount, anyParamCount, largeParamCount, mapLockCount, bgCtxCount, deadCaseCount, httpTimeoutCount, panicFlowCount, uncheckedAssertCount, constIndexCount, makeSizeCount, nilCheckCount, unkeyedCount, errStyleCount, todoCount)
+	return nil
+}
+
+// createReceiverAnalysis checks pointer vs value receiver correctness using the
+// field_write edges and receiver_* properties emitted by the AST walk.
+//
+// value_receiver_mutation: a value-receiver method assigns to a field of its
+// receiver copy (not through a pointer/slice/map) and never hands the copy
+// back, so the write is lost when the method returns.
+//
+// pointer_receiver_could_be_value: a pointer-receiver method on a type where no
+// method writes receiver fields, takes a field address, calls a pointer method
+// on the receiver, or lets the receiver escape.
+func createReceiverAnalysis(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'value_receiver_mutation', 'warning', fn.id, s.file, s.line,
+    fn.name || ' assigns receiver field ' || json_extract(w.properties, '$.field') ||
+      ' on a value receiver (the write is lost when the method returns)',
+    json_object('field', json_extract(w.properties, '$.field'), 'field_id', w.target,
+                'statement_id', w.source,
+                'receiver', json_extract(fn.properties, '$.receiver'),
+                'package', fn.package)
+  FROM edges w
+  JOIN nodes s ON s.id = w.source
+  JOIN nodes fn ON fn.id = json_extract(w.properties, '$.method')
+  WHERE w.kind = 'field_write'
+    AND json_extract(w.properties, '$.via_receiver') = 1
+    AND json_extract(w.properties, '$.shared') IS NULL
+    AND json_extract(fn.properties, '$.receiver') NOT LIKE '*%'
+    AND json_extract(fn.properties, '$.receiver_escapes') IS NULL;
+
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH receiver_writes AS (
+    SELECT DISTINCT json_extract(properties, '$.method') AS method_id
+    FROM edges
+    WHERE kind = 'field_write' AND json_extract(properties, '$.via_receiver') = 1
+  ),
+  methods AS (
+    SELECT fn.id, fn.name, fn.file, fn.line, fn.package,
+      json_extract(fn.properties, '$.receiver') AS receiver,
+      LTRIM(json_extract(fn.properties, '$.receiver'), '*') AS recv_type,
+      CASE WHEN rw.method_id IS NOT NULL
+             OR json_extract(fn.properties, '$.receiver_escapes') IS NOT NULL
+             OR json_extract(fn.properties, '$.receiver_addressed') IS NOT NULL
+           THEN 1 ELSE 0 END AS needs_pointer
+    FROM nodes fn
+    LEFT JOIN receiver_writes rw ON rw.method_id = fn.id
+    WHERE fn.kind = 'function'
+      AND json_extract(fn.properties, '$.receiver') IS NOT NULL
+  )
+  SELECT 'pointer_receiver_could_be_value', 'info', m.id, m.file, m.line,
+    m.name || ' has a pointer receiver but no method on ' || m.recv_type ||
+      ' mutates or needs the identity of its receiver',
+    json_object('receiver', m.receiver, 'type', m.recv_type, 'package', m.package)
+  FROM methods m
+  WHERE m.receiver LIKE '*%'
+    AND NOT EXISTS (
+      SELECT 1 FROM methods o
+      WHERE o.package = m.package AND o.recv_type = m.recv_type AND o.needs_pointer = 1
+    );
+`
+	if err := execFindingsScript(conn, ddl); err != nil {
+		return fmt.Errorf("receiver analysis: %w", err)
+	}
+
+	var valueCount, pointerCount int64
+	for _, pair := range []struct {
+		cat  string
+		dest *int64
+	}{
+		{"value_receiver_mutation", &valueCount},
+		{"pointer_receiver_could_be_value", &pointerCount},
+	} {
+		_ = sqlitex.ExecuteTransient(conn,
+			`SELECT COUNT(*) FROM findings WHERE category = ?`,
+			&sqlitex.ExecOptions{
+				Args: []any{pair.cat},
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					*pair.dest = stmt.ColumnInt64(0)
+					return nil
+				},
+			})
+	}
+
+	prog.Log("Receivers: %d value-receiver mutations, %d pointer receivers could be values",
+		valueCount, pointerCount)
 	return nil
 }
 
@@ -2412,7 +4253,15 @@ INSERT INTO dashboard_overview (key, value) VALUES
 
 // createGraphIntelligence adds top-N tables, cross-package coupling analysis,
 // error propagation chains, and hotspot detection for the interview web app.
-func createGraphIntelligence(conn *sqlite.Conn, prog *Progress) error {
+//
+// topN caps every leaderboard (rows per dashboard_top_functions metric and
+// dashboard_hotspots rows); 0 keeps the built-in limits of 50 and 200.
+func createGraphIntelligence(conn *sqlite.Conn, topN int, prog *Progress) error {
+	topLimit, hotspotLimit := 50, 200
+	if topN > 0 {
+		topLimit, hotspotLimit = topN, topN
+	}
+
 	ddl := `
 -- Top functions by multiple metrics (leaderboard-ready)
 CREATE TABLE dashboard_top_functions (
@@ -2439,6 +4288,15 @@ CREATE TABLE dashboard_hotspots (
     hotspot_score REAL NOT NULL
 );
 
+-- Shortest call paths between the top hotspots (path is "a -> b -> c")
+CREATE TABLE hot_call_paths (
+    from_id TEXT NOT NULL,
+    to_id TEXT NOT NULL,
+    hop_count INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    PRIMARY KEY (from_id, to_id)
+);
+
 -- Cross-package coupling matrix: how tightly packages are coupled
 CREATE TABLE package_coupling (
     source_package TEXT NOT NULL,
@@ -2457,18 +4315,18 @@ CREATE TABLE error_chains (
     chain_depth INTEGER DEFAULT 0
 );
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return fmt.Errorf("graph intelligence DDL: %w", err)
 	}
 
 	// Top functions by complexity
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO dashboard_top_functions
-  SELECT 'complexity', ROW_NUMBER() OVER (ORDER BY m.cyclomatic_complexity DESC), m.function_id,
+  SELECT 'complexity', ROW_NUMBER() OVER (ORDER BY m.cyclomatic_complexity DESC, m.function_id), m.function_id,
     n.name, n.package, n.file, m.cyclomatic_complexity
   FROM metrics m JOIN nodes n ON n.id = m.function_id
   WHERE m.cyclomatic_complexity > 0
-  ORDER BY m.cyclomatic_complexity DESC LIMIT 50`,
+  ORDER BY m.cyclomatic_complexity DESC, m.function_id LIMIT `+strconv.Itoa(topLimit),
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("top complexity: %w", err)
 	}
@@ -2476,11 +4334,11 @@ INSERT INTO dashboard_top_functions
 	// Top by LOC
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO dashboard_top_functions
-  SELECT 'loc', ROW_NUMBER() OVER (ORDER BY m.loc DESC), m.function_id,
+  SELECT 'loc', ROW_NUMBER() OVER (ORDER BY m.loc DESC, m.function_id), m.function_id,
     n.name, n.package, n.file, m.loc
   FROM metrics m JOIN nodes n ON n.id = m.function_id
   WHERE m.loc > 0
-  ORDER BY m.loc DESC LIMIT 50`,
+  ORDER BY m.loc DESC, m.function_id LIMIT `+strconv.Itoa(topLimit),
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("top loc: %w", err)
 	}
@@ -2488,11 +4346,11 @@ INSERT INTO dashboard_top_functions
 	// Top by fan-in (most called)
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO dashboard_top_functions
-  SELECT 'fan_in', ROW_NUMBER() OVER (ORDER BY m.fan_in DESC), m.function_id,
+  SELECT 'fan_in', ROW_NUMBER() OVER (ORDER BY m.fan_in DESC, m.function_id), m.function_id,
     n.name, n.package, n.file, m.fan_in
   FROM metrics m JOIN nodes n ON n.id = m.function_id
   WHERE m.fan_in > 0
-  ORDER BY m.fan_in DESC LIMIT 50`,
+  ORDER BY m.fan_in DESC, m.function_id LIMIT `+strconv.Itoa(topLimit),
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("top fan_in: %w", err)
 	}
@@ -2500,11 +4358,11 @@ INSERT INTO dashboard_top_functions
 	// Top by fan-out (calls the most)
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO dashboard_top_functions
-  SELECT 'fan_out', ROW_NUMBER() OVER (ORDER BY m.fan_out DESC), m.function_id,
+  SELECT 'fan_out', ROW_NUMBER() OVER (ORDER BY m.fan_out DESC, m.function_id), m.function_id,
     n.name, n.package, n.file, m.fan_out
   FROM metrics m JOIN nodes n ON n.id = m.function_id
   WHERE m.fan_out > 0
-  ORDER BY m.fan_out DESC LIMIT 50`,
+  ORDER BY m.fan_out DESC, m.function_id LIMIT `+strconv.Itoa(topLimit),
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("top fan_out: %w", err)
 	}
@@ -2517,20 +4375,26 @@ INSERT INTO dashboard_hotspots
     COALESCE(fc.cnt, 0),
     -- Hotspot score: weighted combination of normalized metrics
     ROUND(
-      (CAST(m.cyclomatic_complexity AS REAL) / MAX((SELECT MAX(cyclomatic_complexity) FROM metrics), 1)) * 30 +
-      (CAST(m.loc AS REAL) / MAX((SELECT MAX(loc) FROM metrics), 1)) * 20 +
-      (CAST(m.fan_in AS REAL) / MAX((SELECT MAX(fan_in) FROM metrics WHERE fan_in > 0), 1)) * 25 +
-      (CAST(COALESCE(fc.cnt, 0) AS REAL) / MAX((SELECT MAX(c) FROM (SELECT COUNT(*) as c FROM findings GROUP BY node_id)), 1)) * 25
+      -- (scalar MAX(NULL, 1) is NULL: COALESCE the empty-set maxima)
+      (CAST(m.cyclomatic_complexity AS REAL) / MAX(COALESCE((SELECT MAX(cyclomatic_complexity) FROM metrics), 0), 1)) * 30 +
+      (CAST(m.loc AS REAL) / MAX(COALESCE((SELECT MAX(loc) FROM metrics), 0), 1)) * 20 +
+      (CAST(m.fan_in AS REAL) / MAX(COALESCE((SELECT MAX(fan_in) FROM metrics WHERE fan_in > 0), 0), 1)) * 25 +
+      (CAST(COALESCE(fc.cnt, 0) AS REAL) / MAX(COALESCE((SELECT MAX(c) FROM (SELECT COUNT(*) as c FROM findings GROUP BY node_id)), 0), 1)) * 25
     , 2)
   FROM metrics m
   JOIN nodes n ON n.id = m.function_id
   LEFT JOIN (SELECT node_id, COUNT(*) AS cnt FROM findings GROUP BY node_id) fc ON fc.node_id = m.function_id
   WHERE m.cyclomatic_complexity > 0
-  ORDER BY 10 DESC LIMIT 200`,
+  ORDER BY 10 DESC, m.function_id LIMIT `+strconv.Itoa(hotspotLimit),
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("hotspots: %w", err)
 	}
 
+	hotPathCount, err := createHotCallPaths(conn, topLimit)
+	if err != nil {
+		return fmt.Errorf("hot call paths: %w", err)
+	}
+
 	// Cross-package coupling
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO package_coupling
@@ -2565,44 +4429,64 @@ INSERT INTO error_chains
 	}
 
 	// Findings: long parameter list (>5 params)
-	var longParamCount int
-	if err := sqlitex.ExecuteTransient(conn, `
+	longParamCount, err := insertFindings(conn, "long_param_list", `
 INSERT INTO findings (node_id, category, severity, message)
   SELECT m.function_id, 'long_param_list', 'info',
     n.name || ' has ' || m.num_params || ' parameters (threshold: 5)'
   FROM metrics m JOIN nodes n ON n.id = m.function_id
   WHERE m.num_params > 5`,
-		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
 		return fmt.Errorf("long_param_list findings: %w", err)
 	}
-	longParamCount = conn.Changes()
 
 	// Findings: god package (>50 functions)
-	var godPkgCount int
-	if err := sqlitex.ExecuteTransient(conn, `
+	godPkgCount, err := insertFindings(conn, "god_package", `
 INSERT INTO findings (node_id, category, severity, message)
   SELECT MIN(n.id), 'god_package', 'warning',
     n.package || ' has ' || COUNT(*) || ' functions (threshold: 50)'
   FROM nodes n WHERE n.kind = 'function' AND n.package IS NOT NULL
   GROUP BY n.package HAVING COUNT(*) > 50`,
-		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
 		return fmt.Errorf("god_package findings: %w", err)
 	}
-	godPkgCount = conn.Changes()
+
+	// Findings: god type (struct with too many fields and too many methods)
+	godTypeCount, err := insertFindings(conn, "god_type", `
+INSERT INTO findings (node_id, category, severity, file, line, message, details)
+  SELECT t.id, 'god_type', 'warning', t.file, t.line,
+    t.name || ' has ' || fc.fields || ' fields and ' || mc.methods || ' methods (thresholds: ' || ?1 || '/' || ?2 || ')',
+    json_object('field_count', fc.fields, 'method_count', mc.methods, 'package', t.package,
+      'field_threshold', ?1, 'method_threshold', ?2)
+  FROM nodes t
+  JOIN (SELECT e.source AS type_id, COUNT(*) AS fields FROM edges e
+        JOIN nodes f ON f.id = e.target AND f.kind = 'field'
+        WHERE e.kind = 'ast' GROUP BY e.source) fc ON fc.type_id = t.id
+  JOIN (SELECT source AS type_id, COUNT(*) AS methods FROM edges
+        WHERE kind = 'has_method' GROUP BY source) mc ON mc.type_id = t.id
+  WHERE t.kind = 'type_decl' AND json_extract(t.properties, '$.type_kind') = 'struct'
+    AND fc.fields > ?1 AND mc.methods > ?2`,
+		&sqlitex.ExecOptions{
+			Args:       []any{godTypeFields, godTypeMethods},
+			ResultFunc: func(stmt *sqlite.Stmt) error { return nil },
+		})
+	if err != nil {
+		return fmt.Errorf("god_type findings: %w", err)
+	}
 
 	// Findings: high coupling (packages that call >10 other packages)
-	var couplingCount int
-	if err := sqlitex.ExecuteTransient(conn, `
+	couplingCount, err := insertFindings(conn, "high_coupling", `
 INSERT INTO findings (node_id, category, severity, message)
   SELECT (SELECT MIN(id) FROM nodes WHERE kind = 'function' AND package = pc.source_package),
     'high_coupling', 'warning',
     pc.source_package || ' depends on ' || COUNT(DISTINCT pc.target_package) || ' packages (threshold: 10)'
   FROM package_coupling pc
   GROUP BY pc.source_package HAVING COUNT(DISTINCT pc.target_package) > 10`,
-		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
 		return fmt.Errorf("high_coupling findings: %w", err)
 	}
-	couplingCount = conn.Changes()
 
 	// Queries: hotspot analysis
 	if err := sqlitex.ExecuteTransient(conn, `
@@ -2618,7 +4502,9 @@ INSERT INTO queries (name, description, sql) VALUES
   ('package_coupling_degree', 'Packages ranked by number of coupled packages (high coupling = risky)',
    'SELECT source_package, COUNT(DISTINCT target_package) as coupled_to, SUM(call_count) as total_calls FROM package_coupling GROUP BY source_package ORDER BY coupled_to DESC'),
   ('call_chain_pathfinder', 'Find all call paths from function A to function B (up to 6 hops)',
-   'WITH RECURSIVE chain(fn, path, depth) AS (SELECT target, source || '' -> '' || target, 1 FROM edges WHERE kind = ''call'' AND source = :start UNION ALL SELECT e.target, chain.path || '' -> '' || e.target, chain.depth + 1 FROM chain JOIN edges e ON e.source = chain.fn AND e.kind = ''call'' WHERE chain.depth < 6 AND chain.path NOT LIKE ''%'' || e.target || ''%'') SELECT path, depth FROM chain WHERE fn = :end ORDER BY depth LIMIT 10')`,
+   'WITH RECURSIVE chain(fn, path, depth) AS (SELECT target, source || '' -> '' || target, 1 FROM edges WHERE kind = ''call'' AND source = :start UNION ALL SELECT e.target, chain.path || '' -> '' || e.target, chain.depth + 1 FROM chain JOIN edges e ON e.source = chain.fn AND e.kind = ''call'' WHERE chain.depth < 6 AND chain.path NOT LIKE ''%'' || e.target || ''%'') SELECT path, depth FROM chain WHERE fn = :end ORDER BY depth LIMIT 10'),
+  ('hot_call_path', 'Precomputed shortest call path between two top hotspots (see hot_call_paths)',
+   'SELECT hop_count, path FROM hot_call_paths WHERE from_id = :start AND to_id = :end')`,
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("graph intelligence queries: %w", err)
 	}
@@ -2642,15 +4528,131 @@ INSERT INTO queries (name, description, sql) VALUES
 		}})
 	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM error_chains",
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
-			errorChainCount = stmt.ColumnInt(0)
+			errorChainCount = stmt.ColumnInt(0)
+			return nil
+		}})
+
+	prog.Log("Graph intelligence: %d top-N entries, %d hotspots, %d hot call paths, %d coupling pairs, %d error chains",
+		topCount, hotspotCount, hotPathCount, couplingRows, errorChainCount)
+	prog.Log("  findings: %d long-param, %d god-package, %d god-type, %d high-coupling; 7 queries",
+		longParamCount, godPkgCount, godTypeCount, couplingCount)
+	return nil
+}
+
+// createBinaryFootprint fills binary_footprint with, for each main package,
+// the module functions reachable from its main and init functions over call
+// edges (VTA already resolves interface calls to their implementations) and
+// their summed LOC: a rough proxy for what the binary links in, to spot
+// heavy imports pulled in by accident. Function literals count once the
+// function declaring them is reachable, since callbacks handed to external
+// code have no call edge.
+func createBinaryFootprint(conn *sqlite.Conn, prog *Progress) error {
+	if err := sqlitex.ExecuteScript(conn, `
+CREATE TABLE binary_footprint (
+    main_package TEXT PRIMARY KEY,
+    reachable_functions INTEGER NOT NULL,
+    reachable_loc INTEGER NOT NULL
+);
+
+INSERT INTO binary_footprint (main_package, reachable_functions, reachable_loc)
+  WITH RECURSIVE reach(main_package, id) AS (
+    SELECT fn.package, fn.id
+    FROM nodes fn
+    JOIN nodes p ON p.kind = 'package' AND p.package = fn.package AND p.name = 'main'
+    WHERE fn.kind = 'function' AND fn.name IN ('main', 'init')
+      AND json_extract(fn.properties, '$.receiver') IS NULL
+    UNION
+    SELECT r.main_package, e.target
+    FROM reach r JOIN edges e ON e.source = r.id AND e.kind = 'call'
+    UNION
+    SELECT r.main_package, lit.id
+    FROM reach r JOIN nodes lit ON lit.parent_function = r.id AND lit.kind = 'function'
+  )
+  SELECT r.main_package, COUNT(*), COALESCE(SUM(m.loc), 0)
+  FROM reach r
+  JOIN nodes fn ON fn.id = r.id AND fn.kind = 'function' AND fn.file IS NOT NULL
+  LEFT JOIN metrics m ON m.function_id = fn.id
+  GROUP BY r.main_package;`, nil); err != nil {
+		return fmt.Errorf("binary footprint: %w", err)
+	}
+	var mains int64
+	_ = sqlitex.ExecuteTransient(conn, `SELECT COUNT(*) FROM binary_footprint`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			mains = stmt.ColumnInt64(0)
+			return nil
+		}})
+	prog.Log("Binary footprint: %d main packages", mains)
+	return nil
+}
+
+// createHotCallPaths fills hot_call_paths with the shortest call path between
+// every ordered pair of the top limit hotspots, so "how does A reach B" is a
+// lookup instead of a call_chain_pathfinder search. A breadth-first search
+// from each hotspot over the call edges visits callees in ID order, which
+// keeps the chosen path stable among equally short ones.
+func createHotCallPaths(conn *sqlite.Conn, limit int) (int, error) {
+	var hot []string
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT function_id FROM dashboard_hotspots ORDER BY hotspot_score DESC, function_id LIMIT ?`,
+		&sqlitex.ExecOptions{
+			Args: []any{limit},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				hot = append(hot, stmt.ColumnText(0))
+				return nil
+			},
+		}); err != nil {
+		return 0, err
+	}
+	if len(hot) < 2 {
+		return 0, nil
+	}
+
+	callees := make(map[string][]string)
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT DISTINCT source, target FROM edges WHERE kind = 'call' ORDER BY source, target`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			src := stmt.ColumnText(0)
+			callees[src] = append(callees[src], stmt.ColumnText(1))
 			return nil
-		}})
+		}}); err != nil {
+		return 0, err
+	}
 
-	prog.Log("Graph intelligence: %d top-N entries, %d hotspots, %d coupling pairs, %d error chains",
-		topCount, hotspotCount, couplingRows, errorChainCount)
-	prog.Log("  findings: %d long-param, %d god-package, %d high-coupling; 6 queries",
-		longParamCount, godPkgCount, couplingCount)
-	return nil
+	count := 0
+	for _, from := range hot {
+		prev := map[string]string{from: ""}
+		queue := []string{from}
+		for len(queue) > 0 {
+			fn := queue[0]
+			queue = queue[1:]
+			for _, callee := range callees[fn] {
+				if _, seen := prev[callee]; !seen {
+					prev[callee] = fn
+					queue = append(queue, callee)
+				}
+			}
+		}
+		for _, to := range hot {
+			if to == from {
+				continue
+			}
+			if _, ok := prev[to]; !ok {
+				continue
+			}
+			path := []string{to}
+			for fn := prev[to]; fn != ""; fn = prev[fn] {
+				path = append(path, fn)
+			}
+			slices.Reverse(path)
+			if err := sqlitex.Execute(conn,
+				`INSERT INTO hot_call_paths (from_id, to_id, hop_count, path) VALUES (?, ?, ?, ?)`,
+				&sqlitex.ExecOptions{Args: []any{from, to, len(path) - 1, strings.Join(path, " -> ")}}); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
 }
 
 // createFileAndDepAnalysis creates file-level analysis tables and dependency
@@ -2761,7 +4763,8 @@ INSERT INTO dashboard_function_detail
     COALESCE(m.fan_out, 0),
     COALESCE(m.num_params, 0),
     (SELECT COUNT(*) FROM nodes loc WHERE loc.kind = 'local' AND loc.parent_function = n.id),
-    (SELECT COUNT(*) FROM nodes c WHERE c.kind = 'call' AND c.parent_function = n.id),
+    (SELECT COUNT(*) FROM nodes c WHERE c.kind = 'call' AND c.parent_function = n.id
+       AND json_extract(c.properties, '$.call_kind') IS NOT 'conversion'),
     (SELECT COUNT(*) FROM nodes b WHERE b.kind IN ('if','for','switch','select') AND b.parent_function = n.id),
     (SELECT COUNT(*) FROM nodes r WHERE r.kind = 'return' AND r.parent_function = n.id),
     COALESCE((SELECT COUNT(*) FROM findings fi WHERE fi.node_id = n.id), 0),
@@ -2849,11 +4852,47 @@ CREATE TABLE type_method_set (
     complexity INTEGER DEFAULT 0,
     loc INTEGER DEFAULT 0
 );
+
+-- Method resolution through embedding: for each type that embeds others,
+-- every method name in its promoted method set, the declaration a selector
+-- picks (the shallowest; NULL when two tie, an ambiguous selector) and the
+-- same-named methods it shadows. depth 0 is a method declared on the type.
+CREATE TABLE method_resolution (
+    type_id TEXT NOT NULL,
+    method_name TEXT NOT NULL,
+    resolved_method_id TEXT,
+    depth INTEGER NOT NULL,
+    shadowed_ids TEXT,
+    PRIMARY KEY (type_id, method_name)
+);
+
+-- Parsed struct tags: json:"name,omitempty" → (json, name, omitempty).
+-- tag_name is NULL when the tag keeps the Go field name (json:",omitempty").
+CREATE TABLE struct_tags (
+    field_id TEXT NOT NULL,
+    tag_key TEXT NOT NULL,
+    tag_name TEXT,
+    options TEXT,
+    PRIMARY KEY (field_id, tag_key)
+);
+CREATE INDEX idx_struct_tags_key ON struct_tags(tag_key, tag_name);
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return fmt.Errorf("type system DDL: %w", err)
 	}
 
+	// Struct tags, from the per-key map the AST walk parsed with reflect.StructTag
+	if err := sqlitex.ExecuteTransient(conn, `
+INSERT INTO struct_tags (field_id, tag_key, tag_name, options)
+  SELECT f.id, t.key,
+    NULLIF(CASE WHEN instr(t.value, ',') > 0 THEN substr(t.value, 1, instr(t.value, ',') - 1) ELSE t.value END, ''),
+    CASE WHEN instr(t.value, ',') > 0 THEN substr(t.value, instr(t.value, ',') + 1) END
+  FROM nodes f, json_each(f.properties, '$.tags') t
+  WHERE f.kind = 'field' AND json_extract(f.properties, '$.tags') IS NOT NULL`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		return fmt.Errorf("struct tags: %w", err)
+	}
+
 	// Interface implementation map
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO type_impl_map
@@ -2892,6 +4931,45 @@ INSERT INTO type_hierarchy
 		return fmt.Errorf("type hierarchy: %w", err)
 	}
 
+	// Method resolution: walk embeds edges (bounded; pointer embedding can
+	// cycle) and keep each method at its shallowest depth.
+	if err := sqlitex.ExecuteTransient(conn, `
+INSERT INTO method_resolution (type_id, method_name, resolved_method_id, depth, shadowed_ids)
+WITH RECURSIVE reach(type_id, member_id, depth) AS (
+  SELECT DISTINCT source, source, 0 FROM edges WHERE kind = 'embeds'
+  UNION
+  SELECT r.type_id, e.target, r.depth + 1
+  FROM reach r JOIN edges e ON e.source = r.member_id AND e.kind = 'embeds'
+  WHERE r.depth < 8
+),
+cands AS (
+  SELECT r.type_id, substr(m.name, instr(m.name, '.') + 1) AS method_name,
+    m.id AS method_id, MIN(r.depth) AS depth
+  FROM reach r
+  JOIN edges hm ON hm.source = r.member_id AND hm.kind = 'has_method'
+  JOIN nodes m ON m.id = hm.target
+  GROUP BY r.type_id, m.id
+),
+best AS (
+  SELECT type_id, method_name, MIN(depth) AS depth FROM cands GROUP BY type_id, method_name
+),
+winners AS (
+  SELECT b.type_id, b.method_name, b.depth,
+    CASE WHEN COUNT(*) = 1 THEN MIN(c.method_id) END AS method_id
+  FROM best b
+  JOIN cands c ON c.type_id = b.type_id AND c.method_name = b.method_name AND c.depth = b.depth
+  GROUP BY b.type_id, b.method_name
+)
+SELECT w.type_id, w.method_name, w.method_id, w.depth,
+  (SELECT json_group_array(c.method_id) FROM cands c
+   WHERE c.type_id = w.type_id AND c.method_name = w.method_name
+     AND c.method_id IS NOT w.method_id
+   HAVING COUNT(*) > 0)
+FROM winners w`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		return fmt.Errorf("method resolution: %w", err)
+	}
+
 	// Method sets per type
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO type_method_set
@@ -2911,8 +4989,7 @@ INSERT INTO type_method_set
 	}
 
 	// Findings: large interfaces (>10 methods)
-	var largeIfaceCount int
-	if err := sqlitex.ExecuteTransient(conn, `
+	largeIfaceCount, err := insertFindings(conn, "large_interface", `
 INSERT INTO findings (node_id, category, severity, message)
   SELECT t.id, 'large_interface', 'info',
     t.name || ' in ' || t.package || ' has ' || COUNT(*) || ' methods (threshold: 10)'
@@ -2922,24 +4999,47 @@ INSERT INTO findings (node_id, category, severity, message)
   JOIN nodes meth ON meth.id = e.target AND meth.kind = 'function'
   WHERE e.kind = 'has_method'
   GROUP BY t.id HAVING COUNT(*) > 10`,
-		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
 		return fmt.Errorf("large_interface findings: %w", err)
 	}
-	largeIfaceCount = conn.Changes()
 
 	// Findings: orphan types (declared but never used in implements/embeds/call)
-	var orphanTypeCount int
-	if err := sqlitex.ExecuteTransient(conn, `
+	orphanTypeCount, err := insertFindings(conn, "orphan_type", `
 INSERT INTO findings (node_id, category, severity, message)
   SELECT n.id, 'orphan_type', 'info',
     n.name || ' in ' || n.package || ' has no implements/embeds/method edges'
   FROM nodes n
   WHERE n.kind = 'type_decl'
     AND NOT EXISTS (SELECT 1 FROM edges e WHERE (e.source = n.id OR e.target = n.id) AND e.kind IN ('implements', 'embeds', 'has_method'))`,
-		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
 		return fmt.Errorf("orphan_type findings: %w", err)
 	}
-	orphanTypeCount = conn.Changes()
+
+	// Findings: exported struct fields without a json tag in packages whose
+	// other struct fields use json tags (inconsistent wire names)
+	jsonTagCount, err := insertFindings(conn, "json_tag_missing", `
+INSERT INTO findings (node_id, category, severity, file, line, message, details)
+  SELECT f.id, 'json_tag_missing', 'info', f.file, f.line,
+    'exported field ' || t.name || '.' || f.name || ' has no json tag; other fields in ' || f.package || ' use json tags',
+    json_object('type_id', t.id, 'package', f.package)
+  FROM nodes f
+  JOIN edges e ON e.target = f.id AND e.kind = 'ast'
+  JOIN nodes t ON t.id = e.source AND t.kind = 'type_decl'
+    AND json_extract(t.properties, '$.type_kind') = 'struct'
+  WHERE f.kind = 'field'
+    AND json_extract(f.properties, '$.exported') = 1
+    AND json_extract(f.properties, '$.embedded') IS NULL
+    AND NOT EXISTS (SELECT 1 FROM struct_tags st WHERE st.field_id = f.id AND st.tag_key = 'json')
+    AND EXISTS (
+      SELECT 1 FROM struct_tags st JOIN nodes o ON o.id = st.field_id
+      WHERE st.tag_key = 'json' AND o.package = f.package
+    )`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
+	if err != nil {
+		return fmt.Errorf("json_tag_missing findings: %w", err)
+	}
 
 	// Queries
 	if err := sqlitex.ExecuteTransient(conn, `
@@ -2953,12 +5053,24 @@ INSERT INTO queries (name, description, sql) VALUES
   ('largest_interfaces', 'Interfaces ranked by method count',
    'SELECT interface_name, interface_package, COUNT(*) as impl_count FROM type_impl_map GROUP BY interface_id ORDER BY impl_count DESC'),
   ('most_implemented', 'Interfaces with the most concrete implementations',
-   'SELECT interface_name, interface_package, COUNT(DISTINCT concrete_id) as impl_count FROM type_impl_map GROUP BY interface_id ORDER BY impl_count DESC LIMIT 20')`,
+   'SELECT interface_name, interface_package, COUNT(DISTINCT concrete_id) as impl_count FROM type_impl_map GROUP BY interface_id ORDER BY impl_count DESC LIMIT 20'),
+  ('fields_by_tag', 'Struct fields carrying a given tag key and name (e.g. json / id)',
+   'SELECT f.package, f.name, f.file, f.line, st.tag_name, st.options FROM struct_tags st JOIN nodes f ON f.id = st.field_id WHERE st.tag_key = :key AND (:name IS NULL OR st.tag_name = :name) ORDER BY f.package, f.file, f.line')`,
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("type system queries: %w", err)
 	}
 
-	var implCount, hierarchyCount, methodSetCount int
+	var implCount, hierarchyCount, methodSetCount, tagCount, resolutionCount int
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM method_resolution",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			resolutionCount = stmt.ColumnInt(0)
+			return nil
+		}})
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM struct_tags",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			tagCount = stmt.ColumnInt(0)
+			return nil
+		}})
 	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM type_impl_map",
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
 			implCount = stmt.ColumnInt(0)
@@ -2975,8 +5087,8 @@ INSERT INTO queries (name, description, sql) VALUES
 			return nil
 		}})
 
-	prog.Log("Type system: %d impl mappings, %d hierarchy entries, %d method set entries; %d large-iface, %d orphan-type findings; 5 queries",
-		implCount, hierarchyCount, methodSetCount, largeIfaceCount, orphanTypeCount)
+	prog.Log("Type system: %d impl mappings, %d hierarchy entries, %d method set entries, %d method resolutions, %d struct tags; %d large-iface, %d orphan-type, %d json-tag-missing findings; 6 queries",
+		implCount, hierarchyCount, methodSetCount, resolutionCount, tagCount, largeIfaceCount, orphanTypeCount, jsonTagCount)
 	return nil
 }
 
@@ -3037,7 +5149,9 @@ CREATE TABLE go_pattern_summary (
     interface_count INTEGER DEFAULT 0,
     type_assert_count INTEGER DEFAULT 0,
     error_wrap_count INTEGER DEFAULT 0,
-    context_param_count INTEGER DEFAULT 0
+    context_param_count INTEGER DEFAULT 0,
+    any_param_count INTEGER DEFAULT 0,
+    lazy_init_count INTEGER DEFAULT 0
 );
 `
 	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
@@ -3049,13 +5163,21 @@ CREATE TABLE go_pattern_summary (
 INSERT INTO symbol_index
   SELECT id, name, kind, package, file, line, type_info, parent_function
   FROM nodes
-  WHERE kind IN ('function', 'type_decl', 'local', 'parameter')
+  WHERE kind IN ('function', 'type_decl', 'global_var', 'global_const', 'local', 'parameter')
     AND name != '' AND name != '_'
     AND file IS NOT NULL`,
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }}); err != nil {
 		return fmt.Errorf("symbol index: %w", err)
 	}
 
+	// Symbol FTS: trigram index over symbol names for ranked prefix/substring
+	// search. symbol_index stays the source of truth for exact lookups.
+	if err := sqlitex.ExecuteScript(conn, `
+CREATE VIRTUAL TABLE symbol_fts USING fts5(name, package, content=symbol_index, content_rowid=rowid, tokenize='trigram');
+INSERT INTO symbol_fts(symbol_fts) VALUES('rebuild');`, nil); err != nil {
+		return fmt.Errorf("symbol fts: %w", err)
+	}
+
 	// File outline: top-level and function-level declarations
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO file_outline
@@ -3093,7 +5215,7 @@ INSERT INTO go_pattern_summary
     SUM(CASE WHEN n.kind = 'defer' THEN 1 ELSE 0 END),
     SUM(CASE WHEN n.kind = 'send' THEN 1 ELSE 0 END),
     SUM(CASE WHEN n.kind = 'select' THEN 1 ELSE 0 END),
-    0, 0, 0, 0, 0
+    0, 0, 0, 0, 0, 0, 0
   FROM nodes n
   WHERE n.package IS NOT NULL AND n.kind IN ('go', 'defer', 'send', 'select')
   GROUP BY n.package`,
@@ -3123,11 +5245,33 @@ UPDATE go_pattern_summary SET context_param_count = (
     AND p.package = go_pattern_summary.package)`,
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { return nil }})
 
+	// any_parameter findings, by the package of their function; packages
+	// with none of the constructs above get a row here too
+	sqlitex.ExecuteScript(conn, `
+INSERT OR IGNORE INTO go_pattern_summary (package)
+  SELECT DISTINCT json_extract(details, '$.package') FROM findings
+  WHERE category = 'any_parameter' AND json_extract(details, '$.package') IS NOT NULL;
+UPDATE go_pattern_summary SET any_param_count = (
+  SELECT COUNT(*) FROM findings f
+  WHERE f.category = 'any_parameter' AND json_extract(f.details, '$.package') = go_pattern_summary.package)`, nil)
+
+	// Lazy initialization: sync.Once.Do calls
+	sqlitex.ExecuteScript(conn, `
+INSERT OR IGNORE INTO go_pattern_summary (package)
+  SELECT DISTINCT package FROM nodes
+  WHERE kind = 'call' AND json_extract(properties, '$.sync_kind') = 'once_do' AND package IS NOT NULL;
+UPDATE go_pattern_summary SET lazy_init_count = (
+  SELECT COUNT(*) FROM nodes c
+  WHERE c.kind = 'call' AND json_extract(c.properties, '$.sync_kind') = 'once_do'
+    AND c.package = go_pattern_summary.package)`, nil)
+
 	// Queries
 	if err := sqlitex.ExecuteTransient(conn, `
 INSERT INTO queries (name, description, sql) VALUES
   ('symbol_search', 'Search symbols by name (supports LIKE patterns)',
    'SELECT id, name, kind, package, file, line FROM symbol_index WHERE name LIKE :pattern ORDER BY kind, name LIMIT 50'),
+  ('symbol_fts_search', 'Ranked symbol search: exact, then prefix, then substring matches (3+ chars)',
+   'SELECT s.id, s.name, s.kind, s.package, s.file, s.line FROM symbol_fts f JOIN symbol_index s ON s.rowid = f.rowid WHERE symbol_fts MATCH ''name:"'' || replace(:q, ''"'', ''""'') || ''"'' ORDER BY CASE WHEN s.name = :q THEN 0 WHEN s.name LIKE replace(replace(replace(:q, ''\'', ''\\''), ''%'', ''\%''), ''_'', ''\_'') || ''%'' ESCAPE ''\'' THEN 1 ELSE 2 END, bm25(symbol_fts), length(s.name) LIMIT 20'),
   ('file_outline_query', 'Get hierarchical outline of a file for sidebar navigation',
    'SELECT name, kind, line, end_line, signature, depth FROM file_outline WHERE file = :file ORDER BY line'),
   ('xref_lookup', 'Find all usages of a symbol by its definition ID',
@@ -3160,13 +5304,14 @@ INSERT INTO queries (name, description, sql) VALUES
 			return nil
 		}})
 
-	prog.Log("Navigation: %d symbols, %d outline entries, %d xrefs, %d package patterns; 4 queries",
+	prog.Log("Navigation: %d symbols, %d outline entries, %d xrefs, %d package patterns; 5 queries",
 		symbolCount, outlineCount, xrefCount, patternCount)
 	return nil
 }
 
 // applyGitHistory creates the git_file_history table and populates it from
-// git log --numstat output, then enriches with a file risk view.
+// git log --numstat output, then enriches with a file risk view and its
+// function-level counterpart, hotspot_trend.
 func applyGitHistory(conn *sqlite.Conn, history []GitFileHistory, prog *Progress) error {
 	ddl := `
 CREATE TABLE git_file_history (
@@ -3179,7 +5324,7 @@ CREATE TABLE git_file_history (
     deletions INTEGER NOT NULL DEFAULT 0,
     churn INTEGER NOT NULL DEFAULT 0
 );`
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return fmt.Errorf("git history DDL: %w", err)
 	}
 
@@ -3242,29 +5387,65 @@ FROM dashboard_file_heatmap fh
 JOIN git_file_history g ON g.file = fh.file
 WHERE g.commit_count >= 10 AND fh.avg_complexity >= 5;
 
+-- Function-level hotspots: each function inherits its file's change
+-- frequency. trend_score (0-100) is the product of complexity and commit
+-- count, each relative to the repo maximum, so only functions that are both
+-- complex and often changed score high.
+CREATE TABLE hotspot_trend AS
+WITH f AS (
+  SELECT n.id AS function_id, n.file, g.commit_count, g.churn,
+    COALESCE(m.cyclomatic_complexity, 1) AS complexity
+  FROM nodes n
+  JOIN git_file_history g ON g.file = n.file
+  LEFT JOIN metrics m ON m.function_id = n.id
+  WHERE n.kind = 'function'
+)
+SELECT function_id, file, commit_count, churn, complexity,
+  ROUND(100.0 * complexity / (SELECT MAX(complexity) FROM f)
+    * commit_count / MAX((SELECT MAX(commit_count) FROM f), 1), 1) AS trend_score
+FROM f;
+CREATE INDEX idx_hotspot_trend_score ON hotspot_trend(trend_score);
+
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+SELECT 'refactor_candidate', 'info', n.id, n.file, n.line,
+  n.name || ': complexity ' || t.complexity || ' in a file changed in ' || t.commit_count || ' commits (trend score ' || t.trend_score || ')',
+  json_object('complexity', t.complexity, 'commits', t.commit_count, 'churn', t.churn, 'trend_score', t.trend_score)
+FROM hotspot_trend t
+JOIN nodes n ON n.id = t.function_id
+WHERE t.complexity >= 10 AND t.commit_count >= 10 AND t.trend_score >= 25;
+
 INSERT INTO schema_docs (category, name, description, example) VALUES
 ('table', 'git_file_history', 'Per-file git change metrics from recent 500 commits', 'SELECT * FROM git_file_history ORDER BY churn DESC LIMIT 20'),
-('view', 'v_file_risk', 'Combined file risk: complexity metrics joined with git change velocity', 'SELECT * FROM v_file_risk WHERE commit_count > 5 ORDER BY change_risk_score DESC LIMIT 20');
+('view', 'v_file_risk', 'Combined file risk: complexity metrics joined with git change velocity', 'SELECT * FROM v_file_risk WHERE commit_count > 5 ORDER BY change_risk_score DESC LIMIT 20'),
+('table', 'hotspot_trend', 'v_file_risk at function level: function_id, file, commit_count and churn of its file, complexity, trend_score (0-100, complexity × commits relative to the repo maxima)', 'SELECT * FROM hotspot_trend ORDER BY trend_score DESC LIMIT 20'),
+('finding', 'refactor_candidate', 'Function with complexity >= 10 in a file with 10+ commits and hotspot_trend.trend_score >= 25', NULL);
 `
-	if err := sqlitex.ExecuteScript(conn, enrich, nil); err != nil {
+	if err := execFindingsScript(conn, enrich); err != nil {
 		return fmt.Errorf("git history enrichment: %w", err)
 	}
 
-	var churnFindings int
+	var churnFindings, refactorFindings int
 	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM findings WHERE category = 'high_churn_complexity'",
 		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
 			churnFindings = stmt.ColumnInt(0)
 			return nil
 		}})
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM findings WHERE category = 'refactor_candidate'",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			refactorFindings = stmt.ColumnInt(0)
+			return nil
+		}})
 
-	prog.Log("Git history: %d files, %d high-churn findings", len(history), churnFindings)
+	prog.Log("Git history: %d files, %d high-churn findings, %d refactor candidates", len(history), churnFindings, refactorFindings)
 	return nil
 }
 
 // createTaintFlowStates materializes taint propagation by BFS through DFG
-// edges from annotated taint sources. Each reachable node gets a label:
-// source, propagated, sanitized, or sink_reached.
+// edges from annotated taint sources, up to taintMaxHops steps. Each
+// reachable node gets a label: source, propagated, sanitized, or
+// sink_reached.
 func createTaintFlowStates(conn *sqlite.Conn, prog *Progress) error {
+	hops := strconv.Itoa(taintMaxHops)
 	ddl := `
 CREATE TABLE taint_flow_state (
     node_id TEXT NOT NULL,
@@ -3274,7 +5455,7 @@ CREATE TABLE taint_flow_state (
     min_hops INTEGER NOT NULL
 );
 
--- BFS through DFG from taint sources (bounded to 8 hops)
+-- BFS through DFG from taint sources (bounded to -taint-max-hops steps)
 INSERT INTO taint_flow_state (node_id, label, source_id, source_category, min_hops)
 WITH RECURSIVE taint_reach(node_id, source_id, source_category, hop) AS (
     -- Seed: call nodes annotated as taint sources
@@ -3289,7 +5470,28 @@ WITH RECURSIVE taint_reach(node_id, source_id, source_category, hop) AS (
     SELECT e.target, tr.source_id, tr.source_category, tr.hop + 1
     FROM taint_reach tr
     JOIN edges e ON e.source = tr.node_id AND e.kind = 'dfg'
-    WHERE tr.hop < 8
+    WHERE tr.hop < ` + hops + `
+
+    UNION
+
+    -- Receiver-carried taint: a variable initialized from a tainted node, or
+    -- referenced by a tainted identifier, taints its uses as a method
+    -- receiver; the heuristic DFG then carries those into the method call
+    SELECT recv.target, tr.source_id, tr.source_category, tr.hop + 1
+    FROM taint_reach tr
+    JOIN edges init ON init.target = tr.node_id AND init.kind = 'initializer'
+    JOIN edges r ON r.target = init.source AND r.kind = 'ref'
+    JOIN edges recv ON recv.target = r.source AND recv.kind = 'receiver'
+    WHERE tr.hop < ` + hops + `
+
+    UNION
+
+    SELECT recv.target, tr.source_id, tr.source_category, tr.hop + 1
+    FROM taint_reach tr
+    JOIN edges v ON v.source = tr.node_id AND v.kind = 'ref'
+    JOIN edges r ON r.target = v.target AND r.kind = 'ref'
+    JOIN edges recv ON recv.target = r.source AND recv.kind = 'receiver'
+    WHERE tr.hop < ` + hops + `
 )
 SELECT
   node_id,
@@ -3312,6 +5514,41 @@ GROUP BY node_id, source_id;
 CREATE INDEX idx_taint_flow_node ON taint_flow_state(node_id);
 CREATE INDEX idx_taint_flow_label ON taint_flow_state(label);
 
+-- BFS parent pointers: for each node reached at min_hops h > 0 from a
+-- source, one predecessor (the smallest ID) reached at h - 1 through the
+-- same steps as taint_reach. Following parent_id from a sink back to the
+-- source yields one shortest path.
+CREATE TABLE taint_path_edges (
+    source_id TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    parent_id TEXT NOT NULL,
+    hop INTEGER NOT NULL,
+    PRIMARY KEY (source_id, node_id)
+);
+INSERT INTO taint_path_edges (source_id, node_id, parent_id, hop)
+WITH steps(from_id, to_id) AS (
+    SELECT source, target FROM edges WHERE kind = 'dfg'
+    UNION
+    SELECT init.target, recv.target
+    FROM edges init
+    JOIN edges r ON r.target = init.source AND r.kind = 'ref'
+    JOIN edges recv ON recv.target = r.source AND recv.kind = 'receiver'
+    WHERE init.kind = 'initializer'
+    UNION
+    SELECT v.source, recv.target
+    FROM edges v
+    JOIN edges r ON r.target = v.target AND r.kind = 'ref'
+    JOIN edges recv ON recv.target = r.source AND recv.kind = 'receiver'
+    WHERE v.kind = 'ref'
+)
+SELECT t.source_id, t.node_id, MIN(p.node_id), t.min_hops
+FROM taint_flow_state t
+JOIN steps s ON s.to_id = t.node_id
+JOIN taint_flow_state p ON p.node_id = s.from_id AND p.source_id = t.source_id
+  AND p.min_hops = t.min_hops - 1
+WHERE t.min_hops > 0
+GROUP BY t.source_id, t.node_id;
+
 -- Findings: unsanitized taint reaching sinks
 INSERT INTO findings (category, severity, node_id, file, line, message, details)
 SELECT 'unsanitized_sink', 'error',
@@ -3331,14 +5568,15 @@ GROUP BY label, source_category
 ORDER BY node_count DESC;
 
 INSERT INTO schema_docs (category, name, description, example) VALUES
-('table', 'taint_flow_state', 'Materialized taint propagation via DFG from sources (8-hop BFS)', 'SELECT * FROM taint_flow_state WHERE label = ''sink_reached'''),
+('table', 'taint_flow_state', 'Materialized taint propagation via DFG from sources (BFS of up to -taint-max-hops steps, 8 by default; META_DATA taint_max_hops)', 'SELECT * FROM taint_flow_state WHERE label = ''sink_reached'''),
+('table', 'taint_path_edges', 'BFS parent pointers of taint_flow_state: per (source_id, node_id) one predecessor parent_id one hop closer to the source; follow them from a sink to get a shortest path', 'SELECT * FROM taint_path_edges WHERE source_id = :source_id AND node_id = :sink_id'),
 ('view', 'v_taint_summary', 'Taint flow distribution by label and source category', 'SELECT * FROM v_taint_summary');
 
 INSERT INTO queries (name, description, sql) VALUES
 ('taint_path_to_sink', 'Find taint paths reaching sinks without sanitization',
  'SELECT tfs.source_id, src.name AS source_name, tfs.source_category, tfs.node_id AS sink_id, n.name AS sink_name, n.file, n.line, tfs.min_hops FROM taint_flow_state tfs JOIN nodes n ON n.id = tfs.node_id JOIN nodes src ON src.id = tfs.source_id WHERE tfs.label = ''sink_reached'' ORDER BY tfs.min_hops');
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return fmt.Errorf("taint flow states: %w", err)
 	}
 
@@ -3388,7 +5626,7 @@ SELECT n.id, n.kind,
 FROM nodes n
 WHERE (n.type_info LIKE 'map[%' OR n.type_info LIKE '[]%'
        OR n.type_info LIKE '*map[%' OR n.type_info LIKE '*[]%')
-  AND n.kind IN ('identifier', 'local', 'parameter', 'field', 'assign')
+  AND n.kind IN ('identifier', 'global_var', 'local', 'parameter', 'field', 'assign')
   AND n.file IS NOT NULL;
 
 CREATE INDEX idx_index_sens_taint ON index_sensitivity(has_taint);
@@ -3418,7 +5656,7 @@ INSERT INTO queries (name, description, sql) VALUES
 ('tainted_containers', 'Find container operations with tainted data flow',
  'SELECT s.node_id, s.container_kind, s.type_info, s.file, s.line, n.name FROM index_sensitivity s JOIN nodes n ON n.id = s.node_id WHERE s.has_taint = 1 ORDER BY s.file, s.line');
 `
-	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+	if err := execFindingsScript(conn, ddl); err != nil {
 		return fmt.Errorf("index sensitivity: %w", err)
 	}
 
@@ -3438,6 +5676,183 @@ INSERT INTO queries (name, description, sql) VALUES
 	return nil
 }
 
+// createConcurrentAccess flags map/slice variables (from index_sensitivity)
+// that a go statement's closure captures and that are also accessed by the
+// launching function, with at least one write on either side and no mutex
+// lock call in either function. Heuristic: ignores WaitGroup/channel ordering.
+func createConcurrentAccess(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  WITH spawned AS (
+    SELECT g.id AS go_id, g.parent_function AS parent_fn, sp.target AS closure_id
+    FROM nodes g
+    JOIN edges sp ON sp.source = g.id AND sp.kind = 'spawn'
+    JOIN nodes fl ON fl.id = sp.target AND fl.kind = 'function'
+    WHERE g.kind = 'go' AND g.parent_function IS NOT NULL
+  ),
+  shared AS (
+    SELECT DISTINCT s.go_id, s.parent_fn, s.closure_id, c.target AS var_id, ix.container_kind
+    FROM spawned s
+    JOIN edges c ON c.source = s.closure_id AND c.kind = 'capture'
+    JOIN index_sensitivity ix ON ix.node_id = c.target AND ix.container_kind IN ('map', 'slice')
+  ),
+  accesses AS (
+    SELECT r.target AS var_id, u.parent_function AS fn_id,
+      MAX(CASE WHEN json_extract(u.properties, '$.write') = 1 THEN 1 ELSE 0 END) AS writes
+    FROM edges r
+    JOIN nodes u ON u.id = r.source AND u.kind = 'identifier'
+    WHERE r.kind = 'ref' AND r.target IN (SELECT var_id FROM shared)
+    GROUP BY r.target, u.parent_function
+  )
+  SELECT 'concurrent_map_access', 'warning', sh.var_id, v.file, v.line,
+    sh.container_kind || ' ' || v.name || ' is shared with a goroutine and accessed in ' ||
+      COALESCE(fn.name, '?') || ' without a mutex',
+    json_object('container_kind', sh.container_kind, 'go_id', sh.go_id,
+                'closure_id', sh.closure_id, 'function_id', sh.parent_fn,
+                'writes_inside', inside.writes, 'writes_outside', outside.writes)
+  FROM shared sh
+  JOIN nodes v ON v.id = sh.var_id
+  LEFT JOIN nodes fn ON fn.id = sh.parent_fn
+  JOIN accesses inside ON inside.var_id = sh.var_id AND inside.fn_id = sh.closure_id
+  JOIN accesses outside ON outside.var_id = sh.var_id AND outside.fn_id = sh.parent_fn
+  WHERE (inside.writes = 1 OR outside.writes = 1)
+    AND NOT EXISTS (
+      SELECT 1 FROM nodes m
+      WHERE m.parent_function IN (sh.parent_fn, sh.closure_id)
+        AND m.kind = 'call'
+        AND json_extract(m.properties, '$.sync_kind') IN ('mutex_lock', 'rwmutex_lock', 'rwmutex_rlock')
+    );
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('finding', 'concurrent_map_access', 'Map/slice captured by a goroutine closure and accessed by its launcher with a write and no mutex', 'SELECT * FROM findings WHERE category = ''concurrent_map_access''');
+`
+	if err := execFindingsScript(conn, ddl); err != nil {
+		return fmt.Errorf("concurrent access: %w", err)
+	}
+
+	var count int
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM findings WHERE category = 'concurrent_map_access'",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			count = stmt.ColumnInt(0)
+			return nil
+		}})
+
+	prog.Log("Concurrent access: %d unsynchronized shared containers", count)
+	return nil
+}
+
+// createGoroutineCaptures lists, per go statement, the variables its closure
+// captures (capture edges of the spawned function literal). Go closures share
+// every captured variable with the launcher, but by_ref = 1 singles out those
+// whose pointee escapes to the goroutine too: pointer-typed variables and
+// variables whose address is taken (&v) in the launcher or the closure.
+func createGoroutineCaptures(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+CREATE TABLE goroutine_captures (
+    go_node_id TEXT NOT NULL,
+    captured_var_id TEXT NOT NULL,
+    captured_var_name TEXT NOT NULL,
+    by_ref INTEGER NOT NULL,
+    PRIMARY KEY (go_node_id, captured_var_id)
+);
+
+INSERT OR IGNORE INTO goroutine_captures (go_node_id, captured_var_id, captured_var_name, by_ref)
+  SELECT g.id, v.id, COALESCE(json_extract(c.properties, '$.var_name'), v.name),
+    CASE WHEN v.type_info LIKE '*%' OR EXISTS (
+      SELECT 1 FROM edges r
+      JOIN nodes u ON u.id = r.source AND u.kind = 'identifier'
+      JOIN edges a ON a.target = u.id AND a.kind = 'ast'
+      JOIN nodes amp ON amp.id = a.source AND amp.kind = 'unary_expr' AND amp.name = '&'
+      WHERE r.target = v.id AND r.kind = 'ref'
+        AND u.parent_function IN (g.parent_function, sp.target)
+    ) THEN 1 ELSE 0 END
+  FROM nodes g
+  JOIN edges sp ON sp.source = g.id AND sp.kind = 'spawn'
+  JOIN nodes fl ON fl.id = sp.target AND fl.kind = 'function'
+  JOIN edges c ON c.source = fl.id AND c.kind = 'capture'
+  JOIN nodes v ON v.id = c.target
+  WHERE g.kind = 'go';
+
+CREATE INDEX idx_goroutine_captures_var ON goroutine_captures(captured_var_id);
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('table', 'goroutine_captures', 'Variables captured by each go statement''s closure; by_ref = 1 for pointer-typed or address-taken (&v) variables', 'SELECT * FROM goroutine_captures WHERE by_ref = 1');
+`
+	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+		return fmt.Errorf("goroutine captures: %w", err)
+	}
+
+	var total, byRef int
+	if err := sqlitex.ExecuteTransient(conn, "SELECT COUNT(*), COALESCE(SUM(by_ref), 0) FROM goroutine_captures",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			total, byRef = stmt.ColumnInt(0), stmt.ColumnInt(1)
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("goroutine captures: %w", err)
+	}
+
+	prog.Log("Goroutine captures: %d captured variables, %d by reference", total, byRef)
+	return nil
+}
+
+// createGoroutineJoins links go statements to the sync.WaitGroup Wait calls of
+// the launching function (waitgroup_member edges) and flags functions whose
+// goroutines are never joined: no wg.Wait and no channel receive fed by a
+// chan_flow edge in the launching function.
+func createGoroutineJoins(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+INSERT INTO edges (source, target, kind, properties)
+  SELECT DISTINCT g.id, w.id, 'waitgroup_member', NULL
+  FROM nodes g
+  JOIN nodes w ON w.parent_function = g.parent_function AND w.kind = 'call'
+    AND json_extract(w.properties, '$.sync_kind') = 'wg_wait'
+  WHERE g.kind = 'go' AND g.parent_function IS NOT NULL;
+
+INSERT INTO findings (category, severity, node_id, file, line, message, details)
+  SELECT 'goroutine_not_awaited', 'info', fn.id, fn.file, fn.line,
+    fn.name || ' launches ' || sub.go_count || ' goroutine(s) it never waits for',
+    json_object('goroutine_count', sub.go_count, 'first_go_id', sub.first_go, 'package', fn.package)
+  FROM (
+    SELECT g.parent_function AS func_id, COUNT(*) AS go_count, MIN(g.id) AS first_go
+    FROM nodes g
+    WHERE g.kind = 'go' AND g.parent_function IS NOT NULL
+    GROUP BY g.parent_function
+  ) sub
+  JOIN nodes fn ON fn.id = sub.func_id AND fn.kind = 'function'
+  WHERE NOT EXISTS (
+      SELECT 1 FROM edges e WHERE e.source IN (
+        SELECT id FROM nodes WHERE kind = 'go' AND parent_function = fn.id)
+      AND e.kind = 'waitgroup_member'
+    )
+    AND NOT EXISTS (
+      SELECT 1 FROM edges cf JOIN nodes r ON r.id = cf.target
+      WHERE cf.kind = 'chan_flow' AND r.parent_function = fn.id
+    );
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('edge_kind', 'waitgroup_member', 'go statement→wg.Wait() call in the same function', NULL),
+('finding', 'goroutine_not_awaited', 'Function spawns goroutines with no wg.Wait() or channel receive joining them', 'SELECT * FROM findings WHERE category = ''goroutine_not_awaited''');
+`
+	if err := execFindingsScript(conn, ddl); err != nil {
+		return fmt.Errorf("goroutine joins: %w", err)
+	}
+
+	var edges, unawaited int
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM edges WHERE kind = 'waitgroup_member'",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			edges = stmt.ColumnInt(0)
+			return nil
+		}})
+	sqlitex.ExecuteTransient(conn, "SELECT COUNT(*) FROM findings WHERE category = 'goroutine_not_awaited'",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			unawaited = stmt.ColumnInt(0)
+			return nil
+		}})
+
+	prog.Log("Goroutine joins: %d waitgroup_member edges, %d functions with unawaited goroutines", edges, unawaited)
+	return nil
+}
+
 // createSCIPSymbols generates SCIP (Source Code Intelligence Protocol) compatible
 // symbol identifiers for cross-repository code navigation.
 func createSCIPSymbols(conn *sqlite.Conn, prog *Progress) error {
@@ -3526,6 +5941,326 @@ INSERT INTO queries (name, description, sql) VALUES
 	return nil
 }
 
+// createAPIFingerprint records every exported function, method, type and
+// struct field with its signature and a hash of it, so two CPGs can be diffed
+// for breaking API changes (see DiffAPI). Signatures come from the
+// api_signature property set during the AST walk; fields use their type.
+func createAPIFingerprint(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+CREATE TABLE api_fingerprint (
+    package TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    hash TEXT,
+    node_id TEXT,
+    PRIMARY KEY (package, symbol)
+);
+
+-- Functions and methods (methods keyed as Type.Method, receiver kind in signature)
+INSERT OR IGNORE INTO api_fingerprint (package, symbol, kind, signature, node_id)
+SELECT n.package, REPLACE(n.name, '*', ''),
+  CASE WHEN json_extract(n.properties, '$.receiver') IS NOT NULL THEN 'method' ELSE 'function' END,
+  json_extract(n.properties, '$.api_signature'), n.id
+FROM nodes n
+WHERE n.kind = 'function' AND n.package IS NOT NULL
+  AND json_extract(n.properties, '$.api_signature') IS NOT NULL;
+
+-- Types
+INSERT OR IGNORE INTO api_fingerprint (package, symbol, kind, signature, node_id)
+SELECT n.package, n.name, 'type', json_extract(n.properties, '$.api_signature'), n.id
+FROM nodes n
+WHERE n.kind = 'type_decl' AND n.package IS NOT NULL
+  AND json_extract(n.properties, '$.api_signature') IS NOT NULL;
+
+-- Exported fields of exported structs
+INSERT OR IGNORE INTO api_fingerprint (package, symbol, kind, signature, node_id)
+SELECT t.package, t.name || '.' || f.name, 'field', COALESCE(f.type_info, ''), f.id
+FROM nodes t
+JOIN edges e ON e.source = t.id AND e.kind = 'ast'
+JOIN nodes f ON f.id = e.target AND f.kind = 'field'
+WHERE t.kind = 'type_decl' AND t.package IS NOT NULL
+  AND json_extract(t.properties, '$.api_signature') = 'struct'
+  AND f.name GLOB '[A-Z]*';
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('table', 'api_fingerprint', 'Exported functions, methods, types and struct fields with signature hashes; compare two CPGs with cpg-gen api-diff', 'SELECT symbol, signature FROM api_fingerprint WHERE package = ''scrape'' AND kind = ''method''');
+`
+	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+		return fmt.Errorf("api fingerprint: %w", err)
+	}
+
+	// Hash in Go: SQLite has no built-in digest function.
+	type row struct{ pkg, symbol, sig string }
+	var rows []row
+	if err := sqlitex.ExecuteTransient(conn, "SELECT package, symbol, signature FROM api_fingerprint",
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			rows = append(rows, row{stmt.ColumnText(0), stmt.ColumnText(1), stmt.ColumnText(2)})
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("api fingerprint: %w", err)
+	}
+	for _, r := range rows {
+		h := fnv.New64a()
+		h.Write([]byte(r.sig))
+		if err := sqlitex.Execute(conn, "UPDATE api_fingerprint SET hash = ? WHERE package = ? AND symbol = ?",
+			&sqlitex.ExecOptions{Args: []any{fmt.Sprintf("%016x", h.Sum64()), r.pkg, r.symbol}}); err != nil {
+			return fmt.Errorf("api fingerprint hash: %w", err)
+		}
+	}
+
+	prog.Log("API fingerprint: %d exported symbols", len(rows))
+	return nil
+}
+
+// LoadSeverityMap reads a -severity-map file: a JSON object from finding
+// category to severity, e.g. {"long_param_list": "error"}.
+func LoadSeverityMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("-severity-map: %w", err)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("-severity-map %s: %w", path, err)
+	}
+	for cat, sev := range m {
+		if !slices.Contains(findingSeverities, sev) {
+			return nil, fmt.Errorf("-severity-map %s: %s: severity must be info, warning or error, got %q", path, cat, sev)
+		}
+	}
+	return m, nil
+}
+
+// applySeverityMap sets the severity of every finding whose category the
+// -severity-map names, in one UPDATE. Categories no pass produced are
+// reported, since they are usually typos.
+func applySeverityMap(conn *sqlite.Conn, m map[string]string, prog *Progress) error {
+	produced := make(map[string]bool)
+	if err := sqlitex.ExecuteTransient(conn, `SELECT DISTINCT category FROM findings`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			produced[stmt.ColumnText(0)] = true
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("severity map: %w", err)
+	}
+	for _, cat := range slices.Sorted(maps.Keys(m)) {
+		if !produced[cat] {
+			prog.Log("Warning: -severity-map category %q matches no findings", cat)
+		}
+	}
+
+	list, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("severity map: %w", err)
+	}
+	if err := sqlitex.ExecuteTransient(conn, `
+UPDATE findings SET severity = m.value
+FROM json_each(?) m
+WHERE m.key = findings.category AND findings.severity != m.value`,
+		&sqlitex.ExecOptions{Args: []any{string(list)}}); err != nil {
+		return fmt.Errorf("severity map: %w", err)
+	}
+	prog.Log("Severity map: %d findings remapped across %d categories", conn.Changes(), len(m))
+	return nil
+}
+
+// applySuppressions marks findings silenced by a //cpg:ignore or //nolint
+// comment (the comment node's suppresses property) with suppressed = 1. A
+// directive applies to findings on a line its comment group covers or on the
+// line right below it, so it can trail the flagged statement or sit above a
+// declaration. Findings without a position use their node's. Suppressed
+// findings are kept; v_active_findings hides them.
+func applySuppressions(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+UPDATE findings SET suppressed = 1
+WHERE id IN (
+  SELECT f.id
+  FROM findings f
+  LEFT JOIN nodes n ON n.id = f.node_id
+  JOIN nodes c ON c.kind = 'comment' AND c.file = COALESCE(f.file, n.file)
+    AND COALESCE(f.line, n.line) BETWEEN c.line AND c.end_line + 1
+  JOIN json_each(c.properties, '$.suppresses') s
+  WHERE json_extract(c.properties, '$.suppresses') IS NOT NULL
+    AND s.value IN ('*', f.category)
+);
+
+CREATE VIEW v_active_findings AS
+  SELECT * FROM findings WHERE suppressed = 0;
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('view', 'v_active_findings', 'findings minus those silenced by a //cpg:ignore <category> or //nolint[:<category>] comment on or above their line (suppressed = 1)', 'SELECT category, COUNT(*) FROM v_active_findings GROUP BY category'),
+('node_property', 'suppresses', 'Comment carrying //cpg:ignore or //nolint directives: the finding categories it silences ("*" for all)', '["complexity"]');
+`
+	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+		return fmt.Errorf("suppressions: %w", err)
+	}
+	var count int64
+	if err := sqlitex.ExecuteTransient(conn, `SELECT COUNT(*) FROM findings WHERE suppressed = 1`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			count = stmt.ColumnInt64(0)
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("suppressions: %w", err)
+	}
+	prog.Log("Suppressions: %d findings silenced by comments", count)
+	return nil
+}
+
+// queryParamTypes declares the type (text, integer or real) of each :param
+// used by the saved queries; queries share names with a single meaning. A
+// saved query using a parameter missing here fails generation.
+var queryParamTypes = map[string]string{
+	"block_id":       "text",
+	"end":            "text",
+	"file":           "text",
+	"function_a":     "text",
+	"function_b":     "text",
+	"function_id":    "text",
+	"global_id":      "text",
+	"id":             "text",
+	"interface_id":   "text",
+	"key":            "text",
+	"min_confidence": "text",
+	"name":           "text",
+	"node_id":        "text",
+	"pattern":        "text",
+	"q":              "text",
+	"receiver_type":  "text",
+	"source_id":      "text",
+	"start":          "text",
+	"type_name":      "text",
+}
+
+// optionalQueryParams lists query.param pairs whose SQL handles NULL; every
+// other parameter is required.
+var optionalQueryParams = map[string]bool{
+	"backward_slice.min_confidence": true,
+	"forward_slice.min_confidence":  true,
+	"fields_by_tag.name":            true,
+}
+
+var (
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNamedParam    = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// createQueryParams fills query_params with the :param placeholders of every
+// saved query (string literals are skipped) and their declared types, so
+// clients such as cpg-server can validate bindings before running a query.
+func createQueryParams(conn *sqlite.Conn) error {
+	if err := sqlitex.ExecuteScript(conn, `
+CREATE TABLE query_params (
+    query_name TEXT NOT NULL,
+    param_name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    required INTEGER NOT NULL,
+    PRIMARY KEY (query_name, param_name)
+);
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('table', 'query_params', 'Declared :param bindings of each saved query: type (text, integer, real) and whether it is required (optional ones accept NULL)', 'SELECT * FROM query_params WHERE query_name = ''backward_slice''');
+`, nil); err != nil {
+		return fmt.Errorf("query params: %w", err)
+	}
+
+	type param struct{ query, name string }
+	var params []param
+	if err := sqlitex.ExecuteTransient(conn, `SELECT name, sql FROM queries ORDER BY name`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			name := stmt.ColumnText(0)
+			code := sqlStringLiteral.ReplaceAllString(stmt.ColumnText(1), "''")
+			for _, m := range sqlNamedParam.FindAllStringSubmatch(code, -1) {
+				params = append(params, param{name, m[1]})
+			}
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("query params: %w", err)
+	}
+
+	for _, p := range params {
+		typ, ok := queryParamTypes[p.name]
+		if !ok {
+			return fmt.Errorf("query params: %s uses undeclared parameter :%s", p.query, p.name)
+		}
+		if err := sqlitex.ExecuteTransient(conn,
+			`INSERT OR IGNORE INTO query_params (query_name, param_name, type, required) VALUES (?, ?, ?, ?)`,
+			&sqlitex.ExecOptions{Args: []any{p.query, p.name, typ, !optionalQueryParams[p.query+"."+p.name]}}); err != nil {
+			return fmt.Errorf("query params: %w", err)
+		}
+	}
+	return nil
+}
+
+// createManifest records every table and view in cpg_manifest with its row
+// count, so consumers can detect schema drift and absent optional tables
+// (e.g. git_history without git) without probing sqlite_master themselves.
+// Views are listed with a NULL row_count: counting them would re-run their
+// queries. Runs last so it sees every generated object.
+func createManifest(conn *sqlite.Conn, prog *Progress) error {
+	ddl := `
+CREATE TABLE cpg_manifest (
+    object_type TEXT NOT NULL,
+    name TEXT PRIMARY KEY,
+    row_count INTEGER,
+    generator_version TEXT NOT NULL
+);
+
+INSERT INTO schema_docs (category, name, description, example) VALUES
+('table', 'cpg_manifest', 'Every generated table/view with its row count and the generator version', 'SELECT name, row_count FROM cpg_manifest WHERE object_type = ''table'' ORDER BY name');
+`
+	if err := sqlitex.ExecuteScript(conn, ddl, nil); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	// pragma_table_list types FTS internals as 'shadow', which are skipped.
+	type object struct{ name, kind string }
+	var objects []object
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT name, type FROM pragma_table_list
+		 WHERE schema = 'main' AND type IN ('table', 'view', 'virtual') AND name NOT LIKE 'sqlite_%'
+		 ORDER BY name`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			objects = append(objects, object{stmt.ColumnText(0), stmt.ColumnText(1)})
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	tables := 0
+	for _, o := range objects {
+		kind := o.kind
+		var count any
+		if kind == "virtual" {
+			kind = "table"
+		}
+		if kind == "table" {
+			tables++
+			if o.name == "cpg_manifest" {
+				count = len(objects)
+			} else {
+				var n int64
+				if err := sqlitex.ExecuteTransient(conn, `SELECT COUNT(*) FROM "`+o.name+`"`,
+					&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+						n = stmt.ColumnInt64(0)
+						return nil
+					}}); err != nil {
+					return fmt.Errorf("manifest count %s: %w", o.name, err)
+				}
+				count = n
+			}
+		}
+		if err := sqlitex.Execute(conn,
+			"INSERT INTO cpg_manifest (object_type, name, row_count, generator_version) VALUES (?, ?, ?, ?)",
+			&sqlitex.ExecOptions{Args: []any{kind, o.name, count, generatorVersion}}); err != nil {
+			return fmt.Errorf("manifest: %w", err)
+		}
+	}
+
+	prog.Log("Manifest: %d tables, %d views", tables, len(objects)-tables)
+	return nil
+}
+
 // createCommunicationPatterns builds Honda session type-inspired protocol
 // analysis connecting Prometheus with its ecosystem services (adapter, alertmanager, etc.).
 // Inspired by Honda 1998 (binary session types) and Honda 2008 (multiparty asynchronous session types).
@@ -0,0 +1,2528 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+const receiverFixture = `package fixture
+
+type Counter struct{ n int }
+
+// Inc assigns to a copy of the receiver: the increment is lost.
+func (c Counter) Inc() { c.n++ }
+
+// Reset assigns a field on a value receiver.
+func (c Counter) Reset() { c.n = 0 }
+
+type Point struct{ X, Y int }
+
+// Sum only reads the receiver.
+func (p Point) Sum() int { return p.X + p.Y }
+
+// WithX mutates the copy and returns it (builder idiom).
+func (p Point) WithX(x int) Point { p.X = x; return p }
+
+type Reader struct{ name string }
+
+// Name never mutates the receiver.
+func (r *Reader) Name() string { return r.name }
+
+func Describe(r *Reader, p Point) string { return r.Name() + string(rune(p.Sum())) }
+`
+
+func TestValueReceiverMutation(t *testing.T) {
+	conn := buildTestDB(t, receiverFixture)
+	got := queryStrings(t, conn,
+		`SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id
+		 WHERE f.category = 'value_receiver_mutation' AND f.severity = 'warning'
+		 ORDER BY n.name`)
+	want := []string{"Counter.Inc", "Counter.Reset"}
+	if len(got) != len(want) {
+		t.Fatalf("value_receiver_mutation findings = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("finding %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValueReceiverPureHasNoFinding(t *testing.T) {
+	conn := buildTestDB(t, receiverFixture)
+	got := queryStrings(t, conn,
+		`SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id
+		 WHERE f.category = 'value_receiver_mutation' AND n.name IN ('Point.Sum', 'Point.WithX')`)
+	if len(got) != 0 {
+		t.Errorf("unexpected value_receiver_mutation findings: %v", got)
+	}
+}
+
+func TestPointerReceiverCouldBeValue(t *testing.T) {
+	conn := buildTestDB(t, receiverFixture)
+	got := queryStrings(t, conn,
+		`SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id
+		 WHERE f.category = 'pointer_receiver_could_be_value' AND f.severity = 'info'`)
+	if len(got) != 1 || got[0] != "*Reader.Name" {
+		t.Errorf("pointer_receiver_could_be_value findings = %v, want [*Reader.Name]", got)
+	}
+}
+
+const concurrentFixture = `package fixture
+
+import "sync"
+
+// Racy writes the map in a goroutine and reads it in the parent.
+func Racy() int {
+	m := map[string]int{}
+	go func() {
+		m["a"] = 1
+	}()
+	return m["a"]
+}
+
+// Guarded does the same under a mutex.
+func Guarded() int {
+	var mu sync.Mutex
+	m := map[string]int{}
+	go func() {
+		mu.Lock()
+		m["a"] = 1
+		mu.Unlock()
+	}()
+	mu.Lock()
+	defer mu.Unlock()
+	return m["a"]
+}
+`
+
+func TestConcurrentMapAccess(t *testing.T) {
+	conn := buildTestDB(t, concurrentFixture)
+	got := queryStrings(t, conn,
+		`SELECT fn.name FROM findings f
+		 JOIN nodes fn ON fn.id = json_extract(f.details, '$.function_id')
+		 WHERE f.category = 'concurrent_map_access' AND f.severity = 'warning'`)
+	if len(got) != 1 || got[0] != "Racy" {
+		t.Errorf("concurrent_map_access findings in %v, want [Racy]", got)
+	}
+}
+
+func TestExternalSignatures(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "fmt"
+
+func Label(n int) string { return fmt.Sprintf("n=%d", n) }
+`)
+	got := queryStrings(t, conn,
+		`SELECT params || ' ' || results || ' ' || variadic FROM external_signatures WHERE id = 'ext::fmt.Sprintf'`)
+	want := `["string","...any"] ["string"] 1`
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ext::fmt.Sprintf signature = %v, want %q", got, want)
+	}
+}
+
+func TestDeferredRecoverToError(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "fmt"
+
+func Safe(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func Plain(f func()) (err error) {
+	defer func() { recover() }()
+	f()
+	return nil
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT t.kind || ' ' || t.name FROM edges e JOIN nodes t ON t.id = e.target
+		 WHERE e.kind = 'error_recovery'`)
+	if len(got) != 1 || got[0] != "result err" {
+		t.Errorf("error_recovery targets = %v, want [result err]", got)
+	}
+	got = queryStrings(t, conn,
+		`SELECT n.name FROM nodes n JOIN node_properties np ON np.node_id = n.id
+		 WHERE np.key = 'recovers_to_error' AND n.kind = 'function'`)
+	if len(got) != 1 || got[0] != "Safe" {
+		t.Errorf("recovers_to_error functions = %v, want [Safe]", got)
+	}
+}
+
+func TestLibraryTerminatesProcess(t *testing.T) {
+	const body = `
+
+import "log"
+
+func Load(path string) {
+	if path == "" {
+		log.Fatalf("no path")
+	}
+}
+`
+	lib := buildTestDB(t, "package fixture"+body)
+	got := queryStrings(t, lib,
+		`SELECT json_extract(details, '$.call') FROM findings
+		 WHERE category = 'library_terminates_process' AND severity = 'warning'`)
+	if len(got) != 1 || got[0] != "log.Fatalf" {
+		t.Errorf("library findings = %v, want [log.Fatalf]", got)
+	}
+
+	cmd := buildTestDB(t, "package main"+body+"\nfunc main() { Load(\"x\") }\n")
+	got = queryStrings(t, cmd,
+		`SELECT message FROM findings WHERE category = 'library_terminates_process'`)
+	if len(got) != 0 {
+		t.Errorf("unexpected findings in package main: %v", got)
+	}
+}
+
+func TestUnreachableCode(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func After(x int) int {
+	return x
+	x++
+	return x + 1
+}
+
+func Both(x int) int {
+	if x > 0 {
+		return 1
+	} else {
+		panic("neg")
+	}
+	return 0
+}
+
+func Fall(x int) int {
+	n := 0
+	switch x {
+	case 0:
+		n++
+		fallthrough
+	case 1:
+		n++
+	default:
+		n = After(x) + Both(x)
+	}
+	return n
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT fn.name || ':' || s.kind FROM findings f
+		 JOIN nodes s ON s.id = f.node_id JOIN nodes fn ON fn.id = s.parent_function
+		 WHERE f.category = 'unreachable_code' ORDER BY f.line`)
+	want := []string{"After:inc_dec", "Both:return"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("unreachable_code findings = %v, want %v", got, want)
+	}
+}
+
+const goroutineFixture = `package fixture
+
+import "sync"
+
+// Awaited joins its goroutines with a WaitGroup.
+func Awaited(jobs []int) {
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = j
+		}()
+	}
+	wg.Wait()
+}
+
+// Joined receives the goroutine's result over a channel.
+func Joined() int {
+	ch := make(chan int)
+	go func() { ch <- 1 }()
+	return <-ch
+}
+
+// FireAndForget never waits.
+func FireAndForget() {
+	go func() {}()
+}
+
+func Run() int {
+	Awaited(nil)
+	FireAndForget()
+	return Joined()
+}
+`
+
+func TestGoroutineJoins(t *testing.T) {
+	conn := buildTestDB(t, goroutineFixture)
+	got := queryStrings(t, conn,
+		`SELECT fn.name FROM edges e JOIN nodes g ON g.id = e.source
+		 JOIN nodes fn ON fn.id = g.parent_function
+		 WHERE e.kind = 'waitgroup_member'`)
+	if len(got) != 1 || got[0] != "Awaited" {
+		t.Errorf("waitgroup_member edges from %v, want [Awaited]", got)
+	}
+	got = queryStrings(t, conn,
+		`SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id
+		 WHERE f.category = 'goroutine_not_awaited'`)
+	if len(got) != 1 || got[0] != "FireAndForget" {
+		t.Errorf("goroutine_not_awaited findings = %v, want [FireAndForget]", got)
+	}
+}
+
+func TestMainSequenceOutlier(t *testing.T) {
+	// core is concrete and used by three packages while depending on none:
+	// I = 0, A = 0, so D = 1 (zone of pain).
+	files := map[string]string{
+		"core/core.go": `package core
+
+type Store struct{ n int }
+
+func New() *Store { return &Store{} }
+
+func (s *Store) Add() { s.n++ }
+`,
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		files[name+"/"+name+".go"] = "package " + name + `
+
+import "example.com/fixture/core"
+
+func Use() { core.New().Add() }
+`
+	}
+	conn, err := sqlite.OpenConn(writeTestDB(t, buildTestCPGFiles(t, files)), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn,
+		`SELECT json_extract(details, '$.package') || ':' || json_extract(details, '$.zone')
+		 FROM findings WHERE category = 'main_sequence_outlier'`)
+	if len(got) != 1 || got[0] != "core:pain" {
+		t.Errorf("main_sequence_outlier findings = %v, want [core:pain]", got)
+	}
+}
+
+func TestReceiverCarriedTaint(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Render executes a template whose text came from the environment.
+func Render(w http.ResponseWriter) error {
+	tmpl, err := template.New("page").Parse(os.Getenv("TPL"))
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, nil)
+}
+
+// Build runs a command line accumulated in a Builder.
+func Build() error {
+	var b strings.Builder
+	b.WriteString(os.Getenv("CMD"))
+	return exec.Command(b.String()).Run()
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT DISTINCT n.name FROM taint_flow_state tfs JOIN nodes n ON n.id = tfs.node_id
+		 WHERE tfs.label = 'sink_reached' ORDER BY n.name`)
+	if strings.Join(got, ",") != "Run,exec.Command,tmpl.Execute" {
+		t.Errorf("sinks reached = %v, want [Run exec.Command tmpl.Execute]", got)
+	}
+}
+
+func TestTaintMaxHops(t *testing.T) {
+	// os.Getenv -> TrimSpace -> ToLower -> exec.Command: the sink is 3 hops
+	// from the source
+	const src = `package fixture
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func Run() *exec.Cmd {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("CMD")))
+	return exec.Command(name)
+}
+`
+	prev := taintMaxHops
+	t.Cleanup(func() { taintMaxHops = prev })
+	for _, tc := range []struct {
+		hops int
+		want string
+	}{
+		{2, ""},
+		{4, "exec.Command:3"},
+	} {
+		taintMaxHops = tc.hops
+		conn := buildTestDB(t, src)
+		got := queryStrings(t, conn, `SELECT n.name || ':' || t.min_hops FROM taint_flow_state t
+			JOIN nodes n ON n.id = t.node_id WHERE t.label = 'sink_reached'`)
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("-taint-max-hops %d: sinks reached = %v, want [%s]", tc.hops, got, tc.want)
+		}
+		if got := queryStrings(t, conn, `SELECT MAX(min_hops) FROM taint_flow_state`); got[0] != fmt.Sprint(tc.hops) {
+			t.Errorf("-taint-max-hops %d: deepest taint state at %s hops", tc.hops, got[0])
+		}
+	}
+}
+
+func TestTaintPathEdges(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func Run() error {
+	name := strings.TrimSpace(os.Getenv("CMD"))
+	return exec.Command(name).Run()
+}
+`)
+	// Every sink reach walks back through parent pointers to its source in
+	// exactly min_hops steps.
+	got := queryStrings(t, conn, `
+		WITH RECURSIVE walk(source_id, sink_id, node_id, steps) AS (
+		  SELECT source_id, node_id, node_id, 0 FROM taint_flow_state WHERE label = 'sink_reached'
+		  UNION ALL
+		  SELECT w.source_id, w.sink_id, p.parent_id, w.steps + 1
+		  FROM walk w JOIN taint_path_edges p ON p.source_id = w.source_id AND p.node_id = w.node_id
+		)
+		SELECT n.name || ':' || (w.node_id = w.source_id) || ':' || (w.steps = t.min_hops)
+		FROM walk w
+		JOIN taint_flow_state t ON t.source_id = w.source_id AND t.node_id = w.sink_id
+		JOIN nodes n ON n.id = w.sink_id
+		WHERE NOT EXISTS (SELECT 1 FROM taint_path_edges p WHERE p.source_id = w.source_id AND p.node_id = w.node_id)
+		GROUP BY w.source_id, w.sink_id`)
+	if len(got) == 0 {
+		t.Fatal("no sink reaches")
+	}
+	for _, g := range got {
+		if !strings.HasSuffix(g, ":1:1") {
+			t.Errorf("path for sink %s does not end at its source in min_hops steps", g)
+		}
+	}
+}
+
+func TestManifestRowCounts(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func helper() int { return 1 }
+
+func Use() int { return helper() + 1 }
+`)
+	for _, table := range []string{"nodes", "edges", "dashboard_overview"} {
+		got := queryStrings(t, conn,
+			`SELECT object_type || ':' || row_count || ':' || generator_version FROM cpg_manifest WHERE name = ?`, table)
+		want := queryStrings(t, conn, `SELECT 'table:' || COUNT(*) || ':1.0' FROM `+table)
+		if len(got) != 1 || got[0] != want[0] {
+			t.Errorf("manifest %s = %v, want %v", table, got, want)
+		}
+	}
+	got := queryStrings(t, conn, `SELECT COUNT(*) FROM cpg_manifest WHERE object_type = 'view' AND row_count IS NULL`)
+	if got[0] == "0" {
+		t.Error("manifest lists no views")
+	}
+}
+
+func TestContextNotCheckedInLoop(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "context"
+
+func work(n int) int { return n * 2 }
+
+// Drain stops when ctx is cancelled.
+func Drain(ctx context.Context, jobs <-chan int) int {
+	total := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return total
+		case j := <-jobs:
+			total += work(j)
+		}
+	}
+}
+
+// Spin cannot be cancelled.
+func Spin(ctx context.Context, jobs []int) int {
+	total := 0
+	for _, j := range jobs {
+		for i := 0; i < j; i++ {
+			total += work(i)
+		}
+	}
+	return total
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT fn.name || ':' || f.line FROM findings f
+		 JOIN nodes fn ON fn.id = json_extract(f.details, '$.function_id')
+		 WHERE f.category = 'context_not_checked_in_loop'`)
+	if len(got) != 1 || got[0] != "Spin:23" {
+		t.Errorf("context_not_checked_in_loop findings = %v, want [Spin:23]", got)
+	}
+}
+
+func TestErrorEqualityComparison(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"errors"
+	"io"
+)
+
+var errLocal = errors.New("local")
+
+func Read(r io.Reader, buf []byte) (int, error) {
+	n, err := r.Read(buf)
+	if err == io.EOF {
+		return n, nil
+	}
+	if errors.Is(err, io.EOF) || err == nil {
+		return n, nil
+	}
+	if errLocal != err {
+		return 0, err
+	}
+	return n, err
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT json_extract(details, '$.sentinel') || ':' || line FROM findings
+		 WHERE category = 'error_equality_comparison' ORDER BY line`)
+	if strings.Join(got, ",") != "io.EOF:12,fixture.errLocal:18" {
+		t.Errorf("error_equality_comparison findings = %v, want [io.EOF:12 fixture.errLocal:18]", got)
+	}
+}
+
+func TestTickerNotStopped(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "time"
+
+func Poll(work func()) {
+	t := time.NewTicker(time.Second)
+	for range t.C {
+		work()
+	}
+}
+
+func PollStopped(work func()) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for range t.C {
+		work()
+	}
+}
+
+func Deadline() *time.Timer {
+	t := time.NewTimer(time.Minute)
+	return t
+}
+
+func Wait() {
+	t := time.NewTimer(time.Second)
+	<-t.C
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.call') FROM findings WHERE category = 'ticker_not_stopped' ORDER BY line`)
+	if want := "6:time.NewTicker,26:time.NewTimer"; strings.Join(got, ",") != want {
+		t.Errorf("ticker_not_stopped = %v, want [%s]", got, want)
+	}
+}
+
+func TestQueryInLoop(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+func Names(db *sql.DB, ids []int) []string {
+	var names []string
+	for _, id := range ids {
+		var n string
+		if err := db.QueryRow("SELECT name FROM t WHERE id = ?", id).Scan(&n); err == nil {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+func All(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM t")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+func Ping(urls []string) {
+	for _, u := range urls {
+		for i := 0; i < 3; i++ {
+			if resp, err := http.Get(u); err == nil {
+				resp.Body.Close()
+				break
+			}
+		}
+	}
+}
+
+func Header(db *sql.DB) {
+	for rows, _ := db.Query("SELECT name FROM t"); rows.Next(); {
+	}
+}
+`)
+	got := queryStrings(t, conn, `SELECT f.line || ':' || json_extract(f.details, '$.remote_call') || ':' || l.line
+		FROM findings f JOIN nodes l ON l.id = json_extract(f.details, '$.loop_id')
+		WHERE f.category = 'query_in_loop' ORDER BY f.line`)
+	if want := "12:db:10,39:http:38"; strings.Join(got, ",") != want {
+		t.Errorf("query_in_loop line:remote_call:loop line = %v, want [%s]", got, want)
+	}
+}
+
+func TestRedundantNilCheck(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Made(keys []string) map[string]int {
+	m := make(map[string]int)
+	if m != nil {
+		for i, k := range keys {
+			m[k] = i
+		}
+	}
+	return m
+}
+
+func Param(m map[string]int) int {
+	if m == nil {
+		return 0
+	}
+	return len(m)
+}
+
+type T struct{ n int }
+
+func Fresh() int {
+	p := &T{}
+	if p == nil || p.n > 0 {
+		return 1
+	}
+	return 0
+}
+`)
+	got := queryStrings(t, conn, `SELECT f.line || ':' || json_extract(f.details, '$.operator') || ':' || src.name
+		FROM findings f JOIN nodes src ON src.id = json_extract(f.details, '$.source_id')
+		WHERE f.category = 'redundant_nil_check' ORDER BY f.line`)
+	if want := "5:!=:make,24:==:T"; strings.Join(got, ",") != want {
+		t.Errorf("redundant_nil_check = %v, want [%s]", got, want)
+	}
+}
+
+func TestUnkeyedStructLiteral(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"image"
+	"net"
+)
+
+type T struct {
+	N    int
+	Name string
+}
+
+func Build(ip net.IP) []any {
+	return []any{
+		T{1, "x"},
+		T{N: 2},
+		net.TCPAddr{ip, 80, ""},
+		image.Point{1, 2},
+		struct{ a, b int }{1, 2},
+	}
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || severity || ':' || json_extract(details, '$.struct_fields')
+		FROM findings WHERE category = 'unkeyed_struct_literal' ORDER BY line`)
+	if want := "15:info:2,17:warning:3"; strings.Join(got, ",") != want {
+		t.Errorf("unkeyed_struct_literal = %v, want [%s]", got, want)
+	}
+}
+
+func TestResponseBodyNotClosed(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"io"
+	"net/http"
+)
+
+func Status(url string) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+func Fetch(c *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func Open(url string) (*http.Response, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Header
+	return resp, nil
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT fn.name || ':' || f.line FROM findings f
+		 JOIN nodes fn ON fn.id = json_extract(f.details, '$.function_id')
+		 WHERE f.category = 'response_body_not_closed'`)
+	if len(got) != 1 || got[0] != "Status:9" {
+		t.Errorf("response_body_not_closed findings = %v, want [Status:9]", got)
+	}
+}
+
+func TestInternalPrefixStubs(t *testing.T) {
+	prev := internalPrefixes
+	t.Cleanup(func() { internalPrefixes = prev })
+	// Any package outside the fixture module stands in for a first-party dep;
+	// the example.com/ prefix also covers the fixture, which must stay analyzed.
+	internalPrefixes = []string{"strconv", "example.com/"}
+
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func Label(n int) string {
+	return fmt.Sprint("n=", strconv.Itoa(n))
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT id || ':' || json_extract(properties, '$.external') || ':' ||
+		   COALESCE(json_extract(properties, '$.source_file'), '-')
+		 FROM nodes WHERE id IN ('int::strconv.Itoa', 'ext::fmt.Sprint', 'ext::strconv.Itoa') ORDER BY id`)
+	// The file declaring Itoa moves between Go releases: find it in GOROOT
+	if len(got) != 2 || got[0] != "ext::fmt.Sprint:1:-" || !strings.HasPrefix(got[1], "int::strconv.Itoa:0:") {
+		t.Errorf("stubs = %v", got)
+	} else {
+		goroot, err := exec.Command("go", "env", "GOROOT").Output()
+		if err != nil {
+			t.Fatalf("go env GOROOT: %v", err)
+		}
+		file := strings.TrimPrefix(got[1], "int::strconv.Itoa:0:")
+		src, err := os.ReadFile(filepath.Join(strings.TrimSpace(string(goroot)), "src", "strconv", file))
+		if err != nil || !strings.Contains(string(src), "\nfunc Itoa(") {
+			t.Errorf("int::strconv.Itoa source_file = %q, not the file declaring Itoa (%v)", file, err)
+		}
+	}
+	sigs := queryStrings(t, conn, `SELECT id FROM external_signatures WHERE id = 'int::strconv.Itoa'`)
+	if len(sigs) != 1 {
+		t.Errorf("int:: stub missing from external_signatures")
+	}
+	fns := queryStrings(t, conn, `SELECT name FROM nodes WHERE kind = 'function' AND package = 'main'`)
+	if strings.Join(fns, ",") != "Label" {
+		t.Errorf("fixture functions = %v, want [Label] (analyzed module beats prefix)", fns)
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+type User struct {
+	ID    int    `+"`json:\"id\" db:\"user_id\"`"+`
+	Email string `+"`json:\",omitempty\"`"+`
+	Name  string
+	note  string
+}
+
+func NewUser(id int) User { return User{ID: id, note: label(id)} }
+
+func label(id int) string { return "u" }
+`)
+	tags := queryStrings(t, conn,
+		`SELECT f.name || ':' || st.tag_key || '=' || COALESCE(st.tag_name, '-') || '/' || COALESCE(st.options, '-')
+		 FROM struct_tags st JOIN nodes f ON f.id = st.field_id ORDER BY f.line, st.tag_key`)
+	if strings.Join(tags, ",") != "ID:db=user_id/-,ID:json=id/-,Email:json=-/omitempty" {
+		t.Errorf("struct_tags = %v", tags)
+	}
+	missing := queryStrings(t, conn,
+		`SELECT n.name FROM findings f JOIN nodes n ON n.id = f.node_id WHERE f.category = 'json_tag_missing'`)
+	if len(missing) != 1 || missing[0] != "Name" {
+		t.Errorf("json_tag_missing findings = %v, want [Name]", missing)
+	}
+}
+
+func TestPathCounts(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Linear(n int) int {
+	n++
+	return double(n)
+}
+
+func ThreeIfs(a, b, c bool) int {
+	n := 0
+	if a {
+		n++
+	}
+	if b {
+		n += 2
+	}
+	if c {
+		n += 4
+	}
+	return n
+}
+
+func Loop(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		if x > 0 {
+			n += x
+		}
+	}
+	return n
+}
+
+func double(n int) int { return n * 2 }
+`)
+	got := queryStrings(t, conn,
+		`SELECT n.name || '=' || m.path_count FROM metrics m JOIN nodes n ON n.id = m.function_id
+		 WHERE n.name IN ('Linear', 'ThreeIfs', 'Loop') ORDER BY n.name`)
+	// Loop (Ball–Larus): from entry and again from the loop header, one path
+	// straight to the exit plus two body paths ending at the back edge.
+	if strings.Join(got, ",") != "Linear=1,Loop=6,ThreeIfs=8" {
+		t.Errorf("path counts = %v", got)
+	}
+}
+
+func TestGodType(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package fixture\n\n")
+	for _, name := range []string{"Big", "Wide"} {
+		fmt.Fprintf(&src, "type %s struct {\n", name)
+		for i := range 21 {
+			fmt.Fprintf(&src, "\tF%d int\n", i)
+		}
+		src.WriteString("}\n\n")
+	}
+	for i := range 16 {
+		fmt.Fprintf(&src, "func (b *Big) M%d() int { return b.F%d + helper() }\n", i, i)
+	}
+	src.WriteString("func (w Wide) Sum() int { return w.F0 + helper() }\n")
+	src.WriteString("func helper() int { return 1 }\n")
+
+	conn := buildTestDB(t, src.String())
+	got := queryStrings(t, conn,
+		`SELECT n.name || ':' || json_extract(f.details, '$.field_count') || '/' || json_extract(f.details, '$.method_count')
+		 FROM findings f JOIN nodes n ON n.id = f.node_id WHERE f.category = 'god_type'`)
+	if len(got) != 1 || got[0] != "Big:21/16" {
+		t.Errorf("god_type findings = %v, want [Big:21/16]", got)
+	}
+}
+
+func TestTopNLimitsLeaderboards(t *testing.T) {
+	prev := leaderboardLimit
+	t.Cleanup(func() { leaderboardLimit = prev })
+	leaderboardLimit = 2
+
+	conn := buildTestDB(t, `package fixture
+
+func a(n int) int { if n > 0 { return 1 }; return 0 }
+func b(n int) int { if n > 1 { return 2 }; return 0 }
+func c(n int) int { if n > 2 { return 3 }; return 0 }
+
+func All(n int) int { return a(n) + b(n) + c(n) }
+`)
+	got := queryStrings(t, conn,
+		`SELECT metric || ':' || COUNT(*) FROM dashboard_top_functions
+		 WHERE metric IN ('complexity', 'loc') GROUP BY metric ORDER BY metric`)
+	if strings.Join(got, ",") != "complexity:2,loc:2" {
+		t.Errorf("dashboard_top_functions rows per metric = %v, want 2 each", got)
+	}
+	if n := queryStrings(t, conn, `SELECT COUNT(*) FROM dashboard_hotspots`); n[0] != "2" {
+		t.Errorf("dashboard_hotspots rows = %v, want 2", n)
+	}
+}
+
+func TestGoroutineCapturesLoopVar(t *testing.T) {
+	src := `package fixture
+
+func Spawn(items []string, out chan<- string) {
+	for _, it := range items {
+		go func() {
+			out <- it
+		}()
+	}
+	for i := 0; i < len(items); i++ {
+		go func() {
+			out <- items[i]
+		}()
+	}
+	for _, it := range items {
+		go func(s string) {
+			out <- s
+		}(it)
+	}
+}
+`
+	for _, tc := range []struct {
+		goVersion string
+		want      string
+	}{
+		{"1.21", "it:5,i:10"},
+		{"1.22", ""},
+	} {
+		t.Run("go"+tc.goVersion, func(t *testing.T) {
+			cpg := buildTestCPGFiles(t, map[string]string{
+				"go.mod":     "module example.com/fixture\n\ngo " + tc.goVersion + "\n",
+				"fixture.go": src,
+			})
+			conn, err := sqlite.OpenConn(writeTestDB(t, cpg), sqlite.OpenReadOnly)
+			if err != nil {
+				t.Fatalf("open db: %v", err)
+			}
+			defer conn.Close()
+
+			got := queryStrings(t, conn, `SELECT json_extract(details, '$.variable') || ':' || line FROM findings
+				WHERE category = 'goroutine_captures_loop_var' ORDER BY line`)
+			if strings.Join(got, ",") != tc.want {
+				t.Errorf("goroutine_captures_loop_var = %v, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOnlyFindings(t *testing.T) {
+	src := `package fixture
+
+import "io"
+
+func IsEOF(err error) bool {
+	return err == io.EOF
+}
+`
+	cpg := buildTestCPG(t, src)
+	prev := onlyFindings
+	t.Cleanup(func() { onlyFindings = prev })
+	onlyFindings = true
+
+	conn, err := sqlite.OpenConn(writeTestDB(t, cpg), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn, `SELECT category FROM findings WHERE category = 'error_equality_comparison'`)
+	if len(got) != 1 {
+		t.Errorf("error_equality_comparison findings = %v, want 1", got)
+	}
+	for _, table := range []string{"comm_protocols", "dashboard_hotspots", "scip_symbols", "xrefs"} {
+		if n := queryStrings(t, conn, `SELECT name FROM sqlite_master WHERE name = ?`, table); len(n) != 0 {
+			t.Errorf("table %s exists with -only-findings", table)
+		}
+	}
+	if n := queryStrings(t, conn, `SELECT name FROM cpg_manifest WHERE name = 'findings'`); len(n) != 1 {
+		t.Errorf("manifest lacks findings: %v", n)
+	}
+}
+
+func TestSelectorChainDFG(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"net/http"
+	"os/exec"
+)
+
+type wrapper struct{ req *http.Request }
+
+func Handle(w wrapper) error {
+	v := w.req.Header.Get("X")
+	return exec.Command(v).Run()
+}
+
+func Store(w *wrapper, r *http.Request) {
+	w.req = r
+}
+`)
+	// Forward dfg closure from the base identifier w on line 11
+	got := queryStrings(t, conn, `
+		WITH RECURSIVE reach(id) AS (
+		  SELECT id FROM nodes WHERE kind = 'identifier' AND name = 'w' AND line = 11
+		  UNION
+		  SELECT e.target FROM reach r JOIN edges e ON e.source = r.id AND e.kind = 'dfg'
+		)
+		SELECT n.kind || ':' || n.name FROM reach r JOIN nodes n ON n.id = r.id
+		WHERE n.kind IN ('selector', 'call') ORDER BY n.line, n.col`)
+	want := "selector:w.req,selector:Header,call:Get,call:exec.Command,call:Run"
+	if strings.Join(got, ",") != want {
+		t.Errorf("dfg reach from w = %v, want %s", got, want)
+	}
+
+	reads := queryStrings(t, conn, `SELECT json_extract(e.properties, '$.field') FROM edges e
+		JOIN nodes n ON n.id = e.target
+		WHERE e.kind = 'dfg' AND json_extract(e.properties, '$.field_read') = 1 ORDER BY n.line, n.col`)
+	if strings.Join(reads, ",") != "req,Header" {
+		t.Errorf("field_read dfg edges = %v, want [req Header] (the w.req store is not a read)", reads)
+	}
+}
+
+func TestDeferredCloseInLoop(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"os"
+	"sync"
+)
+
+func ReadAll(paths []string) error {
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	return nil
+}
+
+func ReadEach(paths []string) error {
+	for _, p := range paths {
+		if err := func() error {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return nil
+		}(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Count(mu *sync.Mutex, n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 0; i < n; i++ {
+		defer println(i)
+	}
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.call') FROM findings WHERE category = 'deferred_close_in_loop'`)
+	if want := "14:f.Close"; strings.Join(got, ",") != want {
+		t.Errorf("deferred_close_in_loop = %v, want [%s]", got, want)
+	}
+}
+
+func TestLockWithoutUnlock(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "sync"
+
+type Store struct {
+	mu   sync.Mutex
+	rw   sync.RWMutex
+	data map[string]int
+}
+
+func (s *Store) Deferred(k string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[k]
+}
+
+func (s *Store) Manual(k string, v int) {
+	s.mu.Lock()
+	s.data[k] = v
+	s.mu.Unlock()
+}
+
+func (s *Store) Forgot(k string) int {
+	s.rw.RLock()
+	return s.data[k]
+}
+
+func (s *Store) WrongMutex(k string) int {
+	s.mu.Lock()
+	defer s.rw.Unlock()
+	return s.data[k]
+}
+
+func (s *Store) lockAll() {
+	s.mu.Lock()
+}
+
+func (s *Store) Block() {
+	s.mu.Lock()
+}
+`)
+	got := queryStrings(t, conn, `SELECT n.name || ':' || f.line FROM findings f JOIN nodes n ON n.id = json_extract(f.details, '$.function_id')
+		WHERE f.category = 'lock_without_unlock' ORDER BY f.line`)
+	if want := "*Store.Forgot:24,*Store.WrongMutex:29,*Store.Block:39"; strings.Join(got, ",") != want {
+		t.Errorf("lock_without_unlock = %v, want [%s]", got, want)
+	}
+}
+
+func TestDFGConfidence(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"math"
+	"strconv"
+)
+
+func Abs(x float64) float64 {
+	y := math.Abs(x)
+	return y
+}
+
+func Parse(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n + int(Abs(1))
+}
+`)
+	byConf := func(view string) []string {
+		return queryStrings(t, conn, `SELECT v.def_name || '->' || v.use_name || ':' ||
+			COALESCE(json_extract(e.properties, '$.confidence'), '?')
+			FROM `+view+` v JOIN edges e ON e.source = v.def_id AND e.target = v.use_id AND e.kind = 'dfg'
+			WHERE v.use_kind = 'call' AND v.use_name IN ('math.Abs', 'strconv.Atoi')
+			ORDER BY v.use_line`)
+	}
+	if got := strings.Join(byConf("v_data_flow"), ","); got != "x->math.Abs:fallback,s->strconv.Atoi:heuristic" {
+		t.Errorf("heuristic dfg edges = %s", got)
+	}
+	if got := byConf("v_precise_data_flow"); len(got) != 0 {
+		t.Errorf("v_precise_data_flow kept heuristic edges: %v", got)
+	}
+	if got := queryStrings(t, conn, `SELECT COUNT(*) FROM v_precise_data_flow`); got[0] == "0" {
+		t.Error("v_precise_data_flow is empty; SSA edges should be precise")
+	}
+
+	// backward_slice from math.Abs(x) reaches x only via the fallback edge.
+	var sliceSQL string
+	for _, q := range queryStrings(t, conn, `SELECT sql FROM queries WHERE name = 'backward_slice'`) {
+		sliceSQL = q
+	}
+	var callID string
+	for _, id := range queryStrings(t, conn, `SELECT id FROM nodes WHERE kind = 'call' AND name = 'math.Abs'`) {
+		callID = id
+	}
+	slice := func(minConf any) []string {
+		var names []string
+		err := sqlitex.Execute(conn, sliceSQL, &sqlitex.ExecOptions{
+			Named: map[string]any{":node_id": callID, ":min_confidence": minConf},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				if stmt.GetText("kind") == "identifier" {
+					names = append(names, stmt.GetText("name"))
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("backward_slice: %v", err)
+		}
+		return names
+	}
+	if got := slice(nil); strings.Join(got, ",") != "x" {
+		t.Errorf("backward_slice without :min_confidence = %v, want [x]", got)
+	}
+	if got := slice("precise"); len(got) != 0 {
+		t.Errorf("backward_slice with :min_confidence=precise = %v, want none", got)
+	}
+}
+
+func TestGoroutinePanicNoRecover(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func mustPositive(n int) int {
+	if n < 0 {
+		panic("negative")
+	}
+	return n
+}
+
+func recoverAndLog() {
+	if r := recover(); r != nil {
+		_ = r
+	}
+}
+
+func worker(n int) { mustPositive(n) }
+
+func Bare(n int) {
+	go func() {
+		mustPositive(n)
+	}()
+}
+
+func Named(n int) {
+	go worker(n)
+}
+
+func Guarded(n int) {
+	go func() {
+		defer func() { _ = recover() }()
+		mustPositive(n)
+	}()
+}
+
+func Handled(n int) {
+	go func() {
+		defer recoverAndLog()
+		mustPositive(n)
+	}()
+}
+
+func Safe(n int) {
+	go func() { _ = n + 1 }()
+}
+
+func Undeferred(n int) {
+	go func() {
+		recoverAndLog()
+		_ = recover()
+		mustPositive(n)
+	}()
+}
+`)
+	got := queryStrings(t, conn, `SELECT fn.name || ':' || f.line || ':' || f.severity FROM findings f
+		JOIN nodes fn ON fn.id = json_extract(f.details, '$.function_id')
+		WHERE f.category = 'goroutine_panic_no_recover' ORDER BY f.line`)
+	if strings.Join(got, ",") != "Bare:19:error,Named:25:error,Undeferred:47:error" {
+		t.Errorf("goroutine_panic_no_recover = %v, want [Bare:19:error Named:25:error Undeferred:47:error]", got)
+	}
+}
+
+func TestNodeModules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app/go.mod": "module example.com/fixture\n\ngo 1.22\n",
+		"app/app.go": `package fixture
+
+import (
+	"fmt"
+
+	"example.com/lib"
+)
+
+func Greet() string { return fmt.Sprint(lib.Hello()) }
+`,
+		"lib/go.mod": "module example.com/lib\n\ngo 1.22\n",
+		"lib/lib.go": `package lib
+
+func Hello() string { return hi() }
+
+func hi() string { return "hi" }
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appDir, libDir := filepath.Join(root, "app"), filepath.Join(root, "lib")
+	cpg := buildTestCPGModules(t, NewModuleSet(ModuleInfo{ModPath: "example.com/fixture", Dir: appDir},
+		[]ModuleInfo{{ModPath: "example.com/lib", Dir: libDir, Prefix: "lib"}}))
+	conn, err := sqlite.OpenConn(writeTestDB(t, cpg), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn, `SELECT prefix || '=' || mod_path || '@' || dir FROM modules ORDER BY prefix`)
+	want := []string{"=example.com/fixture@" + appDir, "lib=example.com/lib@" + libDir}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("modules = %v, want %v", got, want)
+	}
+
+	got = queryStrings(t, conn, `SELECT kind || ' ' || name || ': ' || COALESCE(module, 'NULL') FROM nodes
+		WHERE kind IN ('function', 'package', 'file') ORDER BY kind, name`)
+	want = []string{
+		"file app.go: example.com/fixture", "file lib.go: example.com/lib",
+		"function Greet: example.com/fixture", "function Hello: example.com/lib", "function Sprint: NULL", "function hi: example.com/lib",
+		"package fixture: example.com/fixture", "package lib: example.com/lib",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("node modules:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := queryStrings(t, conn, `SELECT COUNT(*) FROM nodes WHERE file IS NOT NULL AND module IS NULL`); n[0] != "0" {
+		t.Errorf("%s nodes with a file have no module", n[0])
+	}
+
+	// Cross-module calls: from the primary module into lib.
+	got = queryStrings(t, conn, `SELECT c.name || '->' || d.name FROM edges e
+		JOIN nodes c ON c.id = e.source JOIN nodes d ON d.id = e.target
+		JOIN modules mc ON mc.mod_path = c.module JOIN modules md ON md.mod_path = d.module
+		WHERE e.kind = 'call' AND mc.prefix = '' AND md.prefix = 'lib'`)
+	if strings.Join(got, ",") != "Greet->Hello" {
+		t.Errorf("primary→lib calls = %v, want [Greet->Hello]", got)
+	}
+}
+
+func TestLostAppend(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Blank(s []int) []int {
+	_ = append(s, 1)
+	return s
+}
+
+func Other(s []int) (int, []int) {
+	t := append(s, 1)
+	return len(s), t
+}
+
+func Moved(s []int) []int {
+	t := append(s, 1)
+	s = t
+	return s
+}
+
+func Kept(s []int) []int {
+	s = append(s, 1)
+	return s
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || message FROM findings WHERE category = 'lost_append' ORDER BY line`)
+	want := "4:result of append(s, 1) is discarded; assign it back (s = append(s, ...))," +
+		"9:result of append(s, 1) goes to t but s is read again afterwards without the new elements; assign it back (s = t)"
+	if strings.Join(got, ",") != want {
+		t.Errorf("lost_append = %v, want [%s]", got, want)
+	}
+}
+
+func TestSleepInHandler(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"net/http"
+	"time"
+)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(time.Second)
+	backoff()
+}
+
+func backoff() {
+	time.Sleep(time.Millisecond)
+}
+
+func Poll() {
+	time.Sleep(time.Minute)
+}
+
+func Register() {
+	http.HandleFunc("/", Handle)
+	Poll()
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || severity || ':' || json_extract(details, '$.depth') FROM findings WHERE category = 'sleep_in_handler' ORDER BY line`)
+	if want := "9:info:0,14:info:1"; strings.Join(got, ",") != want {
+		t.Errorf("sleep_in_handler = %v, want [%s]", got, want)
+	}
+}
+
+func TestBlockingChannelOp(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"context"
+	"net/http"
+)
+
+var results = make(chan int)
+
+func Handle(w http.ResponseWriter, r *http.Request) {
+	publish(1)
+	await(r.Context())
+	select {
+	case results <- 2:
+	case <-r.Context().Done():
+	}
+}
+
+func publish(n int) {
+	results <- n
+}
+
+func await(ctx context.Context) int {
+	<-ctx.Done()
+	return <-results
+}
+
+func Worker() {
+	results <- 3
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || severity || ':' || json_extract(details, '$.op') || ':' || json_extract(details, '$.depth')
+		FROM findings WHERE category = 'blocking_channel_op' ORDER BY line`)
+	if want := "20:warning:send:1,25:info:receive:1"; strings.Join(got, ",") != want {
+		t.Errorf("blocking_channel_op = %v, want [%s]", got, want)
+	}
+}
+
+func TestUnboundedRecursion(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Forever(n int) int {
+	return Forever(n+1) + 1
+}
+
+func Fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * Fact(n-1)
+}
+`)
+	got := queryStrings(t, conn, `SELECT category || ':' || severity || ':' || line FROM findings
+		WHERE category IN ('recursive', 'unbounded_recursion') ORDER BY line`)
+	if want := "unbounded_recursion:warning:4,recursive:info:7"; strings.Join(got, ",") != want {
+		t.Errorf("recursion findings = %v, want [%s]", got, want)
+	}
+}
+
+func TestHotspotTrend(t *testing.T) {
+	cpg := buildTestCPG(t, `package fixture
+
+func Classify(a, b, c int) string {
+	switch {
+	case a > 0 && b > 0:
+		return "pp"
+	case a > 0 && c > 0:
+		return "pc"
+	case b > 0 || c > 0:
+		return "bc"
+	}
+	for i := 0; i < a; i++ {
+		if i%2 == 0 && i%3 == 0 {
+			return "six"
+		}
+		if i > 100 || i < -100 {
+			return "big"
+		}
+	}
+	return ""
+}
+
+func Name() string { return Classify(1, 2, 3) }
+`)
+	dbPath := filepath.Join(t.TempDir(), "cpg.db")
+	history := []GitFileHistory{{RelFile: "fixture.go", CommitCount: 40, AuthorCount: 3, Insertions: 900, Deletions: 400}}
+	if err := WriteDB(dbPath, cpg, nil, history, false, NewProgress(false)); err != nil {
+		t.Fatalf("write db: %v", err)
+	}
+	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn, `SELECT n.name || ':' || t.commit_count || ':' || t.churn || ':' || t.trend_score
+		FROM hotspot_trend t JOIN nodes n ON n.id = t.function_id ORDER BY t.trend_score DESC`)
+	if len(got) != 2 || got[0] != "Classify:40:1300:100.0" {
+		t.Fatalf("hotspot_trend = %v, want Classify:40:1300:100.0 first", got)
+	}
+	if !strings.HasPrefix(got[1], "Name:40:1300:") || got[1] == "Name:40:1300:100.0" {
+		t.Errorf("simple function trend = %s, want well below Classify's", got[1])
+	}
+	if got := queryStrings(t, conn, `SELECT line FROM findings WHERE category = 'refactor_candidate'`); strings.Join(got, ",") != "3" {
+		t.Errorf("refactor_candidate lines = %v, want [3]", got)
+	}
+}
+
+func TestAnyParameter(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func Store(key string, x any) {}
+
+func Keep(v interface{}) {}
+
+func Printf(format string, a ...any) { fmt.Printf(format, a...) }
+
+func Decode(data []byte, v any) error {
+	if reflect.TypeOf(v).Kind() != reflect.Pointer {
+		return fmt.Errorf("decode: want pointer")
+	}
+	return nil
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || severity FROM findings WHERE category = 'any_parameter' ORDER BY line`)
+	if want := "8:info,10:info"; strings.Join(got, ",") != want {
+		t.Errorf("any_parameter = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT any_param_count FROM go_pattern_summary`)
+	if want := "2"; strings.Join(got, ",") != want {
+		t.Errorf("go_pattern_summary = %v, want [%s]", got, want)
+	}
+}
+
+func TestLargeValueParam(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+type Config struct {
+	Name    string
+	Labels  [16]string
+	Limits  [4]int64
+	Enabled bool
+}
+
+func ByValue(c Config) string { return c.Name }
+
+func ByPointer(c *Config) string { return c.Name }
+
+func A(c Config) string { return ByValue(c) + ByPointer(&c) }
+func B(c Config) string { return ByValue(c) + ByPointer(&c) }
+func C(c Config) string { return ByValue(c) + ByPointer(&c) }
+func D(c Config) string { return ByValue(c) + ByPointer(&c) }
+func E(c Config) string { return ByValue(c) + ByPointer(&c) }
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.type_size') || ':' || json_extract(details, '$.fan_in')
+		FROM findings WHERE category = 'large_value_param'`)
+	if want := "10:312:5"; strings.Join(got, ",") != want {
+		t.Errorf("large_value_param = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT p.name || ':' || json_extract(p.properties, '$.type_size') FROM nodes p
+		WHERE p.kind = 'parameter' AND p.line = 12`)
+	if want := "c:8"; strings.Join(got, ",") != want {
+		t.Errorf("pointer parameter type_size = %v, want [%s]", got, want)
+	}
+
+	// A generic struct has no size until instantiated, but is still a value.
+	conn = buildTestDB(t, `package fixture
+
+type Box[T any] struct{ v T }
+
+func Use[T any](b Box[T]) T { return b.v }
+`)
+	got = queryStrings(t, conn, `SELECT p.name || ':' || COALESCE(json_extract(p.properties, '$.type_size'), '-') || ':' || json_extract(p.properties, '$.struct_value')
+		FROM nodes p WHERE p.kind = 'parameter'`)
+	if want := "b:-:1"; strings.Join(got, ",") != want {
+		t.Errorf("generic by-value parameter = %v, want [%s]", got, want)
+	}
+}
+
+func TestMapWithLock(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "sync"
+
+type Cache struct {
+	mu    sync.RWMutex
+	items map[string]int
+	hits  map[string]int
+}
+
+func (c *Cache) Get(k string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.items[k]
+}
+
+func (c *Cache) Len() int { return len(c.hits) }
+
+type Registry struct {
+	byName map[string]int
+}
+
+func (r *Registry) Get(k string) int { return r.byName[k] }
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.mutex_field') || ':' || json_extract(details, '$.guarded_methods')
+		FROM findings WHERE category = 'map_with_lock'`)
+	if want := "7:mu:1"; strings.Join(got, ",") != want {
+		t.Errorf("map_with_lock = %v, want [%s]", got, want)
+	}
+}
+
+func TestMethodResolution(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+type A struct{}
+
+func (A) Name() string { return "a" }
+
+type B struct{}
+
+func (B) Name() string { return "b" }
+func (B) Size() int    { return 0 }
+
+type Inner struct{ B }
+
+type Outer struct {
+	A
+	Inner
+}
+
+type Both struct {
+	A
+	B
+}
+`)
+	resolve := func(typ string) []string {
+		return queryStrings(t, conn, `SELECT mr.method_name || ':' || COALESCE(r.line, 'ambiguous') || ':' || mr.depth || ':' ||
+				COALESCE((SELECT group_concat(s.line) FROM json_each(mr.shadowed_ids) j JOIN nodes s ON s.id = j.value), '')
+			FROM method_resolution mr
+			JOIN nodes t ON t.id = mr.type_id
+			LEFT JOIN nodes r ON r.id = mr.resolved_method_id
+			WHERE t.kind = 'type_decl' AND t.name = ? ORDER BY mr.method_name`, typ)
+	}
+	// A.Name at depth 1 wins over Inner's promoted B.Name at depth 2.
+	if got, want := strings.Join(resolve("Outer"), ","), "Name:5:1:9,Size:10:2:"; got != want {
+		t.Errorf("Outer resolution = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(resolve("Both"), ","), "Name:ambiguous:1:5,9,Size:10:1:"; got != want {
+		t.Errorf("Both resolution = %s, want %s", got, want)
+	}
+}
+
+func TestContextBackgroundInLeaf(t *testing.T) {
+	conn := buildTestDB(t, `package main
+
+import "context"
+
+func fetch(ctx context.Context, key string) error {
+	return lookup(context.Background(), key)
+}
+
+func lookup(ctx context.Context, key string) error {
+	return ctx.Err()
+}
+
+func main() {
+	_ = fetch(context.TODO(), "k")
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.call') FROM findings
+		WHERE category = 'context_background_in_leaf'`)
+	if want := "6:context.Background"; strings.Join(got, ",") != want {
+		t.Errorf("context_background_in_leaf = %v, want [%s]", got, want)
+	}
+}
+
+func TestExternalDFG(t *testing.T) {
+	cpg := buildTestCPG(t, `package fixture
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+func Clean(s string) string { return strings.TrimSpace(s) }
+
+func Decode(b []byte) (v map[string]any) {
+	_ = json.Unmarshal(b, &v)
+	return v
+}
+
+func Twice(s string) string { return strings.Repeat(s, 2) }
+`)
+	prev := externalDFG
+	t.Cleanup(func() { externalDFG = prev })
+
+	// Heuristic dfg edges per level, as confidence[+side_effect]:count.
+	counts := func(level string) string {
+		t.Helper()
+		externalDFG = level
+		conn, err := sqlite.OpenConn(writeTestDB(t, cpg), sqlite.OpenReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return strings.Join(queryStrings(t, conn, `SELECT kind || ':' || COUNT(*) FROM (
+				SELECT json_extract(properties, '$.confidence') ||
+					CASE WHEN json_extract(properties, '$.side_effect') THEN '+side_effect' ELSE '' END AS kind
+				FROM edges WHERE kind = 'dfg' AND json_extract(properties, '$.heuristic') = 1)
+			GROUP BY kind ORDER BY kind`), ",")
+	}
+	if got := counts("none"); got != "" {
+		t.Errorf("none: heuristic edges %s, want none", got)
+	}
+	if got, want := counts("precise"), "heuristic:1"; got != want {
+		t.Errorf("precise: heuristic edges %s, want %s", got, want)
+	}
+	if got, want := counts("heuristic"), "heuristic:1,heuristic+side_effect:1"; got != want {
+		t.Errorf("heuristic: heuristic edges %s, want %s", got, want)
+	}
+	if got, want := counts("fallback"), "fallback:2,heuristic:1,heuristic+side_effect:1"; got != want {
+		t.Errorf("fallback: heuristic edges %s, want %s", got, want)
+	}
+}
+
+func TestDuplicateSwitchCase(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+const (
+	verbose = true
+	tracing = true
+)
+
+func Level() string {
+	switch {
+	case verbose:
+		return "verbose"
+	case tracing:
+		return "trace"
+	}
+	return ""
+}
+
+func Bucket(n int) string {
+	switch {
+	case n > 10:
+		return "big"
+	case n >= 100:
+		return "huge"
+	case 10 <= n:
+		return "ten"
+	case n == 10:
+		return "exactly ten"
+	}
+	return "small"
+}
+
+func Sign(n int) string {
+	switch {
+	case n < 0, n < -5:
+		return "negative"
+	case n > 10, n < -10:
+		return "big"
+	case n > 20, n < -1:
+		return "never"
+	}
+	return "small"
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.kind') || ':' || json_extract(details, '$.covered_by_line')
+		FROM findings WHERE category = 'duplicate_switch_case' ORDER BY line`)
+	// 10 <= n admits 10, which n > 10 does not; n == 10 is inside 10 <= n.
+	// A clause is dead only once all its expressions are pre-empted by
+	// earlier clauses: n < -5 shares its clause with n < 0, and n > 10 keeps
+	// the clause of line 36 live.
+	if want := "12:duplicate:10,22:subsumed:20,26:subsumed:24,38:subsumed:36"; strings.Join(got, ",") != want {
+		t.Errorf("duplicate_switch_case = %v, want [%s]", got, want)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	conn := buildTestDB(t, "package fixture\n\nfunc F() {}\n")
+	got := queryStrings(t, conn, `SELECT param_name || ':' || type || ':' || required FROM query_params
+		WHERE query_name = 'backward_slice' ORDER BY param_name`)
+	if want := "min_confidence:text:0,node_id:text:1"; strings.Join(got, ",") != want {
+		t.Errorf("backward_slice params = %v, want [%s]", got, want)
+	}
+	// Every placeholder outside string literals is declared.
+	got = queryStrings(t, conn, `SELECT q.name || '.' || p.param_name FROM queries q
+		JOIN query_params p ON p.query_name = q.name
+		WHERE instr(q.sql, ':' || p.param_name) = 0`)
+	if len(got) != 0 {
+		t.Errorf("declared params missing from their query: %v", got)
+	}
+	got = queryStrings(t, conn, `SELECT name FROM queries
+		WHERE sql LIKE '%:function_id%' AND name NOT IN (SELECT query_name FROM query_params)`)
+	if len(got) != 0 {
+		t.Errorf("queries using :function_id without declarations: %v", got)
+	}
+
+	mem, err := sqlite.OpenConn(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mem.Close()
+	if err := sqlitex.ExecuteScript(mem, `
+CREATE TABLE schema_docs (category TEXT, name TEXT, description TEXT, example TEXT);
+CREATE TABLE queries (name TEXT, description TEXT, sql TEXT);
+INSERT INTO queries VALUES ('callers_by_global', '', 'SELECT * FROM nodes WHERE id = :global_key');`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := createQueryParams(mem); err == nil || !strings.Contains(err.Error(), ":global_key") {
+		t.Errorf("createQueryParams with an undeclared parameter = %v, want an error naming :global_key", err)
+	}
+}
+
+func TestHTTPClientNoTimeout(t *testing.T) {
+	conn := buildTestDB(t, `package main
+
+import (
+	"net/http"
+	"time"
+)
+
+func main() {
+	_, _ = http.Get("http://example.com")
+	bare := &http.Client{}
+	timed := &http.Client{Timeout: 5 * time.Second}
+	_, _ = bare.Get("http://example.com")
+	_, _ = timed.Get("http://example.com")
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || COALESCE(json_extract(details, '$.call'), json_extract(details, '$.type'))
+		FROM findings WHERE category = 'http_client_no_timeout' ORDER BY line`)
+	if want := "9:http.Get,10:net/http.Client"; strings.Join(got, ",") != want {
+		t.Errorf("http_client_no_timeout = %v, want [%s]", got, want)
+	}
+}
+
+func TestReproducibleDB(t *testing.T) {
+	src := `package fixture
+
+import (
+	"fmt"
+	"sync"
+)
+
+type Store struct {
+	mu   sync.Mutex
+	data map[string]int
+}
+
+func (s *Store) Put(k string, v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[k] = v
+}
+
+func (s *Store) Get(k string) int { return s.data[k] }
+
+func Sum(xs []int) (total int) {
+	for _, x := range xs {
+		if x > 0 {
+			total += x
+		}
+	}
+	go func() { fmt.Println(total) }()
+	return total
+}
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+`
+	hashes := func() map[string]uint64 {
+		conn := buildTestDB(t, src)
+		tables := queryStrings(t, conn, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+		out := make(map[string]uint64, len(tables))
+		for _, table := range tables {
+			h := fnv.New64a()
+			err := sqlitex.Execute(conn, `SELECT * FROM "`+table+`"`, &sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					for i := range stmt.ColumnCount() {
+						fmt.Fprintf(h, "%q|", stmt.ColumnText(i))
+					}
+					h.Write([]byte{'\n'})
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("hash %s: %v", table, err)
+			}
+			out[table] = h.Sum64()
+		}
+		return out
+	}
+	first, second := hashes(), hashes()
+	for table, h := range first {
+		// modules.dir is the fixture's absolute path, a new temp dir per run
+		if table != "modules" && second[table] != h {
+			t.Errorf("table %s differs between two runs", table)
+		}
+	}
+}
+
+func TestShouldBeUnexported(t *testing.T) {
+	files := map[string]string{
+		"core/core.go": `package core
+
+func Shared() int { return 1 }
+
+func Helper() int { return 2 }
+
+func Unused() int { return 3 }
+
+func run() int { return Helper() + Shared() }
+`,
+		"app/app.go": `package app
+
+import "example.com/fixture/core"
+
+func Use() int { return core.Shared() }
+`,
+	}
+	conn, err := sqlite.OpenConn(writeTestDB(t, buildTestCPGFiles(t, files)), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+
+	got := queryStrings(t, conn, `SELECT json_extract(details, '$.name')
+		FROM findings WHERE category = 'should_be_unexported' AND json_extract(details, '$.package') = 'core'`)
+	if len(got) != 1 || got[0] != "Helper" {
+		t.Errorf("should_be_unexported findings = %v, want [Helper]", got)
+	}
+	got = queryStrings(t, conn, `SELECT json_extract(details, '$.name')
+		FROM findings WHERE category = 'unused_export' AND json_extract(details, '$.package') = 'core'`)
+	if len(got) != 1 || got[0] != "Unused" {
+		t.Errorf("unused_export findings = %v, want [Unused]", got)
+	}
+}
+
+func TestPanicRecoverControlFlow(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "errors"
+
+type parseError struct{ msg string }
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+func (p *parser) expect(tok string) {
+	if p.pos >= len(p.toks) || p.toks[p.pos] != tok {
+		panic(parseError{"expected " + tok})
+	}
+	p.pos++
+}
+
+func (p *parser) fail() {
+	panic("unreachable")
+}
+
+func Parse(toks []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			err = errors.New(pe.msg)
+		}
+	}()
+	p := &parser{toks: toks}
+	p.expect("(")
+	p.expect(")")
+	return nil
+}
+
+func Guard(f func()) {
+	defer func() { _ = recover() }()
+	f()
+}
+`)
+	got := queryStrings(t, conn, `SELECT json_extract(details, '$.type') || ':' || json_extract(details, '$.panic_functions')
+		FROM findings WHERE category = 'panic_recover_control_flow'`)
+	if want := "example.com/fixture.parseError:1"; strings.Join(got, ",") != want {
+		t.Errorf("panic_recover_control_flow = %v, want [%s]", got, want)
+	}
+}
+
+func TestHotCallPaths(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func leaf(n int) int {
+	if n > 0 {
+		return n
+	}
+	return 0
+}
+
+func mid(n int) int { return leaf(n) + 1 }
+
+func Top(n int) int {
+	if n > 10 {
+		return mid(n)
+	}
+	return leaf(n)
+}
+
+func Alone(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+`)
+	got := queryStrings(t, conn, `SELECT f.name || '->' || t.name || ':' || p.hop_count
+		FROM hot_call_paths p
+		JOIN nodes f ON f.id = p.from_id
+		JOIN nodes t ON t.id = p.to_id
+		ORDER BY f.name, t.name`)
+	if want := "Top->leaf:1,Top->mid:1,mid->leaf:1"; strings.Join(got, ",") != want {
+		t.Errorf("hot_call_paths = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT path FROM hot_call_paths p
+		JOIN nodes f ON f.id = p.from_id AND f.name = 'mid'`)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "main::mid@") || !strings.Contains(got[0], " -> main::leaf@") {
+		t.Errorf("mid->leaf path = %v, want main::mid@... -> main::leaf@...", got)
+	}
+}
+
+func TestSuppressionComments(t *testing.T) {
+	body := `
+	switch {
+	case n == 1:
+		return 1
+	case n == 2:
+		return 2
+	case n == 3:
+		return 3
+	case n == 4:
+		return 4
+	case n == 5:
+		return 5
+	case n == 6:
+		return 6
+	case n == 7:
+		return 7
+	case n == 8:
+		return 8
+	case n == 9:
+		return 9
+	case n == 10:
+		return 10
+	case n == 11:
+		return 11
+	case n == 12:
+		return 12
+	case n == 13:
+		return 13
+	case n == 14:
+		return 14
+	case n == 15:
+		return 15
+	case n == 16:
+		return 16
+	}
+	return 0
+}
+`
+	conn := buildTestDB(t, `package fixture
+
+// Quiet is a dispatch table kept flat on purpose.
+//
+//cpg:ignore complexity table-driven
+func Quiet(n int) int {`+body+`
+//nolint:god_function
+func Loud(n int) int {`+body)
+	got := queryStrings(t, conn, `SELECT n.name || ':' || f.suppressed
+		FROM findings f JOIN nodes n ON n.id = f.node_id
+		WHERE f.category = 'complexity' ORDER BY n.name`)
+	if want := "Loud:0,Quiet:1"; strings.Join(got, ",") != want {
+		t.Errorf("complexity findings = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT n.name FROM v_active_findings f
+		JOIN nodes n ON n.id = f.node_id WHERE f.category = 'complexity'`)
+	if len(got) != 1 || got[0] != "Loud" {
+		t.Errorf("v_active_findings complexity = %v, want [Loud]", got)
+	}
+	got = queryStrings(t, conn, `SELECT count FROM dashboard_findings_summary WHERE category = 'complexity'`)
+	if len(got) != 1 || got[0] != "1" {
+		t.Errorf("dashboard_findings_summary complexity count = %v, want [1]", got)
+	}
+}
+
+func TestGoroutineCaptures(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+type state struct{ n int }
+
+func Launch(done chan<- int) {
+	count := 3
+	st := &state{}
+	go func() {
+		st.n += count
+		done <- st.n
+	}()
+}
+`)
+	got := queryStrings(t, conn, `SELECT captured_var_name || ':' || by_ref
+		FROM goroutine_captures WHERE captured_var_name IN ('count', 'st')
+		ORDER BY captured_var_name`)
+	if want := "count:0,st:1"; strings.Join(got, ",") != want {
+		t.Errorf("goroutine_captures = %v, want [%s]", got, want)
+	}
+}
+
+func TestSeverityMap(t *testing.T) {
+	prev := severityMap
+	t.Cleanup(func() { severityMap = prev })
+	severityMap = map[string]string{"long_param_list": "error", "no_such_category": "info"}
+
+	conn := buildTestDB(t, `package fixture
+
+func Wide(a, b, c, d, e, f int) int { return a + b + c + d + e + f }
+`)
+	got := queryStrings(t, conn, `SELECT DISTINCT severity FROM findings WHERE category = 'long_param_list'`)
+	if len(got) != 1 || got[0] != "error" {
+		t.Errorf("long_param_list severities = %v, want [error]", got)
+	}
+	got = queryStrings(t, conn, `SELECT severity FROM dashboard_findings_summary WHERE category = 'long_param_list'`)
+	if len(got) != 1 || got[0] != "error" {
+		t.Errorf("dashboard_findings_summary long_param_list severity = %v, want [error]", got)
+	}
+}
+
+func TestLoadSeverityMapRejectsUnknownSeverity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "severity.json")
+	if err := os.WriteFile(path, []byte(`{"long_param_list": "fatal"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSeverityMap(path); err == nil || !strings.Contains(err.Error(), `"fatal"`) {
+		t.Errorf("LoadSeverityMap error = %v, want one naming \"fatal\"", err)
+	}
+}
+
+func TestExcludeFindings(t *testing.T) {
+	prev := excludeFindings
+	t.Cleanup(func() { excludeFindings = prev })
+	excludeFindings = map[string]bool{"long_param_list": true, "risk_score": true}
+
+	conn := buildTestDB(t, `package fixture
+
+func Wide(a, b, c, d, e, f int) int { return a + b + c + d + e + f }
+`)
+	if got := queryStrings(t, conn, `SELECT id FROM findings WHERE category IN ('long_param_list', 'risk_score')`); len(got) != 0 {
+		t.Errorf("excluded findings = %v, want none", got)
+	}
+	if got := queryStrings(t, conn, `SELECT count FROM dashboard_findings_summary WHERE category = 'long_param_list'`); len(got) != 0 {
+		t.Errorf("dashboard_findings_summary long_param_list = %v, want no row", got)
+	}
+	if got := queryStrings(t, conn, `SELECT category FROM findings LIMIT 1`); len(got) == 0 {
+		t.Error("no findings at all; other categories should be unaffected")
+	}
+	// function_risk_ranking only reads risk_score findings
+	if got := queryStrings(t, conn, `SELECT name FROM queries WHERE name = 'function_risk_ranking'
+		UNION ALL SELECT name FROM schema_docs WHERE name = 'function_risk_ranking'
+		UNION ALL SELECT query_name FROM query_params WHERE query_name = 'function_risk_ranking'`); len(got) != 0 {
+		t.Errorf("function_risk_ranking still in %d places, want dropped", len(got))
+	}
+	if got := queryStrings(t, conn, `SELECT name FROM queries WHERE name = 'package_stability'`); len(got) != 1 {
+		t.Error("package_stability query dropped; it reads no findings")
+	}
+}
+
+func TestSkipsFindings(t *testing.T) {
+	prev := excludeFindings
+	t.Cleanup(func() { excludeFindings = prev })
+	excludeFindings = map[string]bool{"dead_code": true, "hub": true}
+
+	for _, tc := range []struct {
+		query string
+		want  bool
+	}{
+		{"-- Dead code\nINSERT INTO findings (category, severity)\n  SELECT 'dead_code', 'warning' FROM nodes", true},
+		{"INSERT INTO findings (category) WITH x AS (SELECT 'hub', 1) SELECT 'hub', 'info' FROM x", true},
+		{"INSERT INTO findings (category) SELECT 'dead_code', 'info' UNION ALL SELECT 'size', 'info'", false},
+		{"INSERT INTO findings (category) SELECT CASE WHEN 1 THEN 'dead_code' END, 'info'", false},
+		{"CREATE TABLE t AS SELECT 'dead_code', 1", false},
+	} {
+		if got := skipsFindings(tc.query); got != tc.want {
+			t.Errorf("skipsFindings(%q) = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestLockOrderInversion(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "sync"
+
+type Bank struct {
+	accounts sync.Mutex
+	audit    sync.Mutex
+	n        int
+}
+
+func (b *Bank) Transfer() {
+	b.accounts.Lock()
+	b.audit.Lock()
+	b.n++
+	b.audit.Unlock()
+	b.accounts.Unlock()
+}
+
+func (b *Bank) Report() int {
+	b.audit.Lock()
+	defer b.audit.Unlock()
+	b.accounts.Lock()
+	defer b.accounts.Unlock()
+	return b.n
+}
+
+func (b *Bank) Deposit() {
+	b.accounts.Lock()
+	b.audit.Lock()
+	b.n++
+	b.audit.Unlock()
+	b.accounts.Unlock()
+}
+
+var cfgMu, cacheMu, logMu sync.Mutex
+
+func Reload() {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+}
+
+func Evict() {
+	cacheMu.Lock()
+	logMu.Lock()
+	logMu.Unlock()
+	cacheMu.Unlock()
+}
+
+func Rotate() {
+	logMu.Lock()
+	cfgMu.Lock()
+	cfgMu.Unlock()
+	logMu.Unlock()
+}
+
+// Sequential: logMu is released before cacheMu is taken
+func Flush() {
+	logMu.Lock()
+	logMu.Unlock()
+	cacheMu.Lock()
+	cacheMu.Unlock()
+}
+`)
+	got := queryStrings(t, conn, `SELECT json_array_length(details, '$.function_ids') || ':' ||
+			(SELECT group_concat(value, '>') FROM json_each(details, '$.cycle'))
+		FROM findings WHERE category = 'lock_order_inversion' ORDER BY 1`)
+	// The Bank pair is one cycle however many functions share an order;
+	// Flush's sequential locks add no cacheMu/logMu edge in reverse
+	if len(got) != 2 || !strings.HasPrefix(got[0], "2:") || !strings.HasPrefix(got[1], "3:") {
+		t.Fatalf("lock_order_inversion = %v, want a 2-lock and a 3-lock cycle", got)
+	}
+	for _, want := range []string{"Bank.accounts", "Bank.audit"} {
+		if !strings.Contains(got[0], want) {
+			t.Errorf("2-lock cycle %s lacks %s", got[0], want)
+		}
+	}
+	// Cycles start at their smallest lock id, so compare rotations
+	ring := strings.Split(got[1][2:], ">")[:3]
+	if c := strings.Join(append(ring, ring...), ">"); !strings.Contains(c, "cfgMu>cacheMu>logMu") {
+		t.Errorf("3-lock cycle = %s, want cfgMu -> cacheMu -> logMu -> cfgMu", got[1])
+	}
+}
+
+func TestUncheckedTypeAssertion(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Unchecked(x any) string {
+	v := x.(string)
+	return v
+}
+
+func Checked(x any) string {
+	v, ok := x.(string)
+	if !ok {
+		return ""
+	}
+	var w, _ = x.(Stringer)
+	_ = w
+	return v
+}
+
+func Switched(x any) int {
+	switch x := x.(type) {
+	case int:
+		return x
+	}
+	return 0
+}
+
+type Stringer interface{ String() string }
+`)
+	got := queryStrings(t, conn, `SELECT line FROM findings WHERE category = 'unchecked_type_assertion' ORDER BY line`)
+	if strings.Join(got, ",") != "4" {
+		t.Errorf("unchecked_type_assertion lines = %v, want [4]", got)
+	}
+}
+
+func TestBinaryFootprint(t *testing.T) {
+	files := map[string]string{
+		"cmd/tool/main.go": `package main
+
+import "example.com/fixture/lib"
+
+type greeter interface{ Greet() string }
+
+func main() {
+	var g greeter = lib.English{}
+	println(g.Greet())
+}
+`,
+		"lib/lib.go": `package lib
+
+type English struct{}
+
+func (English) Greet() string { return word() }
+
+func word() string { return "hello" }
+
+func Unused() int {
+	return 1 + 2
+}
+`,
+	}
+	conn, err := sqlite.OpenConn(writeTestDB(t, buildTestCPGFiles(t, files)), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+
+	// main, English.Greet (through the interface) and word; not Unused
+	got := queryStrings(t, conn, `SELECT main_package || ' ' || reachable_functions FROM binary_footprint`)
+	if strings.Join(got, ",") != "cmd/tool 3" {
+		t.Errorf("binary_footprint = %v, want [cmd/tool 3]", got)
+	}
+	got = queryStrings(t, conn, `SELECT reachable_loc FROM binary_footprint`)
+	if len(got) != 1 || got[0] == "0" {
+		t.Errorf("reachable_loc = %v, want non-zero", got)
+	}
+}
+
+func TestErrorStringStyle(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errBad     = errors.New("Bad input.")
+	errGood    = errors.New("bad input")
+	errAcronym = errors.New("HTTP request failed")
+	errLoud    = errors.New("stop!")
+)
+
+func Wrap(err error) error {
+	return fmt.Errorf("Wrapping: %w", err)
+}
+`)
+	got := queryStrings(t, conn, `SELECT line FROM findings WHERE category = 'error_string_style' ORDER BY line`)
+	if strings.Join(got, ",") != "9,12,16" {
+		t.Errorf("error_string_style lines = %v, want [9 12 16]", got)
+	}
+}
+
+func TestIndexOutOfRangeConst(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+const two = 2
+
+func Pick(n int) int {
+	s := []int{1, 2, 3}
+	k := []int{5: 1}
+	return s[two*2] + s[two] + k[5] + k[6] + make([]int, n)[4]
+}
+
+func Grown(more bool) int {
+	s := []int{1, 2, 3}
+	if more {
+		s = append(s, 4)
+	}
+	return s[3]
+}
+
+func Buffers(n int) ([]int, []int, chan int) {
+	return make([]int, n, 0), make([]int, 0, 8), make(chan int, 0)
+}
+`)
+	got := queryStrings(t, conn, `SELECT line || ':' || json_extract(details, '$.index') || ':' || json_extract(details, '$.length')
+		FROM findings WHERE category = 'index_out_of_range_const' ORDER BY message`)
+	if want := "8:4:3,8:6:6"; strings.Join(got, ",") != want {
+		t.Errorf("index_out_of_range_const = %v, want [%s]", got, want)
+	}
+	got = queryStrings(t, conn, `SELECT line || ':' || message FROM findings WHERE category = 'invalid_make_size'`)
+	if want := "20:make in Buffers has an explicit zero capacity"; strings.Join(got, ",") != want {
+		t.Errorf("invalid_make_size = %v, want [%s]", got, want)
+	}
+}
+
+func TestGoroutineInInit(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func poll() {}
+
+func init() {
+	go poll()
+	func() {
+		go poll()
+	}()
+}
+
+var started = func() bool {
+	go poll()
+	return true
+}()
+
+func Start() {
+	go poll()
+}
+
+// Runs only when called
+var later = func() { go poll() }
+
+func run(f func()) bool { return f != nil }
+
+// Passed to run, which never calls it
+var passed = run(func() { go poll() })
+`)
+	got := queryStrings(t, conn, `SELECT line FROM findings WHERE category = 'goroutine_in_init' ORDER BY line`)
+	if strings.Join(got, ",") != "6,8,13" {
+		t.Errorf("goroutine_in_init lines = %v, want [6 8 13]", got)
+	}
+}
+
+func TestMapRangeOrderDependence(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "sort"
+
+func Keys(m map[string]int) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func SortedKeys(m map[string]int) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func Count(m map[string]int) int {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return len(out)
+}
+
+func Names(list []string) []string {
+	var out []string
+	for _, s := range list {
+		out = append(out, s)
+	}
+	return out
+}
+`)
+	got := queryStrings(t, conn, `SELECT message FROM findings WHERE category = 'map_range_order_dependence'`)
+	if want := "Keys returns out, appended to in map iteration order, without sorting it"; strings.Join(got, ",") != want {
+		t.Errorf("map_range_order_dependence = %v, want [%s]", got, want)
+	}
+}
+
+func TestSymbolFTSSearchEscapes(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+func Manage() {}
+
+func Ma_x() {}
+`)
+	var searchSQL string
+	for _, q := range queryStrings(t, conn, `SELECT sql FROM queries WHERE name = 'symbol_fts_search'`) {
+		searchSQL = q
+	}
+	search := func(q string) []string {
+		var names []string
+		err := sqlitex.Execute(conn, searchSQL, &sqlitex.ExecOptions{
+			Named: map[string]any{":q": q},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				names = append(names, stmt.GetText("name"))
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("symbol_fts_search %q: %v", q, err)
+		}
+		return names
+	}
+	if got := search(`Man"age OR`); len(got) != 0 {
+		t.Errorf("symbol_fts_search with FTS syntax = %v, want none", got)
+	}
+	if got := search("Ma_"); len(got) == 0 || got[0] != "Ma_x" {
+		t.Errorf("symbol_fts_search Ma_ = %v, want Ma_x first", got)
+	}
+}
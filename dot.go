@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// dotTypesFlag is -dot-types: given bare it exports every package, given as
+// -dot-types=prefix only relationships touching packages under prefix.
+type dotTypesFlag struct {
+	set    bool
+	prefix string
+}
+
+func (f *dotTypesFlag) String() string { return f.prefix }
+
+func (f *dotTypesFlag) Set(s string) error {
+	f.set = true
+	if s != "true" {
+		f.prefix = s
+	}
+	return nil
+}
+
+// IsBoolFlag lets -dot-types stand alone; a prefix needs the = form.
+func (f *dotTypesFlag) IsBoolFlag() bool { return true }
+
+// WriteTypeDOTFile writes the type hierarchy DOT of dbPath to stdout.
+func WriteTypeDOTFile(dbPath, prefix string, prog *Progress) error {
+	w := bufio.NewWriter(os.Stdout)
+	if err := WriteTypeDOT(w, dbPath, prefix, prog); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// WriteTypeDOT renders the embeds and implements rows of v_type_hierarchy as
+// a Graphviz digraph: types as boxes, interfaces as ellipses, embeds edges
+// solid and implements edges dashed. With a package prefix only edges with an
+// endpoint in a matching package ("scrape" matches scrape and scrape/...)
+// are drawn; types without either relationship are left out.
+func WriteTypeDOT(w io.Writer, dbPath, prefix string, prog *Progress) error {
+	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	if err != nil {
+		return fmt.Errorf("dot: open %s: %w", dbPath, err)
+	}
+	defer conn.Close()
+
+	type dotNode struct{ label, shape string }
+	nodes := map[string]dotNode{}
+	var order []string
+	addNode := func(id, pkg, name, kind string) {
+		if _, ok := nodes[id]; ok {
+			return
+		}
+		shape := "box"
+		if kind == "interface" {
+			shape = "ellipse"
+		}
+		nodes[id] = dotNode{label: pkg + "." + name, shape: shape}
+		order = append(order, id)
+	}
+	type dotEdge struct{ from, to, style string }
+	var edges []dotEdge
+
+	err = sqlitex.ExecuteTransient(conn, `
+SELECT h.type_id, h.type_package, h.type_name, COALESCE(json_extract(s.properties, '$.type_kind'), ''),
+       h.target_id, h.target_package, h.target_name, COALESCE(json_extract(t.properties, '$.type_kind'), ''),
+       h.relationship
+FROM v_type_hierarchy h
+JOIN nodes s ON s.id = h.type_id AND s.kind = 'type_decl'
+JOIN nodes t ON t.id = h.target_id AND t.kind = 'type_decl'
+WHERE h.relationship IN ('embeds', 'implements')
+  AND (?1 = ''
+    OR h.type_package = ?1 OR h.type_package LIKE ?1 || '/%'
+    OR h.target_package = ?1 OR h.target_package LIKE ?1 || '/%')
+ORDER BY h.type_package, h.type_name, h.relationship, h.target_package, h.target_name`,
+		&sqlitex.ExecOptions{
+			Args: []any{prefix},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				addNode(stmt.ColumnText(0), stmt.ColumnText(1), stmt.ColumnText(2), stmt.ColumnText(3))
+				addNode(stmt.ColumnText(4), stmt.ColumnText(5), stmt.ColumnText(6), stmt.ColumnText(7))
+				style := "solid"
+				if stmt.ColumnText(8) == "implements" {
+					style = "dashed"
+				}
+				edges = append(edges, dotEdge{stmt.ColumnText(0), stmt.ColumnText(4), style})
+				return nil
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("dot: %w", err)
+	}
+
+	fmt.Fprintln(w, "digraph types {")
+	fmt.Fprintln(w, "  rankdir=BT;")
+	fmt.Fprintln(w, `  node [fontname="Helvetica"];`)
+	for _, id := range order {
+		n := nodes[id]
+		fmt.Fprintf(w, "  %s [label=%s, shape=%s];\n", strconv.Quote(id), strconv.Quote(n.label), n.shape)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(w, "  %s -> %s [style=%s];\n", strconv.Quote(e.from), strconv.Quote(e.to), e.style)
+	}
+	_, err = fmt.Fprintln(w, "}")
+	prog.Log("Wrote type DOT: %d types, %d edges", len(order), len(edges))
+	return err
+}
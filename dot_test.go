@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestWriteTypeDOT(t *testing.T) {
+	dbPath := writeTestDB(t, buildTestCPGFiles(t, map[string]string{
+		"fixture.go": `package fixture
+
+type Named interface{ Name() string }
+
+type Base struct{}
+
+func (Base) Name() string { return "base" }
+
+type Widget struct{ Base }
+`,
+		"other/other.go": `package other
+
+import "example.com/fixture"
+
+type Gadget struct{ fixture.Base }
+`,
+	}))
+
+	nodeLine := regexp.MustCompile(`^\s+"([^"]+)" \[label="([^"]+)", shape=(\w+)\];$`)
+	edgeLine := regexp.MustCompile(`^\s+"([^"]+)" -> "([^"]+)" \[style=(\w+)\];$`)
+	render := func(prefix string) (shapes, edges []string) {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := WriteTypeDOT(&buf, dbPath, prefix, NewProgress(false)); err != nil {
+			t.Fatalf("dot %q: %v", prefix, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("digraph types {\n")) || !bytes.HasSuffix(buf.Bytes(), []byte("}\n")) {
+			t.Fatalf("dot %q: not a digraph:\n%s", prefix, buf.String())
+		}
+		labels := map[string]string{}
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			if m := nodeLine.FindSubmatch(line); m != nil {
+				labels[string(m[1])] = string(m[2])
+				shapes = append(shapes, string(m[2])+":"+string(m[3]))
+			} else if m := edgeLine.FindSubmatch(line); m != nil {
+				edges = append(edges, labels[string(m[1])]+"->"+labels[string(m[2])]+":"+string(m[3]))
+			}
+		}
+		slices.Sort(shapes)
+		slices.Sort(edges)
+		return shapes, edges
+	}
+
+	shapes, edges := render("")
+	if want := []string{"main.Base:box", "main.Named:ellipse", "main.Widget:box", "other.Gadget:box"}; !slices.Equal(shapes, want) {
+		t.Errorf("nodes = %v, want %v", shapes, want)
+	}
+	wantEdges := []string{
+		"main.Base->main.Named:dashed",
+		"main.Widget->main.Base:solid",
+		"main.Widget->main.Named:dashed",
+		"other.Gadget->main.Base:solid",
+		"other.Gadget->main.Named:dashed",
+	}
+	if !slices.Equal(edges, wantEdges) {
+		t.Errorf("edges = %v, want %v", edges, wantEdges)
+	}
+
+	// A prefix keeps only relationships touching its packages.
+	_, edges = render("other")
+	if want := []string{"other.Gadget->main.Base:solid", "other.Gadget->main.Named:dashed"}; !slices.Equal(edges, want) {
+		t.Errorf("edges under other = %v, want %v", edges, want)
+	}
+}
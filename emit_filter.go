@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// EmitFilter restricts which node and edge kinds are emitted (-emit-nodes,
+// -emit-edges). A nil set allows every kind. Phases whose kinds are all
+// excluded are skipped rather than run and discarded.
+//
+// Later analyses read what earlier ones emitted, so excluding a kind empties
+// everything built on it: taint flows and slices need dfg (and param_in /
+// param_out across calls), call-graph tables and fan-in/out need call,
+// findings and metrics need function nodes, path counts need cfg, and the
+// type-system tables need implements/embeds.
+type EmitFilter struct {
+	Nodes map[string]bool
+	Edges map[string]bool
+}
+
+// emitFilter is set by main before any pipeline phase runs.
+var emitFilter EmitFilter
+
+// ParseKindList parses a comma-separated kind list; "" means no restriction.
+func ParseKindList(s string) map[string]bool {
+	var kinds map[string]bool
+	for k := range strings.SplitSeq(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			if kinds == nil {
+				kinds = map[string]bool{}
+			}
+			kinds[k] = true
+		}
+	}
+	return kinds
+}
+
+// Node reports whether nodes of kind are emitted. META_DATA always is.
+func (f EmitFilter) Node(kind string) bool {
+	return f.Nodes == nil || f.Nodes[kind] || kind == "meta_data"
+}
+
+// Edge reports whether edges of kind are emitted.
+func (f EmitFilter) Edge(kind string) bool {
+	return f.Edges == nil || f.Edges[kind]
+}
+
+// AnyEdge reports whether at least one of kinds is emitted, i.e. whether a
+// phase producing them is worth running.
+func (f EmitFilter) AnyEdge(kinds ...string) bool {
+	return slices.ContainsFunc(kinds, f.Edge)
+}
+
+// SortedEdges returns the allowed edge kinds, or nil when unrestricted.
+func (f EmitFilter) SortedEdges() []string {
+	if f.Edges == nil {
+		return nil
+	}
+	kinds := make([]string, 0, len(f.Edges))
+	for k := range f.Edges {
+		kinds = append(kinds, k)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// Edge kinds produced by each phase that can be skipped. A phase runs when
+// at least one of its kinds is emitted; properties it sets (recursive,
+// recovers_to_error, ...) are skipped with it.
+var (
+	cfgPhaseEdges   = []string{"cfg", "dfg", "capture"} // plus basic_block nodes
+	cdgPhaseEdges   = []string{"cdg", "dom", "pdom"}
+	chanPhaseEdges  = []string{"chan_flow"}
+	panicPhaseEdges = []string{"panic_recover", "error_recovery"}
+	callPhaseEdges  = []string{"call", "call_site", "param_in", "param_out", "call_to_return"}
+	typePhaseEdges  = []string{"implements", "embeds", "alias_of", "satisfies_method"}
+)
+
+// wantsSSA reports whether any SSA-based phase will run.
+func (f EmitFilter) wantsSSA() bool {
+	return f.Node("basic_block") || f.AnyEdge(cfgPhaseEdges...) || f.AnyEdge(cdgPhaseEdges...) ||
+		f.AnyEdge(chanPhaseEdges...) || f.AnyEdge(panicPhaseEdges...) || f.AnyEdge(callPhaseEdges...)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+)
+
+func TestEmitEdgesCallGraphOnly(t *testing.T) {
+	src := `package fixture
+
+import "strings"
+
+type Shape interface{ Area() float64 }
+
+type Square struct{ side float64 }
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+func Total(shapes []Shape) float64 {
+	sum := 0.0
+	for _, s := range shapes {
+		if a := s.Area(); a > 0 {
+			sum += a
+		}
+	}
+	return sum
+}
+
+func Label(parts []string) string {
+	out := make([]string, 0, len(parts))
+	for i, p := range parts {
+		if i%2 == 0 {
+			p = strings.ToUpper(p)
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, "-")
+}
+`
+	countEdges := func(path string) map[string]string {
+		conn, err := sqlite.OpenConn(path, sqlite.OpenReadOnly)
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		defer conn.Close()
+		counts := map[string]string{}
+		for _, kind := range []string{"dfg", "cfg", "call", "ast", "implements"} {
+			counts[kind] = queryStrings(t, conn, `SELECT COUNT(*) FROM edges WHERE kind = ?`, kind)[0]
+		}
+		counts["total"] = queryStrings(t, conn, `SELECT COUNT(*) FROM edges`)[0]
+		return counts
+	}
+	fileSize := func(path string) int64 {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+
+	fullPath := buildTestDBFile(t, src)
+	full := countEdges(fullPath)
+
+	prev := emitFilter
+	t.Cleanup(func() { emitFilter = prev })
+	emitFilter = EmitFilter{Edges: ParseKindList("call,ast,implements")}
+	slimPath := buildTestDBFile(t, src)
+	slim := countEdges(slimPath)
+
+	if full["dfg"] == "0" || full["cfg"] == "0" {
+		t.Fatalf("full run has no dfg/cfg edges: %v", full)
+	}
+	if slim["dfg"] != "0" || slim["cfg"] != "0" {
+		t.Errorf("call-graph-only run kept dfg/cfg edges: %v", slim)
+	}
+	for _, kind := range []string{"call", "ast", "implements"} {
+		if slim[kind] != full[kind] {
+			t.Errorf("%s edges = %s, want %s as in the full run", kind, slim[kind], full[kind])
+		}
+	}
+	// Schema and derived tables dominate a fixture this small, so the edge
+	// table carries the size claim and the file only has to shrink.
+	if s, f := atoi(t, slim["total"]), atoi(t, full["total"]); s*2 > f {
+		t.Errorf("call-graph-only run has %d edges vs %d full, want under half", s, f)
+	}
+	if s, f := fileSize(slimPath), fileSize(fullPath); s >= f {
+		t.Errorf("call-graph-only DB is %d bytes vs %d full, want smaller", s, f)
+	}
+}
+
+func atoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
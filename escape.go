@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"os"
 	"os/exec"
 	"regexp"
@@ -19,14 +20,22 @@ type EscapeResult struct {
 }
 
 // RunEscapeAnalysis runs `go build -gcflags=-m` on each module directory
-// and parses the compiler's escape analysis decisions.
-func RunEscapeAnalysis(prog *Progress) []EscapeResult {
+// and parses the compiler's escape analysis decisions. Cancelling ctx kills
+// the running build. It is skipped with -scope.
+func RunEscapeAnalysis(ctx context.Context, prog *Progress) []EscapeResult {
+	if flagScope != "" {
+		prog.Log("Skipping escape analysis (-scope)")
+		return nil
+	}
 	prog.Log("Running Go escape analysis (-gcflags=-m) across %d modules...", len(modSet.Dirs()))
 
 	var allResults []EscapeResult
 
 	for _, mod := range modSet.Dirs() {
-		results := runEscapeForDir(mod.Dir, mod.Prefix, prog)
+		if ctx.Err() != nil {
+			break
+		}
+		results := runEscapeForDir(ctx, mod.Dir, mod.Prefix, prog)
 		allResults = append(allResults, results...)
 	}
 
@@ -34,8 +43,8 @@ func RunEscapeAnalysis(prog *Progress) []EscapeResult {
 	return allResults
 }
 
-func runEscapeForDir(dir, prefix string, prog *Progress) []EscapeResult {
-	cmd := exec.Command("go", "build", "-gcflags=-m", "./...")
+func runEscapeForDir(ctx context.Context, dir, prefix string, prog *Progress) []EscapeResult {
+	cmd := exec.CommandContext(ctx, "go", "build", "-gcflags=-m", "./...")
 	cmd.Dir = dir
 	cmd.Env = replaceEnv(os.Environ(), "GOFLAGS", "-buildvcs=false")
 	cmd.Stdout = nil // discard
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Finding is one row of a CPG's findings table.
+type Finding struct {
+	Category string
+	Severity string
+	File     string
+	Line     int64
+	Message  string
+	Key      string // position-free identity, see findingKeys
+}
+
+// DiffFindings returns the findings of newPath that baselinePath lacks, so a
+// PR gate only fails on what the change introduced. Findings match on the
+// enclosing function's package, receiver and name (not its position), the
+// category, and the message with digit runs (line numbers, counts) masked;
+// duplicates match one-for-one. Suppressed findings of newPath are ignored.
+func DiffFindings(baselinePath, newPath string) ([]Finding, error) {
+	baseline, err := findingKeys(baselinePath, "findings")
+	if err != nil {
+		return nil, err
+	}
+	current, err := findingKeys(newPath, "v_active_findings")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int, len(baseline))
+	for _, f := range baseline {
+		seen[f.Key]++
+	}
+	var added []Finding
+	for _, f := range current {
+		if seen[f.Key] > 0 {
+			seen[f.Key]--
+			continue
+		}
+		added = append(added, f)
+	}
+	return added, nil
+}
+
+// digitRuns masks the volatile numbers in finding messages.
+var digitRuns = regexp.MustCompile(`[0-9]+`)
+
+// findingKeys reads the findings of the database at path from table (findings
+// or v_active_findings) and computes each one's Key. The owner of a finding is
+// its node, or the function containing it; function literals defer to the
+// function declaring them, since their own IDs are positional.
+func findingKeys(path, table string) ([]Finding, error) {
+	conn, err := sqlite.OpenConn(path, sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer conn.Close()
+
+	type owner struct {
+		kind, name, pkg, recv, parent string
+	}
+	owners := make(map[string]owner)
+	lookup := func(id string) (owner, bool) {
+		if o, ok := owners[id]; ok {
+			return o, true
+		}
+		var o owner
+		found := false
+		err := sqlitex.Execute(conn,
+			`SELECT kind, name, COALESCE(package, ''), COALESCE(json_extract(properties, '$.receiver'), ''),
+			   COALESCE(parent_function, '') FROM nodes WHERE id = ?`,
+			&sqlitex.ExecOptions{
+				Args: []any{id},
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					o = owner{stmt.ColumnText(0), stmt.ColumnText(1), stmt.ColumnText(2), stmt.ColumnText(3), stmt.ColumnText(4)}
+					found = true
+					return nil
+				}})
+		if err != nil || !found {
+			return owner{}, false
+		}
+		owners[id] = o
+		return o, true
+	}
+
+	var findings []Finding
+	var nodeIDs []string
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT category, severity, COALESCE(file, ''), COALESCE(line, 0), message, COALESCE(node_id, '')
+		 FROM `+table+` ORDER BY id`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			findings = append(findings, Finding{
+				Category: stmt.ColumnText(0),
+				Severity: stmt.ColumnText(1),
+				File:     stmt.ColumnText(2),
+				Line:     stmt.ColumnInt64(3),
+				Message:  stmt.ColumnText(4),
+			})
+			nodeIDs = append(nodeIDs, stmt.ColumnText(5))
+			return nil
+		}}); err != nil {
+		return nil, fmt.Errorf("%s: findings: %w", path, err)
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		subject := f.File
+		if o, ok := lookup(nodeIDs[i]); ok {
+			if o.kind != "function" && o.parent != "" {
+				o, ok = lookup(o.parent)
+			}
+			for ok && o.kind == "function" && o.name == "func literal" && o.parent != "" {
+				o, ok = lookup(o.parent)
+			}
+			if ok {
+				subject = o.pkg + "." + o.name
+				if o.recv != "" {
+					subject = o.pkg + ".(" + o.recv + ")." + o.name
+				}
+			}
+		}
+		f.Key = subject + "\x00" + f.Category + "\x00" + digitRuns.ReplaceAllString(f.Message, "N")
+	}
+	return findings, nil
+}
+
+// runDiffFindings prints the findings of dbPath missing from baselinePath
+// (-diff-findings) and, with failOnNew, fails when there is any.
+func runDiffFindings(baselinePath, dbPath string, failOnNew bool, prog *Progress) error {
+	added, err := DiffFindings(baselinePath, dbPath)
+	if err != nil {
+		return fmt.Errorf("diff findings: %w", err)
+	}
+	for _, f := range added {
+		fmt.Printf("%s:%d: %s [%s] %s\n", f.File, f.Line, f.Severity, f.Category, f.Message)
+	}
+	prog.Log("%d new findings relative to %s", len(added), baselinePath)
+	if failOnNew && len(added) > 0 {
+		return fmt.Errorf("%d new findings relative to %s", len(added), baselinePath)
+	}
+	return nil
+}
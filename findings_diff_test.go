@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffFindingsReportsOnlyNew(t *testing.T) {
+	baseline := buildTestDBFile(t, `package fixture
+
+func Old(n int) int {
+	if n < 0 {
+		panic("negative")
+	}
+	return n
+}
+`)
+	// Old moves down a few lines; New is the only added finding.
+	current := buildTestDBFile(t, `package fixture
+
+// Old rejects negative input.
+//
+// It is unchanged apart from its position.
+func Old(n int) int {
+	if n < 0 {
+		panic("negative")
+	}
+	return n
+}
+
+func New(n int) int {
+	if n > 100 {
+		panic("too large")
+	}
+	return n
+}
+`)
+	added, err := DiffFindings(baseline, current)
+	if err != nil {
+		t.Fatalf("DiffFindings: %v", err)
+	}
+	var got []string
+	for _, f := range added {
+		if f.Category == "panic_call" {
+			got = append(got, f.Message)
+		}
+	}
+	if len(got) != 1 || !strings.HasPrefix(got[0], "New ") {
+		t.Errorf("new panic_call findings = %v, want only New's", got)
+	}
+	for _, f := range added {
+		if strings.HasPrefix(f.Message, "Old ") {
+			t.Errorf("pre-existing finding reported as new: %+v", f)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -29,14 +30,17 @@ type GitBlameEntry struct {
 }
 
 // RunGitHistory extracts per-file change frequency from `git log --numstat`
-// across all modules in the ModuleSet.
-func RunGitHistory(prog *Progress) []GitFileHistory {
+// across all modules in the ModuleSet. Cancelling ctx kills the running git.
+func RunGitHistory(ctx context.Context, prog *Progress) []GitFileHistory {
 	prog.Log("Running git log for file history across %d modules...", len(modSet.Dirs()))
 
 	var allResults []GitFileHistory
 
 	for _, mod := range modSet.Dirs() {
-		results := runGitHistoryForDir(mod.Dir, mod.Prefix, prog)
+		if ctx.Err() != nil {
+			break
+		}
+		results := runGitHistoryForDir(ctx, mod.Dir, mod.Prefix, prog)
 		allResults = append(allResults, results...)
 	}
 
@@ -44,8 +48,8 @@ func RunGitHistory(prog *Progress) []GitFileHistory {
 	return allResults
 }
 
-func runGitHistoryForDir(dir, prefix string, prog *Progress) []GitFileHistory {
-	cmd := exec.Command("git", "log", "--format=%H %aI %aN", "--numstat", "--no-merges", "-n", "500")
+func runGitHistoryForDir(ctx context.Context, dir, prefix string, prog *Progress) []GitFileHistory {
+	cmd := exec.CommandContext(ctx, "git", "log", "--format=%H %aI %aN", "--numstat", "--no-merges", "-n", "500")
 	cmd.Dir = dir
 
 	out, err := cmd.Output()
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// gobMagic and gobVersion head every -format gob file. Bump gobVersion when
+// Node, Edge or Metrics change shape; LoadCPGGob refuses other versions.
+const (
+	gobMagic   = "cpg-gob"
+	gobVersion = 1
+)
+
+// gobHeader is the first value in a gob CPG file.
+type gobHeader struct {
+	Magic     string
+	Version   int
+	Generator string
+}
+
+func init() {
+	// Property values travel as interface values; gob needs the concrete
+	// types that are not among its predeclared ones.
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+// GobSink is the CPGSink behind -format gob: the in-memory graph (nodes,
+// edges, sources, metrics) encoded with encoding/gob after a versioned
+// header, for Go programs that want the graph without SQLite. No derived
+// tables are computed.
+type GobSink struct {
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	enc  *gob.Encoder
+	prog *Progress
+}
+
+// NewGobSink creates (replacing) the file at path and writes the header.
+func NewGobSink(path string, prog *Progress) (*GobSink, error) {
+	prog.Log("Writing gob to %s ...", path)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create gob: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	s := &GobSink{path: path, f: f, w: w, enc: gob.NewEncoder(w), prog: prog}
+	if err := s.encode(gobHeader{Magic: gobMagic, Version: gobVersion, Generator: generatorVersion}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// encode writes v, removing the partial file on failure.
+func (s *GobSink) encode(v any) error {
+	if err := s.enc.Encode(v); err != nil {
+		_ = s.f.Close()
+		_ = os.Remove(s.path)
+		return fmt.Errorf("encode gob: %w", err)
+	}
+	return nil
+}
+
+func (s *GobSink) WriteNodes(nodes []Node) error { return s.encode(nodes) }
+
+func (s *GobSink) WriteEdges(edges []Edge) error { return s.encode(edges) }
+
+func (s *GobSink) WriteSources(sources map[string]string) error { return s.encode(sources) }
+
+func (s *GobSink) WriteMetrics(metrics map[string]*Metrics) error { return s.encode(metrics) }
+
+// Finalize flushes and closes the file.
+func (s *GobSink) Finalize() error {
+	if err := s.w.Flush(); err != nil {
+		_ = s.f.Close()
+		return fmt.Errorf("write gob: %w", err)
+	}
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("write gob: %w", err)
+	}
+	s.prog.Log("Wrote gob CPG to %s", s.path)
+	return nil
+}
+
+// WriteGob writes the CPG to a gob file.
+func WriteGob(path string, cpg *CPG, prog *Progress) error {
+	sink, err := NewGobSink(path, prog)
+	if err != nil {
+		return err
+	}
+	return WriteCPG(sink, cpg)
+}
+
+// LoadCPGGob reads a CPG written by -format gob. The result deduplicates
+// further AddNode/AddEdge calls against the loaded graph like a freshly
+// built one. gob does not keep empty slices apart from nil ones, so an empty
+// slice property (an external stub's params) loads as nil.
+func LoadCPGGob(path string) (*CPG, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := gob.NewDecoder(bufio.NewReader(f))
+
+	var hdr gobHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return nil, fmt.Errorf("%s: not a gob CPG: %w", path, err)
+	}
+	if hdr.Magic != gobMagic {
+		return nil, fmt.Errorf("%s: not a gob CPG (magic %q)", path, hdr.Magic)
+	}
+	if hdr.Version != gobVersion {
+		return nil, fmt.Errorf("%s: gob CPG version %d, want %d", path, hdr.Version, gobVersion)
+	}
+
+	cpg := NewCPG()
+	for _, v := range []any{&cpg.Nodes, &cpg.Edges, &cpg.Sources, &cpg.Metrics} {
+		if err := dec.Decode(v); err != nil {
+			return nil, fmt.Errorf("%s: decode gob: %w", path, err)
+		}
+	}
+	if cpg.Sources == nil {
+		cpg.Sources = make(map[string]string)
+	}
+	if cpg.Metrics == nil {
+		cpg.Metrics = make(map[string]*Metrics)
+	}
+	for _, n := range cpg.Nodes {
+		cpg.nodeSeen[n.ID] = struct{}{}
+	}
+	for _, e := range cpg.Edges {
+		cpg.edgeSeen[edgeKey{e.Source, e.Target, e.Kind}] = struct{}{}
+	}
+	return cpg, nil
+}
@@ -2,12 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"go/token"
+	"go/version"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -34,53 +39,166 @@ func readModulePath(dir string) string {
 	return ""
 }
 
+// defaultGoWorkVersion is the go line of a fabricated workspace.
+const defaultGoWorkVersion = "1.25.7"
+
+// readPrimaryGoWork parses dir/go.work. Returns nil, nil when there is none.
+func readPrimaryGoWork(dir string) (*modfile.WorkFile, error) {
+	path := filepath.Join(dir, "go.work")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read go.work: %w", err)
+	}
+	wf, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse go.work: %w", err)
+	}
+	return wf, nil
+}
+
+// WorkspaceModules returns the modules listed by `use` in primaryDir/go.work,
+// other than the primary module itself, so a monorepo that already uses a
+// workspace is analyzed as a whole without repeating every module in
+// -modules. Prefixes are the use paths relative to primaryDir (the base name
+// for directories outside it). Returns nil when there is no go.work.
+func WorkspaceModules(primaryDir string) ([]ModuleInfo, error) {
+	wf, err := readPrimaryGoWork(primaryDir)
+	if wf == nil || err != nil {
+		return nil, err
+	}
+	var mods []ModuleInfo
+	for _, u := range wf.Use {
+		dir := workPath(primaryDir, u.Path)
+		if dir == primaryDir {
+			continue
+		}
+		modPath := readModulePath(dir)
+		if modPath == "" {
+			return nil, fmt.Errorf("go.work: use %s: no module path in go.mod", u.Path)
+		}
+		prefix, err := filepath.Rel(primaryDir, dir)
+		if err != nil || strings.HasPrefix(prefix, "..") {
+			prefix = filepath.Base(dir)
+		}
+		mods = append(mods, ModuleInfo{ModPath: modPath, Dir: dir, Prefix: filepath.ToSlash(prefix)})
+	}
+	return mods, nil
+}
+
+// workPath resolves a go.work path (relative to the workspace dir) to an
+// absolute, cleaned path.
+func workPath(workDir, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(workDir, filepath.FromSlash(p))
+}
+
 // CreateTempGoWork writes a temporary go.work file that includes all modules
 // in the ModuleSet. Returns the path to the temp file (caller must os.Remove).
-// If a nested submodule declares the same module path as an already-listed
-// directory (e.g. alertmanager/internal/tools vs prometheus/internal/tools),
-// only the first occurrence is used to avoid "module appears multiple times".
+//
+// If the primary dir has its own go.work, that workspace is extended rather
+// than replaced: its use directives, replaces, toolchain and godebug lines
+// are kept (paths made absolute, since the temp file lives elsewhere) and
+// modules from the set that it doesn't list are added. Its use list is then
+// authoritative, so nested submodules are not discovered.
+//
+// Without one, nested submodules of every module are added too. If a nested
+// submodule declares the same module path as an already-listed directory
+// (e.g. alertmanager/internal/tools vs prometheus/internal/tools), only the
+// first occurrence is used to avoid "module appears multiple times".
 func CreateTempGoWork(ms *ModuleSet) (string, error) {
+	wf, err := readPrimaryGoWork(ms.PrimaryDir())
+	if err != nil {
+		return "", err
+	}
+
+	goVersion := defaultGoWorkVersion
+	if wf != nil && wf.Go != nil && version.Compare("go"+wf.Go.Version, "go"+goVersion) > 0 {
+		goVersion = wf.Go.Version
+	}
 	var buf strings.Builder
-	buf.WriteString("go 1.25.7\n\nuse (\n")
+	buf.WriteString("go " + goVersion + "\n\n")
+	if wf != nil {
+		if wf.Toolchain != nil {
+			buf.WriteString("toolchain " + wf.Toolchain.Name + "\n\n")
+		}
+		for _, g := range wf.Godebug {
+			buf.WriteString("godebug " + g.Key + "=" + g.Value + "\n")
+		}
+		if len(wf.Godebug) > 0 {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("use (\n")
 
 	// Track dirs and module paths we've already added.
 	seenDirs := make(map[string]bool, len(ms.Dirs()))
 	seenModPaths := make(map[string]bool, len(ms.Dirs())*2)
+	addUse := func(dir, modPath string) {
+		buf.WriteString("\t" + modfile.AutoQuote(dir) + "\n")
+		seenDirs[dir] = true
+		if modPath != "" {
+			seenModPaths[modPath] = true
+		}
+	}
 
-	for _, m := range ms.Dirs() {
-		buf.WriteString("\t" + m.Dir + "\n")
-		seenDirs[m.Dir] = true
-		if m.ModPath != "" {
-			seenModPaths[m.ModPath] = true
-		} else {
-			if p := readModulePath(m.Dir); p != "" {
-				seenModPaths[p] = true
+	if wf != nil {
+		for _, u := range wf.Use {
+			dir := workPath(ms.PrimaryDir(), u.Path)
+			if !seenDirs[dir] {
+				addUse(dir, readModulePath(dir))
 			}
 		}
 	}
 
+	for _, m := range ms.Dirs() {
+		if seenDirs[m.Dir] {
+			continue
+		}
+		modPath := m.ModPath
+		if modPath == "" {
+			modPath = readModulePath(m.Dir)
+		}
+		addUse(m.Dir, modPath)
+	}
+
 	// Walk ALL module directories to find nested sub-modules. Skip any submodule
 	// whose module path is already in the workspace (avoids duplicate e.g.
 	// github.com/prometheus/prometheus/internal/tools from alertmanager).
-	for _, m := range ms.Dirs() {
-		for _, d := range findSubModules(m.Dir) {
-			if seenDirs[d] {
-				continue
-			}
-			modPath := readModulePath(d)
-			if modPath != "" && seenModPaths[modPath] {
-				continue
-			}
-			buf.WriteString("\t" + d + "\n")
-			seenDirs[d] = true
-			if modPath != "" {
-				seenModPaths[modPath] = true
+	if wf == nil {
+		for _, m := range ms.Dirs() {
+			for _, d := range findSubModules(m.Dir) {
+				if seenDirs[d] {
+					continue
+				}
+				modPath := readModulePath(d)
+				if modPath != "" && seenModPaths[modPath] {
+					continue
+				}
+				addUse(d, modPath)
 			}
 		}
 	}
 
 	buf.WriteString(")\n")
 
+	if wf != nil && len(wf.Replace) > 0 {
+		buf.WriteString("\nreplace (\n")
+		for _, r := range wf.Replace {
+			buf.WriteString("\t" + modVersionString(r.Old.Path, r.Old.Version) + " => ")
+			newPath := r.New.Path
+			if r.New.Version == "" && modfile.IsDirectoryPath(newPath) {
+				newPath = workPath(ms.PrimaryDir(), newPath)
+			}
+			buf.WriteString(modVersionString(newPath, r.New.Version) + "\n")
+		}
+		buf.WriteString(")\n")
+	}
+
 	f, err := os.CreateTemp("", "cpg-workspace-*.work")
 	if err != nil {
 		return "", fmt.Errorf("create temp go.work: %w", err)
@@ -97,6 +215,14 @@ func CreateTempGoWork(ms *ModuleSet) (string, error) {
 	return f.Name(), nil
 }
 
+// modVersionString formats one side of a replace directive.
+func modVersionString(path, version string) string {
+	if version == "" {
+		return modfile.AutoQuote(path)
+	}
+	return modfile.AutoQuote(path) + " " + version
+}
+
 // findSubModules walks dir looking for directories with go.mod (excluding dir itself).
 func findSubModules(dir string) []string {
 	var dirs []string
@@ -121,8 +247,9 @@ func findSubModules(dir string) []string {
 }
 
 // LoadPackages loads all Go packages from all modules via a workspace,
-// filtering to only packages belonging to known modules.
-func LoadPackages(goworkPath string, prog *Progress) (*LoadResult, error) {
+// filtering to only packages belonging to known modules. Cancelling ctx stops
+// the go list driver.
+func LoadPackages(ctx context.Context, goworkPath string, prog *Progress) (*LoadResult, error) {
 	prog.Log("Loading packages via workspace (%d modules)...", len(modSet.Dirs()))
 
 	fset := token.NewFileSet()
@@ -136,13 +263,41 @@ func LoadPackages(goworkPath string, prog *Progress) (*LoadResult, error) {
 			packages.NeedSyntax |
 			packages.NeedTypesInfo |
 			packages.NeedTypesSizes,
-		Dir:   modSet.PrimaryDir(),
-		Fset:  fset,
-		Tests: false,
-		Env:   replaceEnv(os.Environ(), "GOWORK", goworkPath),
+		Context: ctx,
+		Dir:     modSet.PrimaryDir(),
+		Fset:    fset,
+		Tests:   false,
+		Env:     replaceEnv(os.Environ(), "GOWORK", goworkPath),
+	}
+	// Workspace mode ignores module vendor directories, so -include-vendor
+	// loads a single vendoring module on its own in vendor mode.
+	vendoring := false
+	if flagIncludeVendor {
+		primaryVendor := filepath.Join(modSet.PrimaryDir(), "vendor", "modules.txt")
+		if _, err := os.Stat(primaryVendor); err != nil {
+			prog.Log("Warning: -include-vendor: no %s; nothing to include", primaryVendor)
+		} else if len(modSet.Dirs()) > 1 {
+			prog.Log("Warning: -include-vendor: ignored with -modules (workspaces do not use module vendor directories)")
+		} else {
+			vendoring = true
+			env := replaceEnv(os.Environ(), "GOWORK", "off")
+			cfg.Env = replaceEnv(env, "GOFLAGS", strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=vendor"))
+		}
 	}
 
-	initial, err := packages.Load(cfg, modSet.LoadPatterns()...)
+	patterns := modSet.LoadPatterns()
+	if flagScope != "" {
+		// Only the scoped package is parsed and type-checked; its imports'
+		// types come from export data instead of source.
+		patterns = []string{flagScope}
+		cfg.Mode &^= packages.NeedDeps
+		prog.Log("Loading only %s (-scope)", flagScope)
+	}
+
+	initial, err := packages.Load(cfg, patterns...)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("packages.Load: %w", err)
 	}
@@ -160,6 +315,28 @@ func LoadPackages(goworkPath string, prog *Progress) (*LoadResult, error) {
 		}
 		filtered = append(filtered, pkg)
 	}
+	if flagScope != "" {
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("-scope %s matched no package of the analyzed modules", flagScope)
+		}
+		paths := make([]string, len(filtered))
+		for i, pkg := range filtered {
+			paths[i] = pkg.PkgPath
+		}
+		modSet.SetScope(paths)
+	}
+	if vendoring {
+		var vendored int
+		packages.Visit(initial, nil, func(pkg *packages.Package) {
+			if len(pkg.GoFiles) == 0 || modSet.IsKnownPkg(pkg.PkgPath) || modSet.VendorDir(filepath.Dir(pkg.GoFiles[0])) == "" {
+				return
+			}
+			modSet.AddVendored(pkg.PkgPath)
+			filtered = append(filtered, pkg)
+			vendored++
+		})
+		prog.Log("Including %d vendored packages", vendored)
+	}
 
 	// Count files and LOC (respecting skip filters)
 	var fileCount, loc int
@@ -191,6 +368,10 @@ func LoadPackages(goworkPath string, prog *Progress) (*LoadResult, error) {
 var (
 	flagSkipTests     = true
 	flagSkipGenerated = true
+	flagMaxFileSize   int64 // bytes; 0 means unlimited
+	flagIncludeVendor bool  // analyze vendor/ packages instead of stubbing them
+	flagEmitComments  = true
+	flagScope         string // package pattern (-scope) to analyze alone; "" for every module package
 )
 
 // replaceEnv returns a copy of environ with key set to val, replacing any
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExistingGoWorkIsExtended(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app/go.mod":  "module example.com/fixture\n\ngo 1.22\n",
+		"app/go.work": "go 1.22\n\nuse (\n\t.\n\t../lib\n)\n\nreplace example.com/unused => ../unused\n",
+		"app/app.go": `package fixture
+
+import "example.com/lib"
+
+func Greet() string { return lib.Hello() }
+`,
+		"lib/go.mod": "module example.com/lib\n\ngo 1.22\n",
+		"lib/lib.go": `package lib
+
+func Hello() string { return "hi" }
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOFLAGS", "")
+
+	appDir := filepath.Join(root, "app")
+	libDir := filepath.Join(root, "lib")
+	extras, err := WorkspaceModules(appDir)
+	if err != nil {
+		t.Fatalf("workspace modules: %v", err)
+	}
+	want := []ModuleInfo{{ModPath: "example.com/lib", Dir: libDir, Prefix: "lib"}}
+	if !slices.Equal(extras, want) {
+		t.Fatalf("workspace modules = %+v, want %+v", extras, want)
+	}
+
+	prevSet := modSet
+	t.Cleanup(func() { modSet = prevSet })
+	modSet = NewModuleSet(ModuleInfo{ModPath: "example.com/fixture", Dir: appDir}, extras)
+
+	goworkPath, err := CreateTempGoWork(modSet)
+	if err != nil {
+		t.Fatalf("go.work: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(goworkPath) })
+	work, err := os.ReadFile(goworkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(work), "example.com/unused => "+filepath.Join(root, "unused")) {
+		t.Errorf("replace not preserved with an absolute path:\n%s", work)
+	}
+
+	res, err := LoadPackages(context.Background(), goworkPath, NewProgress(false))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var paths []string
+	for _, pkg := range res.Packages {
+		paths = append(paths, pkg.PkgPath)
+	}
+	slices.Sort(paths)
+	if strings.Join(paths, ",") != "example.com/fixture,example.com/lib" {
+		t.Errorf("loaded packages = %v", paths)
+	}
+}
+
+func TestIncludeVendor(t *testing.T) {
+	prev := flagIncludeVendor
+	t.Cleanup(func() { flagIncludeVendor = prev })
+	flagIncludeVendor = true
+
+	cpg := buildTestCPGFiles(t, map[string]string{
+		"go.mod":             "module example.com/fixture\n\ngo 1.22\n\nrequire github.com/dep/lib v1.0.0\n",
+		"vendor/modules.txt": "# github.com/dep/lib v1.0.0\n## explicit; go 1.22\ngithub.com/dep/lib\n",
+		"vendor/github.com/dep/lib/lib.go": `package lib
+
+func Hello() string { return "hi" }
+`,
+		"fixture.go": `package fixture
+
+import "github.com/dep/lib"
+
+func Greet() string { return lib.Hello() }
+`,
+	})
+
+	var hello, greet *Node
+	for i := range cpg.Nodes {
+		n := &cpg.Nodes[i]
+		if n.Kind != "function" {
+			continue
+		}
+		switch n.Name {
+		case "Hello":
+			hello = n
+		case "Greet":
+			greet = n
+		}
+	}
+	if hello == nil || greet == nil {
+		t.Fatalf("functions: Hello=%v Greet=%v", hello, greet)
+	}
+	if hello.File != "vendor/github.com/dep/lib/lib.go" || hello.Package != "github.com/dep/lib" {
+		t.Errorf("vendored Hello: file %q, package %q", hello.File, hello.Package)
+	}
+	var call, imports bool
+	for _, e := range cpg.Edges {
+		call = call || e.Kind == "call" && e.Source == greet.ID && e.Target == hello.ID
+		imports = imports || e.Kind == "imports" && e.Source == PkgID("example.com/fixture") && e.Target == PkgID("github.com/dep/lib")
+	}
+	if !call {
+		t.Error("no call edge from Greet to the vendored Hello")
+	}
+	if !imports {
+		t.Error("no imports edge to the vendored package")
+	}
+}
+
+func TestScope(t *testing.T) {
+	prev := flagScope
+	t.Cleanup(func() { flagScope = prev })
+	flagScope = "./scrape"
+
+	cpg := buildTestCPGFiles(t, map[string]string{
+		"scrape/scrape.go": `package scrape
+
+import (
+	"strings"
+
+	"example.com/fixture/util"
+)
+
+func Scrape(s string) string { return strings.ToUpper(util.Trim(s)) }
+
+func helper() string { return Scrape("x") }
+`,
+		"util/util.go": `package util
+
+func Trim(s string) string { return s }
+`,
+		"other/other.go": `package other
+
+func Unrelated() {}
+`,
+	})
+
+	full := map[string]string{}
+	for _, n := range cpg.Nodes {
+		if n.Kind == "function" {
+			full[n.Name] = n.ID
+			if n.File != "" && n.Package != "scrape" {
+				t.Errorf("function %s of package %s has a full node outside -scope", n.Name, n.Package)
+			}
+		}
+	}
+	if full["Scrape"] == "" || full["helper"] == "" {
+		t.Fatalf("scoped functions missing: %v", full)
+	}
+	if !strings.HasPrefix(full["Trim"], "int::") || !strings.HasPrefix(full["ToUpper"], "ext::") {
+		t.Errorf("Trim = %q, ToUpper = %q; want int:: and ext:: stubs", full["Trim"], full["ToUpper"])
+	}
+	if _, ok := full["Unrelated"]; ok {
+		t.Error("unimported package analyzed under -scope")
+	}
+	var call bool
+	for _, e := range cpg.Edges {
+		call = call || e.Kind == "call" && e.Source == full["helper"] && e.Target == full["Scrape"]
+	}
+	if !call {
+		t.Error("no call edge helper -> Scrape within the scope")
+	}
+}
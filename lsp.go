@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// LSP bridge: `cpg-gen lsp <cpg.db>` answers textDocument/definition and
+// textDocument/references over stdio from the xrefs table, so an editor gets
+// go-to-definition from a generated CPG without a type checker. CPG lines and
+// columns are 1-based byte offsets; LSP positions are 0-based with characters
+// counted in UTF-16 code units, converted via the stored file contents.
+
+// lspPosition and friends mirror the LSP wire types the bridge needs.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+	Context  struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type lspRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// lspSymbol is a definition or use site: a CPG file, 1-based line, and the
+// 1-based byte column and name of its identifier.
+type lspSymbol struct {
+	file string
+	line int
+	col  int
+	name string
+}
+
+// lspServer resolves LSP requests against one CPG database.
+type lspServer struct {
+	conn  *sqlite.Conn
+	files []string            // CPG file paths, longest first for suffix matching
+	lines map[string][]string // file → source lines, loaded on demand
+}
+
+// runLSP implements `cpg-gen lsp <cpg.db>`.
+func runLSP(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: cpg-gen lsp <cpg.db>")
+	}
+	conn, err := sqlite.OpenConn(args[0], sqlite.OpenReadOnly)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", args[0], err)
+	}
+	defer conn.Close()
+	return ServeLSP(os.Stdin, os.Stdout, conn)
+}
+
+// ServeLSP reads JSON-RPC messages from r and writes responses to w until the
+// client sends exit or closes r. Requests other than initialize, shutdown,
+// definition and references get MethodNotFound; notifications are ignored.
+func ServeLSP(r io.Reader, w io.Writer, conn *sqlite.Conn) error {
+	s := &lspServer{conn: conn, lines: map[string][]string{}}
+	if err := s.loadFiles(); err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	for {
+		body, err := readLSPMessage(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("lsp: %w", err)
+		}
+		var req lspRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("lsp: %w", err)
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		if req.ID == nil {
+			continue
+		}
+		result, rpcErr := s.handle(req)
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		if err := writeLSPMessage(w, resp); err != nil {
+			return fmt.Errorf("lsp: %w", err)
+		}
+	}
+}
+
+func (s *lspServer) handle(req lspRequest) (any, map[string]any) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{"definitionProvider": true, "referencesProvider": true},
+			"serverInfo":   map[string]any{"name": "cpg-lsp", "version": generatorVersion},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/definition", "textDocument/references":
+		var p lspPositionParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, map[string]any{"code": -32602, "message": err.Error()}
+		}
+		locs, err := s.locations(req.Method, p)
+		if err != nil {
+			return nil, map[string]any{"code": -32603, "message": err.Error()}
+		}
+		if locs == nil {
+			return nil, nil
+		}
+		return locs, nil
+	}
+	return nil, map[string]any{"code": -32601, "message": "method not found: " + req.Method}
+}
+
+// loadFiles lists the CPG's source files. A DB built with -only-findings has
+// no xrefs table and cannot back the bridge.
+func (s *lspServer) loadFiles() error {
+	hasXrefs := false
+	if err := sqlitex.Execute(s.conn, `SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'xrefs'`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error { hasXrefs = true; return nil }}); err != nil {
+		return fmt.Errorf("lsp: %w", err)
+	}
+	if !hasXrefs {
+		return fmt.Errorf("lsp: database has no xrefs table (generated with -only-findings?)")
+	}
+	if err := sqlitex.Execute(s.conn, `SELECT file FROM sources`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			s.files = append(s.files, stmt.ColumnText(0))
+			return nil
+		}}); err != nil {
+		return fmt.Errorf("lsp: %w", err)
+	}
+	sort.Slice(s.files, func(i, j int) bool { return len(s.files[i]) > len(s.files[j]) })
+	return nil
+}
+
+// resolveURI maps a document URI to its CPG file and the directory the CPG's
+// relative paths are rooted at, by matching the longest CPG path that is a
+// suffix of the URI's path.
+func (s *lspServer) resolveURI(uri string) (file, root string, ok bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", "", false
+	}
+	for _, f := range s.files {
+		if strings.HasSuffix(u.Path, "/"+f) {
+			return f, strings.TrimSuffix(u.Path, f), true
+		}
+	}
+	return "", "", false
+}
+
+func (s *lspServer) locations(method string, p lspPositionParams) ([]lspLocation, error) {
+	file, root, ok := s.resolveURI(p.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	line := p.Position.Line + 1
+	col := s.byteCol(file, line, p.Position.Character)
+	defID, def, err := s.symbolAt(file, line, col)
+	if err != nil || defID == "" {
+		return nil, err
+	}
+
+	var syms []lspSymbol
+	if method == "textDocument/definition" || p.Context.IncludeDeclaration {
+		syms = append(syms, def)
+	}
+	if method == "textDocument/references" {
+		err := sqlitex.Execute(s.conn, `
+SELECT x.use_file, x.use_line, u.col, u.name
+FROM xrefs x JOIN nodes u ON u.id = x.use_id
+WHERE x.def_id = ? AND x.use_kind = 'identifier'
+ORDER BY x.use_file, x.use_line, u.col`,
+			&sqlitex.ExecOptions{Args: []any{defID}, ResultFunc: func(stmt *sqlite.Stmt) error {
+				syms = append(syms, lspSymbol{stmt.ColumnText(0), stmt.ColumnInt(1), stmt.ColumnInt(2), stmt.ColumnText(3)})
+				return nil
+			}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	locs := make([]lspLocation, 0, len(syms))
+	for _, sym := range syms {
+		start := lspPosition{Line: sym.line - 1, Character: s.utf16Col(sym.file, sym.line, sym.col)}
+		end := lspPosition{Line: sym.line - 1, Character: s.utf16Col(sym.file, sym.line, sym.col+len(sym.name))}
+		locs = append(locs, lspLocation{
+			URI:   (&url.URL{Scheme: "file", Path: root + sym.file}).String(),
+			Range: lspRange{Start: start, End: end},
+		})
+	}
+	return locs, nil
+}
+
+// symbolAt finds the definition referenced or declared by the identifier
+// covering file:line:col. Use sites come from xrefs; failing that, the
+// position may be on a definition's own name.
+func (s *lspServer) symbolAt(file string, line, col int) (string, lspSymbol, error) {
+	var defID string
+	var def lspSymbol
+	var defKind string
+	err := sqlitex.Execute(s.conn, `
+SELECT x.def_id, d.kind, d.name, x.def_file, x.def_line, d.col, u.col, u.name
+FROM xrefs x
+JOIN nodes u ON u.id = x.use_id
+JOIN nodes d ON d.id = x.def_id
+WHERE x.use_file = ? AND x.use_line = ? AND x.use_kind = 'identifier'`,
+		&sqlitex.ExecOptions{Args: []any{file, line}, ResultFunc: func(stmt *sqlite.Stmt) error {
+			if useCol := stmt.ColumnInt(6); defID == "" && useCol <= col && col < useCol+len(stmt.ColumnText(7)) {
+				defID, defKind = stmt.ColumnText(0), stmt.ColumnText(1)
+				def = lspSymbol{stmt.ColumnText(3), stmt.ColumnInt(4), stmt.ColumnInt(5), stmt.ColumnText(2)}
+			}
+			return nil
+		}})
+	if err != nil {
+		return "", def, err
+	}
+	if defID != "" {
+		return defID, s.nameSite(defKind, def), nil
+	}
+
+	err = sqlitex.Execute(s.conn, `
+SELECT DISTINCT x.def_id, d.kind, d.name, d.col
+FROM xrefs x JOIN nodes d ON d.id = x.def_id
+WHERE x.def_file = ? AND x.def_line = ?`,
+		&sqlitex.ExecOptions{Args: []any{file, line}, ResultFunc: func(stmt *sqlite.Stmt) error {
+			site := s.nameSite(stmt.ColumnText(1), lspSymbol{file, line, stmt.ColumnInt(3), stmt.ColumnText(2)})
+			if defID == "" && site.col <= col && col < site.col+len(site.name) {
+				defID, def = stmt.ColumnText(0), site
+			}
+			return nil
+		}})
+	return defID, def, err
+}
+
+// nameSite narrows a definition to its identifier. Function nodes start at
+// the func keyword and are named "Recv.Method", so the short name is looked
+// up on the declaration line (followed by "(" or "[" to skip a receiver type
+// that merely contains it).
+func (s *lspServer) nameSite(kind string, def lspSymbol) lspSymbol {
+	if kind != "function" {
+		return def
+	}
+	name := def.name[strings.LastIndex(def.name, ".")+1:]
+	def.name = name
+	text := s.line(def.file, def.line)
+	from := min(max(def.col-1, 0), len(text))
+	for i := from; ; {
+		j := strings.Index(text[i:], name)
+		if j < 0 {
+			return def
+		}
+		if k := i + j + len(name); k < len(text) && (text[k] == '(' || text[k] == '[') {
+			def.col = i + j + 1
+			return def
+		}
+		i += j + len(name)
+	}
+}
+
+// line returns the text of 1-based line n of a CPG file, or "" if unknown.
+func (s *lspServer) line(file string, n int) string {
+	lines, ok := s.lines[file]
+	if !ok {
+		_ = sqlitex.Execute(s.conn, `SELECT content FROM sources WHERE file = ?`,
+			&sqlitex.ExecOptions{Args: []any{file}, ResultFunc: func(stmt *sqlite.Stmt) error {
+				lines = strings.Split(stmt.ColumnText(0), "\n")
+				return nil
+			}})
+		s.lines[file] = lines
+	}
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
+}
+
+// byteCol converts a 0-based UTF-16 character offset on a line to a 1-based
+// CPG byte column.
+func (s *lspServer) byteCol(file string, line, char int) int {
+	text := s.line(file, line)
+	units := 0
+	for i, r := range text {
+		if units >= char {
+			return i + 1
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(text) + 1 + (char - units)
+}
+
+// utf16Col converts a 1-based CPG byte column to a 0-based UTF-16 character
+// offset.
+func (s *lspServer) utf16Col(file string, line, col int) int {
+	text := s.line(file, line)
+	prefix := text[:min(max(col-1, 0), len(text))]
+	units := 0
+	for _, r := range prefix {
+		units += utf16.RuneLen(r)
+	}
+	return units + (col - 1 - len(prefix))
+}
+
+// readLSPMessage reads one Content-Length framed message body.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && header == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		header = strings.TrimRight(header, "\r\n")
+		if header == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(header, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("bad Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(body) {
+		return nil, fmt.Errorf("message body is not UTF-8")
+	}
+	return body, nil
+}
+
+// writeLSPMessage writes v as one Content-Length framed JSON message.
+func writeLSPMessage(w io.Writer, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+)
+
+func TestLSPDefinitionAndReferences(t *testing.T) {
+	src := `package fixture
+
+type Store struct{ n int }
+
+func (s *Store) Get() int { return s.n }
+
+func helper(s *Store) int {
+	return s.Get() + s.n
+}
+
+// Run — uses helper.
+func Run() int { return /* é */ helper(&Store{}) }
+`
+	conn, err := sqlite.OpenConn(buildTestDBFile(t, src), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const uri = "file:///work/fixture.go"
+	pos := func(line, char int) map[string]any {
+		return map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": line, "character": char},
+			"context":      map[string]any{"includeDeclaration": true},
+		}
+	}
+	var in bytes.Buffer
+	for i, msg := range []map[string]any{
+		{"id": 1, "method": "initialize", "params": map[string]any{}},
+		{"method": "initialized", "params": map[string]any{}},
+		// helper in Run: 0-based line 11; the é before it is two bytes
+		// but one UTF-16 unit, so the name starts at character 32.
+		{"id": 2, "method": "textDocument/definition", "params": pos(11, 33)},
+		// Get in helper's body.
+		{"id": 3, "method": "textDocument/definition", "params": pos(7, 10)},
+		// References from helper's own declaration.
+		{"id": 4, "method": "textDocument/references", "params": pos(6, 6)},
+		{"id": 5, "method": "shutdown"},
+		{"method": "exit"},
+	} {
+		msg["jsonrpc"] = "2.0"
+		if err := writeLSPMessage(&in, msg); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+	var out bytes.Buffer
+	if err := ServeLSP(&in, &out, conn); err != nil {
+		t.Fatalf("ServeLSP: %v", err)
+	}
+
+	results := map[int]json.RawMessage{}
+	br := bufio.NewReader(&out)
+	for {
+		body, err := readLSPMessage(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil {
+			t.Errorf("request %d: error %s", resp.ID, resp.Error)
+		}
+		results[resp.ID] = resp.Result
+	}
+	if len(results) != 5 {
+		t.Fatalf("got %d responses, want 5 (notifications are not answered)", len(results))
+	}
+
+	locs := func(id int) []lspLocation {
+		var l []lspLocation
+		if err := json.Unmarshal(results[id], &l); err != nil {
+			t.Fatalf("request %d: %v", id, err)
+		}
+		return l
+	}
+	want := lspLocation{URI: uri, Range: lspRange{Start: lspPosition{6, 5}, End: lspPosition{6, 11}}}
+	if got := locs(2); len(got) != 1 || got[0] != want {
+		t.Errorf("definition of helper = %+v, want %+v", got, want)
+	}
+	want = lspLocation{URI: uri, Range: lspRange{Start: lspPosition{4, 16}, End: lspPosition{4, 19}}}
+	if got := locs(3); len(got) != 1 || got[0] != want {
+		t.Errorf("definition of Get = %+v, want %+v", got, want)
+	}
+	refs := locs(4)
+	if len(refs) != 2 || refs[0].Range.Start != (lspPosition{6, 5}) || refs[1].Range.Start != (lspPosition{11, 32}) {
+		t.Errorf("references of helper = %+v, want declaration at 6:5 and use at 11:32", refs)
+	}
+	if string(results[5]) != "null" {
+		t.Errorf("shutdown result = %s, want null", results[5])
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime/debug"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// generatorVersion is recorded in META_DATA and cpg_manifest.
+const generatorVersion = "1.0"
+
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -20,13 +28,51 @@ func main() {
 // (including temp file cleanup) execute even on error paths, unlike os.Exit
 // which skips deferred calls.
 func run() error {
+	if len(os.Args) > 1 && os.Args[1] == "api-diff" {
+		return runAPIDiff(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		return runLSP(os.Args[2:])
+	}
+
 	skipGenerated := flag.Bool("skip-generated", true, "Skip .pb.go files")
 	skipTests := flag.Bool("skip-tests", true, "Skip _test.go files")
+	includeVendor := flag.Bool("include-vendor", false, "Analyze packages under the primary module's vendor/ as full nodes instead of ext:: stubs (single module only; much larger DB)")
+	scope := flag.String("scope", "", "Analyze only this package (e.g. ./scrape, relative to <primary-dir>, or an import path): its imports become stubs, types come from export data and escape analysis is skipped; for quick single-package graphs")
+	maxFileSize := flag.Int64("max-file-size", 0, "Files larger than this many bytes get only a file node marked oversized (no source text, declarations or statements); 0 means unlimited")
+	emitComments := flag.Bool("emit-comments", true, "Create comment nodes and doc edges; -emit-comments=false shrinks the DB but loses doc edges, //cpg:ignore and //nolint suppressions and todo_comment findings")
 	verbose := flag.Bool("verbose", false, "Print detailed progress")
 	validate := flag.Bool("validate", false, "Run validation queries after write")
+	coverProfile := flag.String("coverage", "", "Go coverage profile (go test -coverprofile) to overlay on functions and statements")
+	dumpSchema := flag.String("dump-schema", "", "Also write the database's CREATE TABLE/VIEW/INDEX statements, without data, to this directory as cpg-schema-<version>.sql")
+	bundle := flag.String("bundle", "", "Also write a tar (cpg.db, findings.sarif, report.txt, manifest.json) to this path, or - for stdout")
+	serve := flag.String("serve", "", "After writing the DB, serve it with cpg-server on this address (:8080 for all interfaces, or host:port, e.g. 127.0.0.1:8080) until interrupted")
+	watch := flag.Bool("watch", false, "After writing the DB, regenerate it whenever .go/go.mod files change in the analyzed modules (restarts -serve's server) until interrupted")
+	topN := flag.Int("top-n", 0, "Rows per dashboard leaderboard (top functions, hotspots); 0 keeps the defaults of 50/200")
+	godFields := flag.Int("god-type-fields", godTypeFields, "god_type finding: struct has more than this many fields")
+	godMethods := flag.Int("god-type-methods", godTypeMethods, "god_type finding: struct has more than this many methods")
+	findingsOnly := flag.Bool("only-findings", false, "Lint mode: build the graph, metrics, taint and findings but skip dashboard, graph intelligence, centrality, navigation, SCIP and communication tables (no long_param_list/god_package/god_type/high_coupling/central_function findings)")
+	stableIDs := flag.Bool("stable-ids", false, "Derive node IDs from names and structural paths instead of positions")
+	internal := flag.String("internal-prefixes", "", "Comma-separated import-path prefixes (e.g. github.com/acme/) of first-party dependencies; their callees get int:: stubs instead of ext::")
+	nodeLimit := flag.Int("node-limit", 0, "Past this many nodes, keep only package, file and function nodes (0 = no limit); META_DATA records truncated=true and the capped kinds")
+	edgeLimit := flag.Int("edge-limit", 0, "Past this many edges, keep only call edges (0 = no limit); META_DATA records truncated=true and the capped kinds")
+	emitNodes := flag.String("emit-nodes", "", "Comma-separated node kinds to keep (e.g. function,type_decl,package,file); default all")
+	emitEdges := flag.String("emit-edges", "", "Comma-separated edge kinds to keep (e.g. call,ast,implements); phases producing none are skipped. Analyses need their inputs: taint and slices need dfg, call tables need call")
+	extDFG := flag.String("external-dfg", externalDFG, "DFG inferred through ext::/int:: calls: none, precise (flow_semantics argument→result), heuristic (plus modelled side effects) or fallback (plus all arguments→result for unmodelled calls)")
+	taintHops := flag.Int("taint-max-hops", taintMaxHops, "Steps the taint BFS follows from a source (DFG and receiver-carried); deep codebases need more to reach their sinks, shallow ones run faster with fewer")
+	timeout := flag.Duration("timeout", 0, "Give up generating after this long (e.g. 30m), keeping any previous output; 0 means no limit. With -watch it bounds each regeneration")
+	format := flag.String("format", "sqlite", "Output format: sqlite (the full database), gob (nodes, edges, sources and metrics only, for LoadCPGGob) or parquet (a directory of nodes, edges and metrics .parquet files)")
+	diffFindings := flag.String("diff-findings", "", "After writing the DB, print only the findings missing from this baseline CPG (matched on function, category and message without numbers)")
+	failOnNew := flag.Bool("fail-on-new-findings", false, "With -diff-findings, exit non-zero when there are new findings")
+	excludeCats := flag.String("exclude-findings", "", "Comma-separated finding categories not to generate (e.g. todo_comment,similar_function); their SQL is not run, and saved queries reading only them are dropped")
+	sevMap := flag.String("severity-map", "", "JSON file mapping finding category to severity (info, warning or error), e.g. {\"long_param_list\": \"error\"}; applied after every finding is generated")
+	var dotTypes dotTypesFlag
+	flag.Var(&dotTypes, "dot-types", "After writing the DB, print a Graphviz DOT of type embeds (solid) and implements (dashed) edges to stdout; -dot-types=prefix limits it to packages under prefix")
 	modules := flag.String("modules", "", "Comma-separated dir:modpath:name triples for additional modules (e.g. ./adapter:sigs.k8s.io/prometheus-adapter:adapter)")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: cpg-gen [flags] <primary-dir> <output.db>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: cpg-gen [flags] <primary-dir> <output.db>\n")
+		fmt.Fprintf(os.Stderr, "       cpg-gen api-diff <old.db> <new.db>\n")
+		fmt.Fprintf(os.Stderr, "       cpg-gen lsp <cpg.db>   (LSP definition/references over stdio)\n\n")
 		fmt.Fprintf(os.Stderr, "Generates a Code Property Graph (CPG) SQLite database from Go modules.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
@@ -50,19 +96,181 @@ func run() error {
 	// Wire skip flags into the package-level config used by shouldSkipFile
 	flagSkipGenerated = *skipGenerated
 	flagSkipTests = *skipTests
+	flagIncludeVendor = *includeVendor
+	flagEmitComments = *emitComments
+	if strings.Contains(*scope, "...") {
+		return fmt.Errorf("-scope takes a single package, got %q", *scope)
+	}
+	if *scope != "" && *includeVendor {
+		return fmt.Errorf("-scope and -include-vendor are mutually exclusive")
+	}
+	flagScope = *scope
+	if *maxFileSize < 0 {
+		return fmt.Errorf("-max-file-size must be >= 0, got %d", *maxFileSize)
+	}
+	flagMaxFileSize = *maxFileSize
+
+	if *topN < 0 {
+		return fmt.Errorf("-top-n must be >= 0, got %d", *topN)
+	}
+	leaderboardLimit = *topN
+	godTypeFields, godTypeMethods = *godFields, *godMethods
+	onlyFindings = *findingsOnly
+	if _, ok := externalDFGLevels[*extDFG]; !ok {
+		return fmt.Errorf("-external-dfg must be none, precise, heuristic or fallback, got %q", *extDFG)
+	}
+	externalDFG = *extDFG
+	if *sevMap != "" {
+		if severityMap, err = LoadSeverityMap(*sevMap); err != nil {
+			return err
+		}
+	}
+	excludeFindings = ParseKindList(*excludeCats)
+	if *nodeLimit < 0 || *edgeLimit < 0 {
+		return fmt.Errorf("-node-limit and -edge-limit must be >= 0, got %d and %d", *nodeLimit, *edgeLimit)
+	}
+	graphLimits = GraphLimits{Nodes: *nodeLimit, Edges: *edgeLimit}
+	emitFilter = EmitFilter{Nodes: ParseKindList(*emitNodes), Edges: ParseKindList(*emitEdges)}
+	if *taintHops < 1 {
+		return fmt.Errorf("-taint-max-hops must be >= 1, got %d", *taintHops)
+	}
+	taintMaxHops = *taintHops
+
+	switch *format {
+	case "sqlite":
+	case "gob", "parquet":
+		if *bundle != "" || *serve != "" || *validate || dotTypes.set || *diffFindings != "" || *dumpSchema != "" {
+			return fmt.Errorf("-bundle, -serve, -validate, -dot-types, -diff-findings and -dump-schema need -format sqlite")
+		}
+	default:
+		return fmt.Errorf("-format must be sqlite, gob or parquet, got %q", *format)
+	}
+
+	if dotTypes.set && *bundle == "-" {
+		return fmt.Errorf("-dot-types and -bundle - both write to stdout")
+	}
+	if *diffFindings != "" && (dotTypes.set || *bundle == "-") {
+		return fmt.Errorf("-diff-findings writes to stdout, as do -dot-types and -bundle -")
+	}
+	if *failOnNew && *diffFindings == "" {
+		return fmt.Errorf("-fail-on-new-findings needs -diff-findings")
+	}
+	if *diffFindings != "" {
+		if _, err := os.Stat(*diffFindings); err != nil {
+			return fmt.Errorf("-diff-findings: %w", err)
+		}
+	}
+
+	// Check -serve up front rather than after a long generation run
+	if *serve != "" {
+		if _, _, err := splitServeAddr(*serve); err != nil {
+			return err
+		}
+		if _, err := findServer(); err != nil {
+			return err
+		}
+	}
+
+	if *timeout < 0 {
+		return fmt.Errorf("-timeout must be >= 0, got %s", *timeout)
+	}
 
 	prog := NewProgress(*verbose)
+	if taintMaxHops > taintHopsWarn {
+		prog.Log("Warning: -taint-max-hops %d: the taint BFS grows with every step, so large bounds can take much longer on big codebases", taintMaxHops)
+	}
+
+	// SIGINT/SIGTERM cancel generation (removing the partial output) and stop
+	// -watch and -serve
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	goworkPath, opts, err := prepareWorkspace(workspaceOptions{
+		PrimaryDir:       promDir,
+		Modules:          *modules,
+		InternalPrefixes: *internal,
+		CoverProfile:     *coverProfile,
+		StableIDs:        *stableIDs,
+		Validate:         *validate,
+		Format:           *format,
+		Timeout:          *timeout,
+	}, prog)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(goworkPath)
 
+	if err := generate(ctx, goworkPath, outputPath, opts, prog); err != nil {
+		return err
+	}
+
+	// Optional: tar of the DB plus its exports for CI artifacts
+	if *bundle != "" {
+		if err := WriteBundleFile(*bundle, outputPath, prog); err != nil {
+			return err
+		}
+	}
+
+	// Optional: standalone DDL for tools that create compatible databases
+	if *dumpSchema != "" {
+		if _, err := WriteSchemaFile(*dumpSchema, outputPath, prog); err != nil {
+			return err
+		}
+	}
+
+	// Optional: type hierarchy diagram for architecture docs
+	if dotTypes.set {
+		if err := WriteTypeDOTFile(outputPath, dotTypes.prefix, prog); err != nil {
+			return err
+		}
+	}
+
+	// Optional: findings introduced relative to a baseline, for PR gating
+	if *diffFindings != "" {
+		if err := runDiffFindings(*diffFindings, outputPath, *failOnNew, prog); err != nil {
+			return err
+		}
+	}
+
+	// Optional: rebuild on source changes (restarting -serve's server)
+	if *watch {
+		return runWatch(ctx, goworkPath, outputPath, *bundle, *serve, opts, prog)
+	}
+
+	// Optional: serve the fresh DB, shutting the server down on SIGINT/SIGTERM
+	if *serve != "" {
+		return serveDB(ctx, outputPath, *serve, prog)
+	}
+	return nil
+}
+
+// workspaceOptions are the command-line arguments and flags that decide the
+// module set and how generate writes it.
+type workspaceOptions struct {
+	PrimaryDir       string // analyzed as the github.com/prometheus/prometheus module
+	Modules          string // dir:modpath:name triples, as -modules
+	InternalPrefixes string // comma-separated, as -internal-prefixes
+	CoverProfile     string
+	StableIDs        bool
+	Validate         bool
+	Format           string        // "sqlite" (the default), "gob" or "parquet"
+	Timeout          time.Duration // 0 = none
+}
+
+// prepareWorkspace sets the module set and internal prefixes of opts, whose
+// PrimaryDir is absolute, and creates the temporary go.work that unifies
+// their type universe; the caller removes it.
+func prepareWorkspace(opts workspaceOptions, prog *Progress) (string, generateOptions, error) {
 	// Build ModuleSet from primary dir + extra modules
 	primary := ModuleInfo{
 		ModPath: "github.com/prometheus/prometheus",
-		Dir:     promDir,
+		Dir:     opts.PrimaryDir,
 		Prefix:  "", // primary module keeps paths unprefixed for backward compat
 	}
 
 	var extras []ModuleInfo
-	if *modules != "" {
-		for _, spec := range strings.Split(*modules, ",") {
+	if opts.Modules != "" {
+		for _, spec := range strings.Split(opts.Modules, ",") {
 			parts := strings.SplitN(strings.TrimSpace(spec), ":", 3)
 			if len(parts) != 3 {
 				prog.Log("Warning: invalid --modules spec %q (want dir:modpath:name)", spec)
@@ -81,32 +289,146 @@ func run() error {
 		}
 	}
 
+	// Modules from an existing go.work in the primary dir join the set, unless
+	// -modules already names them
+	wsMods, err := WorkspaceModules(opts.PrimaryDir)
+	if err != nil {
+		return "", generateOptions{}, err
+	}
+	for _, wm := range wsMods {
+		dup := false
+		for _, m := range extras {
+			if m.Dir == wm.Dir || m.ModPath == wm.ModPath {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			extras = append(extras, wm)
+		}
+	}
+	if len(wsMods) > 0 {
+		prog.Log("Using go.work in %s (%d additional use directories)", opts.PrimaryDir, len(wsMods))
+	}
+
 	modSet = NewModuleSet(primary, extras)
+	internalPrefixes = nil
+	for _, p := range strings.Split(opts.InternalPrefixes, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			internalPrefixes = append(internalPrefixes, p)
+		}
+	}
+	if len(internalPrefixes) > 0 {
+		prog.Log("Treating %s as internal dependencies", strings.Join(internalPrefixes, ", "))
+	}
 	prog.Log("Analyzing %d modules: %s", len(modSet.Dirs()), moduleNames(modSet))
 
+	// Parse the coverage profile up front so a bad path fails fast
+	var coverBlocks []CoverBlock
+	if opts.CoverProfile != "" {
+		if coverBlocks, err = ParseCoverProfile(opts.CoverProfile); err != nil {
+			return "", generateOptions{}, err
+		}
+	}
+
 	// Create temporary go.work for unified type universe
 	goworkPath, err := CreateTempGoWork(modSet)
 	if err != nil {
-		return err
+		return "", generateOptions{}, err
 	}
-	defer os.Remove(goworkPath)
 	prog.Verbose("Created workspace: %s", goworkPath)
 
+	format := opts.Format
+	if format == "" {
+		format = "sqlite"
+	}
+	return goworkPath, generateOptions{
+		root:        opts.PrimaryDir,
+		coverBlocks: coverBlocks,
+		stableIDs:   opts.StableIDs,
+		validate:    opts.Validate,
+		format:      format,
+		timeout:     opts.Timeout,
+	}, nil
+}
+
+// generateOptions carries the flag-derived inputs of one pipeline run.
+type generateOptions struct {
+	root        string // primary module dir, recorded in META_DATA
+	coverBlocks []CoverBlock
+	stableIDs   bool
+	validate    bool
+	format      string        // "sqlite", "gob" or "parquet"
+	timeout     time.Duration // per generate call; 0 = none
+	// onPhase, if set, is called as each phase after loading starts; tests
+	// cancel at a phase boundary through it
+	onPhase func(phase string)
+}
+
+// generate runs every phase over the workspace at goworkPath and writes the
+// database (with -format gob or parquet, the bare graph) to outputPath. -watch calls it
+// again on each change.
+//
+// Cancelling ctx, or exceeding opts.timeout, stops the run between phases
+// (SSA and the call graph also check within). The output is written beside
+// outputPath and moved into place only once complete (writeOutput), so a
+// failed run leaves the previous output as it was: a partial database would
+// look complete to its readers.
+func generate(ctx context.Context, goworkPath, outputPath string, opts generateOptions, prog *Progress) (err error) {
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	phase := "loading packages"
+	enter := func(next string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		phase = next
+		if opts.onPhase != nil {
+			opts.onPhase(next)
+		}
+		return nil
+	}
+	defer func() {
+		if err == nil || ctx.Err() == nil {
+			return
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && opts.timeout > 0 {
+			err = fmt.Errorf("generation timed out after %s (-timeout) while %s: %w", opts.timeout, phase, ctx.Err())
+		} else {
+			err = fmt.Errorf("generation cancelled while %s: %w", phase, ctx.Err())
+		}
+	}()
+
 	cpg := NewCPG()
 
 	// Phase 1: Load packages (all modules, single type universe)
-	loadResult, err := LoadPackages(goworkPath, prog)
+	loadResult, err := LoadPackages(ctx, goworkPath, prog)
 	if err != nil {
 		return err
 	}
 
 	// Phase 2: Walk AST → nodes + AST edges + position lookup
+	if err := enter("walking the AST"); err != nil {
+		return err
+	}
 	posLookup, funcLookup := WalkAST(loadResult.Packages, loadResult.Fset, cpg, prog)
 
 	// Phase 3: Build SSA
-	ssaResult := BuildSSA(loadResult.Packages, prog)
+	if err := enter("building SSA"); err != nil {
+		return err
+	}
+	ssaResult, err := BuildSSA(ctx, loadResult.Packages, prog)
+	if err != nil {
+		return err
+	}
 
 	// Phase 4: Extract CFG + DFG from SSA
+	if err := enter("extracting CFG, DFG and CDG"); err != nil {
+		return err
+	}
 	ExtractCFGAndDFG(ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog)
 
 	// Phase 4b: Extract CDG from post-dominator tree
@@ -119,9 +441,17 @@ func run() error {
 	ExtractPanicRecover(ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog)
 
 	// Phase 5: Build VTA call graph → call edges
-	BuildCallGraph(ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog)
+	if err := enter("building the call graph"); err != nil {
+		return err
+	}
+	if err := BuildCallGraph(ctx, ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog); err != nil {
+		return err
+	}
 
 	// Phase 6: Extract type relationships (implements, embeds)
+	if err := enter("computing types and metrics"); err != nil {
+		return err
+	}
 	ExtractTypeRelationships(loadResult.Packages, loadResult.Fset, posLookup, cpg, prog)
 
 	// Phase 7: Compute function metrics
@@ -130,28 +460,89 @@ func run() error {
 	// Phase 7b: Fill fan-in/fan-out from call graph
 	ComputeFanInOut(cpg)
 
+	// Phase 7c: Acyclic CFG path counts
+	ComputePathCounts(cpg, prog)
+
+	// Optional: coverage overlay
+	if opts.coverBlocks != nil {
+		ApplyCoverage(cpg, opts.coverBlocks, prog)
+	}
+
 	// Add META_DATA node with generator info
+	meta := map[string]any{
+		"language":   "go",
+		"version":    generatorVersion,
+		"generator":  "cpg-gen",
+		"root":       opts.root,
+		"modules":    len(modSet.Dirs()),
+		"stable_ids": opts.stableIDs,
+		// -taint-max-hops: taint_flow_state misses sinks further away
+		"taint_max_hops": taintMaxHops,
+	}
+	// -node-limit / -edge-limit: record what the graph lacks
+	if cpg.Truncated() {
+		cpg.LogTruncation(prog)
+		if n := cpg.PruneCappedEdges(); n > 0 {
+			prog.Log("Removed %d edges to dropped nodes", n)
+		}
+		meta["truncated"] = true
+		nodeKinds, edgeKinds := cpg.CappedKinds()
+		if len(nodeKinds) > 0 {
+			meta["capped_node_kinds"] = nodeKinds
+		}
+		if len(edgeKinds) > 0 {
+			meta["capped_edge_kinds"] = edgeKinds
+		}
+	}
 	cpg.AddNode(Node{
-		ID:   "META_DATA",
-		Kind: "meta_data",
-		Name: "CPG Metadata",
-		Properties: map[string]any{
-			"language":  "go",
-			"version":   "1.0",
-			"generator": "cpg-gen",
-			"root":      promDir,
-			"modules":   len(modSet.Dirs()),
-		},
+		ID:         "META_DATA",
+		Kind:       "meta_data",
+		Name:       "CPG Metadata",
+		Properties: meta,
 	})
 
+	// Optional: position-independent IDs (line/col stay as columns)
+	if opts.stableIDs {
+		StabilizeIDs(cpg, prog)
+	}
+
+	// -format gob/parquet: the in-memory graph only; escape analysis, git
+	// history and every derived table belong to the SQLite output
+	switch opts.format {
+	case "gob", "parquet":
+		if err := enter("writing the " + opts.format); err != nil {
+			return err
+		}
+		write := WriteGob
+		if opts.format == "parquet" {
+			write = WriteParquet
+		}
+		if err := writeOutput(outputPath, func(path string) error { return write(path, cpg, prog) }); err != nil {
+			return err
+		}
+		prog.Log("Done. %d nodes, %d edges.", len(cpg.Nodes), len(cpg.Edges))
+		return nil
+	}
+
 	// Phase 7c: Escape analysis from Go compiler (all modules)
-	escapeResults := RunEscapeAnalysis(prog)
+	if err := enter("running escape analysis"); err != nil {
+		return err
+	}
+	escapeResults := RunEscapeAnalysis(ctx, prog)
 
 	// Phase 7d: Git history for diff-aware analysis (all modules)
-	gitHistory := RunGitHistory(prog)
+	if err := enter("reading git history"); err != nil {
+		return err
+	}
+	gitHistory := RunGitHistory(ctx, prog)
 
 	// Phase 8: Write SQLite
-	if err := WriteDB(outputPath, cpg, escapeResults, gitHistory, *validate, prog); err != nil {
+	if err := enter("writing the database"); err != nil {
+		return err
+	}
+	if err := writeOutput(outputPath, func(path string) error {
+		return WriteDBContext(ctx, path, cpg, escapeResults, gitHistory, opts.validate, prog)
+	}); err != nil {
 		return err
 	}
 
@@ -159,6 +550,47 @@ func run() error {
 	return nil
 }
 
+// writeOutput calls write with a path in a temporary directory beside
+// outputPath (the same filesystem, so renames are atomic) and, if it
+// succeeds, moves the result to outputPath. The temporary directory is
+// removed either way. A -format parquet output is a directory: its files are
+// moved into outputPath, replacing same-named ones and leaving others alone.
+func writeOutput(outputPath string, write func(path string) error) error {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(outputPath), ".cpg-gen-")
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmp := filepath.Join(tmpDir, filepath.Base(outputPath))
+	if err := write(tmp); err != nil {
+		return err
+	}
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		// A WAL left by an earlier database would be replayed into this one
+		for _, suffix := range []string{"-wal", "-shm"} {
+			_ = os.Remove(outputPath + suffix)
+		}
+		return os.Rename(tmp, outputPath)
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outputPath, 0o755); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Rename(filepath.Join(tmp, e.Name()), filepath.Join(outputPath, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // moduleNames returns a human-readable list of module prefixes.
 func moduleNames(ms *ModuleSet) string {
 	names := make([]string, len(ms.Dirs()))
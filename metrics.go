@@ -99,3 +99,116 @@ func countParams(ft *ast.FuncType) int {
 	}
 	return n
 }
+
+// maxPathCount caps ComputePathCounts; anything at the cap means "explosive".
+const maxPathCount = 1_000_000_000
+
+// ComputePathCounts fills Metrics.PathCount with the number of distinct
+// acyclic entry→exit paths through each function's CFG (basic blocks and cfg
+// edges from ExtractCFGAndDFG). Loops are counted Ball–Larus style: a back
+// edge ends one path and starts another at the loop header, so a loop body
+// contributes its paths once instead of once per iteration. Must run after
+// ComputeMetrics.
+func ComputePathCounts(cpg *CPG, prog *Progress) {
+	blockFunc := make(map[string]string) // basic block → function
+	for _, n := range cpg.Nodes {
+		if n.Kind == "basic_block" {
+			blockFunc[n.ID] = n.ParentFunction
+		}
+	}
+
+	entry := make(map[string]string) // function → entry block
+	exits := make(map[string]bool)   // blocks with an exit edge
+	succs := make(map[string][]string)
+	for _, e := range cpg.Edges {
+		if e.Kind != "cfg" {
+			continue
+		}
+		switch e.Properties["label"] {
+		case "entry":
+			entry[e.Source] = e.Target
+		case "exit":
+			exits[e.Source] = true
+		default:
+			if _, ok := blockFunc[e.Source]; ok {
+				succs[e.Source] = append(succs[e.Source], e.Target)
+			}
+		}
+	}
+
+	var count, capped int
+	for funcID, start := range entry {
+		m := cpg.Metrics[funcID]
+		if m == nil {
+			continue
+		}
+		m.PathCount = countAcyclicPaths(start, succs, exits)
+		count++
+		if m.PathCount >= maxPathCount {
+			capped++
+		}
+	}
+	prog.Log("Computed CFG path counts for %d functions (%d at the %d cap)", count, capped, maxPathCount)
+}
+
+// countAcyclicPaths counts Ball–Larus paths from start: back edges (found by
+// DFS) are replaced with an edge to exit plus an edge from entry to the loop
+// header. Sums saturate at maxPathCount.
+func countAcyclicPaths(start string, succs map[string][]string, exits map[string]bool) int {
+	add := func(a, b int) int { return min(a+b, maxPathCount) }
+
+	// Classify back edges by DFS from the entry block.
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := map[string]int{}
+	back := map[[2]string]bool{}
+	headers := map[string]bool{}
+	var dfs func(b string)
+	dfs = func(b string) {
+		state[b] = onStack
+		for _, s := range succs[b] {
+			switch state[s] {
+			case unvisited:
+				dfs(s)
+			case onStack:
+				back[[2]string{b, s}] = true
+				headers[s] = true
+			}
+		}
+		state[b] = done
+	}
+	dfs(start)
+
+	// Paths from each block to the virtual exit over the remaining DAG.
+	memo := map[string]int{}
+	var paths func(b string) int
+	paths = func(b string) int {
+		if n, ok := memo[b]; ok {
+			return n
+		}
+		n := 0
+		if exits[b] {
+			n = 1
+		}
+		for _, s := range succs[b] {
+			if back[[2]string{b, s}] {
+				n = add(n, 1)
+			} else {
+				n = add(n, paths(s))
+			}
+		}
+		memo[b] = n
+		return n
+	}
+
+	total := paths(start)
+	for h := range headers {
+		if h != start {
+			total = add(total, paths(h))
+		}
+	}
+	return total
+}
@@ -1,6 +1,12 @@
 package main
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
 
 // Node represents a vertex in the Code Property Graph.
 type Node struct {
@@ -10,6 +16,7 @@ type Node struct {
 	File           string // relative to repo root
 	Line, Col      int
 	EndLine        int
+	EndCol         int    // exclusive; 0 when unknown
 	Package        string // relative import path
 	ParentFunction string // node ID of enclosing function, or ""
 	TypeInfo       string
@@ -32,6 +39,7 @@ type Metrics struct {
 	FanOut               int
 	LOC                  int
 	NumParams            int
+	PathCount            int // acyclic CFG paths (ComputePathCounts); 0 when no CFG
 }
 
 // edgeKey is the deduplication key for edges.
@@ -39,6 +47,24 @@ type edgeKey struct {
 	Source, Target, Kind string
 }
 
+// GraphLimits caps the graph size (-node-limit, -edge-limit; 0 = no cap).
+// Once a CPG holds Nodes nodes (Edges edges), AddNode (AddEdge) drops every
+// kind outside essentialNodeKinds (essentialEdgeKinds), so generated code or
+// a huge switch degrades the graph instead of exhausting memory.
+type GraphLimits struct {
+	Nodes, Edges int
+}
+
+// graphLimits is set by main before any pipeline phase runs.
+var graphLimits GraphLimits
+
+// essentialNodeKinds and essentialEdgeKinds are kept past the graph limits:
+// the function-level call graph and the package/file skeleton it hangs on.
+var (
+	essentialNodeKinds = map[string]bool{"meta_data": true, "package": true, "file": true, "function": true}
+	essentialEdgeKinds = map[string]bool{"call": true}
+)
+
 // CPG accumulates the entire Code Property Graph in memory before flushing to SQLite.
 type CPG struct {
 	Nodes    []Node
@@ -47,6 +73,12 @@ type CPG struct {
 	edgeSeen map[edgeKey]struct{}
 	Sources  map[string]string   // file → content
 	Metrics  map[string]*Metrics // function_id → metrics
+
+	// Past graphLimits: kind → nodes/edges dropped, and the dropped node IDs
+	// (whose edges PruneCappedEdges removes)
+	cappedNodes  map[string]int
+	cappedEdges  map[string]int
+	droppedNodes map[string]struct{}
 }
 
 // NewCPG creates an empty CPG ready for population.
@@ -59,25 +91,101 @@ func NewCPG() *CPG {
 	}
 }
 
-// AddNode appends a node, deduplicating by ID (first wins).
+// AddNode appends a node, deduplicating by ID (first wins). Kinds excluded
+// by emitFilter are dropped, as are non-essential kinds past graphLimits.
 func (g *CPG) AddNode(n Node) {
+	if !emitFilter.Node(n.Kind) {
+		return
+	}
 	if _, dup := g.nodeSeen[n.ID]; dup {
 		return
 	}
+	if graphLimits.Nodes > 0 && len(g.Nodes) >= graphLimits.Nodes && !essentialNodeKinds[n.Kind] {
+		if g.cappedNodes == nil {
+			g.cappedNodes = make(map[string]int)
+			g.droppedNodes = make(map[string]struct{})
+		}
+		g.cappedNodes[n.Kind]++
+		g.droppedNodes[n.ID] = struct{}{}
+		return
+	}
 	g.nodeSeen[n.ID] = struct{}{}
 	g.Nodes = append(g.Nodes, n)
 }
 
 // AddEdge appends an edge if no edge with the same (source, target, kind) already exists.
+// Kinds excluded by emitFilter are dropped, as are non-essential kinds past
+// graphLimits.
 func (g *CPG) AddEdge(e Edge) {
+	if !emitFilter.Edge(e.Kind) {
+		return
+	}
 	k := edgeKey{e.Source, e.Target, e.Kind}
 	if _, dup := g.edgeSeen[k]; dup {
 		return
 	}
+	if graphLimits.Edges > 0 && len(g.Edges) >= graphLimits.Edges && !essentialEdgeKinds[e.Kind] {
+		if g.cappedEdges == nil {
+			g.cappedEdges = make(map[string]int)
+		}
+		g.cappedEdges[e.Kind]++
+		return
+	}
 	g.edgeSeen[k] = struct{}{}
 	g.Edges = append(g.Edges, e)
 }
 
+// Truncated reports whether graphLimits dropped any node or edge.
+func (g *CPG) Truncated() bool {
+	return len(g.cappedNodes) > 0 || len(g.cappedEdges) > 0
+}
+
+// CappedKinds returns the sorted node and edge kinds graphLimits dropped.
+func (g *CPG) CappedKinds() (nodes, edges []string) {
+	return slices.Sorted(maps.Keys(g.cappedNodes)), slices.Sorted(maps.Keys(g.cappedEdges))
+}
+
+// LogTruncation reports the node and edge kinds graphLimits dropped, with
+// how many of each.
+func (g *CPG) LogTruncation(prog *Progress) {
+	for _, capped := range []struct {
+		what   string
+		limit  int
+		counts map[string]int
+	}{{"node", graphLimits.Nodes, g.cappedNodes}, {"edge", graphLimits.Edges, g.cappedEdges}} {
+		if len(capped.counts) == 0 {
+			continue
+		}
+		var parts []string
+		total := 0
+		for _, kind := range slices.Sorted(maps.Keys(capped.counts)) {
+			parts = append(parts, fmt.Sprintf("%s=%d", kind, capped.counts[kind]))
+			total += capped.counts[kind]
+		}
+		prog.Log("Warning: -%s-limit %d reached; dropped %d %ss (%s)",
+			capped.what, capped.limit, total, capped.what, strings.Join(parts, ", "))
+	}
+}
+
+// PruneCappedEdges removes the edges that touch a node graphLimits dropped
+// (added before or after it was), so no edge dangles, and returns how many.
+func (g *CPG) PruneCappedEdges() int {
+	if len(g.droppedNodes) == 0 {
+		return 0
+	}
+	n := len(g.Edges)
+	g.Edges = slices.DeleteFunc(g.Edges, func(e Edge) bool {
+		_, src := g.droppedNodes[e.Source]
+		_, dst := g.droppedNodes[e.Target]
+		if src || dst {
+			delete(g.edgeSeen, edgeKey{e.Source, e.Target, e.Kind})
+			return true
+		}
+		return false
+	})
+	return n - len(g.Edges)
+}
+
 // PropsJSON marshals a properties map to JSON string, or "" if empty.
 func PropsJSON(m map[string]any) string {
 	if len(m) == 0 {
@@ -18,7 +18,9 @@ type ModuleInfo struct {
 // Global instance (modSet) is set once in main() before any pipeline phase,
 // consistent with flagSkipTests/flagSkipGenerated globals.
 type ModuleSet struct {
-	modules []ModuleInfo
+	modules  []ModuleInfo
+	vendored map[string]bool // -include-vendor package paths, analyzed like module code
+	scope    map[string]bool // -scope package paths; when set, the only analyzed packages
 }
 
 // Global instance — set in main() before pipeline runs.
@@ -35,8 +37,21 @@ func NewModuleSet(primary ModuleInfo, extras []ModuleInfo) *ModuleSet {
 	return ms
 }
 
-// IsKnownPkg returns true if pkgPath belongs to any module in the set.
+// IsKnownPkg returns true if pkgPath belongs to any module in the set or is
+// a vendored package added by AddVendored; with a scope (SetScope), only for
+// the scoped packages.
 func (ms *ModuleSet) IsKnownPkg(pkgPath string) bool {
+	if ms.scope != nil {
+		return ms.scope[pkgPath]
+	}
+	if ms.vendored[pkgPath] {
+		return true
+	}
+	return ms.inModules(pkgPath)
+}
+
+// inModules reports whether pkgPath belongs to any module in the set.
+func (ms *ModuleSet) inModules(pkgPath string) bool {
 	for _, m := range ms.modules {
 		if pkgPath == m.ModPath || strings.HasPrefix(pkgPath, m.ModPath+"/") {
 			return true
@@ -45,9 +60,50 @@ func (ms *ModuleSet) IsKnownPkg(pkgPath string) bool {
 	return false
 }
 
+// AddVendored marks pkgPath, a package loaded from a module's vendor
+// directory, as analyzed code (-include-vendor).
+func (ms *ModuleSet) AddVendored(pkgPath string) {
+	if ms.vendored == nil {
+		ms.vendored = make(map[string]bool)
+	}
+	ms.vendored[pkgPath] = true
+}
+
+// SetScope restricts the analyzed packages to pkgPaths (-scope): the rest of
+// the modules become internal dependencies, reached through int:: stubs.
+func (ms *ModuleSet) SetScope(pkgPaths []string) {
+	ms.scope = make(map[string]bool, len(pkgPaths))
+	for _, p := range pkgPaths {
+		ms.scope[p] = true
+	}
+}
+
+// VendorDir returns the vendor directory dir belongs to ("" if none): the
+// vendor/ directly under an analyzed module's root.
+func (ms *ModuleSet) VendorDir(dir string) string {
+	for _, m := range ms.modules {
+		vendor := filepath.Join(m.Dir, "vendor")
+		if rel, err := filepath.Rel(vendor, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return vendor
+		}
+	}
+	return ""
+}
+
+// trimVendor strips a vendor directory prefix from an import path:
+// "vendor/golang.org/x/net" and "example.com/app/vendor/golang.org/x/net"
+// both display as "golang.org/x/net".
+func trimVendor(pkgPath string) string {
+	if i := strings.LastIndex(pkgPath, "/vendor/"); i >= 0 {
+		return pkgPath[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(pkgPath, "vendor/")
+}
+
 // RelPkg strips the module prefix from a full import path and prepends the
 // module's Prefix. Prometheus (Prefix:"") yields "scrape"; adapter (Prefix:"adapter")
-// yields "adapter/pkg/client".
+// yields "adapter/pkg/client". Vendored packages keep their import path, minus
+// any vendor/ prefix.
 //
 // When module paths are nested (e.g., "github.com/foo" and "github.com/foo/bar"),
 // we prefer the longest matching ModPath to avoid the parent claiming child packages.
@@ -79,6 +135,9 @@ func (ms *ModuleSet) RelPkg(fullPath string) string {
 	if matched {
 		return bestResult
 	}
+	if ms.vendored[fullPath] {
+		return trimVendor(fullPath)
+	}
 	return fullPath
 }
 
@@ -118,6 +177,52 @@ func (ms *ModuleSet) RelFile(absPath string) string {
 	return bestPrefix + "/" + bestRel
 }
 
+// RelFileFromImport converts an import-path-qualified file name, as written
+// in coverage profiles ("github.com/prometheus/prometheus/scrape/manager.go"),
+// to the relative file path used in node IDs. Returns "" for unknown modules.
+func (ms *ModuleSet) RelFileFromImport(importFile string) string {
+	best := -1
+	var bestAbs string
+	for _, m := range ms.modules {
+		if rel, ok := strings.CutPrefix(importFile, m.ModPath+"/"); ok && len(m.ModPath) > best {
+			best = len(m.ModPath)
+			bestAbs = filepath.Join(m.Dir, filepath.FromSlash(rel))
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return ms.RelFile(bestAbs)
+}
+
+// ModuleOf returns the ModPath of the analyzed module a node comes from: by
+// its prefixed file path, or by its relative package for package nodes.
+// ext::/int:: stubs and other file-less nodes belong to no module ("").
+func (ms *ModuleSet) ModuleOf(n Node) string {
+	var path string
+	switch {
+	case n.File != "":
+		path = n.File
+	case n.Kind == "package":
+		path = n.Package
+	default:
+		return ""
+	}
+	// Unprefixed paths belong to the primary module; a prefixed module claims
+	// its own subtree (the longest prefix wins).
+	best, mod := -1, ""
+	for _, m := range ms.modules {
+		if m.Prefix == "" {
+			if best < 0 {
+				best, mod = 0, m.ModPath
+			}
+		} else if (path == m.Prefix || strings.HasPrefix(path, m.Prefix+"/")) && len(m.Prefix) > best {
+			best, mod = len(m.Prefix), m.ModPath
+		}
+	}
+	return mod
+}
+
 // PrimaryDir returns the first (primary) module's directory.
 func (ms *ModuleSet) PrimaryDir() string {
 	return ms.modules[0].Dir
@@ -137,3 +242,31 @@ func (ms *ModuleSet) LoadPatterns() []string {
 	}
 	return patterns
 }
+
+// internalPrefixes are import-path prefixes (-internal-prefixes) of
+// first-party packages that are dependencies rather than analyzed modules,
+// e.g. "github.com/acme/" in a multi-module monorepo. Set by main before any
+// pipeline phase runs.
+var internalPrefixes []string
+
+// IsInternalDep reports whether pkgPath is outside every analyzed module but
+// matches an -internal-prefixes entry. Such packages have no source nodes
+// (add the module with -modules for that), but their callees get int:: stubs
+// instead of being lumped in with stdlib and third-party ext:: stubs.
+// Analyzed modules always win, so the primary module's unprefixed IDs and
+// "main" package name are unaffected. With -scope, module packages outside
+// the scope are internal dependencies too.
+func (ms *ModuleSet) IsInternalDep(pkgPath string) bool {
+	if ms.IsKnownPkg(pkgPath) {
+		return false
+	}
+	if ms.scope != nil && ms.inModules(pkgPath) {
+		return true
+	}
+	for _, p := range internalPrefixes {
+		if strings.HasPrefix(pkgPath, p) {
+			return true
+		}
+	}
+	return false
+}
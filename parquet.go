@@ -0,0 +1,137 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetNode is a row of nodes.parquet. Columns mirror the nodes table:
+// empty strings are written as nulls and properties is the same JSON text
+// the SQLite output stores.
+type parquetNode struct {
+	ID             string `parquet:"id"`
+	Kind           string `parquet:"kind"`
+	Name           string `parquet:"name"`
+	File           string `parquet:"file,optional"`
+	Line           int64  `parquet:"line"`
+	Col            int64  `parquet:"col"`
+	EndLine        int64  `parquet:"end_line"`
+	EndCol         int64  `parquet:"end_col"`
+	Package        string `parquet:"package,optional"`
+	ParentFunction string `parquet:"parent_function,optional"`
+	TypeInfo       string `parquet:"type_info,optional"`
+	Properties     string `parquet:"properties,optional"`
+	Module         string `parquet:"module,optional"`
+}
+
+// parquetEdge is a row of edges.parquet (source, target, kind, properties).
+type parquetEdge struct {
+	Source     string `parquet:"source"`
+	Target     string `parquet:"target"`
+	Kind       string `parquet:"kind"`
+	Properties string `parquet:"properties,optional"`
+}
+
+// parquetMetrics is a row of metrics.parquet, one per function, sorted by
+// function_id.
+type parquetMetrics struct {
+	FunctionID           string `parquet:"function_id"`
+	CyclomaticComplexity int64  `parquet:"cyclomatic_complexity"`
+	FanIn                int64  `parquet:"fan_in"`
+	FanOut               int64  `parquet:"fan_out"`
+	LOC                  int64  `parquet:"loc"`
+	NumParams            int64  `parquet:"num_params"`
+	PathCount            int64  `parquet:"path_count"`
+}
+
+// ParquetSink is the CPGSink behind -format parquet: a directory holding
+// nodes.parquet, edges.parquet and metrics.parquet for columnar analytics
+// (DuckDB, pandas, Spark). Sources and the derived tables of the SQLite
+// output are not written.
+type ParquetSink struct {
+	dir  string
+	prog *Progress
+}
+
+// NewParquetSink creates the output directory dir.
+func NewParquetSink(dir string, prog *Progress) (*ParquetSink, error) {
+	prog.Log("Writing parquet to %s ...", dir)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create parquet dir: %w", err)
+	}
+	return &ParquetSink{dir: dir, prog: prog}, nil
+}
+
+// writeParquetFile writes rows to dir/name, removing the partial file on
+// failure.
+func writeParquetFile[T any](dir, name string, rows []T) error {
+	path := filepath.Join(dir, name)
+	if err := parquet.WriteFile(path, rows); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *ParquetSink) WriteNodes(nodes []Node) error {
+	rows := make([]parquetNode, len(nodes))
+	for i, n := range nodes {
+		rows[i] = parquetNode{
+			ID: n.ID, Kind: n.Kind, Name: n.Name, File: n.File,
+			Line: int64(n.Line), Col: int64(n.Col), EndLine: int64(n.EndLine), EndCol: int64(n.EndCol),
+			Package: n.Package, ParentFunction: n.ParentFunction, TypeInfo: n.TypeInfo,
+			Properties: PropsJSON(n.Properties), Module: modSet.ModuleOf(n),
+		}
+	}
+	return writeParquetFile(s.dir, "nodes.parquet", rows)
+}
+
+func (s *ParquetSink) WriteEdges(edges []Edge) error {
+	rows := make([]parquetEdge, len(edges))
+	for i, e := range edges {
+		rows[i] = parquetEdge{Source: e.Source, Target: e.Target, Kind: e.Kind, Properties: PropsJSON(e.Properties)}
+	}
+	return writeParquetFile(s.dir, "edges.parquet", rows)
+}
+
+// WriteSources is a no-op: file contents have no place in the columnar export.
+func (s *ParquetSink) WriteSources(map[string]string) error { return nil }
+
+func (s *ParquetSink) WriteMetrics(metrics map[string]*Metrics) error {
+	rows := make([]parquetMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		rows = append(rows, parquetMetrics{
+			FunctionID:           m.FunctionID,
+			CyclomaticComplexity: int64(m.CyclomaticComplexity),
+			FanIn:                int64(m.FanIn),
+			FanOut:               int64(m.FanOut),
+			LOC:                  int64(m.LOC),
+			NumParams:            int64(m.NumParams),
+			PathCount:            int64(m.PathCount),
+		})
+	}
+	slices.SortFunc(rows, func(a, b parquetMetrics) int { return cmp.Compare(a.FunctionID, b.FunctionID) })
+	return writeParquetFile(s.dir, "metrics.parquet", rows)
+}
+
+// Finalize only logs; each file is complete once its Write method returns.
+func (s *ParquetSink) Finalize() error {
+	s.prog.Log("Wrote parquet CPG to %s", s.dir)
+	return nil
+}
+
+// WriteParquet writes the CPG's nodes, edges and metrics as parquet files
+// under dir.
+func WriteParquet(dir string, cpg *CPG, prog *Progress) error {
+	sink, err := NewParquetSink(dir, prog)
+	if err != nil {
+		return err
+	}
+	return WriteCPG(sink, cpg)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestWriteParquet(t *testing.T) {
+	cpg := buildTestCPG(t, `package fixture
+
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+`)
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := WriteParquet(dir, cpg, NewProgress(false)); err != nil {
+		t.Fatalf("WriteParquet: %v", err)
+	}
+
+	nodes, err := parquet.ReadFile[parquetNode](filepath.Join(dir, "nodes.parquet"))
+	if err != nil {
+		t.Fatalf("read nodes: %v", err)
+	}
+	edges, err := parquet.ReadFile[parquetEdge](filepath.Join(dir, "edges.parquet"))
+	if err != nil {
+		t.Fatalf("read edges: %v", err)
+	}
+	metrics, err := parquet.ReadFile[parquetMetrics](filepath.Join(dir, "metrics.parquet"))
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	if len(nodes) != len(cpg.Nodes) || len(edges) != len(cpg.Edges) || len(metrics) != len(cpg.Metrics) {
+		t.Fatalf("rows: %d nodes, %d edges, %d metrics; want %d, %d, %d",
+			len(nodes), len(edges), len(metrics), len(cpg.Nodes), len(cpg.Edges), len(cpg.Metrics))
+	}
+
+	var abs *parquetNode
+	for i := range nodes {
+		if nodes[i].Kind == "function" && nodes[i].Name == "Abs" {
+			abs = &nodes[i]
+		}
+	}
+	if abs == nil {
+		t.Fatal("no Abs function row")
+	}
+	if abs.File != "fixture.go" || abs.Line != 3 || abs.Properties == "" || abs.Module != "example.com/fixture" {
+		t.Errorf("Abs row: file %q, line %d, properties %q, module %q", abs.File, abs.Line, abs.Properties, abs.Module)
+	}
+	var m *parquetMetrics
+	for i := range metrics {
+		if metrics[i].FunctionID == abs.ID {
+			m = &metrics[i]
+		}
+	}
+	if m == nil || m.CyclomaticComplexity != 2 {
+		t.Errorf("Abs metrics = %+v, want cyclomatic_complexity 2", m)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// buildTestDB runs the full pipeline over a single-file fixture module and
+// returns a connection to the resulting database. Escape analysis and git
+// history are skipped (they shell out to go build / git).
+func buildTestDB(t *testing.T, src string) *sqlite.Conn {
+	t.Helper()
+	conn, err := sqlite.OpenConn(buildTestDBFile(t, src), sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// buildTestDBFile is buildTestDB for callers that need the database path.
+func buildTestDBFile(t *testing.T, src string) string {
+	t.Helper()
+	return writeTestDB(t, buildTestCPG(t, src))
+}
+
+// writeTestDB writes cpg to a temporary database and returns its path.
+func writeTestDB(t *testing.T, cpg *CPG) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "cpg.db")
+	if err := WriteDB(dbPath, cpg, nil, nil, false, NewProgress(false)); err != nil {
+		t.Fatalf("write db: %v", err)
+	}
+	return dbPath
+}
+
+// buildTestCPG runs the in-memory phases of the pipeline over a single-file
+// fixture module.
+func buildTestCPG(t *testing.T, src string) *CPG {
+	t.Helper()
+	return buildTestCPGFiles(t, map[string]string{"fixture.go": src})
+}
+
+// buildTestCPGFiles is buildTestCPG for multi-package fixtures: files maps
+// slash-separated paths (relative to the module root) to their contents. A
+// "go.mod" entry replaces the default one (go 1.22).
+func buildTestCPGFiles(t *testing.T, files map[string]string) *CPG {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/fixture\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return buildTestCPGModules(t, NewModuleSet(ModuleInfo{ModPath: "example.com/fixture", Dir: dir}, nil))
+}
+
+// buildTestCPGModules runs the in-memory phases over the modules of set,
+// whose directories the caller has populated.
+func buildTestCPGModules(t *testing.T, set *ModuleSet) *CPG {
+	t.Helper()
+	// Workspace mode rejects -mod=mod, which some environments set globally.
+	t.Setenv("GOFLAGS", "")
+	// Load stdlib sources from the workspace's toolchain: x/tools' SSA builder
+	// cannot construct bodies for stdlib packages newer than it knows about.
+
+	prevSet := modSet
+	t.Cleanup(func() { modSet = prevSet })
+	modSet = set
+
+	goworkPath, err := CreateTempGoWork(modSet)
+	if err != nil {
+		t.Fatalf("go.work: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(goworkPath) })
+
+	prog := NewProgress(false)
+	cpg := NewCPG()
+	loadResult, err := LoadPackages(context.Background(), goworkPath, prog)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	posLookup, funcLookup := WalkAST(loadResult.Packages, loadResult.Fset, cpg, prog)
+	ssaResult, err := BuildSSA(context.Background(), loadResult.Packages, prog)
+	if err != nil {
+		t.Fatalf("ssa: %v", err)
+	}
+	ExtractCFGAndDFG(ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog)
+	ExtractCDG(ssaResult, loadResult.Fset, funcLookup, cpg, prog)
+	ExtractChannelFlow(ssaResult, loadResult.Fset, posLookup, cpg, prog)
+	ExtractPanicRecover(ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog)
+	if err := BuildCallGraph(context.Background(), ssaResult, loadResult.Fset, posLookup, funcLookup, cpg, prog); err != nil {
+		t.Fatalf("call graph: %v", err)
+	}
+	ExtractTypeRelationships(loadResult.Packages, loadResult.Fset, posLookup, cpg, prog)
+	ComputeMetrics(loadResult.Packages, loadResult.Fset, funcLookup, cpg, prog)
+	ComputeFanInOut(cpg)
+	ComputePathCounts(cpg, prog)
+	return cpg
+}
+
+// queryStrings returns the first column of every row produced by query.
+func queryStrings(t *testing.T, conn *sqlite.Conn, query string, args ...any) []string {
+	t.Helper()
+	var out []string
+	err := sqlitex.Execute(conn, query, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			out = append(out, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	return out
+}
+
+func TestGenerateCancelled(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":     "module example.com/fixture\n\ngo 1.22\n",
+		"fixture.go": "package fixture\n\nimport \"fmt\"\n\nfunc Hello() { fmt.Println(\"hi\") }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prevSet := modSet
+	t.Cleanup(func() { modSet = prevSet })
+	modSet = NewModuleSet(ModuleInfo{ModPath: "example.com/fixture", Dir: dir}, nil)
+	goworkPath, err := CreateTempGoWork(modSet)
+	if err != nil {
+		t.Fatalf("go.work: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(goworkPath) })
+	outDir := t.TempDir()
+	out := filepath.Join(outDir, "cpg.db")
+	// A previous run's output survives a failed one
+	if err := os.WriteFile(out, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unchanged := func(what string) {
+		t.Helper()
+		if b, err := os.ReadFile(out); err != nil || string(b) != "previous" {
+			t.Errorf("%s replaced the previous output (read: %q, %v)", what, b, err)
+		}
+		if entries, _ := os.ReadDir(outDir); len(entries) != 1 {
+			t.Errorf("%s left %d entries in the output dir, want just cpg.db", what, len(entries))
+		}
+	}
+	// cancelAt returns options whose run is cancelled as it enters phase.
+	cancelAt := func(phase string, opts generateOptions) (context.Context, generateOptions) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		opts.onPhase = func(p string) {
+			if p == phase {
+				cancel()
+			}
+		}
+		return ctx, opts
+	}
+
+	ctx, opts := cancelAt("building SSA", generateOptions{})
+	start := time.Now()
+	err = generate(ctx, goworkPath, out, opts, NewProgress(false))
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "cancelled while building SSA") {
+		t.Fatalf("cancelled run: err = %v", err)
+	}
+	if d := time.Since(start); d > time.Minute {
+		t.Errorf("cancelled run took %s", d)
+	}
+	unchanged("run cancelled while building SSA")
+
+	ctx, opts = cancelAt("writing the database", generateOptions{})
+	err = generate(ctx, goworkPath, out, opts, NewProgress(false))
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "cancelled while writing the database") {
+		t.Fatalf("run cancelled while writing: err = %v", err)
+	}
+	unchanged("run cancelled while writing")
+
+	// -timeout: the deadline passes while packages load.
+	err = generate(context.Background(), goworkPath, out, generateOptions{timeout: time.Nanosecond}, NewProgress(false))
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after 1ns (-timeout) while loading packages") {
+		t.Fatalf("timed-out run: err = %v", err)
+	}
+	unchanged("timed-out run")
+
+	// A complete run replaces the previous output
+	if err := generate(context.Background(), goworkPath, out, generateOptions{}, NewProgress(false)); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if b, err := os.ReadFile(out); err != nil || !strings.HasPrefix(string(b), "SQLite format 3") {
+		t.Errorf("complete run did not write a database to %s (read: %v)", out, err)
+	}
+}
+
+func TestGraphLimits(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod": "module example.com/fixture\n\ngo 1.22\n",
+		"fixture.go": `package fixture
+
+import "fmt"
+
+func Hello(n int) {
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Println("fizz")
+		case 1:
+			fmt.Println("buzz")
+		default:
+			greet(i)
+		}
+	}
+}
+
+func greet(i int) { fmt.Println(i) }
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prevSet, prevLimits := modSet, graphLimits
+	t.Cleanup(func() { modSet, graphLimits = prevSet, prevLimits })
+	modSet = NewModuleSet(ModuleInfo{ModPath: "example.com/fixture", Dir: dir}, nil)
+	graphLimits = GraphLimits{Nodes: 10, Edges: 10}
+	goworkPath, err := CreateTempGoWork(modSet)
+	if err != nil {
+		t.Fatalf("go.work: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(goworkPath) })
+	out := filepath.Join(t.TempDir(), "cpg.db")
+	if err := generate(context.Background(), goworkPath, out, generateOptions{}, NewProgress(false)); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	conn, err := sqlite.OpenConn(out, sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer conn.Close()
+	if got := queryStrings(t, conn, `PRAGMA integrity_check`); strings.Join(got, ",") != "ok" {
+		t.Errorf("integrity_check = %v", got)
+	}
+	got := queryStrings(t, conn, `SELECT json_extract(properties, '$.truncated') || ':' ||
+		(json_array_length(properties, '$.capped_node_kinds') > 0) || ':' ||
+		(json_array_length(properties, '$.capped_edge_kinds') > 0)
+		FROM nodes WHERE id = 'META_DATA'`)
+	if want := "1:1:1"; strings.Join(got, ",") != want {
+		t.Errorf("META_DATA truncated:node kinds:edge kinds = %v, want [%s]", got, want)
+	}
+	// Functions and calls survive the caps; no edge points at a dropped node
+	if got := queryStrings(t, conn, `SELECT caller_name || '->' || callee_name FROM v_call_graph WHERE caller_name = 'Hello' AND callee_name = 'greet'`); len(got) != 1 {
+		t.Errorf("Hello->greet call = %v, want it kept", got)
+	}
+	if got := queryStrings(t, conn, `SELECT COUNT(*) FROM edges WHERE source NOT IN (SELECT id FROM nodes) OR target NOT IN (SELECT id FROM nodes)`); got[0] != "0" {
+		t.Errorf("orphan edges = %s, want 0", got[0])
+	}
+	if got := queryStrings(t, conn, `SELECT COUNT(*) <= 10 FROM nodes WHERE kind NOT IN ('meta_data', 'package', 'file', 'function')`); got[0] != "1" {
+		t.Error("more non-essential nodes than -node-limit")
+	}
+}
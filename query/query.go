@@ -0,0 +1,283 @@
+// Package query reads a database written by cpg-gen through typed helpers,
+// so Go tools need not repeat its SQL. Helpers run the saved queries of the
+// queries table, or the views, that ship in every database; a DB is a single
+// SQLite connection and, like one, must not be used from several goroutines
+// at once.
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// ErrNoQuery reports a saved query missing from the database, which was
+// written by a cpg-gen too old to have it.
+var ErrNoQuery = errors.New("saved query not in database")
+
+// DB is a read-only connection to a CPG database.
+type DB struct {
+	conn *sqlite.Conn
+}
+
+// Open opens the CPG database at path read-only.
+func Open(path string) (*DB, error) {
+	conn, err := sqlite.OpenConn(path, sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return &DB{conn: conn}, nil
+}
+
+// Close closes the connection.
+func (db *DB) Close() error {
+	return db.conn.Close()
+}
+
+// Function is a function or method, from v_function_summary.
+type Function struct {
+	ID         string
+	Name       string
+	Package    string
+	File       string
+	Line       int
+	EndLine    int
+	Complexity int
+	LOC        int
+	FanIn      int
+	FanOut     int
+	NumParams  int
+}
+
+// Call is a function reached over call edges, from the callers_of and
+// call_chain saved queries. Depth 1 is a direct caller or callee.
+type Call struct {
+	ID      string
+	Name    string
+	Package string
+	Depth   int
+}
+
+// Node is a row of the nodes table. Properties is its raw JSON object, or
+// empty.
+type Node struct {
+	ID             string
+	Kind           string
+	Name           string
+	File           string
+	Line           int
+	Col            int
+	EndLine        int
+	EndCol         int
+	Package        string
+	ParentFunction string
+	TypeInfo       string
+	Properties     string
+	Module         string
+}
+
+// Finding is a row of the findings table. Details is its raw JSON object, or
+// empty.
+type Finding struct {
+	ID         int64
+	Category   string
+	Severity   string
+	NodeID     string
+	File       string
+	Line       int
+	Message    string
+	Details    string
+	Suppressed bool
+}
+
+// PackageStability is a package's Martin metrics, from the package_stability
+// saved query.
+type PackageStability struct {
+	Package             string
+	AfferentCoupling    int
+	EfferentCoupling    int
+	Instability         float64
+	TotalTypes          int
+	InterfaceCount      int
+	Abstractness        float64
+	DistanceFromMainSeq float64
+}
+
+// Functions returns every function and method, by package, file and line.
+func (db *DB) Functions() ([]Function, error) {
+	var fns []Function
+	err := sqlitex.Execute(db.conn, `
+SELECT id, name, package, file, line, end_line, complexity, loc, fan_in, fan_out, num_params
+FROM v_function_summary
+ORDER BY package, file, line`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			fns = append(fns, Function{
+				ID:         stmt.ColumnText(0),
+				Name:       stmt.ColumnText(1),
+				Package:    stmt.ColumnText(2),
+				File:       stmt.ColumnText(3),
+				Line:       stmt.ColumnInt(4),
+				EndLine:    stmt.ColumnInt(5),
+				Complexity: stmt.ColumnInt(6),
+				LOC:        stmt.ColumnInt(7),
+				FanIn:      stmt.ColumnInt(8),
+				FanOut:     stmt.ColumnInt(9),
+				NumParams:  stmt.ColumnInt(10),
+			})
+			return nil
+		}})
+	if err != nil {
+		return nil, fmt.Errorf("functions: %w", err)
+	}
+	return fns, nil
+}
+
+// Callers returns the functions that reach function id over call edges, up
+// to five calls away, nearest first.
+func (db *DB) Callers(id string) ([]Call, error) {
+	return db.calls("callers_of", id)
+}
+
+// Callees returns the functions function id reaches over call edges, up to
+// ten calls away, nearest first.
+func (db *DB) Callees(id string) ([]Call, error) {
+	return db.calls("call_chain", id)
+}
+
+// calls runs a saved call-graph query from function id. The queries start
+// at id itself (depth 0), which is dropped, and may reach a function at
+// several depths; only the nearest is kept.
+func (db *DB) calls(query, id string) ([]Call, error) {
+	var calls []Call
+	seen := map[string]bool{}
+	err := db.saved(query, map[string]any{":function_id": id}, func(stmt *sqlite.Stmt) error {
+		c := Call{
+			ID:      stmt.ColumnText(0),
+			Name:    stmt.ColumnText(1),
+			Package: stmt.ColumnText(2),
+			Depth:   stmt.ColumnInt(3),
+		}
+		if c.Depth == 0 || seen[c.ID] {
+			return nil
+		}
+		seen[c.ID] = true
+		calls = append(calls, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+// ForwardSlice returns the nodes node id's value flows to over dfg,
+// param_out and returns edges (up to 20 steps, dfg edges of any confidence),
+// by file and line; id itself is included.
+func (db *DB) ForwardSlice(id string) ([]Node, error) {
+	var nodes []Node
+	named := map[string]any{":node_id": id, ":min_confidence": nil}
+	err := db.saved("forward_slice", named, func(stmt *sqlite.Stmt) error {
+		nodes = append(nodes, Node{
+			ID:             stmt.GetText("id"),
+			Kind:           stmt.GetText("kind"),
+			Name:           stmt.GetText("name"),
+			File:           stmt.GetText("file"),
+			Line:           int(stmt.GetInt64("line")),
+			Col:            int(stmt.GetInt64("col")),
+			EndLine:        int(stmt.GetInt64("end_line")),
+			EndCol:         int(stmt.GetInt64("end_col")),
+			Package:        stmt.GetText("package"),
+			ParentFunction: stmt.GetText("parent_function"),
+			TypeInfo:       stmt.GetText("type_info"),
+			Properties:     stmt.GetText("properties"),
+			Module:         stmt.GetText("module"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Findings returns the findings of category, or all findings when category
+// is empty, by file and line. Suppressed findings are included.
+func (db *DB) Findings(category string) ([]Finding, error) {
+	var findings []Finding
+	err := sqlitex.Execute(db.conn, `
+SELECT id, category, severity, node_id, file, line, message, details, suppressed
+FROM findings
+WHERE ?1 = '' OR category = ?1
+ORDER BY file, line, id`,
+		&sqlitex.ExecOptions{
+			Args: []any{category},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				findings = append(findings, Finding{
+					ID:         stmt.ColumnInt64(0),
+					Category:   stmt.ColumnText(1),
+					Severity:   stmt.ColumnText(2),
+					NodeID:     stmt.ColumnText(3),
+					File:       stmt.ColumnText(4),
+					Line:       stmt.ColumnInt(5),
+					Message:    stmt.ColumnText(6),
+					Details:    stmt.ColumnText(7),
+					Suppressed: stmt.ColumnBool(8),
+				})
+				return nil
+			},
+		})
+	if err != nil {
+		return nil, fmt.Errorf("findings: %w", err)
+	}
+	return findings, nil
+}
+
+// PackageStability returns every package's stability metrics, furthest from
+// the main sequence first.
+func (db *DB) PackageStability() ([]PackageStability, error) {
+	var pkgs []PackageStability
+	err := db.saved("package_stability", nil, func(stmt *sqlite.Stmt) error {
+		pkgs = append(pkgs, PackageStability{
+			Package:             stmt.GetText("package"),
+			AfferentCoupling:    int(stmt.GetInt64("afferent_coupling")),
+			EfferentCoupling:    int(stmt.GetInt64("efferent_coupling")),
+			Instability:         stmt.GetFloat("instability"),
+			TotalTypes:          int(stmt.GetInt64("total_types")),
+			InterfaceCount:      int(stmt.GetInt64("interface_count")),
+			Abstractness:        stmt.GetFloat("abstractness"),
+			DistanceFromMainSeq: stmt.GetFloat("distance_from_main_seq"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// saved runs the saved query name with the :param bindings of named, which
+// must cover all of them (nil is NULL, the default of optional ones),
+// calling fn for each row.
+func (db *DB) saved(name string, named map[string]any, fn func(*sqlite.Stmt) error) error {
+	var code string
+	found := false
+	if err := sqlitex.Execute(db.conn, `SELECT sql FROM queries WHERE name = ?`,
+		&sqlitex.ExecOptions{
+			Args: []any{name},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				code, found = stmt.ColumnText(0), true
+				return nil
+			},
+		}); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", name, ErrNoQuery)
+	}
+	if err := sqlitex.Execute(db.conn, code, &sqlitex.ExecOptions{Named: named, ResultFunc: fn}); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package query
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const fixtureSrc = `package parse
+
+import "errors"
+
+// Parse returns twice the length of s.
+func Parse(s string) (int, error) {
+	if s == "" {
+		return 0, errors.New("empty input")
+	}
+	n := len(s)
+	return double(n), nil
+}
+
+func double(x int) int {
+	return x * 2
+}
+
+// Count recurses once per byte.
+func Count(s string) int {
+	if s == "" {
+		return 0
+	}
+	return 1 + Count(s[1:])
+}
+`
+
+var (
+	fixtureDir  string
+	fixtureOnce sync.Once
+	fixturePath string
+	fixtureErr  string
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "cpg-query-test")
+	if err != nil {
+		panic(err)
+	}
+	fixtureDir = dir
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// openFixture builds cpg-gen, runs it over a one-package module and opens
+// the result. The database is generated once per test binary.
+func openFixture(t *testing.T) *DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds and runs cpg-gen")
+	}
+	fixtureOnce.Do(func() {
+		dir := fixtureDir
+		bin := filepath.Join(dir, "cpg-gen")
+		build := exec.Command("go", "build", "-o", bin, ".")
+		build.Dir = ".."
+		if out, err := build.CombinedOutput(); err != nil {
+			fixtureErr = "build cpg-gen: " + err.Error() + "\n" + string(out)
+			return
+		}
+		// cpg-gen analyzes its primary directory as the prometheus module
+		mod := filepath.Join(dir, "src")
+		if err := os.MkdirAll(filepath.Join(mod, "parse"), 0o755); err != nil {
+			fixtureErr = err.Error()
+			return
+		}
+		if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module github.com/prometheus/prometheus\n\ngo 1.22\n"), 0o644); err != nil {
+			fixtureErr = err.Error()
+			return
+		}
+		if err := os.WriteFile(filepath.Join(mod, "parse", "parse.go"), []byte(fixtureSrc), 0o644); err != nil {
+			fixtureErr = err.Error()
+			return
+		}
+		path := filepath.Join(dir, "cpg.db")
+		gen := exec.Command(bin, mod, path)
+		// Workspace mode rejects -mod=mod, which some environments set
+		// globally; pin the toolchain the generator's own tests use.
+		gen.Env = append(os.Environ(), "GOFLAGS=")
+		if out, err := gen.CombinedOutput(); err != nil {
+			fixtureErr = "run cpg-gen: " + err.Error() + "\n" + string(out)
+			return
+		}
+		fixturePath = path
+	})
+	if fixtureErr != "" {
+		t.Fatal(fixtureErr)
+	}
+	db, err := Open(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// callNames renders calls as name:depth.
+func callNames(calls []Call) []string {
+	var names []string
+	for _, c := range calls {
+		names = append(names, c.Name+":"+strconv.Itoa(c.Depth))
+	}
+	return names
+}
+
+func TestFunctions(t *testing.T) {
+	db := openFixture(t)
+	fns, err := db.Functions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(fns, func(f Function) bool { return f.ID == "parse::Parse@parse.go:6:1" })
+	if i < 0 {
+		t.Fatalf("Functions() lacks Parse: %+v", fns)
+	}
+	if f := fns[i]; f.Name != "Parse" || f.Package != "parse" || f.File != "parse/parse.go" || f.Line != 6 || f.EndLine != 12 || f.Complexity != 2 || f.NumParams != 1 {
+		t.Errorf("Parse = %+v", f)
+	}
+}
+
+func TestCallers(t *testing.T) {
+	db := openFixture(t)
+	calls, err := db.Callers("parse::double@parse.go:14:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := callNames(calls), []string{"Parse:1"}; !slices.Equal(got, want) {
+		t.Errorf("Callers(double) = %v, want %v", got, want)
+	}
+}
+
+func TestCallees(t *testing.T) {
+	db := openFixture(t)
+	calls, err := db.Callees("parse::Parse@parse.go:6:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := callNames(calls), []string{"New:1", "double:1"}; !slices.Equal(got, want) {
+		t.Errorf("Callees(Parse) = %v, want %v", got, want)
+	}
+}
+
+func TestForwardSlice(t *testing.T) {
+	db := openFixture(t)
+	// n := len(s) flows into double(n) and Parse's return
+	nodes, err := db.ForwardSlice("parse::@parse.go:10:10:call")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.Kind+":"+n.Name)
+	}
+	for _, want := range []string{"call:len", "call:double", "return:return"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("ForwardSlice(len(s)) = %v, want it to contain %s", got, want)
+		}
+	}
+	if len(nodes) > 0 && nodes[0].ParentFunction != "parse::Parse@parse.go:6:1" {
+		t.Errorf("ForwardSlice(len(s))[0].ParentFunction = %q", nodes[0].ParentFunction)
+	}
+}
+
+func TestFindings(t *testing.T) {
+	db := openFixture(t)
+	findings, err := db.Findings("recursive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Findings(recursive) = %+v, want 1", findings)
+	}
+	if f := findings[0]; f.NodeID != "parse::Count@parse.go:19:1" || f.Line != 19 || f.Suppressed {
+		t.Errorf("recursive finding = %+v", f)
+	}
+
+	all, err := db.Findings("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) <= len(findings) {
+		t.Errorf("Findings(\"\") = %d findings, want more than the %d recursive", len(all), len(findings))
+	}
+}
+
+func TestPackageStability(t *testing.T) {
+	db := openFixture(t)
+	pkgs, err := db.PackageStability()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(pkgs, func(p PackageStability) bool { return p.Package == "parse" })
+	if i < 0 {
+		t.Fatalf("PackageStability() lacks parse: %+v", pkgs)
+	}
+	// parse depends on errors and nothing depends on parse
+	if p := pkgs[i]; p.EfferentCoupling != 1 || p.AfferentCoupling != 0 || p.Instability != 1 {
+		t.Errorf("parse stability = %+v", p)
+	}
+}
+
+func TestMissingSavedQuery(t *testing.T) {
+	db := openFixture(t)
+	err := db.saved("no_such_query", nil, nil)
+	if err == nil {
+		t.Fatal("saved(no_such_query) succeeded")
+	}
+	if !errors.Is(err, ErrNoQuery) {
+		t.Errorf("saved(no_such_query) = %v, want ErrNoQuery", err)
+	}
+}
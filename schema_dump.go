@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// WriteSchemaFile writes the schema of the CPG database at dbPath to
+// dir/cpg-schema-<version>.sql, creating dir, and returns the file's path.
+// The version is META_DATA's, so the file names the generator that wrote it.
+func WriteSchemaFile(dir, dbPath string, prog *Progress) (string, error) {
+	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	if err != nil {
+		return "", fmt.Errorf("dump schema: open %s: %w", dbPath, err)
+	}
+	defer conn.Close()
+
+	version, err := schemaVersion(conn)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("dump schema: %w", err)
+	}
+	path := filepath.Join(dir, "cpg-schema-"+version+".sql")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("dump schema: %w", err)
+	}
+	n, err := DumpSchema(f, conn, version)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("dump schema: %w", err)
+	}
+	prog.Log("Wrote %d schema statements to %s", n, path)
+	return path, nil
+}
+
+// schemaVersion returns META_DATA's version, or generatorVersion for a
+// database without one.
+func schemaVersion(conn *sqlite.Conn) (string, error) {
+	version := generatorVersion
+	if err := sqlitex.ExecuteTransient(conn,
+		`SELECT json_extract(properties, '$.version') FROM nodes
+		 WHERE id = 'META_DATA' AND json_extract(properties, '$.version') IS NOT NULL`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			version = stmt.ColumnText(0)
+			return nil
+		}}); err != nil {
+		return "", fmt.Errorf("dump schema: %w", err)
+	}
+	return version, nil
+}
+
+// DumpSchema writes the CREATE statements of every table, view, index and
+// trigger of conn's database to w, without data, and returns how many.
+// sqlite_master lists objects in creation order, which is dependency order:
+// each generation pass only reads what earlier ones created. SQLite's own
+// tables and FTS shadow tables, which CREATE VIRTUAL TABLE makes itself,
+// are left out.
+func DumpSchema(w io.Writer, conn *sqlite.Conn, version string) (int, error) {
+	if _, err := fmt.Fprintf(w, "-- cpg-gen schema version %s (META_DATA version)\n"+
+		"-- Tables, views and indexes in dependency order, without data.\n\n", version); err != nil {
+		return 0, fmt.Errorf("dump schema: %w", err)
+	}
+	n := 0
+	err := sqlitex.ExecuteTransient(conn, `
+SELECT sql FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+  AND tbl_name NOT IN (SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'shadow')
+ORDER BY rowid`,
+		&sqlitex.ExecOptions{ResultFunc: func(stmt *sqlite.Stmt) error {
+			n++
+			_, err := fmt.Fprintf(w, "%s;\n\n", stmt.ColumnText(0))
+			return err
+		}})
+	if err != nil {
+		return 0, fmt.Errorf("dump schema: %w", err)
+	}
+	return n, nil
+}
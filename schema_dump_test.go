@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func TestDumpSchema(t *testing.T) {
+	dbPath := buildTestDBFile(t, `package fixture
+
+import "errors"
+
+var errEmpty = errors.New("empty")
+
+func Check(s string) error {
+	if s == "" {
+		return errEmpty
+	}
+	return nil
+}
+`)
+	path, err := WriteSchemaFile(filepath.Join(t.TempDir(), "schema"), dbPath, NewProgress(false))
+	if err != nil {
+		t.Fatalf("dump schema: %v", err)
+	}
+	if want := "cpg-schema-" + generatorVersion + ".sql"; filepath.Base(path) != want {
+		t.Errorf("schema file = %s, want %s", filepath.Base(path), want)
+	}
+	ddl, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := "-- cpg-gen schema version " + generatorVersion + " "; !strings.HasPrefix(string(ddl), header) {
+		t.Errorf("schema starts %q, want header %q", strings.SplitN(string(ddl), "\n", 2)[0], header)
+	}
+
+	fresh, err := sqlite.OpenConn(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if err := sqlitex.ExecuteScript(fresh, string(ddl), nil); err != nil {
+		t.Fatalf("execute dumped schema: %v", err)
+	}
+
+	orig, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer orig.Close()
+	// Every table, view and index, FTS shadow tables and sqlite_* aside
+	objects := `SELECT type || ' ' || name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' AND sql IS NOT NULL
+		  AND tbl_name NOT IN (SELECT name FROM pragma_table_list WHERE type = 'shadow')
+		ORDER BY type, name`
+	want := queryStrings(t, orig, objects)
+	got := queryStrings(t, fresh, objects)
+	if !slices.Equal(got, want) {
+		t.Errorf("fresh DB objects differ from the generated DB:\n got %d: %v\nwant %d: %v", len(got), got, len(want), want)
+	}
+	for _, name := range []string{"table nodes", "table findings", "view v_call_graph", "table sources_fts"} {
+		if !slices.Contains(got, name) {
+			t.Errorf("fresh DB lacks %s", name)
+		}
+	}
+	if n := queryStrings(t, fresh, `SELECT COUNT(*) FROM nodes`); n[0] != "0" {
+		t.Errorf("fresh DB has %s nodes, want an empty schema", n[0])
+	}
+}
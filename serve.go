@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// serveShutdownGrace bounds how long the server gets to drain after an
+// interrupt before it is killed (the server itself allows 10s).
+const serveShutdownGrace = 15 * time.Second
+
+// findServer locates the cpg-server binary: $CPG_SERVER, then cpg-server next
+// to this executable, then cpg-server on PATH. The server is a separate
+// module, so -serve runs it as a child process rather than linking it in.
+func findServer() (string, error) {
+	if p := os.Getenv("CPG_SERVER"); p != "" {
+		return p, nil
+	}
+	if exe, err := os.Executable(); err == nil {
+		p := filepath.Join(filepath.Dir(exe), "cpg-server")
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p, nil
+		}
+	}
+	p, err := exec.LookPath("cpg-server")
+	if err != nil {
+		return "", fmt.Errorf("-serve needs the cpg-server binary (cd server && go build -o cpg-server .) next to cpg-gen, on PATH, or in $CPG_SERVER: %w", err)
+	}
+	return p, nil
+}
+
+// splitServeAddr splits a -serve address into host (empty for all interfaces)
+// and port.
+func splitServeAddr(addr string) (host, port string, err error) {
+	host, port, err = net.SplitHostPort(addr)
+	if err != nil || port == "" {
+		return "", "", fmt.Errorf("invalid -serve address %q (want :port or host:port)", addr)
+	}
+	return host, port, nil
+}
+
+// serveDB runs cpg-server against dbPath on addr (":8080" for all
+// interfaces, or "host:port") until ctx is cancelled, then interrupts it so
+// it shuts down gracefully.
+func serveDB(ctx context.Context, dbPath, addr string, prog *Progress) error {
+	host, port, err := splitServeAddr(addr)
+	if err != nil {
+		return err
+	}
+	bin, err := findServer()
+	if err != nil {
+		return err
+	}
+	absDB, err := filepath.Abs(dbPath)
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, bin, "-db", absDB, "-host", host, "-port", port)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = serveShutdownGrace
+
+	browse := host
+	if browse == "" {
+		browse = "localhost"
+	}
+	prog.Log("Serving %s on http://%s (Ctrl-C to stop)", dbPath, net.JoinHostPort(browse, port))
+	if err := cmd.Run(); err != nil {
+		// Interrupted by us: the server drained and exited, which is success.
+		if ctx.Err() != nil && !errors.Is(err, exec.ErrWaitDelay) {
+			return nil
+		}
+		return fmt.Errorf("cpg-server: %w", err)
+	}
+	return nil
+}
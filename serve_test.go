@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServeGeneratedDB(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs cpg-server")
+	}
+	bin := filepath.Join(t.TempDir(), "cpg-server")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Dir = "server"
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build cpg-server: %v\n%s", err, out)
+	}
+	t.Setenv("CPG_SERVER", bin)
+
+	dbPath := buildTestDBFile(t, `package fixture
+
+func helper() int { return 1 }
+
+func Use() int { return helper() }
+`)
+
+	// Reserve an ephemeral port, then hand it to the server.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveDB(ctx, dbPath, "127.0.0.1:"+port, NewProgress(false)) }()
+
+	var objects []struct {
+		Name     string `json:"name"`
+		RowCount *int   `json:"row_count"`
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		resp, err := http.Get("http://127.0.0.1:" + port + "/api/schema")
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&objects)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("decode /api/schema: %v", err)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server never came up: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	found := false
+	for _, o := range objects {
+		if o.Name == "nodes" && o.RowCount != nil && *o.RowCount > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("/api/schema has no populated nodes table: %+v", objects)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveDB after interrupt: %v", err)
+		}
+	case <-time.After(serveShutdownGrace + 5*time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
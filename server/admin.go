@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GenerateFunc writes the CPG of the module at srcDir to the database file
+// outPath, as cpg-gen srcDir outPath does.
+type GenerateFunc func(ctx context.Context, srcDir, outPath string) error
+
+// Job is a regeneration run, as GET /api/admin/jobs/{id} reports it.
+type Job struct {
+	ID       string     `json:"id"`
+	Status   string     `json:"status"` // running, succeeded or failed
+	Error    string     `json:"error,omitempty"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// Admin runs regeneration jobs: each generates sourceDir into a temporary
+// database next to dbPath and renames it over dbPath, so readers of the
+// file never see a partial database. One job runs at a time.
+type Admin struct {
+	token     string
+	sourceDir string
+	dbPath    string
+	generate  GenerateFunc
+	// ctx bounds every job; the server cancels it on shutdown
+	ctx context.Context
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	lastID  int
+	running *Job
+}
+
+// NewAdmin returns an Admin that accepts requests bearing token and
+// regenerates dbPath from sourceDir with generate.
+func NewAdmin(ctx context.Context, token, sourceDir, dbPath string, generate GenerateFunc) *Admin {
+	return &Admin{
+		token:     token,
+		sourceDir: sourceDir,
+		dbPath:    dbPath,
+		generate:  generate,
+		ctx:       ctx,
+		jobs:      map[string]*Job{},
+	}
+}
+
+// requireToken answers 401 unless the request carries
+// Authorization: Bearer <token>.
+func (ad *Admin) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(ad.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "admin token required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// start registers a running job, or returns the running one and false when
+// a regeneration is already under way.
+func (ad *Admin) start() (*Job, bool) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	if ad.running != nil {
+		j := *ad.running
+		return &j, false
+	}
+	ad.lastID++
+	j := &Job{ID: strconv.Itoa(ad.lastID), Status: "running", Started: time.Now().UTC()}
+	ad.jobs[j.ID] = j
+	ad.running = j
+	c := *j
+	return &c, true
+}
+
+// finish records the outcome of the running job.
+func (ad *Admin) finish(j *Job, err error) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	now := time.Now().UTC()
+	job := ad.jobs[j.ID]
+	job.Finished = &now
+	job.Status = "succeeded"
+	if err != nil {
+		job.Status, job.Error = "failed", err.Error()
+	}
+	ad.running = nil
+}
+
+// job returns a copy of job id, or nil for an unknown id.
+func (ad *Admin) job(id string) *Job {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	j, ok := ad.jobs[id]
+	if !ok {
+		return nil
+	}
+	c := *j
+	return &c
+}
+
+// regenerate generates the source dir into a temporary directory beside
+// dbPath (the same filesystem, so the rename is atomic), checks that it
+// opens, renames it over dbPath and returns the reopened database and its
+// hash. A failed run leaves dbPath untouched.
+func (ad *Admin) regenerate() (*sql.DB, string, error) {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(ad.dbPath), ".cpg-regen-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	out := filepath.Join(tmpDir, filepath.Base(ad.dbPath))
+	if err := ad.generate(ad.ctx, ad.sourceDir, out); err != nil {
+		return nil, "", fmt.Errorf("generate: %w", err)
+	}
+	// Validate (and add the dashboard tables to) the new file before it
+	// replaces the served one; the pool is reopened on the final path.
+	check, err := openDB(out)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := check.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(out, ad.dbPath); err != nil {
+		return nil, "", err
+	}
+	db, err := openDB(ad.dbPath)
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := hashDBFile(ad.dbPath)
+	if err != nil {
+		db.Close()
+		return nil, "", err
+	}
+	return db, hash, nil
+}
+
+// handleRegenerate starts a regeneration job and answers 202 with it, or
+// 409 with the running job.
+func (a *App) handleRegenerate(w http.ResponseWriter, r *http.Request) {
+	job, started := a.admin.start()
+	status := http.StatusConflict
+	if started {
+		status = http.StatusAccepted
+		go func() {
+			db, hash, err := a.admin.regenerate()
+			if err == nil {
+				a.swapDB(db, hash)
+				log.Printf("regenerate job %s: now serving %s", job.ID, a.admin.dbPath)
+			} else {
+				log.Printf("regenerate job %s: %v", job.ID, err)
+			}
+			a.admin.finish(job, err)
+		}()
+	}
+	w.Header().Set("Location", "/api/admin/jobs/"+job.ID)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	writeJSON(w, job)
+}
+
+func (a *App) handleJob(w http.ResponseWriter, r *http.Request) {
+	job := a.admin.job(chi.URLParam(r, "id"))
+	if job == nil {
+		http.Error(w, "no such job", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, job)
+}
+
+// execGenerator runs the cpg-gen binary at bin. The generator is a separate
+// module, so the server runs it as a child process rather than linking it in.
+func execGenerator(bin string) GenerateFunc {
+	return func(ctx context.Context, srcDir, outPath string) error {
+		out, err := exec.CommandContext(ctx, bin, srcDir, outPath).CombinedOutput()
+		if err != nil {
+			// The last line of output is cpg-gen's error message
+			msg := strings.TrimSpace(string(out))
+			if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+				msg = msg[i+1:]
+			}
+			return fmt.Errorf("%s: %w: %s", filepath.Base(bin), err, msg)
+		}
+		return nil
+	}
+}
+
+// findGenerator locates the cpg-gen binary: path if set, then cpg-gen next
+// to this executable or in its parent directory (server/ in a checkout),
+// then cpg-gen on PATH.
+func findGenerator(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if exe, err := os.Executable(); err == nil {
+		for _, dir := range []string{filepath.Dir(exe), filepath.Dir(filepath.Dir(exe))} {
+			p := filepath.Join(dir, "cpg-gen")
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p, nil
+			}
+		}
+	}
+	p, err := exec.LookPath("cpg-gen")
+	if err != nil {
+		return "", errors.New("admin regeneration needs the cpg-gen binary: set -generator or CPG_GEN, or put cpg-gen on PATH")
+	}
+	return p, nil
+}
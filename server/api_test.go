@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
 // setupTestDB creates an in-memory SQLite DB with minimal CPG schema and test data.
-func setupTestDB(t *testing.T) *sql.DB {
+func setupTestDB(t testing.TB) *sql.DB {
 	db, err := sql.Open("sqlite", ":memory:")
 	if err != nil {
 		t.Fatalf("open db: %v", err)
@@ -25,6 +34,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 	CREATE TABLE sources (file TEXT PRIMARY KEY, content TEXT, package TEXT);
 	CREATE TABLE dashboard_package_graph (source TEXT, target TEXT, weight INTEGER);
 	CREATE TABLE dashboard_package_treemap (package TEXT PRIMARY KEY, file_count INTEGER, function_count INTEGER, total_loc INTEGER, total_complexity INTEGER, avg_complexity REAL, max_complexity INTEGER, type_count INTEGER, interface_count INTEGER);
+	CREATE TABLE findings (category TEXT, severity TEXT, node_id TEXT, file TEXT, line INTEGER, message TEXT, details TEXT);
 	CREATE TABLE dashboard_function_detail (function_id TEXT PRIMARY KEY, name TEXT, package TEXT, file TEXT, line INTEGER, end_line INTEGER, signature TEXT, complexity INTEGER, loc INTEGER, fan_in INTEGER, fan_out INTEGER, num_params INTEGER, num_locals INTEGER, num_calls INTEGER, num_branches INTEGER, num_returns INTEGER, finding_count INTEGER, callers TEXT, callees TEXT);
 	`)
 	if err != nil {
@@ -32,6 +42,11 @@ func setupTestDB(t *testing.T) *sql.DB {
 	}
 
 	_, _ = db.Exec(`INSERT INTO symbol_index VALUES ('main::Handler@main.go:10:1', 'Handler', 'function', 'main', 'main.go', 10);`)
+	_, _ = db.Exec(`INSERT INTO symbol_index VALUES ('scrape::@manager.go:20:6:type_decl', 'Manager', 'type_decl', 'scrape', 'manager.go', 20);`)
+	_, _ = db.Exec(`INSERT INTO symbol_index VALUES ('scrape::NewManager@manager.go:40:1', 'NewManager', 'function', 'scrape', 'manager.go', 40);`)
+	_, _ = db.Exec(`INSERT INTO symbol_index VALUES ('scrape::@manager.go:60:6:type_decl', 'ManagerOptions', 'type_decl', 'scrape', 'manager.go', 60);`)
+	_, _ = db.Exec(`CREATE VIRTUAL TABLE symbol_fts USING fts5(name, package, content=symbol_index, content_rowid=rowid, tokenize='trigram');
+	INSERT INTO symbol_fts(symbol_fts) VALUES('rebuild');`)
 	_, _ = db.Exec(`INSERT INTO nodes VALUES ('main::Handler@main.go:10:1', 'function', 'Handler', 'main.go', 10, 20, 'main', NULL, NULL);`)
 	_, _ = db.Exec(`INSERT INTO nodes VALUES ('main::Run@main.go:5:1', 'function', 'Run', 'main.go', 5, 8, 'main', NULL, NULL);`)
 	_, _ = db.Exec(`INSERT INTO edges VALUES ('main::Run@main.go:5:1', 'main::Handler@main.go:10:1', 'call');`)
@@ -44,6 +59,11 @@ func setupTestDB(t *testing.T) *sql.DB {
 	_, _ = db.Exec(`INSERT INTO dashboard_package_treemap VALUES ('pkg_b', 1, 1, 50, 5, 1.0, 3, 0, 0);`)
 	_, _ = db.Exec(`INSERT INTO dashboard_function_detail VALUES ('main::Handler@main.go:10:1', 'Handler', 'main', 'main.go', 10, 20, 'func Handler()', 1, 5, 0, 1, 0, 0, 0, 0, 0, 0, '', 'Run');`)
 
+	_, _ = db.Exec(`INSERT INTO findings VALUES ('dead_code', 'warning', 'main::Run@main.go:5:1', 'main.go', 5, 'unreachable function Run', '{"package":"main"}');`)
+	_, _ = db.Exec(`INSERT INTO findings VALUES ('panic_call', 'warning', 'main::Handler@main.go:10:1', 'main.go', 10, 'Handler calls panic() directly', '{"package":"main"}');`)
+	_, _ = db.Exec(`INSERT INTO findings VALUES ('dead_code', 'info', 'scrape::x', 'manager.go', 7, 'unreachable function x', '{"package":"scrape"}');`)
+	_, _ = db.Exec(`INSERT INTO findings VALUES ('bool_params', 'info', 'main::Handler@main.go:10:1', 'main.go', 10, 'Handler has 2 bool parameters', NULL);`)
+
 	return db
 }
 
@@ -79,6 +99,113 @@ func TestAPI_Search_Success(t *testing.T) {
 	}
 }
 
+func TestAPI_Symbols_MissingParam(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/symbols", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/symbols without q: want 400, got %d", rec.Code)
+	}
+}
+
+func TestAPI_Symbols_PrefixAndSubstring(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/symbols?q=Mana&limit=20", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/symbols?q=Mana: want 200, got %d", rec.Code)
+	}
+	var matches []SymbolMatch
+	if err := json.NewDecoder(rec.Body).Decode(&matches); err != nil {
+		t.Fatalf("decode symbols response: %v", err)
+	}
+	// Prefix matches rank ahead of substring matches; shorter names first.
+	want := []struct{ name, match string }{
+		{"Manager", "prefix"},
+		{"ManagerOptions", "prefix"},
+		{"NewManager", "substring"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("got %d matches, want %d: %+v", len(matches), len(want), matches)
+	}
+	for i, w := range want {
+		if matches[i].Name != w.name || matches[i].Match != w.match {
+			t.Errorf("matches[%d] = %s (%s), want %s (%s)", i, matches[i].Name, matches[i].Match, w.name, w.match)
+		}
+	}
+}
+
+func TestAPI_Symbols_ExactFirst(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/symbols?q=manager", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	var matches []SymbolMatch
+	if err := json.NewDecoder(rec.Body).Decode(&matches); err != nil {
+		t.Fatalf("decode symbols response: %v", err)
+	}
+	if len(matches) == 0 || matches[0].Name != "Manager" {
+		t.Errorf("expected Manager first for case-insensitive query, got %+v", matches)
+	}
+}
+
+func TestAPI_Symbols_ShortQueryFallback(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/symbols?q=Ma", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	var matches []SymbolMatch
+	if err := json.NewDecoder(rec.Body).Decode(&matches); err != nil {
+		t.Fatalf("decode symbols response: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("short query should prefix-match 2 symbols, got %+v", matches)
+	}
+}
+
+func TestAPI_Symbols_LikeWildcardsLiteral(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	for _, q := range []string{"%25", "_a"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/symbols?q="+q, nil)
+		rec := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rec, req)
+		var matches []SymbolMatch
+		if err := json.NewDecoder(rec.Body).Decode(&matches); err != nil {
+			t.Fatalf("decode symbols response: %v", err)
+		}
+		if len(matches) != 0 {
+			t.Errorf("q=%s: %% and _ should match only themselves, got %+v", q, matches)
+		}
+	}
+}
+
+func BenchmarkAPI_Symbols(b *testing.B) {
+	db := setupTestDB(b)
+	for i := 0; i < 5000; i++ {
+		_, _ = db.Exec(`INSERT INTO symbol_index VALUES (?, ?, 'function', 'bench', 'bench.go', ?)`,
+			fmt.Sprintf("bench::Fn%d@bench.go:%d:1", i, i), fmt.Sprintf("HandleRequest%dManager", i), i)
+	}
+	_, _ = db.Exec(`INSERT INTO symbol_fts(symbol_fts) VALUES('rebuild')`)
+	app := NewApp(db, "")
+	h := app.Handler()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/symbols?q=Mana&limit=20", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("want 200, got %d", rec.Code)
+		}
+	}
+}
+
 func TestAPI_Subgraph_MissingParam(t *testing.T) {
 	db := setupTestDB(t)
 	app := NewApp(db, "")
@@ -195,6 +322,67 @@ func TestAPI_Source_NotFound(t *testing.T) {
 	}
 }
 
+func TestAPI_Source_NodeOverlay(t *testing.T) {
+	db := setupTestDB(t)
+	for _, stmt := range []string{
+		`ALTER TABLE nodes ADD COLUMN col INTEGER`,
+		`ALTER TABLE nodes ADD COLUMN end_col INTEGER`,
+		`INSERT INTO nodes (id, kind, name, file, line, col, end_line, end_col, package) VALUES
+		  ('main::Handler@main.go:3:1', 'function', 'Handler', 'main.go', 3, 1, 3, 18, 'main'),
+		  ('main::@main.go:3:16:block', 'block', 'block', 'main.go', 3, 16, 3, 18, 'main'),
+		  ('gen::Gen@gen.go:1:1', 'function', 'Gen', 'gen.go', 1, 1, 4, 2, 'gen')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("overlay fixture: %v", err)
+		}
+	}
+	app := NewApp(db, "")
+	get := func(file string) SourceFile {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/source?file="+file, nil)
+		rec := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/source?file=%s: want 200, got %d", file, rec.Code)
+		}
+		var out SourceFile
+		if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+			t.Fatalf("decode source: %v", err)
+		}
+		return out
+	}
+
+	src := get("main.go")
+	if src.Content == nil {
+		t.Fatal("content missing")
+	}
+	if *src.Content != `package main\n\nfunc Handler() {}` {
+		t.Errorf("content = %q", *src.Content)
+	}
+	spans := map[string]string{}
+	for _, n := range src.Nodes {
+		spans[n.ID] = fmt.Sprintf("%s %d:%d-%d:%d", n.Kind, n.Line, n.Col.Int64, n.EndLine.Int64, n.EndCol.Int64)
+	}
+	if got := spans["main::Handler@main.go:3:1"]; got != "function 3:1-3:18" {
+		t.Errorf("Handler span = %q, want function 3:1-3:18 (all spans: %v)", got, spans)
+	}
+	if got := spans["main::@main.go:3:16:block"]; got != "block 3:16-3:18" {
+		t.Errorf("block span = %q", got)
+	}
+	// Rows from setupTestDB predate col/end_col and report them as null.
+	for _, n := range src.Nodes {
+		if n.ID == "main::Handler@main.go:10:1" && (n.Col.Valid || n.EndCol.Valid || n.EndLine.Int64 != 20) {
+			t.Errorf("legacy Handler span = %+v", n)
+		}
+	}
+
+	// A file whose text is not in the DB still returns its overlay.
+	gen := get("gen.go")
+	if gen.Content != nil || gen.Package != "gen" || len(gen.Nodes) != 1 {
+		t.Errorf("gen.go without source = %+v", gen)
+	}
+}
+
 func TestAPI_Slice_MissingParam(t *testing.T) {
 	db := setupTestDB(t)
 	app := NewApp(db, "")
@@ -293,3 +481,728 @@ func TestAPI_ContentType(t *testing.T) {
 		t.Errorf("Content-Type: want application/json; charset=utf-8, got %q", ct)
 	}
 }
+
+func TestAPI_ETag(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+	app.dbHash = "abc123"
+	h := app.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/package-graph", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET /api/package-graph: want 200 with ETag, got %d %q", rec.Code, etag)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control: want no-cache, got %q", cc)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/package-graph", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("conditional GET: want empty 304, got %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/package-graph?limit=1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("other endpoint with stale ETag: want 200, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("ETag") != "" {
+		t.Errorf("error response: want 400 without ETag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func getFindings(t *testing.T, app *App, query string) (*httptest.ResponseRecorder, FindingsPage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/findings"+query, nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	var page FindingsPage
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("decode findings response: %v", err)
+		}
+	}
+	return rec, page
+}
+
+func TestAPI_Findings_CategoryFilter(t *testing.T) {
+	app := NewApp(setupTestDB(t), "")
+	rec, page := getFindings(t, app, "?category=dead_code")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/findings?category=dead_code: want 200, got %d", rec.Code)
+	}
+	if page.Total != 2 || len(page.Findings) != 2 || rec.Header().Get("X-Total-Count") != "2" {
+		t.Fatalf("total=%d len=%d header=%q, want 2", page.Total, len(page.Findings), rec.Header().Get("X-Total-Count"))
+	}
+	// Severity sort: warning before info.
+	if page.Findings[0].Severity != "warning" || page.Findings[1].Package.String != "scrape" {
+		t.Errorf("unexpected order: %+v", page.Findings)
+	}
+	// Category facet ignores the category filter; severity facet honours it.
+	if page.Facets.Category["panic_call"] != 1 || page.Facets.Category["dead_code"] != 2 {
+		t.Errorf("category facet = %v", page.Facets.Category)
+	}
+	if page.Facets.Severity["warning"] != 1 || page.Facets.Severity["info"] != 1 {
+		t.Errorf("severity facet = %v", page.Facets.Severity)
+	}
+
+	_, page = getFindings(t, app, "?package=main&severity=info")
+	if page.Total != 1 || page.Findings[0].Category != "bool_params" {
+		t.Errorf("package+severity filter = %+v", page.Findings)
+	}
+}
+
+func TestAPI_Findings_Pagination(t *testing.T) {
+	app := NewApp(setupTestDB(t), "")
+	_, first := getFindings(t, app, "?sort=line&limit=3")
+	if first.Total != 4 || len(first.Findings) != 3 || first.Findings[0].Line.Int64 != 5 {
+		t.Fatalf("first page = total %d, %+v", first.Total, first.Findings)
+	}
+	_, last := getFindings(t, app, "?sort=line&limit=3&offset=3")
+	if last.Total != 4 || len(last.Findings) != 1 || last.Offset != 3 {
+		t.Errorf("last page = total %d offset %d, %+v", last.Total, last.Offset, last.Findings)
+	}
+	_, past := getFindings(t, app, "?sort=line&limit=3&offset=10")
+	if past.Total != 4 || len(past.Findings) != 0 {
+		t.Errorf("page past the end = total %d, %+v", past.Total, past.Findings)
+	}
+	_, capped := getFindings(t, app, "?limit=100000")
+	if capped.Limit != defaultFindingsLimit {
+		t.Errorf("oversized limit = %d, want default %d", capped.Limit, defaultFindingsLimit)
+	}
+}
+
+func TestAPI_Findings_InvalidSort(t *testing.T) {
+	app := NewApp(setupTestDB(t), "")
+	rec, _ := getFindings(t, app, "?sort=f.line%20DESC%2C%20(SELECT%201)")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid sort: want 400, got %d", rec.Code)
+	}
+	rec, page := getFindings(t, app, "")
+	if rec.Code != http.StatusOK || page.Total != 4 {
+		t.Errorf("findings after rejected sort: code %d total %d", rec.Code, page.Total)
+	}
+}
+
+// setupLocationDB extends the test DB with node columns and rows for
+// position lookups: Handler (main.go:10-20) calls Run on line 12.
+func setupLocationDB(t testing.TB) *sql.DB {
+	db := setupTestDB(t)
+	for _, stmt := range []string{
+		`ALTER TABLE nodes ADD COLUMN col INTEGER`,
+		`UPDATE nodes SET col = 1`,
+		`CREATE TABLE xrefs (def_id TEXT, def_name TEXT, def_file TEXT, def_line INTEGER, use_id TEXT, use_file TEXT, use_line INTEGER, use_kind TEXT)`,
+		`INSERT INTO nodes (id, kind, name, file, line, col, end_line, package, parent_function) VALUES
+		  ('main::@main.go:12:2:call', 'call', 'Run', 'main.go', 12, 2, 12, 'main', 'main::Handler@main.go:10:1'),
+		  ('main::@main.go:12:2:identifier', 'identifier', 'Run', 'main.go', 12, 2, 12, 'main', 'main::Handler@main.go:10:1')`,
+		`INSERT INTO xrefs VALUES ('main::Run@main.go:5:1', 'Run', 'main.go', 5, 'main::@main.go:12:2:identifier', 'main.go', 12, 'call')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("location fixture: %v", err)
+		}
+	}
+	return db
+}
+
+func getLocation(t *testing.T, app *App, query string) Location {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/location"+query, nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/location%s: want 200, got %d: %s", query, rec.Code, rec.Body.String())
+	}
+	var loc Location
+	if err := json.NewDecoder(rec.Body).Decode(&loc); err != nil {
+		t.Fatalf("decode location response: %v", err)
+	}
+	return loc
+}
+
+func TestAPI_Location_FunctionBody(t *testing.T) {
+	app := NewApp(setupLocationDB(t), "")
+	loc := getLocation(t, app, "?file=main.go&line=12&col=3")
+	if loc.Function == nil || loc.Function.Name != "Handler" {
+		t.Errorf("function = %+v, want Handler", loc.Function)
+	}
+	if loc.Statement == nil || loc.Statement.Kind != "call" {
+		t.Errorf("statement = %+v, want the Run call", loc.Statement)
+	}
+	if loc.Definition == nil || loc.Definition.ID != "main::Run@main.go:5:1" {
+		t.Errorf("definition = %+v, want Run via xrefs", loc.Definition)
+	}
+
+	// A blank or comment line inside the body resolves to the function only.
+	loc = getLocation(t, app, "?file=main.go&line=14&col=1")
+	if loc.Function == nil || loc.Function.Name != "Handler" || loc.Statement != nil || loc.Definition != nil {
+		t.Errorf("blank line = %+v", loc)
+	}
+}
+
+func TestAPI_Location_Declaration(t *testing.T) {
+	app := NewApp(setupLocationDB(t), "")
+	loc := getLocation(t, app, "?file=main.go&line=10&col=6")
+	if loc.Function == nil || loc.Function.Name != "Handler" || loc.Statement != nil {
+		t.Errorf("function/statement = %+v / %+v", loc.Function, loc.Statement)
+	}
+	if loc.Definition == nil || loc.Definition.ID != "main::Handler@main.go:10:1" {
+		t.Errorf("definition = %+v, want Handler", loc.Definition)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/location?file=main.go", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/location without line: want 400, got %d", rec.Code)
+	}
+}
+
+func TestAPI_Schema_FallsBackToSqliteMaster(t *testing.T) {
+	app := NewApp(setupTestDB(t), "")
+	req := httptest.NewRequest(http.MethodGet, "/api/schema", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/schema: want 200, got %d", rec.Code)
+	}
+	var objects []SchemaObject
+	if err := json.NewDecoder(rec.Body).Decode(&objects); err != nil {
+		t.Fatalf("decode schema response: %v", err)
+	}
+	found := false
+	for _, o := range objects {
+		if o.Name == "nodes" && o.Type == "table" && !o.RowCount.Valid {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("nodes table missing from schema without manifest: %+v", objects)
+	}
+}
+
+func TestAPI_Package(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.Exec(`
+	CREATE TABLE v_package_stability (package TEXT, afferent_coupling INTEGER, efferent_coupling INTEGER, instability REAL, abstractness REAL);
+	INSERT INTO v_package_stability VALUES ('main', 1, 1, 0.5, 0.0);
+	CREATE TABLE v_package_cohesion (package TEXT, func_count INTEGER, total_calls INTEGER, internal_calls INTEGER, external_calls INTEGER, cohesion_ratio REAL);
+	INSERT INTO v_package_cohesion VALUES ('main', 2, 4, 3, 1, 0.75);
+	INSERT INTO nodes VALUES ('main::@main.go:3:6:type_decl', 'type_decl', 'Server', 'main.go', 3, 6, 'main', NULL, 'struct{}');
+	INSERT INTO dashboard_function_detail VALUES ('main::Run@main.go:5:1', 'Run', 'main', 'main.go', 5, 8, 'func Run()', 4, 3, 0, 1, 0, 0, 1, 2, 0, 1, '', 'Handler');
+	INSERT INTO dashboard_package_graph VALUES ('main', 'pkg_a', 3), ('pkg_b', 'main', 1);
+	`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/package?name=main", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/package: want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var pkg PackageDetail
+	if err := json.NewDecoder(rec.Body).Decode(&pkg); err != nil {
+		t.Fatalf("decode package response: %v", err)
+	}
+	if pkg.Stats.FunctionCount != 2 || pkg.Stats.TotalLoc != 100 {
+		t.Errorf("stats = %+v, want 2 functions and 100 LOC", pkg.Stats)
+	}
+	if pkg.Stability == nil || pkg.Stability.Instability != 0.5 || pkg.Stability.AfferentCoupling != 1 {
+		t.Errorf("stability = %+v", pkg.Stability)
+	}
+	if pkg.Cohesion == nil || pkg.Cohesion.CohesionRatio != 0.75 {
+		t.Errorf("cohesion = %+v", pkg.Cohesion)
+	}
+	var funcs []string
+	for _, f := range pkg.Functions {
+		funcs = append(funcs, f.Name)
+	}
+	if strings.Join(funcs, ",") != "Run,Handler" {
+		t.Errorf("functions = %v, want [Run Handler] (by complexity)", funcs)
+	}
+	if len(pkg.Types) != 1 || pkg.Types[0].Name != "Server" {
+		t.Errorf("types = %+v, want [Server]", pkg.Types)
+	}
+	if len(pkg.Outbound) != 1 || pkg.Outbound[0].Target != "pkg_a" || len(pkg.Inbound) != 1 || pkg.Inbound[0].Source != "pkg_b" {
+		t.Errorf("outbound/inbound = %+v / %+v", pkg.Outbound, pkg.Inbound)
+	}
+
+	for query, want := range map[string]int{"": http.StatusBadRequest, "?name=nope": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/api/package"+query, nil)
+		rec := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("GET /api/package%s: want %d, got %d", query, want, rec.Code)
+		}
+	}
+}
+
+func TestAPI_PackagesGraph_Cycles(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.Exec(`
+	CREATE TABLE v_package_stability (package TEXT, instability REAL, abstractness REAL);
+	INSERT INTO v_package_stability VALUES ('pkg_a', 0.5, 0.0), ('pkg_b', 0.5, 0.25), ('main', 1.0, 0.0);
+	INSERT INTO dashboard_package_graph VALUES ('pkg_b', 'pkg_a', 2), ('main', 'pkg_a', 3);
+	`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	app := NewApp(db, "")
+	req := httptest.NewRequest(http.MethodGet, "/api/packages/graph", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/packages/graph: want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp PackageDepGraph
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode packages/graph: %v", err)
+	}
+	if fmt.Sprint(resp.Cycles) != "[[pkg_a pkg_b]]" {
+		t.Errorf("cycles = %v, want [[pkg_a pkg_b]]", resp.Cycles)
+	}
+	for _, e := range resp.Edges {
+		want := e.Source != "main"
+		if e.Cyclic != want {
+			t.Errorf("edge %s->%s cyclic = %v, want %v", e.Source, e.Target, e.Cyclic, want)
+		}
+	}
+	if len(resp.Edges) != 3 {
+		t.Errorf("got %d edges, want 3", len(resp.Edges))
+	}
+	for _, n := range resp.Nodes {
+		if n.InCycle != (n.ID != "main") {
+			t.Errorf("node %s in_cycle = %v", n.ID, n.InCycle)
+		}
+		if n.ID == "pkg_b" && n.Abstractness != 0.25 {
+			t.Errorf("pkg_b abstractness = %v, want 0.25", n.Abstractness)
+		}
+	}
+	if len(resp.Nodes) != 3 {
+		t.Errorf("got %d nodes, want 3", len(resp.Nodes))
+	}
+}
+
+func TestAPI_FilesGraph(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.Exec(`
+	CREATE TABLE v_file_deps (source_file TEXT, target_file TEXT, call_count INTEGER);
+	INSERT INTO v_file_deps VALUES
+		('scrape/manager.go', 'scrape/target.go', 5),
+		('scrape/manager.go', 'storage/db.go', 2),
+		('scrape/target.go', 'storage/wal.go', 2),
+		('scrape/sub/pool.go', 'scrape/manager.go', 4),
+		('web/api.go', 'storage/db.go', 7);
+	CREATE TABLE dashboard_file_heatmap (file TEXT PRIMARY KEY, package TEXT, function_count INTEGER, total_loc INTEGER, total_complexity INTEGER, max_complexity INTEGER, avg_complexity REAL, finding_count INTEGER, hotspot_score REAL);
+	INSERT INTO dashboard_file_heatmap VALUES
+		('scrape/manager.go', 'scrape', 4, 200, 30, 12, 7.5, 0, 1.0),
+		('scrape/target.go', 'scrape', 2, 80, 6, 4, 3.0, 0, 0.5),
+		('scrape/sub/pool.go', 'sub', 1, 40, 3, 3, 3.0, 0, 0.1),
+		('storage/db.go', 'storage', 3, 120, 9, 5, 3.0, 0, 0.4),
+		('storage/wal.go', 'storage', 2, 90, 8, 6, 4.0, 0, 0.3),
+		('web/api.go', 'web', 5, 300, 20, 9, 4.0, 0, 0.9);
+	`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	h := NewApp(db, "").Handler()
+	get := func(query string) FileGraph {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/files/graph"+query, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/files/graph%s: want 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var g FileGraph
+		if err := json.NewDecoder(rec.Body).Decode(&g); err != nil {
+			t.Fatalf("decode files/graph: %v", err)
+		}
+		return g
+	}
+	edges := func(g FileGraph) string {
+		var out []string
+		for _, e := range g.Edges {
+			out = append(out, fmt.Sprintf("%s->%s:%d", e.Source, e.Target, e.Weight))
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Weight filter: the weight-2 edges to storage fall below 3; web is
+	// outside the package
+	g := get("?package=scrape&min_weight=3")
+	if want := "scrape/manager.go->scrape/target.go:5,scrape/sub/pool.go->scrape/manager.go:4"; g.Bundle != "file" || edges(g) != want {
+		t.Errorf("file graph = %s %s, want file %s", g.Bundle, edges(g), want)
+	}
+	if len(g.Nodes) != 3 || g.Nodes[0].ID != "scrape/manager.go" || g.Nodes[0].TotalComplexity != 30 || g.Nodes[0].Files != 1 {
+		t.Errorf("file nodes = %+v", g.Nodes)
+	}
+
+	// Bundled by directory the two storage edges add up to 4, and the
+	// manager->target edge is inside scrape/
+	g = get("?package=scrape&min_weight=3&bundle=dir")
+	if want := "scrape->storage:4,scrape/sub->scrape:4"; g.Bundle != "dir" || edges(g) != want {
+		t.Errorf("dir graph = %s %s, want dir %s", g.Bundle, edges(g), want)
+	}
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, fmt.Sprintf("%s:%d:%d:%d:%d", n.ID, n.Files, n.FunctionCount, n.TotalComplexity, n.MaxComplexity))
+	}
+	if got, want := strings.Join(nodes, ","), "scrape:2:6:36:12,scrape/sub:1:1:3:3,storage:2:5:17:6"; got != want {
+		t.Errorf("dir nodes (id:files:functions:complexity:max) = %s, want %s", got, want)
+	}
+
+	if g := get(""); len(g.Edges) != 5 {
+		t.Errorf("unfiltered file graph has %d edges, want 5", len(g.Edges))
+	}
+	for _, query := range []string{"?min_weight=0", "?min_weight=x", "?bundle=package"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/files/graph"+query, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/files/graph%s: want 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestAPI_TaintPaths(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+
+	// Before the generator records parent pointers there are no paths.
+	req := httptest.NewRequest(http.MethodGet, "/api/taint/paths", nil)
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("GET /api/taint/paths without taint tables: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// os.Getenv → name → strings.TrimSpace → exec.Command: three hops.
+	for _, stmt := range []string{
+		`CREATE TABLE taint_flow_state (node_id TEXT, label TEXT, source_id TEXT, source_category TEXT, min_hops INTEGER)`,
+		`CREATE TABLE taint_path_edges (source_id TEXT, node_id TEXT, parent_id TEXT, hop INTEGER, PRIMARY KEY (source_id, node_id))`,
+		`INSERT INTO nodes (id, kind, name, file, line, package) VALUES
+		  ('src', 'call', 'os.Getenv', 'run.go', 4, 'main'),
+		  ('name', 'identifier', 'name', 'run.go', 5, 'main'),
+		  ('trim', 'call', 'strings.TrimSpace', 'run.go', 5, 'main'),
+		  ('sink', 'call', 'exec.Command', 'run.go', 6, 'main')`,
+		`INSERT INTO taint_flow_state VALUES
+		  ('src', 'source', 'src', 'env', 0),
+		  ('name', 'propagated', 'src', 'env', 1),
+		  ('trim', 'propagated', 'src', 'env', 2),
+		  ('sink', 'sink_reached', 'src', 'env', 3)`,
+		`INSERT INTO taint_path_edges VALUES
+		  ('src', 'name', 'src', 1), ('src', 'trim', 'name', 2), ('src', 'sink', 'trim', 3)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("taint fixture: %v", err)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/taint/paths?limit=10", nil)
+	rec = httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/taint/paths: want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var paths []TaintPath
+	if err := json.NewDecoder(rec.Body).Decode(&paths); err != nil {
+		t.Fatalf("decode taint paths: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(paths))
+	}
+	p := paths[0]
+	if p.SourceID != "src" || p.SinkID != "sink" || p.Category != "env" || p.Hops != 3 {
+		t.Errorf("path header = %+v", p)
+	}
+	var steps []string
+	for _, n := range p.Nodes {
+		steps = append(steps, fmt.Sprintf("%s@%s:%d", n.Name, n.File.String, n.Line.Int64))
+	}
+	want := "os.Getenv@run.go:4 name@run.go:5 strings.TrimSpace@run.go:5 exec.Command@run.go:6"
+	if strings.Join(steps, " ") != want {
+		t.Errorf("path nodes = %v, want %s", steps, want)
+	}
+}
+
+func TestAPI_QueryParamTypes(t *testing.T) {
+	db := setupTestDB(t)
+	app := NewApp(db, "")
+
+	for _, stmt := range []string{
+		`CREATE TABLE queries (name TEXT PRIMARY KEY, description TEXT, sql TEXT)`,
+		`CREATE TABLE query_params (query_name TEXT, param_name TEXT, type TEXT, required INTEGER, PRIMARY KEY (query_name, param_name))`,
+		`INSERT INTO queries VALUES ('nodes_at_line', 'Nodes at a line', 'SELECT id, line FROM nodes WHERE line = :line ORDER BY id')`,
+		`INSERT INTO query_params VALUES ('nodes_at_line', 'line', 'integer', 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("query fixture: %v", err)
+		}
+	}
+
+	post := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/query/"+name, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, tc := range []struct {
+		name, body string
+		want       int
+	}{
+		{"nodes_at_line", `{"line": "10"}`, http.StatusBadRequest}, // string for an integer
+		{"nodes_at_line", `{"line": 10.5}`, http.StatusBadRequest},
+		{"nodes_at_line", `{}`, http.StatusBadRequest},                        // required
+		{"nodes_at_line", `{"line": 10, "file": "x"}`, http.StatusBadRequest}, // undeclared
+		{"no_such_query", `{}`, http.StatusNotFound},
+	} {
+		if rec := post(tc.name, tc.body); rec.Code != tc.want {
+			t.Errorf("POST /api/query/%s %s: want %d, got %d: %s", tc.name, tc.body, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := post("nodes_at_line", `{"line": 10}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/query/nodes_at_line: want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var res QueryResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Columns) != 2 || res.Columns[0] != "id" || len(res.Rows) == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, row := range res.Rows {
+		if row[1] != float64(10) {
+			t.Errorf("row %v: want line 10", row)
+		}
+	}
+}
+
+// writePackageDB writes a database file at path whose package graph is the
+// single package pkg.
+func writePackageDB(t testing.TB, path, pkg string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE dashboard_package_treemap (package TEXT PRIMARY KEY, file_count INTEGER, function_count INTEGER, total_loc INTEGER, total_complexity INTEGER, avg_complexity REAL, max_complexity INTEGER, type_count INTEGER, interface_count INTEGER);
+		INSERT INTO dashboard_package_treemap VALUES (?, 1, 1, 10, 1, 1.0, 1, 0, 0)`, pkg); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestAPI_AdminRegenerate(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "cpg.db")
+	writePackageDB(t, dbPath, "old_pkg")
+	db, err := openDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(db, "")
+	t.Cleanup(func() { _ = app.currentDB().Close() })
+	if app.dbHash, err = hashDBFile(dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	var gotSrc string
+	generate := func(ctx context.Context, srcDir, outPath string) error {
+		<-release
+		gotSrc = srcDir
+		if filepath.Dir(filepath.Dir(outPath)) != dir {
+			return fmt.Errorf("output %s not beside the served db", outPath)
+		}
+		if srcDir == "fail" {
+			// A half-written file must never replace the served one
+			if err := os.WriteFile(outPath, []byte("partial"), 0o644); err != nil {
+				return err
+			}
+			return errors.New("exit status 1")
+		}
+		writePackageDB(t, outPath, "new_pkg")
+		return nil
+	}
+	app.admin = NewAdmin(context.Background(), "s3cret", "/src/prom", dbPath, generate)
+	h := app.Handler()
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	wait := func(id string) Job {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			var job Job
+			rec := do(http.MethodGet, "/api/admin/jobs/"+id, "s3cret")
+			if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+				t.Fatalf("GET job %s: %d %s", id, rec.Code, rec.Body)
+			}
+			if job.Status != "running" || time.Now().After(deadline) {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	graph := func() string {
+		return do(http.MethodGet, "/api/package-graph", "").Body.String()
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := do(http.MethodPost, "/api/admin/regenerate", token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("POST regenerate with token %q: want 401, got %d", token, rec.Code)
+		}
+	}
+	oldETag := do(http.MethodGet, "/api/package-graph", "").Header().Get("ETag")
+
+	rec := do(http.MethodPost, "/api/admin/regenerate", "s3cret")
+	var job Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); rec.Code != http.StatusAccepted || err != nil || job.ID == "" || job.Status != "running" {
+		t.Fatalf("POST regenerate: want 202 with a running job, got %d %s", rec.Code, rec.Body)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/api/admin/jobs/"+job.ID {
+		t.Errorf("Location = %q", loc)
+	}
+	// One job at a time; the old database is served until the swap
+	if rec := do(http.MethodPost, "/api/admin/regenerate", "s3cret"); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"id": "`+job.ID+`"`) {
+		t.Errorf("second POST regenerate: want 409 naming job %s, got %d %s", job.ID, rec.Code, rec.Body)
+	}
+	if body := graph(); !strings.Contains(body, "old_pkg") {
+		t.Errorf("package graph during regeneration: want old_pkg, got %s", body)
+	}
+	close(release)
+	if done := wait(job.ID); done.Status != "succeeded" || done.Finished == nil {
+		t.Fatalf("job %s = %+v, want succeeded", job.ID, done)
+	}
+	if gotSrc != "/src/prom" {
+		t.Errorf("generate srcDir = %q, want /src/prom", gotSrc)
+	}
+	if body := graph(); !strings.Contains(body, "new_pkg") || strings.Contains(body, "old_pkg") {
+		t.Errorf("package graph after regeneration: want new_pkg only, got %s", body)
+	}
+	if etag := do(http.MethodGet, "/api/package-graph", "").Header().Get("ETag"); etag == "" || etag == oldETag {
+		t.Errorf("ETag after regeneration = %q, want a new one (was %q)", etag, oldETag)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("db dir holds %d entries after regeneration, want just cpg.db", len(entries))
+	}
+
+	// A failed run reports its error and keeps serving the current file
+	app.admin.sourceDir = "fail"
+	rec = do(http.MethodPost, "/api/admin/regenerate", "s3cret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); rec.Code != http.StatusAccepted || err != nil {
+		t.Fatalf("POST regenerate: want 202, got %d %s", rec.Code, rec.Body)
+	}
+	if failed := wait(job.ID); failed.Status != "failed" || !strings.Contains(failed.Error, "exit status 1") {
+		t.Errorf("job %s = %+v, want failed with the generator's error", job.ID, failed)
+	}
+	if body := graph(); !strings.Contains(body, "new_pkg") {
+		t.Errorf("package graph after failed regeneration: want new_pkg, got %s", body)
+	}
+
+	if rec := do(http.MethodGet, "/api/admin/jobs/99", "s3cret"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET unknown job: want 404, got %d", rec.Code)
+	}
+}
+
+func TestAPI_AdminDisabled(t *testing.T) {
+	app := NewApp(setupTestDB(t), "")
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/regenerate", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/admin/regenerate without -admin-token: want 404, got %d", rec.Code)
+	}
+}
+
+func TestAPI_SwapDBWaitsForInFlight(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.db"), filepath.Join(dir, "new.db")
+	writePackageDB(t, oldPath, "old_pkg")
+	writePackageDB(t, newPath, "new_pkg")
+	oldDB, err := openDB(oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDB, err := openDB(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(oldDB, "")
+	app.dbHash = "old"
+	t.Cleanup(func() { _ = app.currentDB().Close() })
+
+	// A request that started before the swap reads its database after it
+	started, release := make(chan struct{}), make(chan struct{})
+	got := make(chan *DB, 1)
+	queryErr := make(chan error, 1)
+	h := app.holdDB(app.cacheMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		db := app.requestDB(r)
+		got <- db
+		_, err := db.PackageGraph()
+		queryErr <- err
+		w.WriteHeader(http.StatusOK)
+	})))
+	rec := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/package-graph", nil))
+		close(served)
+	}()
+	<-started
+	swapped := make(chan struct{})
+	go func() {
+		app.swapDB(newDB, "new")
+		close(swapped)
+	}()
+	deadline := time.Now().Add(10 * time.Second)
+	for app.currentDB().DB != newDB && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-swapped:
+		t.Fatal("swapDB returned while a request still held the old database")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if db := <-got; db.DB != oldDB {
+		t.Error("in-flight request read the database swapped in after it started")
+	}
+	if err := <-queryErr; err != nil {
+		t.Errorf("in-flight query after swap: %v", err)
+	}
+	<-served
+	// The body came from the old database, so its ETag must too
+	sum := sha256.Sum256([]byte("old\x00/api/package-graph"))
+	if want := `"` + hex.EncodeToString(sum[:16]) + `"`; rec.Header().Get("ETag") != want {
+		t.Errorf("ETag of in-flight response = %q, want the old database's %q", rec.Header().Get("ETag"), want)
+	}
+	<-swapped
+	if err := oldDB.Ping(); err == nil {
+		t.Error("old database still open after its requests finished")
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -13,18 +20,88 @@ import (
 
 // App holds server dependencies.
 type App struct {
-	db        *DB
+	// mu guards db, inflight and dbHash, which a regeneration job swaps
+	mu sync.RWMutex
+	db *DB
+	// inflight counts the API requests that may be using db
+	inflight  *sync.WaitGroup
 	staticDir string
+	// dbHash identifies the database contents (hashDBFile); when set, GET
+	// API responses carry an ETag derived from it and are cacheable.
+	dbHash string
+	// admin runs regeneration jobs; nil leaves /api/admin unrouted
+	admin *Admin
 }
 
 // NewApp creates an App with the given database and optional static directory.
 func NewApp(db *sql.DB, staticDir string) *App {
 	return &App{
 		db:        NewDB(db),
+		inflight:  new(sync.WaitGroup),
 		staticDir: strings.TrimSuffix(staticDir, "/"),
 	}
 }
 
+// currentDB returns the database requests are served from.
+func (a *App) currentDB() *DB {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.db
+}
+
+// dbSnapshot is the database a request reads and the hash of its file,
+// taken together so the ETag always describes the body.
+type dbSnapshot struct {
+	db   *DB
+	hash string
+}
+
+type dbSnapshotKey struct{}
+
+// holdDB takes the request's dbSnapshot and counts the request in inflight
+// while it runs, so swapDB does not close that database under it.
+func (a *App) holdDB(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.mu.RLock()
+		snap := dbSnapshot{db: a.db, hash: a.dbHash}
+		inflight := a.inflight
+		inflight.Add(1)
+		a.mu.RUnlock()
+		defer inflight.Done()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), dbSnapshotKey{}, snap)))
+	})
+}
+
+// snapshot returns the dbSnapshot holdDB took for r, or the current one
+// outside holdDB.
+func (a *App) snapshot(r *http.Request) dbSnapshot {
+	if snap, ok := r.Context().Value(dbSnapshotKey{}).(dbSnapshot); ok {
+		return snap
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return dbSnapshot{db: a.db, hash: a.dbHash}
+}
+
+// requestDB returns the database r is served from.
+func (a *App) requestDB(r *http.Request) *DB {
+	return a.snapshot(r).db
+}
+
+// swapDB starts serving db, whose file hashes to hash, and closes the
+// database it replaces once the requests started before the swap finish.
+// It blocks until then.
+func (a *App) swapDB(db *sql.DB, hash string) {
+	a.mu.Lock()
+	old, inflight := a.db, a.inflight
+	a.db, a.inflight, a.dbHash = NewDB(db), new(sync.WaitGroup), hash
+	a.mu.Unlock()
+	inflight.Wait()
+	if err := old.Close(); err != nil {
+		log.Printf("close replaced db: %v", err)
+	}
+}
+
 // Handler returns the HTTP handler (router with CORS, recovery, routes).
 func (a *App) Handler() http.Handler {
 	r := chi.NewRouter()
@@ -33,12 +110,33 @@ func (a *App) Handler() http.Handler {
 	r.Use(corsMiddleware)
 
 	r.Route("/api", func(r chi.Router) {
-		r.Get("/search", a.handleSearch)
-		r.Get("/subgraph", a.handleSubgraph)
-		r.Get("/package-graph", a.handlePackageGraph)
-		r.Get("/package/functions", a.handlePackageFunctions)
-		r.Get("/source", a.handleSource)
-		r.Get("/slice", a.handleSlice)
+		r.Use(a.holdDB)
+		// Job status changes, so admin responses are never cached
+		if a.admin != nil {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(a.admin.requireToken)
+				r.Post("/regenerate", a.handleRegenerate)
+				r.Get("/jobs/{id}", a.handleJob)
+			})
+		}
+		r.Group(func(r chi.Router) {
+			r.Use(a.cacheMiddleware)
+			r.Get("/schema", a.handleSchema)
+			r.Get("/search", a.handleSearch)
+			r.Get("/symbols", a.handleSymbols)
+			r.Get("/findings", a.handleFindings)
+			r.Get("/subgraph", a.handleSubgraph)
+			r.Get("/package-graph", a.handlePackageGraph)
+			r.Get("/packages/graph", a.handlePackageDependencyGraph)
+			r.Get("/files/graph", a.handleFileGraph)
+			r.Get("/package", a.handlePackage)
+			r.Get("/package/functions", a.handlePackageFunctions)
+			r.Get("/source", a.handleSource)
+			r.Get("/location", a.handleLocation)
+			r.Get("/slice", a.handleSlice)
+			r.Get("/taint/paths", a.handleTaintPaths)
+			r.Post("/query/{name}", a.handleQuery)
+		})
 	})
 
 	// SPA: serve static files if dir set, else 404 for /
@@ -57,8 +155,9 @@ func (a *App) Handler() http.Handler {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -67,6 +166,86 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// hashDBFile returns the hex SHA-256 of the database file at path, computed
+// at startup and after each regeneration: a generated CPG never changes
+// while it is served.
+func hashDBFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheMiddleware gives successful GET responses a strong ETag (hash of the
+// database and the request URI), and answers a matching If-None-Match with
+// 304 without running the handler. The URLs are unversioned, so responses
+// are no-cache: clients revalidate every time, and regenerating or replacing
+// the DB changes every ETag.
+func (a *App) cacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dbHash := a.snapshot(r).hash
+		if dbHash == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sum := sha256.Sum256([]byte(dbHash + "\x00" + r.URL.RequestURI()))
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", revalidateCacheControl)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(&cachingWriter{ResponseWriter: w, etag: etag}, r)
+	})
+}
+
+const revalidateCacheControl = "no-cache"
+
+// etagMatches reports whether an If-None-Match header value lists etag (or
+// is *). Weak validators match too, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// cachingWriter adds the caching headers when the handler writes a 200, so
+// errors are never cached.
+type cachingWriter struct {
+	http.ResponseWriter
+	etag        string
+	wroteHeader bool
+}
+
+func (w *cachingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if code == http.StatusOK {
+			w.Header().Set("ETag", w.etag)
+			w.Header().Set("Cache-Control", revalidateCacheControl)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cachingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 // serveSPA serves index.html for SPA routes and static files from staticDir.
 func (a *App) serveSPA(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/")
@@ -76,6 +76,90 @@ type Node struct {
 	Depth          int           `json:"depth,omitempty"`
 }
 
+// SourceFile is a file's content with the spans of its nodes, for code-viewer
+// overlays. Content is omitted when the DB has no text for the file.
+type SourceFile struct {
+	File    string     `json:"file"`
+	Package string     `json:"package"`
+	Content *string    `json:"content,omitempty"`
+	Nodes   []NodeSpan `json:"nodes"`
+}
+
+// NodeSpan is a node's source range: 1-based line and byte column, end_col
+// exclusive. Ends the generator does not record are null.
+type NodeSpan struct {
+	ID      string        `json:"id"`
+	Kind    string        `json:"kind"`
+	Line    int64         `json:"line"`
+	Col     nullInt64JSON `json:"col"`
+	EndLine nullInt64JSON `json:"end_line"`
+	EndCol  nullInt64JSON `json:"end_col"`
+}
+
+// SymbolMatch is one ranked hit from the symbol search index.
+type SymbolMatch struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Kind    string         `json:"kind"`
+	Package nullStringJSON `json:"package"`
+	File    nullStringJSON `json:"file"`
+	Line    nullInt64JSON  `json:"line"`
+	Match   string         `json:"match"` // exact, prefix, or substring
+}
+
+// Finding is one row of the findings table for API responses.
+type Finding struct {
+	ID       int64           `json:"id"`
+	Category string          `json:"category"`
+	Severity string          `json:"severity"`
+	NodeID   nullStringJSON  `json:"node_id"`
+	File     nullStringJSON  `json:"file"`
+	Line     nullInt64JSON   `json:"line"`
+	Message  string          `json:"message"`
+	Package  nullStringJSON  `json:"package"`
+	Details  json.RawMessage `json:"details,omitempty"`
+}
+
+// FindingFilter selects and orders findings; empty fields match everything.
+type FindingFilter struct {
+	Category, Severity, Package, File string
+	Sort                              string // key of findingSortOrders; default "severity"
+	Limit, Offset                     int
+}
+
+// FindingsPage is the paginated findings API response. Facet counts apply
+// every filter except their own dimension, so the UI can offer sibling chips.
+type FindingsPage struct {
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	Findings []Finding `json:"findings"`
+	Facets   struct {
+		Category map[string]int `json:"category"`
+		Severity map[string]int `json:"severity"`
+	} `json:"facets"`
+}
+
+// Location answers "what is at file:line:col": the innermost enclosing
+// function, the innermost statement, and the symbol defined or referenced
+// there. Each part is null when nothing matches.
+type Location struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Col        int    `json:"col,omitempty"`
+	Function   *Node  `json:"function"`
+	Statement  *Node  `json:"statement"`
+	Definition *Node  `json:"definition"`
+}
+
+// SchemaObject is one table or view of the CPG database.
+type SchemaObject struct {
+	Name             string         `json:"name"`
+	Type             string         `json:"type"` // table or view
+	RowCount         nullInt64JSON  `json:"row_count"`
+	GeneratorVersion nullStringJSON `json:"generator_version"`
+}
+
 // Edge is a CPG edge for API responses.
 type Edge struct {
 	Source string `json:"source"`
@@ -116,6 +200,97 @@ type PackageGraphResponse struct {
 	Edges []PackageGraphEdge `json:"edges"`
 }
 
+// PackageDepNode is a package in the dependency graph, with Martin's
+// instability/abstractness (v_package_stability) for sizing and coloring.
+type PackageDepNode struct {
+	ID            string  `json:"id"`
+	FunctionCount int     `json:"function_count"`
+	TotalLoc      int     `json:"total_loc"`
+	Instability   float64 `json:"instability"`
+	Abstractness  float64 `json:"abstractness"`
+	InCycle       bool    `json:"in_cycle"`
+}
+
+// PackageDepEdge is a weighted package dependency; Cyclic marks edges inside
+// a dependency cycle.
+type PackageDepEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+	Cyclic bool   `json:"cyclic"`
+}
+
+// PackageDepGraph is the /api/packages/graph response. Each cycle lists the
+// packages of one strongly connected component, sorted.
+type PackageDepGraph struct {
+	Nodes  []PackageDepNode `json:"nodes"`
+	Edges  []PackageDepEdge `json:"edges"`
+	Cycles [][]string       `json:"cycles"`
+}
+
+// FileGraphNode is a file, or with bundling a directory of files, with its
+// dashboard_file_heatmap sizes (summed over a directory's files; the max for
+// MaxComplexity). Files without a heatmap row count zero.
+type FileGraphNode struct {
+	ID              string `json:"id"`
+	Package         string `json:"package"`
+	Files           int    `json:"files"`
+	FunctionCount   int    `json:"function_count"`
+	TotalLoc        int    `json:"total_loc"`
+	TotalComplexity int    `json:"total_complexity"`
+	MaxComplexity   int    `json:"max_complexity"`
+}
+
+// FileGraphEdge is an aggregated file (or directory) dependency: Weight is
+// the number of calls between them.
+type FileGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+// FileGraph is the /api/files/graph response; Bundle is "file" or "dir".
+type FileGraph struct {
+	Bundle string          `json:"bundle"`
+	Nodes  []FileGraphNode `json:"nodes"`
+	Edges  []FileGraphEdge `json:"edges"`
+}
+
+// PackageStability is a package's row of v_package_stability (Martin's
+// metrics).
+type PackageStability struct {
+	AfferentCoupling int     `json:"afferent_coupling"`
+	EfferentCoupling int     `json:"efferent_coupling"`
+	Instability      float64 `json:"instability"`
+	Abstractness     float64 `json:"abstractness"`
+}
+
+// PackageCohesion is a package's row of v_package_cohesion: how many of its
+// calls stay inside the package.
+type PackageCohesion struct {
+	TotalCalls    int     `json:"total_calls"`
+	InternalCalls int     `json:"internal_calls"`
+	ExternalCalls int     `json:"external_calls"`
+	CohesionRatio float64 `json:"cohesion_ratio"`
+}
+
+// PackageDetail is the /api/package drill-down response. Functions are the
+// package's most complex (up to maxPackageFunctions); Inbound and Outbound are
+// its dashboard_package_graph edges, heaviest first.
+type PackageDetail struct {
+	Package   string             `json:"package"`
+	Stats     PackageGraphNode   `json:"stats"`
+	Stability *PackageStability  `json:"stability"`
+	Cohesion  *PackageCohesion   `json:"cohesion"`
+	Functions []FunctionDetail   `json:"functions"`
+	Types     []Node             `json:"types"`
+	Inbound   []PackageGraphEdge `json:"inbound"`
+	Outbound  []PackageGraphEdge `json:"outbound"`
+}
+
+// maxPackageFunctions caps the functions of a package drill-down.
+const maxPackageFunctions = 50
+
 // FunctionDetail is one row from dashboard_function_detail.
 type FunctionDetail struct {
 	FunctionID   string `json:"id"`
@@ -140,3 +315,34 @@ type FunctionDetail struct {
 }
 
 const maxSubgraphNodes = 200
+
+// maxQueryRows caps the rows a saved query returns through the API.
+const maxQueryRows = 1000
+
+// QueryResult is the output of a saved query: column names and rows of
+// JSON scalars. Truncated is set when rows stopped at maxQueryRows.
+type QueryResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+const (
+	defaultTaintPathsLimit = 50
+	maxTaintPathsLimit     = 500
+)
+
+// TaintPath is one source→sink taint flow: a shortest path through the DFG
+// (and receiver steps) the generator's taint BFS took, source first.
+type TaintPath struct {
+	SourceID string `json:"source_id"`
+	SinkID   string `json:"sink_id"`
+	Category string `json:"category"`
+	Hops     int    `json:"hops"`
+	Nodes    []Node `json:"nodes"`
+}
+
+const (
+	defaultFindingsLimit = 50
+	maxFindingsLimit     = 500
+)
@@ -2,7 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"path"
+	"sort"
 	"strings"
 )
 
@@ -40,6 +45,51 @@ func (db *DB) Search(pattern string, limit int) ([]Node, error) {
 	return out, nil
 }
 
+// Symbols runs a ranked symbol search: exact, prefix, then substring matches via
+// the symbol_fts trigram index. Queries shorter than 3 characters (or DBs built
+// before symbol_fts existed) fall back to a prefix LIKE on symbol_index.
+func (db *DB) Symbols(q string, limit int) ([]SymbolMatch, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var hasFTS int
+	if err := db.QueryRow(querySymbolFTSExists).Scan(&hasFTS); err != nil {
+		return nil, err
+	}
+	var rows *sql.Rows
+	var err error
+	if hasFTS > 0 && len([]rune(q)) >= 3 {
+		match := `name:"` + strings.ReplaceAll(q, `"`, `""`) + `"`
+		rows, err = db.Query(querySymbolFTS, q, match, limit, likePrefix(q))
+	} else {
+		rows, err = db.Query(querySymbolPrefix, q, limit, likePrefix(q))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []SymbolMatch{}
+	for rows.Next() {
+		var m SymbolMatch
+		var pkg, file sql.NullString
+		var line sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.Name, &m.Kind, &pkg, &file, &line, &m.Match); err != nil {
+			return nil, err
+		}
+		m.Package = nullStringJSON{pkg}
+		m.File = nullStringJSON{file}
+		m.Line = nullInt64JSON{line}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// likePrefix returns a LIKE pattern (with ESCAPE '\') matching strings that
+// start with q, whose % and _ match only themselves.
+func likePrefix(q string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q) + "%"
+}
+
 // Subgraph returns nodes and edges for the neighborhood of function nodeID (callers + callees), capped at maxSubgraphNodes.
 // If nodeID is not in the DB, the central node is omitted but neighbors from the neighborhood query may still be returned;
 // callers may treat empty nodes or a missing center as "unknown node_id" and respond with 404 if desired.
@@ -175,6 +225,237 @@ func (db *DB) PackageGraph() (*PackageGraphResponse, error) {
 	return &PackageGraphResponse{Nodes: nodes, Edges: edges}, nil
 }
 
+// PackageDependencyGraph returns the package dependency graph with its cycles.
+// Cycles are found over every dashboard_package_graph edge; the response keeps
+// the heaviest maxPackageGraphEdges edges plus every cyclic edge, and the
+// packages those edges touch.
+func (db *DB) PackageDependencyGraph() (*PackageDepGraph, error) {
+	rows, err := db.Query(queryPackageDepEdges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var all []PackageDepEdge
+	for rows.Next() {
+		var e PackageDepEdge
+		if err := rows.Scan(&e.Source, &e.Target, &e.Weight); err != nil {
+			return nil, err
+		}
+		all = append(all, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cycles, component := packageCycles(all)
+	edges := []PackageDepEdge{}
+	keep := make(map[string]bool)
+	for i, e := range all {
+		cs, okS := component[e.Source]
+		ct, okT := component[e.Target]
+		e.Cyclic = okS && okT && cs == ct
+		if i < maxPackageGraphEdges || e.Cyclic {
+			edges = append(edges, e)
+			keep[e.Source], keep[e.Target] = true, true
+		}
+	}
+
+	rows2, err := db.Query(queryPackageDepNodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows2.Close()
+	nodes := []PackageDepNode{}
+	for rows2.Next() {
+		var n PackageDepNode
+		if err := rows2.Scan(&n.ID, &n.FunctionCount, &n.TotalLoc, &n.Instability, &n.Abstractness); err != nil {
+			return nil, err
+		}
+		if !keep[n.ID] {
+			continue
+		}
+		_, n.InCycle = component[n.ID]
+		nodes = append(nodes, n)
+	}
+	if err := rows2.Err(); err != nil {
+		return nil, err
+	}
+	return &PackageDepGraph{Nodes: nodes, Edges: edges, Cycles: cycles}, nil
+}
+
+// FileGraph returns the file dependency graph (v_file_deps) of pkg, or of
+// every package when pkg is empty. With byDir, files are bundled into their
+// directories: edges between two directories are summed and edges within one
+// dropped. Edges lighter than minWeight (after bundling) are left out, and
+// at most maxFileGraphEdges of the heaviest kept; nodes are the endpoints of
+// the kept edges.
+func (db *DB) FileGraph(pkg string, minWeight int, byDir bool) (*FileGraph, error) {
+	group := func(file string) string { return file }
+	bundle := "file"
+	if byDir {
+		group, bundle = path.Dir, "dir"
+	}
+
+	rows, err := db.Query(queryFileDepEdges, pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	weights := make(map[[2]string]int)
+	for rows.Next() {
+		var src, dst string
+		var n int
+		if err := rows.Scan(&src, &dst, &n); err != nil {
+			return nil, err
+		}
+		key := [2]string{group(src), group(dst)}
+		if key[0] == key[1] {
+			continue
+		}
+		weights[key] += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	edges := []FileGraphEdge{}
+	for key, w := range weights {
+		if w >= minWeight {
+			edges = append(edges, FileGraphEdge{Source: key[0], Target: key[1], Weight: w})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Weight != edges[j].Weight {
+			return edges[i].Weight > edges[j].Weight
+		}
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+	if len(edges) > maxFileGraphEdges {
+		edges = edges[:maxFileGraphEdges]
+	}
+	keep := make(map[string]bool)
+	for _, e := range edges {
+		keep[e.Source], keep[e.Target] = true, true
+	}
+
+	rows2, err := db.Query(queryFileHeatmap)
+	if err != nil {
+		return nil, err
+	}
+	defer rows2.Close()
+	byID := make(map[string]*FileGraphNode)
+	for rows2.Next() {
+		var file string
+		var f FileGraphNode
+		if err := rows2.Scan(&file, &f.Package, &f.FunctionCount, &f.TotalLoc, &f.TotalComplexity, &f.MaxComplexity); err != nil {
+			return nil, err
+		}
+		id := group(file)
+		if !keep[id] {
+			continue
+		}
+		n, ok := byID[id]
+		if !ok {
+			n = &FileGraphNode{ID: id, Package: f.Package}
+			byID[id] = n
+		}
+		n.Files++
+		n.FunctionCount += f.FunctionCount
+		n.TotalLoc += f.TotalLoc
+		n.TotalComplexity += f.TotalComplexity
+		n.MaxComplexity = max(n.MaxComplexity, f.MaxComplexity)
+	}
+	if err := rows2.Err(); err != nil {
+		return nil, err
+	}
+	nodes := []FileGraphNode{}
+	for id := range keep {
+		n, ok := byID[id]
+		if !ok {
+			n = &FileGraphNode{ID: id}
+		}
+		nodes = append(nodes, *n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return &FileGraph{Bundle: bundle, Nodes: nodes, Edges: edges}, nil
+}
+
+// packageCycles finds the strongly connected components with more than one
+// package (Tarjan). It returns each as a sorted package list, ordered by
+// first package, and maps every package in a cycle to its index.
+func packageCycles(edges []PackageDepEdge) ([][]string, map[string]int) {
+	adj := make(map[string][]string)
+	var order []string
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		adj[e.Source] = append(adj[e.Source], e.Target)
+		for _, p := range []string{e.Source, e.Target} {
+			if !seen[p] {
+				seen[p] = true
+				order = append(order, p)
+			}
+		}
+	}
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var sccs [][]string
+	var visit func(p string)
+	visit = func(p string) {
+		index[p] = len(index)
+		low[p] = index[p]
+		stack = append(stack, p)
+		onStack[p] = true
+		for _, q := range adj[p] {
+			if _, ok := index[q]; !ok {
+				visit(q)
+				low[p] = min(low[p], low[q])
+			} else if onStack[q] {
+				low[p] = min(low[p], index[q])
+			}
+		}
+		if low[p] != index[p] {
+			return
+		}
+		var scc []string
+		for {
+			q := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[q] = false
+			scc = append(scc, q)
+			if q == p {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+	for _, p := range order {
+		if _, ok := index[p]; !ok {
+			visit(p)
+		}
+	}
+
+	sort.Slice(sccs, func(i, j int) bool { return sccs[i][0] < sccs[j][0] })
+	component := make(map[string]int)
+	for i, scc := range sccs {
+		for _, p := range scc {
+			component[p] = i
+		}
+	}
+	if sccs == nil {
+		sccs = [][]string{}
+	}
+	return sccs, component
+}
+
 // PackageFunctions returns function list for a package (by package id/name).
 func (db *DB) PackageFunctions(packageIDOrName string) ([]FunctionDetail, error) {
 	like := "%" + packageIDOrName + "%"
@@ -182,6 +463,12 @@ func (db *DB) PackageFunctions(packageIDOrName string) ([]FunctionDetail, error)
 	if err != nil {
 		return nil, err
 	}
+	return scanFunctionDetails(rows)
+}
+
+// scanFunctionDetails reads dashboard_function_detail rows selected in the
+// column order of queryDashboardFunctionDetailByPackage, and closes rows.
+func scanFunctionDetails(rows *sql.Rows) ([]FunctionDetail, error) {
 	defer rows.Close()
 	var out []FunctionDetail
 	for rows.Next() {
@@ -211,10 +498,228 @@ func (db *DB) PackageFunctions(packageIDOrName string) ([]FunctionDetail, error)
 	return out, rows.Err()
 }
 
-// Source returns file content by path (key in sources table).
-func (db *DB) Source(filePath string) (content string, packageName string, err error) {
-	err = db.QueryRow(querySourceByFile, filePath).Scan(&filePath, &content, &packageName)
-	return content, packageName, err
+// Package returns the drill-down view of one package (exact package path):
+// its treemap stats, stability and cohesion, its most complex functions, its
+// types and its package dependency edges. sql.ErrNoRows means the package has
+// no treemap row. Stability and cohesion are null for packages the views
+// do not cover.
+func (db *DB) Package(name string) (*PackageDetail, error) {
+	out := &PackageDetail{Package: name, Functions: []FunctionDetail{}, Types: []Node{},
+		Inbound: []PackageGraphEdge{}, Outbound: []PackageGraphEdge{}}
+	var st PackageGraphNode
+	if err := db.QueryRow(queryPackageTreemapRow, name).Scan(&st.Package, &st.FileCount, &st.FunctionCount, &st.TotalLoc,
+		&st.TotalComplexity, &st.AvgComplexity, &st.MaxComplexity, &st.TypeCount, &st.InterfaceCount); err != nil {
+		return nil, err
+	}
+	st.ID = st.Package
+	out.Stats = st
+
+	var stab PackageStability
+	switch err := db.QueryRow(queryPackageStabilityRow, name).Scan(&stab.AfferentCoupling, &stab.EfferentCoupling,
+		&stab.Instability, &stab.Abstractness); {
+	case err == nil:
+		out.Stability = &stab
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+	var coh PackageCohesion
+	switch err := db.QueryRow(queryPackageCohesionRow, name).Scan(&coh.TotalCalls, &coh.InternalCalls,
+		&coh.ExternalCalls, &coh.CohesionRatio); {
+	case err == nil:
+		out.Cohesion = &coh
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	rows, err := db.Query(queryPackageTopFunctions, name, maxPackageFunctions)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := scanFunctionDetails(rows)
+	if err != nil {
+		return nil, err
+	}
+	if funcs != nil {
+		out.Functions = funcs
+	}
+
+	rows, err = db.Query(queryPackageTypes, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n Node
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Name, &n.File, &n.Line, &n.EndLine, &n.Package, &n.ParentFunction, &n.TypeInfo); err != nil {
+			return nil, err
+		}
+		out.Types = append(out.Types, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(queryPackageEdges, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e PackageGraphEdge
+		if err := rows.Scan(&e.Source, &e.Target, &e.Weight); err != nil {
+			return nil, err
+		}
+		if e.Target == name {
+			out.Inbound = append(out.Inbound, e)
+		} else {
+			out.Outbound = append(out.Outbound, e)
+		}
+	}
+	return out, rows.Err()
+}
+
+// Source returns file content by path (key in sources table) with the spans
+// of the file's nodes. A file with nodes but no sources row (its text left out
+// of the DB) comes back without content; sql.ErrNoRows means neither exists.
+func (db *DB) Source(filePath string) (*SourceFile, error) {
+	out := &SourceFile{File: filePath, Nodes: []NodeSpan{}}
+	var content, pkg sql.NullString
+	err := db.QueryRow(querySourceByFile, filePath).Scan(&out.File, &content, &pkg)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if content.Valid {
+		out.Content = &content.String
+	}
+	out.Package = pkg.String
+
+	cols := map[string]bool{}
+	rows, err := db.Query(queryNodeColumns)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		cols[name] = true
+	}
+	rows.Close()
+	colExpr, endColExpr := "NULL", "NULL"
+	if cols["col"] {
+		colExpr = "col"
+	}
+	if cols["end_col"] {
+		endColExpr = "end_col"
+	}
+
+	rows, err = db.Query(fmt.Sprintf(querySourceNodes, colExpr, endColExpr), filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n NodeSpan
+		var nodePkg sql.NullString
+		if err := rows.Scan(&n.ID, &n.Kind, &nodePkg, &n.Line, &n.Col, &n.EndLine, &n.EndCol); err != nil {
+			return nil, err
+		}
+		if out.Package == "" {
+			out.Package = nodePkg.String
+		}
+		out.Nodes = append(out.Nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if out.Content == nil && len(out.Nodes) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return out, nil
+}
+
+// Schema lists the database's tables and views from cpg_manifest, falling
+// back to sqlite_master for DBs generated before the manifest existed.
+func (db *DB) Schema() ([]SchemaObject, error) {
+	var hasManifest int
+	if err := db.QueryRow(queryManifestExists).Scan(&hasManifest); err != nil {
+		return nil, err
+	}
+	query := querySchemaMaster
+	if hasManifest > 0 {
+		query = querySchemaManifest
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []SchemaObject{}
+	for rows.Next() {
+		var o SchemaObject
+		var count sql.NullInt64
+		var version sql.NullString
+		if err := rows.Scan(&o.Name, &o.Type, &count, &version); err != nil {
+			return nil, err
+		}
+		o.RowCount = nullInt64JSON{count}
+		o.GeneratorVersion = nullStringJSON{version}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// Location resolves a cursor position. col <= 0 means "anywhere on the line".
+// Positions in comments or whitespace yield only the enclosing function.
+func (db *DB) Location(file string, line, col int) (*Location, error) {
+	loc := &Location{File: file, Line: line}
+	if col > 0 {
+		loc.Col = col
+	} else {
+		col = math.MaxInt32
+	}
+
+	var err error
+	if loc.Function, err = db.locationNode(queryLocationFunction, file, line, col); err != nil {
+		return nil, err
+	}
+	if loc.Function != nil {
+		if loc.Statement, err = db.locationNode(queryLocationStatement, file, line, col, loc.Function.ID); err != nil {
+			return nil, err
+		}
+	}
+	if loc.Definition, err = db.locationNode(queryLocationReference, file, line, col); err != nil {
+		return nil, err
+	}
+	if loc.Definition == nil {
+		if loc.Definition, err = db.locationNode(queryLocationDeclaration, file, line, col); err != nil {
+			return nil, err
+		}
+	}
+	return loc, nil
+}
+
+// locationNode runs one of the queryLocation* lookups; nil when nothing matches.
+func (db *DB) locationNode(query string, args ...any) (*Node, error) {
+	var n Node
+	var f, pkg, pf, typ sql.NullString
+	var l, end sql.NullInt64
+	err := db.QueryRow(query, args...).Scan(&n.ID, &n.Kind, &n.Name, &f, &l, &end, &pkg, &pf, &typ)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n.File = nullStringJSON{f}
+	n.Line = nullInt64JSON{l}
+	n.EndLine = nullInt64JSON{end}
+	n.Package = nullStringJSON{pkg}
+	n.ParentFunction = nullStringJSON{pf}
+	n.TypeInfo = nullStringJSON{typ}
+	return &n, nil
 }
 
 // Slice returns backward or forward slice as subgraph (nodes + edges).
@@ -288,3 +793,312 @@ func (db *DB) Slice(nodeID string, direction string, limit int) (*Subgraph, erro
 	}
 	return &Subgraph{Nodes: nodes, Edges: edges}, rows.Err()
 }
+
+// TaintPaths returns up to limit unsanitized source→sink taint flows,
+// shortest first, each with its node sequence from source to sink. A DB
+// without taint_path_edges yields no paths.
+func (db *DB) TaintPaths(limit int) ([]TaintPath, error) {
+	if limit <= 0 {
+		limit = defaultTaintPathsLimit
+	}
+	if limit > maxTaintPathsLimit {
+		limit = maxTaintPathsLimit
+	}
+	out := []TaintPath{}
+	var n int
+	if err := db.QueryRow(queryTaintPathEdgesExists).Scan(&n); err != nil || n == 0 {
+		return out, err
+	}
+
+	rows, err := db.Query(queryTaintSinks, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p TaintPath
+		if err := rows.Scan(&p.SourceID, &p.SinkID, &p.Category, &p.Hops); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		if out[i].Nodes, err = db.taintPathNodes(out[i].SourceID, out[i].SinkID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// taintPathNodes follows parent pointers from sinkID back to sourceID.
+func (db *DB) taintPathNodes(sourceID, sinkID string) ([]Node, error) {
+	rows, err := db.Query(queryTaintPath, sourceID, sinkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	nodes := []Node{}
+	for rows.Next() {
+		var n Node
+		var f, pkg, pf, ti sql.NullString
+		var line, endLine sql.NullInt64
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Name, &f, &line, &endLine, &pkg, &pf, &ti); err != nil {
+			return nil, err
+		}
+		n.File = nullStringJSON{f}
+		n.Line = nullInt64JSON{line}
+		n.EndLine = nullInt64JSON{endLine}
+		n.Package = nullStringJSON{pkg}
+		n.ParentFunction = nullStringJSON{pf}
+		n.TypeInfo = nullStringJSON{ti}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// errInvalidParam is returned by RunQuery for a binding that is unknown,
+// missing or of the wrong type.
+var errInvalidParam = errors.New("invalid query parameter")
+
+// RunQuery runs the saved query name with args (JSON values keyed by :param
+// name), checked against its query_params declarations: text takes a string,
+// integer an integral number, real any number; null or absent is accepted
+// only for optional parameters. A DB without query_params binds args
+// unchecked. sql.ErrNoRows means there is no such query.
+func (db *DB) RunQuery(name string, args map[string]json.RawMessage) (*QueryResult, error) {
+	var code string
+	if err := db.QueryRow(querySavedSQL, name).Scan(&code); err != nil {
+		return nil, err
+	}
+
+	var binds []any
+	var declared int
+	if err := db.QueryRow(queryParamsExists).Scan(&declared); err != nil {
+		return nil, err
+	}
+	if declared == 0 {
+		for k, raw := range args {
+			var v any
+			dec := json.NewDecoder(strings.NewReader(string(raw)))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", errInvalidParam, k, err)
+			}
+			if n, ok := v.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					v = i
+				} else if v, err = n.Float64(); err != nil {
+					return nil, fmt.Errorf("%w: %s: %v", errInvalidParam, k, err)
+				}
+			}
+			binds = append(binds, sql.Named(k, v))
+		}
+	} else {
+		rows, err := db.Query(querySavedParams, name)
+		if err != nil {
+			return nil, err
+		}
+		known := map[string]bool{}
+		for rows.Next() {
+			var param, typ string
+			var required bool
+			if err := rows.Scan(&param, &typ, &required); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			known[param] = true
+			v, err := bindQueryParam(param, typ, required, args[param])
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			binds = append(binds, sql.Named(param, v))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for k := range args {
+			if !known[k] {
+				return nil, fmt.Errorf("%w: query %s has no parameter %s", errInvalidParam, name, k)
+			}
+		}
+	}
+
+	rows, err := db.Query(code, binds...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := &QueryResult{Rows: [][]any{}}
+	if out.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		if len(out.Rows) == maxQueryRows {
+			out.Truncated = true
+			break
+		}
+		row := make([]any, len(out.Columns))
+		ptrs := make([]any, len(row))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		out.Rows = append(out.Rows, row)
+	}
+	return out, rows.Err()
+}
+
+// bindQueryParam converts raw to the Go value bound for a parameter declared
+// with typ; nil (SQL NULL) when it is absent or null and not required.
+func bindQueryParam(param, typ string, required bool, raw json.RawMessage) (any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		if required {
+			return nil, fmt.Errorf("%w: %s is required", errInvalidParam, param)
+		}
+		return nil, nil
+	}
+	switch typ {
+	case "integer":
+		// json.Number also accepts a quoted number; only a bare one will do.
+		var n json.Number
+		if raw[0] != '"' && json.Unmarshal(raw, &n) == nil {
+			if i, err := n.Int64(); err == nil {
+				return i, nil
+			}
+		}
+	case "real":
+		var f float64
+		if err := json.Unmarshal(raw, &f); err == nil {
+			return f, nil
+		}
+	default: // text
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be %s, got %s", errInvalidParam, param, typ, raw)
+}
+
+// errInvalidSort is returned by Findings for a sort key outside the allowlist.
+var errInvalidSort = errors.New("invalid sort column")
+
+// findingConds builds the WHERE clause for f, skipping the dimension named by
+// omit ("category" or "severity") so facet counts ignore their own filter.
+func findingConds(f FindingFilter, omit string) (string, []any) {
+	var conds []string
+	var args []any
+	if f.Category != "" && omit != "category" {
+		conds = append(conds, "f.category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Severity != "" && omit != "severity" {
+		conds = append(conds, "f.severity = ?")
+		args = append(args, f.Severity)
+	}
+	if f.Package != "" {
+		conds = append(conds, findingPackageExpr+" = ?")
+		args = append(args, f.Package)
+	}
+	if f.File != "" {
+		conds = append(conds, "f.file = ?")
+		args = append(args, f.File)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Findings returns one page of findings matching f, the total match count and
+// per-category / per-severity facet counts.
+func (db *DB) Findings(f FindingFilter) (*FindingsPage, error) {
+	if f.Sort == "" {
+		f.Sort = "severity"
+	}
+	order, ok := findingSortOrders[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errInvalidSort, f.Sort)
+	}
+	if f.Limit <= 0 || f.Limit > maxFindingsLimit {
+		f.Limit = defaultFindingsLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	page := &FindingsPage{Limit: f.Limit, Offset: f.Offset, Findings: []Finding{}}
+	where, args := findingConds(f, "")
+	if err := db.QueryRow("SELECT COUNT(*) "+findingsFrom+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(queryFindingsSelect+where+" ORDER BY "+order+" LIMIT ? OFFSET ?",
+		append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fd Finding
+		var nodeID, file, details, pkg sql.NullString
+		var line sql.NullInt64
+		if err := rows.Scan(&fd.ID, &fd.Category, &fd.Severity, &nodeID, &file, &line, &fd.Message, &details, &pkg); err != nil {
+			return nil, err
+		}
+		fd.NodeID = nullStringJSON{nodeID}
+		fd.File = nullStringJSON{file}
+		fd.Line = nullInt64JSON{line}
+		fd.Package = nullStringJSON{pkg}
+		if details.Valid && json.Valid([]byte(details.String)) {
+			fd.Details = json.RawMessage(details.String)
+		}
+		page.Findings = append(page.Findings, fd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if page.Facets.Category, err = db.findingFacet(f, "category"); err != nil {
+		return nil, err
+	}
+	if page.Facets.Severity, err = db.findingFacet(f, "severity"); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// findingFacet counts findings matching f (minus its own filter) per value of
+// column, which must be "category" or "severity".
+func (db *DB) findingFacet(f FindingFilter, column string) (map[string]int, error) {
+	where, args := findingConds(f, column)
+	rows, err := db.Query("SELECT f."+column+", COUNT(*) "+findingsFrom+where+" GROUP BY f."+column, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		counts[key] = n
+	}
+	return counts, rows.Err()
+}
@@ -4,9 +4,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func (a *App) handleSearch(w http.ResponseWriter, r *http.Request) {
@@ -20,7 +23,7 @@ func (a *App) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if limitStr != "" && atoiErr != nil {
 		log.Printf("search: invalid limit %q, using default", limitStr)
 	}
-	nodes, err := a.db.Search(q, limit)
+	nodes, err := a.requestDB(r).Search(q, limit)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -28,6 +31,58 @@ func (a *App) handleSearch(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, nodes)
 }
 
+func (a *App) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "missing query parameter q", http.StatusBadRequest)
+		return
+	}
+	limitStr := r.URL.Query().Get("limit")
+	limit, atoiErr := strconv.Atoi(limitStr)
+	if limitStr != "" && atoiErr != nil {
+		log.Printf("symbols: invalid limit %q, using default", limitStr)
+	}
+	matches, err := a.requestDB(r).Symbols(q, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, matches)
+}
+
+func (a *App) handleFindings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := FindingFilter{
+		Category: q.Get("category"),
+		Severity: q.Get("severity"),
+		Package:  q.Get("package"),
+		File:     q.Get("file"),
+		Sort:     q.Get("sort"),
+	}
+	var atoiErr error
+	if s := q.Get("limit"); s != "" {
+		if f.Limit, atoiErr = strconv.Atoi(s); atoiErr != nil {
+			log.Printf("findings: invalid limit %q, using default", s)
+		}
+	}
+	if s := q.Get("offset"); s != "" {
+		if f.Offset, atoiErr = strconv.Atoi(s); atoiErr != nil {
+			log.Printf("findings: invalid offset %q, using 0", s)
+		}
+	}
+	page, err := a.requestDB(r).Findings(f)
+	if err != nil {
+		if errors.Is(err, errInvalidSort) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	writeJSON(w, page)
+}
+
 func (a *App) handleSubgraph(w http.ResponseWriter, r *http.Request) {
 	nodeID := r.URL.Query().Get("node_id")
 	if nodeID == "" {
@@ -39,7 +94,7 @@ func (a *App) handleSubgraph(w http.ResponseWriter, r *http.Request) {
 	if limitStr != "" && atoiErr != nil {
 		log.Printf("subgraph: invalid limit %q, using default", limitStr)
 	}
-	sg, err := a.db.Subgraph(nodeID, limit)
+	sg, err := a.requestDB(r).Subgraph(nodeID, limit)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -48,7 +103,43 @@ func (a *App) handleSubgraph(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) handlePackageGraph(w http.ResponseWriter, r *http.Request) {
-	resp, err := a.db.PackageGraph()
+	resp, err := a.requestDB(r).PackageGraph()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (a *App) handlePackageDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	resp, err := a.requestDB(r).PackageDependencyGraph()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (a *App) handleFileGraph(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	minWeight := 1
+	if s := q.Get("min_weight"); s != "" {
+		var err error
+		if minWeight, err = strconv.Atoi(s); err != nil || minWeight < 1 {
+			http.Error(w, "query parameter min_weight must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	var byDir bool
+	switch q.Get("bundle") {
+	case "", "file":
+	case "dir":
+		byDir = true
+	default:
+		http.Error(w, "query parameter bundle must be file or dir", http.StatusBadRequest)
+		return
+	}
+	resp, err := a.requestDB(r).FileGraph(q.Get("package"), minWeight, byDir)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -62,7 +153,7 @@ func (a *App) handlePackageFunctions(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing query parameter package", http.StatusBadRequest)
 		return
 	}
-	list, err := a.db.PackageFunctions(id)
+	list, err := a.requestDB(r).PackageFunctions(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -70,13 +161,31 @@ func (a *App) handlePackageFunctions(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, list)
 }
 
+func (a *App) handlePackage(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "missing query parameter name", http.StatusBadRequest)
+		return
+	}
+	pkg, err := a.requestDB(r).Package(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "package not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, pkg)
+}
+
 func (a *App) handleSource(w http.ResponseWriter, r *http.Request) {
 	file := r.URL.Query().Get("file")
 	if file == "" {
 		http.Error(w, "missing query parameter file", http.StatusBadRequest)
 		return
 	}
-	content, pkg, err := a.db.Source(file)
+	src, err := a.requestDB(r).Source(file)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, "file not found", http.StatusNotFound)
@@ -85,7 +194,43 @@ func (a *App) handleSource(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, map[string]string{"file": file, "package": pkg, "content": content})
+	writeJSON(w, src)
+}
+
+func (a *App) handleSchema(w http.ResponseWriter, r *http.Request) {
+	objects, err := a.requestDB(r).Schema()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, objects)
+}
+
+func (a *App) handleLocation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	file := q.Get("file")
+	if file == "" {
+		http.Error(w, "missing query parameter file", http.StatusBadRequest)
+		return
+	}
+	line, err := strconv.Atoi(q.Get("line"))
+	if err != nil || line <= 0 {
+		http.Error(w, "query parameter line must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	col := 0
+	if s := q.Get("col"); s != "" {
+		if col, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "query parameter col must be an integer", http.StatusBadRequest)
+			return
+		}
+	}
+	loc, err := a.requestDB(r).Location(file, line, col)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, loc)
 }
 
 func (a *App) handleSlice(w http.ResponseWriter, r *http.Request) {
@@ -103,7 +248,7 @@ func (a *App) handleSlice(w http.ResponseWriter, r *http.Request) {
 	if limitStr != "" && atoiErr != nil {
 		log.Printf("slice: invalid limit %q, using default", limitStr)
 	}
-	sg, err := a.db.Slice(nodeID, direction, limit)
+	sg, err := a.requestDB(r).Slice(nodeID, direction, limit)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -111,6 +256,41 @@ func (a *App) handleSlice(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, sg)
 }
 
+func (a *App) handleTaintPaths(w http.ResponseWriter, r *http.Request) {
+	limitStr := r.URL.Query().Get("limit")
+	limit, atoiErr := strconv.Atoi(limitStr)
+	if limitStr != "" && atoiErr != nil {
+		log.Printf("taint paths: invalid limit %q, using default", limitStr)
+	}
+	paths, err := a.requestDB(r).TaintPaths(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, paths)
+}
+
+func (a *App) handleQuery(w http.ResponseWriter, r *http.Request) {
+	args := map[string]json.RawMessage{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&args); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "request body must be a JSON object of parameters: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := a.requestDB(r).RunQuery(chi.URLParam(r, "name"), args)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "query not found", http.StatusNotFound)
+		case errors.Is(err, errInvalidParam):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, res)
+}
+
 func writeJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	enc := json.NewEncoder(w)
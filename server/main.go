@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,8 +18,12 @@ import (
 
 func main() {
 	dbPath := flag.String("db", "", "Path to SQLite database (e.g. output.db). Can be set via DB_PATH env.")
+	host := flag.String("host", "", "Interface to listen on (e.g. 127.0.0.1); empty for all. Can be set via HOST env.")
 	port := flag.String("port", "8080", "HTTP port. Can be set via PORT env.")
 	staticDir := flag.String("static", "", "Directory for SPA static files (e.g. client/dist). Can be set via STATIC_DIR env.")
+	adminToken := flag.String("admin-token", "", "Bearer token enabling POST /api/admin/regenerate and GET /api/admin/jobs/{id}; unset leaves them unrouted. Can be set via ADMIN_TOKEN env.")
+	sourceDir := flag.String("source", "", "Primary module dir that /api/admin/regenerate runs the generator on (required with -admin-token). Can be set via SOURCE_DIR env.")
+	generator := flag.String("generator", "", "cpg-gen binary for regeneration (default: cpg-gen next to this binary, in its parent dir, or on PATH). Can be set via CPG_GEN env.")
 	flag.Parse()
 
 	if *dbPath == "" {
@@ -27,6 +32,9 @@ func main() {
 	if *dbPath == "" {
 		log.Fatal("DB path required: set -db or DB_PATH")
 	}
+	if *host == "" {
+		*host = os.Getenv("HOST")
+	}
 	if *port == "" {
 		*port = os.Getenv("PORT")
 	}
@@ -37,52 +45,54 @@ func main() {
 		*staticDir = os.Getenv("STATIC_DIR")
 	}
 
-	db, err := sql.Open("sqlite", *dbPath)
-	if err != nil {
-		log.Fatalf("open db: %v", err)
+	if *adminToken == "" {
+		*adminToken = os.Getenv("ADMIN_TOKEN")
 	}
-	defer db.Close()
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
-
-	if err := db.Ping(); err != nil {
-		log.Fatalf("ping db: %v", err)
+	if *sourceDir == "" {
+		*sourceDir = os.Getenv("SOURCE_DIR")
+	}
+	if *generator == "" {
+		*generator = os.Getenv("CPG_GEN")
 	}
 
-	// Ensure dashboard tables exist (they are created by the CPG pipeline; if DB was created
-	// without full pipeline or is old schema, they may be missing — create empty tables so API returns 200 with empty data).
-	if _, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS dashboard_package_graph (
-			source TEXT NOT NULL,
-			target TEXT NOT NULL,
-			weight INTEGER NOT NULL,
-			PRIMARY KEY (source, target)
-		);
-		CREATE TABLE IF NOT EXISTS dashboard_package_treemap (
-			package TEXT PRIMARY KEY,
-			file_count INTEGER,
-			function_count INTEGER,
-			total_loc INTEGER,
-			total_complexity INTEGER,
-			avg_complexity REAL,
-			max_complexity INTEGER,
-			type_count INTEGER,
-			interface_count INTEGER
-		);
-	`); err != nil {
-		log.Fatalf("ensure dashboard tables: %v", err)
+	db, err := openDB(*dbPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	app := NewApp(db, *staticDir)
+	// Regeneration swaps the database, closing the replaced one
+	defer func() { _ = app.currentDB().Close() }()
+	if app.dbHash, err = hashDBFile(*dbPath); err != nil {
+		log.Fatalf("hash db: %v", err)
+	}
+	// Jobs outlive their request; shutdown cancels a running generator
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	if *adminToken != "" {
+		if *sourceDir == "" {
+			log.Fatal("-admin-token needs the source dir to regenerate from: set -source or SOURCE_DIR")
+		}
+		bin, err := findGenerator(*generator)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.admin = NewAdmin(jobCtx, *adminToken, *sourceDir, *dbPath, execGenerator(bin))
+		log.Printf("Admin regeneration enabled (generator=%s, source=%s)", bin, *sourceDir)
+	}
 	srv := &http.Server{
-		Addr:         ":" + *port,
+		Addr:         net.JoinHostPort(*host, *port),
 		Handler:      app.Handler(),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 60 * time.Second,
 	}
 
 	go func() {
-		log.Printf("Listening on http://localhost:%s (db=%s)", *port, *dbPath)
+		browse := *host
+		if browse == "" {
+			browse = "localhost"
+		}
+		log.Printf("Listening on http://%s (db=%s)", net.JoinHostPort(browse, *port), *dbPath)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server: %v", err)
 		}
@@ -92,6 +102,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down...")
+	cancelJobs()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
@@ -100,3 +111,44 @@ func main() {
 	}
 	log.Println("Bye")
 }
+
+// openDB opens the CPG database at path with a single connection (SQLite
+// serializes access anyway) and adds the dashboard tables a DB written
+// without the full pipeline, or by an older one, lacks, so their endpoints
+// answer 200 with empty data.
+func openDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping db: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS dashboard_package_graph (
+			source TEXT NOT NULL,
+			target TEXT NOT NULL,
+			weight INTEGER NOT NULL,
+			PRIMARY KEY (source, target)
+		);
+		CREATE TABLE IF NOT EXISTS dashboard_package_treemap (
+			package TEXT PRIMARY KEY,
+			file_count INTEGER,
+			function_count INTEGER,
+			total_loc INTEGER,
+			total_complexity INTEGER,
+			avg_complexity REAL,
+			max_complexity INTEGER,
+			type_count INTEGER,
+			interface_count INTEGER
+		);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ensure dashboard tables: %w", err)
+	}
+	return db, nil
+}
@@ -7,6 +7,30 @@ const querySymbolSearch = `
 SELECT id, name, kind, package, file, line FROM symbol_index WHERE name LIKE ? ORDER BY kind, name LIMIT ?
 `
 
+// querySymbolFTS ranks trigram matches from symbol_fts: exact name first, then
+// prefix, then substring; ties broken by bm25 and shorter names. ?4 is the
+// query as an escaped LIKE prefix pattern (likePrefix).
+const querySymbolFTS = `
+SELECT s.id, s.name, s.kind, s.package, s.file, s.line,
+  CASE WHEN s.name = ?1 THEN 'exact' WHEN s.name LIKE ?4 ESCAPE '\' THEN 'prefix' ELSE 'substring' END AS match
+FROM symbol_fts f JOIN symbol_index s ON s.rowid = f.rowid
+WHERE symbol_fts MATCH ?2
+ORDER BY CASE WHEN s.name = ?1 THEN 0 WHEN s.name LIKE ?4 ESCAPE '\' THEN 1 ELSE 2 END, bm25(symbol_fts), length(s.name), s.name
+LIMIT ?3
+`
+
+// querySymbolPrefix is the fallback for queries shorter than a trigram or DBs
+// without symbol_fts; ?3 is the escaped LIKE prefix pattern.
+const querySymbolPrefix = `
+SELECT id, name, kind, package, file, line,
+  CASE WHEN name = ?1 THEN 'exact' ELSE 'prefix' END AS match
+FROM symbol_index WHERE name LIKE ?3 ESCAPE '\'
+ORDER BY CASE WHEN name = ?1 THEN 0 ELSE 1 END, length(name), name
+LIMIT ?2
+`
+
+const querySymbolFTSExists = `SELECT COUNT(*) FROM sqlite_master WHERE name = 'symbol_fts'`
+
 const queryFunctionNeighborhood = `
 SELECT 'caller' AS direction, n.id, n.name, n.package, n.file, n.line
 FROM edges e JOIN nodes n ON n.id = e.source
@@ -57,6 +81,52 @@ const maxPackageGraphEdges = 500
 const queryDashboardPackageGraph = `SELECT source, target, weight FROM dashboard_package_graph ORDER BY weight DESC LIMIT ?`
 const queryDashboardPackageTreemap = `SELECT package, file_count, function_count, total_loc, total_complexity, avg_complexity, max_complexity, type_count, interface_count FROM dashboard_package_treemap LIMIT ?`
 
+const queryPackageDepEdges = `SELECT source, target, weight FROM dashboard_package_graph ORDER BY weight DESC, source, target`
+const queryPackageDepNodes = `
+WITH pkgs AS (SELECT source AS package FROM dashboard_package_graph UNION SELECT target FROM dashboard_package_graph)
+SELECT p.package, COALESCE(t.function_count, 0), COALESCE(t.total_loc, 0),
+  COALESCE(s.instability, 0.5), COALESCE(s.abstractness, 0.0)
+FROM pkgs p
+LEFT JOIN dashboard_package_treemap t ON t.package = p.package
+LEFT JOIN v_package_stability s ON s.package = p.package
+ORDER BY p.package
+`
+
+// maxFileGraphEdges caps /api/files/graph edges, heaviest first.
+const maxFileGraphEdges = 1000
+
+// queryFileDepEdges lists v_file_deps edges with a file of package ?1 at
+// either end (every edge when ?1 is empty).
+const queryFileDepEdges = `
+SELECT d.source_file, d.target_file, d.call_count
+FROM v_file_deps d
+LEFT JOIN dashboard_file_heatmap s ON s.file = d.source_file
+LEFT JOIN dashboard_file_heatmap t ON t.file = d.target_file
+WHERE ?1 = '' OR s.package = ?1 OR t.package = ?1
+`
+
+const queryFileHeatmap = `SELECT file, COALESCE(package, ''), COALESCE(function_count, 0), COALESCE(total_loc, 0), COALESCE(total_complexity, 0), COALESCE(max_complexity, 0) FROM dashboard_file_heatmap`
+
+const queryPackageTreemapRow = `SELECT package, file_count, function_count, total_loc, total_complexity, avg_complexity, max_complexity, type_count, interface_count FROM dashboard_package_treemap WHERE package = ?`
+
+const queryPackageStabilityRow = `SELECT afferent_coupling, efferent_coupling, instability, abstractness FROM v_package_stability WHERE package = ?`
+
+const queryPackageCohesionRow = `SELECT total_calls, internal_calls, external_calls, cohesion_ratio FROM v_package_cohesion WHERE package = ?`
+
+const queryPackageTopFunctions = `
+SELECT function_id, name, package, file, COALESCE(line, 0), COALESCE(end_line, 0), signature,
+  COALESCE(complexity,0), COALESCE(loc,0), COALESCE(fan_in,0), COALESCE(fan_out,0),
+  COALESCE(num_params,0), COALESCE(num_locals,0), COALESCE(num_calls,0), COALESCE(num_branches,0), COALESCE(num_returns,0), COALESCE(finding_count,0), callers, callees
+FROM dashboard_function_detail
+WHERE package = ?
+ORDER BY complexity DESC, name
+LIMIT ?
+`
+
+const queryPackageTypes = `SELECT id, kind, name, file, line, end_line, package, parent_function, type_info FROM nodes WHERE kind = 'type_decl' AND package = ? ORDER BY name`
+
+const queryPackageEdges = `SELECT source, target, weight FROM dashboard_package_graph WHERE ?1 IN (source, target) AND source != target ORDER BY weight DESC, source, target`
+
 const queryDashboardFunctionDetailByPackage = `
 SELECT function_id, name, package, file, COALESCE(line, 0), COALESCE(end_line, 0), signature,
   COALESCE(complexity,0), COALESCE(loc,0), COALESCE(fan_in,0), COALESCE(fan_out,0),
@@ -68,6 +138,16 @@ ORDER BY name LIMIT 200
 
 const querySourceByFile = `SELECT file, content, package FROM sources WHERE file = ?`
 
+// querySourceNodes lists a file's node spans; the two %s are the col and
+// end_col expressions (NULL when the DB predates those columns).
+const querySourceNodes = `
+SELECT id, kind, package, line, %s, end_line, %s FROM nodes
+WHERE file = ? AND line IS NOT NULL
+ORDER BY line, 5, id
+`
+
+const queryNodeColumns = `SELECT name FROM pragma_table_info('nodes')`
+
 const queryBackwardSlice = `
 WITH RECURSIVE slice(id, depth) AS (
   SELECT ?, 0
@@ -97,3 +177,121 @@ LIMIT ?
 `
 
 // querySliceEdges is built dynamically with placeholders for node IDs (see db_slice.go).
+
+// Saved queries: SQL from the queries table, bindings declared in
+// query_params (absent in older DBs).
+const querySavedSQL = `SELECT sql FROM queries WHERE name = ?`
+
+const queryParamsExists = `SELECT COUNT(*) FROM sqlite_master WHERE name = 'query_params'`
+
+const querySavedParams = `SELECT param_name, type, required FROM query_params WHERE query_name = ?`
+
+// Taint paths: sink reaches from taint_flow_state, each walked back to its
+// source through taint_path_edges (BFS parent pointers). Older DBs lack the
+// parent table.
+const queryTaintPathEdgesExists = `SELECT COUNT(*) FROM sqlite_master WHERE name = 'taint_path_edges'`
+
+const queryTaintSinks = `
+SELECT source_id, node_id, COALESCE(source_category, ''), min_hops FROM taint_flow_state
+WHERE label = 'sink_reached'
+ORDER BY min_hops, node_id, source_id
+LIMIT ?
+`
+
+const queryTaintPath = `
+WITH RECURSIVE walk(id, step) AS (
+  SELECT ?2, 0
+  UNION ALL
+  SELECT p.parent_id, w.step + 1
+  FROM walk w JOIN taint_path_edges p ON p.source_id = ?1 AND p.node_id = w.id
+  WHERE w.step < 64
+)
+SELECT n.id, n.kind, n.name, n.file, n.line, n.end_line, n.package, n.parent_function, n.type_info
+FROM walk w JOIN nodes n ON n.id = w.id
+ORDER BY w.step DESC
+`
+
+// Findings list: filters and ORDER BY are assembled in DB.Findings. Package
+// comes from the finding's node, falling back to details.package for
+// findings attached to non-node IDs.
+const findingsFrom = `FROM findings f LEFT JOIN nodes n ON n.id = f.node_id`
+
+const findingPackageExpr = `COALESCE(n.package, json_extract(f.details, '$.package'))`
+
+const findingSeverityRank = `CASE f.severity WHEN 'error' THEN 0 WHEN 'warning' THEN 1 WHEN 'info' THEN 2 ELSE 3 END`
+
+const queryFindingsSelect = `
+SELECT f.rowid, f.category, f.severity, f.node_id, f.file, f.line, f.message, f.details, ` + findingPackageExpr + `
+` + findingsFrom
+
+// findingSortOrders is the allowlist of ?sort= values; user input never
+// reaches the ORDER BY clause directly.
+var findingSortOrders = map[string]string{
+	"severity": findingSeverityRank + `, f.category, f.file, f.line`,
+	"category": `f.category, ` + findingSeverityRank + `, f.file, f.line`,
+	"package":  findingPackageExpr + `, f.file, f.line`,
+	"file":     `f.file, f.line`,
+	"line":     `f.line, f.file`,
+}
+
+// Location lookups (?1 = file, ?2 = line, ?3 = col, ?4 = enclosing function).
+// Innermost = latest start. There is no end_col, so a statement covers every
+// column of its last line.
+const locationNodeColumns = `n.id, n.kind, n.name, n.file, n.line, n.end_line, n.package, n.parent_function, n.type_info`
+
+const queryLocationFunction = `
+SELECT ` + locationNodeColumns + ` FROM nodes n
+WHERE n.kind = 'function' AND n.file = ?1
+  AND (n.line < ?2 OR (n.line = ?2 AND n.col <= ?3))
+  AND COALESCE(n.end_line, n.line) >= ?2
+ORDER BY n.line DESC, n.col DESC
+LIMIT 1
+`
+
+// queryLocationStatement only answers when some node of the function starts
+// on the line, so comment and blank lines resolve to the function alone.
+const queryLocationStatement = `
+SELECT ` + locationNodeColumns + ` FROM nodes n
+WHERE n.file = ?1 AND n.parent_function = ?4
+  AND n.kind IN ('assign', 'call', 'return', 'if', 'for', 'switch', 'select', 'case',
+                 'go', 'defer', 'send', 'branch', 'inc_dec', 'local', 'label')
+  AND (n.line < ?2 OR (n.line = ?2 AND n.col <= ?3))
+  AND COALESCE(n.end_line, n.line) >= ?2
+  AND EXISTS (SELECT 1 FROM nodes o WHERE o.file = ?1 AND o.line = ?2 AND o.parent_function = ?4)
+ORDER BY n.line DESC, n.col DESC
+LIMIT 1
+`
+
+// queryLocationReference resolves an identifier under the cursor to its
+// definition through xrefs (go-to-definition).
+const queryLocationReference = `
+SELECT ` + locationNodeColumns + ` FROM nodes u
+JOIN xrefs x ON x.use_id = u.id
+JOIN nodes n ON n.id = x.def_id
+WHERE u.file = ?1 AND u.line = ?2 AND u.col <= ?3 AND ?3 < u.col + length(u.name)
+ORDER BY u.col DESC
+LIMIT 1
+`
+
+// queryLocationDeclaration finds a symbol declared on the line at or before the cursor.
+const queryLocationDeclaration = `
+SELECT ` + locationNodeColumns + ` FROM symbol_index s
+JOIN nodes n ON n.id = s.id
+WHERE s.file = ?1 AND s.line = ?2 AND n.col <= ?3
+ORDER BY n.col DESC
+LIMIT 1
+`
+
+// Schema listing: cpg_manifest when the DB has one, else a bare sqlite_master
+// listing (older DBs) with unknown counts and version.
+const queryManifestExists = `SELECT COUNT(*) FROM sqlite_master WHERE name = 'cpg_manifest'`
+
+const querySchemaManifest = `
+SELECT name, object_type, row_count, generator_version FROM cpg_manifest ORDER BY object_type, name
+`
+
+const querySchemaMaster = `
+SELECT name, type, NULL, NULL FROM sqlite_master
+WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+ORDER BY type, name
+`
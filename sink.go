@@ -0,0 +1,33 @@
+package main
+
+// CPGSink is an output backend for a finished CPG. WriteCPG calls the Write
+// methods once each, in order, then Finalize; a sink that returns an error
+// from a Write method has released its resources and Finalize is not called.
+//
+// SQLiteSink (used by WriteDB), GobSink (WriteGob, -format gob) and
+// ParquetSink (WriteParquet, -format parquet) are the backends today; others
+// (Postgres, in-memory) implement the same calls.
+type CPGSink interface {
+	WriteNodes(nodes []Node) error
+	WriteEdges(edges []Edge) error
+	WriteSources(sources map[string]string) error
+	WriteMetrics(metrics map[string]*Metrics) error
+	Finalize() error
+}
+
+// WriteCPG streams cpg into sink.
+func WriteCPG(sink CPGSink, cpg *CPG) error {
+	if err := sink.WriteNodes(cpg.Nodes); err != nil {
+		return err
+	}
+	if err := sink.WriteEdges(cpg.Edges); err != nil {
+		return err
+	}
+	if err := sink.WriteSources(cpg.Sources); err != nil {
+		return err
+	}
+	if err := sink.WriteMetrics(cpg.Metrics); err != nil {
+		return err
+	}
+	return sink.Finalize()
+}
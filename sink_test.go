@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+// countingSink is an in-memory CPGSink that records what it was given.
+type countingSink struct {
+	nodes, edges, sources, metrics int
+	finalized                      bool
+}
+
+func (s *countingSink) WriteNodes(nodes []Node) error { s.nodes += len(nodes); return nil }
+func (s *countingSink) WriteEdges(edges []Edge) error { s.edges += len(edges); return nil }
+func (s *countingSink) WriteSources(sources map[string]string) error {
+	s.sources += len(sources)
+	return nil
+}
+func (s *countingSink) WriteMetrics(metrics map[string]*Metrics) error {
+	s.metrics += len(metrics)
+	return nil
+}
+func (s *countingSink) Finalize() error { s.finalized = true; return nil }
+
+func TestWriteCPGToInMemorySink(t *testing.T) {
+	cpg := buildTestCPG(t, `package fixture
+
+func Add(a, b int) int { return a + b }
+
+func Twice(x int) int { return Add(x, x) }
+`)
+	var sink countingSink
+	if err := WriteCPG(&sink, cpg); err != nil {
+		t.Fatalf("WriteCPG: %v", err)
+	}
+	if sink.nodes != len(cpg.Nodes) || sink.edges != len(cpg.Edges) {
+		t.Errorf("sink got %d nodes, %d edges; want %d, %d", sink.nodes, sink.edges, len(cpg.Nodes), len(cpg.Edges))
+	}
+	if sink.sources != 1 || sink.metrics != 2 {
+		t.Errorf("sink got %d sources, %d metrics; want 1, 2", sink.sources, sink.metrics)
+	}
+	if !sink.finalized {
+		t.Error("Finalize not called")
+	}
+}
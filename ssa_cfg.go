@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"go/token"
 	"go/types"
+	"runtime"
+	"slices"
+	"sync"
 
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
@@ -15,11 +20,21 @@ type SSAResult struct {
 	AllFuncs map[*ssa.Function]bool
 }
 
-// BuildSSA constructs the SSA representation from loaded packages.
-func BuildSSA(pkgs []*packages.Package, prog *Progress) *SSAResult {
+// BuildSSA constructs the SSA representation from loaded packages, checking
+// ctx between packages.
+func BuildSSA(ctx context.Context, pkgs []*packages.Package, prog *Progress) (*SSAResult, error) {
+	if !emitFilter.wantsSSA() {
+		prog.Log("Skipping SSA (no SSA-derived node or edge kinds requested)")
+		return &SSAResult{AllFuncs: map[*ssa.Function]bool{}}, nil
+	}
 	prog.Log("Building SSA...")
 
 	ssaProg, ssaPkgs := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	if flagScope != "" {
+		// Loaded without dependencies (-scope): the imports only have types,
+		// so create body-less packages for them, as ssautil.BuildPackage does.
+		createImportPackages(ssaProg, pkgs)
+	}
 	var ssaFailed int
 	for i, sp := range ssaPkgs {
 		if sp == nil && i < len(pkgs) {
@@ -30,7 +45,27 @@ func BuildSSA(pkgs []*packages.Package, prog *Progress) *SSAResult {
 	if ssaFailed > 0 {
 		prog.Log("Warning: %d packages failed SSA construction", ssaFailed)
 	}
-	ssaProg.Build()
+	// Program.Build, but checking ctx before starting each package: the
+	// same GOMAXPROCS-bounded parallelism, minus the packages not yet begun
+	// when ctx is cancelled.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, sp := range ssaProg.AllPackages() {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sp.Build()
+			<-sem
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("build SSA: %w", err)
+	}
 
 	allFuncs := ssautil.AllFunctions(ssaProg)
 
@@ -52,6 +87,30 @@ func BuildSSA(pkgs []*packages.Package, prog *Progress) *SSAResult {
 	return &SSAResult{
 		Prog:     ssaProg,
 		AllFuncs: allFuncs,
+	}, nil
+}
+
+// createImportPackages creates an SSA package, without function bodies, for
+// every package transitively imported by pkgs that has none yet.
+func createImportPackages(ssaProg *ssa.Program, pkgs []*packages.Package) {
+	seen := make(map[*types.Package]bool)
+	var visit func(p *types.Package)
+	visit = func(p *types.Package) {
+		for _, imp := range p.Imports() {
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+			if ssaProg.Package(imp) == nil {
+				ssaProg.CreatePackage(imp, nil, nil, true)
+			}
+			visit(imp)
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.Types != nil {
+			visit(pkg.Types)
+		}
 	}
 }
 
@@ -64,6 +123,9 @@ func ExtractCFGAndDFG(
 	cpg *CPG,
 	prog *Progress,
 ) {
+	if !emitFilter.Node("basic_block") && !emitFilter.AnyEdge(cfgPhaseEdges...) {
+		return
+	}
 	prog.Log("Extracting CFG + DFG...")
 
 	var cfgEdges, dfgEdges, bbNodes, captureEdges int
@@ -228,7 +290,7 @@ func ExtractCFGAndDFG(
 						continue
 					}
 
-					props := map[string]any{}
+					props := map[string]any{"confidence": "precise"}
 					if name := ssaValueName(val); name != "" {
 						props["var_name"] = name
 					}
@@ -257,6 +319,9 @@ func ExtractChannelFlow(
 	cpg *CPG,
 	prog *Progress,
 ) {
+	if !emitFilter.AnyEdge(chanPhaseEdges...) {
+		return
+	}
 	prog.Log("Extracting channel flow edges...")
 
 	var chanFlowEdges int
@@ -432,7 +497,10 @@ func chanFollowCallArgs(
 }
 
 // ExtractPanicRecover connects panic() calls to recover() calls within the same
-// function scope (including deferred closures) via panic_recover edges.
+// function scope (including deferred closures) via panic_recover edges. It
+// also records the package-local type a panic() raises (panic_type) and the
+// types a recover() result is asserted to (recovers_type), which pair up
+// deliberate panic-based control flow across functions.
 func ExtractPanicRecover(
 	ssaResult *SSAResult,
 	fset *token.FileSet,
@@ -441,9 +509,15 @@ func ExtractPanicRecover(
 	cpg *CPG,
 	prog *Progress,
 ) {
+	if !emitFilter.AnyEdge(panicPhaseEdges...) {
+		return
+	}
 	prog.Log("Extracting panic/recover flow edges...")
 
-	var panicRecoverEdges int
+	var panicRecoverEdges, errorRecoveryEdges int
+	recoveringFuncs := make(map[string]bool)
+	panicTypes := make(map[string]string)
+	recoverTypes := make(map[string][]string)
 
 	for fn := range ssaResult.AllFuncs {
 		if fn.Pkg == nil || fn.Synthetic != "" {
@@ -457,6 +531,8 @@ func ExtractPanicRecover(
 		var panicIDs []string
 		// Find all recover sites in deferred closures of this function
 		var recoverIDs []string
+		// Set when a deferred recover assigns one of fn's named results
+		var recoversToError bool
 
 		for _, block := range fn.Blocks {
 			for _, instr := range block.Instrs {
@@ -466,6 +542,9 @@ func ExtractPanicRecover(
 					if file != "" {
 						if id := posLookup.Get(file, line, col); id != "" {
 							panicIDs = append(panicIDs, id)
+							if t := panicLocalType(inst, fn); t != "" {
+								panicTypes[id] = t
+							}
 						}
 					}
 				case *ssa.Call:
@@ -475,6 +554,9 @@ func ExtractPanicRecover(
 						if file != "" {
 							if id := posLookup.Get(file, line, col); id != "" {
 								recoverIDs = append(recoverIDs, id)
+								if ts := recoveredTypes(inst); len(ts) > 0 {
+									recoverTypes[id] = ts
+								}
 							}
 						}
 					}
@@ -486,7 +568,20 @@ func ExtractPanicRecover(
 					//   3. defer recover()                — direct builtin call
 					deferredFn := deferTarget(inst)
 					if deferredFn != nil {
+						n := len(recoverIDs)
 						collectRecoverIDs(deferredFn, fset, posLookup, &recoverIDs)
+						if mc, ok := inst.Call.Value.(*ssa.MakeClosure); ok && len(recoverIDs) > n {
+							for _, resultID := range recoveredResultIDs(fn, mc, deferredFn, fset, cpg) {
+								for _, recoverID := range recoverIDs[n:] {
+									cpg.AddEdge(Edge{
+										Source: recoverID, Target: resultID,
+										Kind: "error_recovery",
+									})
+									errorRecoveryEdges++
+								}
+								recoversToError = true
+							}
+						}
 					} else if b, ok := inst.Call.Value.(*ssa.Builtin); ok && b.Name() == "recover" {
 						// Pattern 3: defer recover() — the defer itself is the recover site
 						file, line, col := instrPos(inst, fset)
@@ -510,9 +605,167 @@ func ExtractPanicRecover(
 				panicRecoverEdges++
 			}
 		}
+
+		if recoversToError {
+			if funcID := ssaFuncNodeID(fn, fset, funcLookup); funcID != "" {
+				recoveringFuncs[funcID] = true
+			}
+		}
+	}
+
+	// Mark functions whose deferred recover rewrites a named result, and the
+	// panic/recover sites with the types they raise and assert
+	for i := range cpg.Nodes {
+		n := &cpg.Nodes[i]
+		if n.Kind == "function" && recoveringFuncs[n.ID] {
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["recovers_to_error"] = true
+		}
+		if t, ok := panicTypes[n.ID]; ok {
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["panic_type"] = t
+		}
+		if ts, ok := recoverTypes[n.ID]; ok {
+			if n.Properties == nil {
+				n.Properties = map[string]any{}
+			}
+			n.Properties["recovers_type"] = ts
+		}
+	}
+
+	prog.Log("Created %d panic/recover flow edges, %d error_recovery edges", panicRecoverEdges, errorRecoveryEdges)
+}
+
+// panicLocalType returns the type of the value passed to panic() when it is a
+// named type (or pointer to one) declared in fn's own package, e.g. the
+// parseError in panic(parseError{msg}). Strings, errors from other packages
+// and interface values yield "".
+func panicLocalType(inst *ssa.Panic, fn *ssa.Function) string {
+	mi, ok := inst.X.(*ssa.MakeInterface)
+	if !ok {
+		return ""
+	}
+	t := mi.X.Type()
+	base := t
+	if ptr, ok := base.(*types.Pointer); ok {
+		base = ptr.Elem()
+	}
+	named, ok := base.(*types.Named)
+	if !ok || named.Obj().Pkg() != fn.Pkg.Pkg {
+		return ""
+	}
+	return types.TypeString(t, nil)
+}
+
+// recoveredTypes returns the types the result of a recover() call is asserted
+// to, by r.(T), r.(T) with comma-ok, or a type switch on r.
+func recoveredTypes(call *ssa.Call) []string {
+	refs := call.Referrers()
+	if refs == nil {
+		return nil
+	}
+	var ts []string
+	for _, ref := range *refs {
+		ta, ok := ref.(*ssa.TypeAssert)
+		if !ok {
+			continue
+		}
+		if t := types.TypeString(ta.AssertedType, nil); !slices.Contains(ts, t) {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// recoveredResultIDs returns the CPG node IDs of fn's named results that the
+// deferred closure assigns after calling recover(), e.g.
+//
+//	defer func() { if r := recover(); r != nil { err = fmt.Errorf("%v", r) } }()
+//
+// Captured named results reach the closure as free variables bound by mc; a
+// store through one counts when its block is dominated by a recover() call.
+func recoveredResultIDs(fn *ssa.Function, mc *ssa.MakeClosure, closure *ssa.Function, fset *token.FileSet, cpg *CPG) []string {
+	// Captured named results are heap Allocs at the result identifier's position.
+	resultAt := make(map[token.Pos]*types.Var)
+	results := fn.Signature.Results()
+	for i := 0; i < results.Len(); i++ {
+		if r := results.At(i); r.Name() != "" && r.Name() != "_" {
+			resultAt[r.Pos()] = r
+		}
+	}
+	if len(resultAt) == 0 {
+		return nil
+	}
+	freeVarResult := make(map[*ssa.FreeVar]*types.Var)
+	for i, fv := range closure.FreeVars {
+		if i >= len(mc.Bindings) {
+			break
+		}
+		if alloc, ok := mc.Bindings[i].(*ssa.Alloc); ok && alloc.Pos().IsValid() {
+			if r, ok := resultAt[alloc.Pos()]; ok {
+				freeVarResult[fv] = r
+			}
+		}
+	}
+	if len(freeVarResult) == 0 {
+		return nil
 	}
 
-	prog.Log("Created %d panic/recover flow edges", panicRecoverEdges)
+	var recoverBlocks []*ssa.BasicBlock
+	for _, block := range closure.Blocks {
+		for _, instr := range block.Instrs {
+			if call, ok := instr.(*ssa.Call); ok {
+				if b, ok := call.Call.Value.(*ssa.Builtin); ok && b.Name() == "recover" {
+					recoverBlocks = append(recoverBlocks, block)
+				}
+			}
+		}
+	}
+
+	var ids []string
+	seen := make(map[*types.Var]bool)
+	for _, block := range closure.Blocks {
+		for _, instr := range block.Instrs {
+			store, ok := instr.(*ssa.Store)
+			if !ok {
+				continue
+			}
+			fv, ok := store.Addr.(*ssa.FreeVar)
+			if !ok {
+				continue
+			}
+			res, ok := freeVarResult[fv]
+			if !ok || seen[res] {
+				continue
+			}
+			dominated := false
+			for _, rb := range recoverBlocks {
+				if rb.Dominates(block) {
+					dominated = true
+					break
+				}
+			}
+			if !dominated {
+				continue
+			}
+			p := fset.Position(res.Pos())
+			relFile := modSet.RelFile(p.Filename)
+			if relFile == "" {
+				continue
+			}
+			id := StmtID(modSet.RelPkg(fn.Pkg.Pkg.Path()), BaseName(relFile), p.Line, p.Column, "result")
+			if _, exists := cpg.nodeSeen[id]; !exists {
+				continue
+			}
+			seen[res] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // deferTarget extracts the SSA function from a Defer instruction.
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// StabilizeIDs rewrites position-based node IDs into position-independent ones
+// for -stable-ids mode, so that shifting lines does not change the graph:
+//
+//   - top-level functions/methods: pkg::Recv.Name#<signature hash>
+//   - top-level types and package-level vars/consts: pkg::type.Name, pkg::var.Name
+//   - every other AST node: a structural path under its AST parent, indexed by
+//     kind in source order (e.g. scrape::Manager.Run#1a2b3c4d/block[0]/if[1]/call[0])
+//   - SSA basic blocks: <function stable ID>::bbN
+//
+// Package, file, META_DATA and ext::/int:: stub IDs are already position-free
+// and are kept. Line/col columns are untouched. Must run after every phase that
+// adds nodes or edges (the pipeline uses position-based IDs internally).
+func StabilizeIDs(cpg *CPG, prog *Progress) {
+	byID := make(map[string]*Node, len(cpg.Nodes))
+	for i := range cpg.Nodes {
+		byID[cpg.Nodes[i].ID] = &cpg.Nodes[i]
+	}
+
+	// AST parent: first ast edge into each node wins, mirroring AddNode.
+	parent := make(map[string]string, len(cpg.Nodes))
+	children := make(map[string][]string)
+	for _, e := range cpg.Edges {
+		if e.Kind != "ast" {
+			continue
+		}
+		if _, seen := parent[e.Target]; seen || e.Source == e.Target {
+			continue
+		}
+		if byID[e.Source] == nil || byID[e.Target] == nil {
+			continue
+		}
+		parent[e.Target] = e.Source
+		children[e.Source] = append(children[e.Source], e.Target)
+	}
+
+	// Sibling index per (parent, segment) in source order.
+	siblingIndex := make(map[string]int, len(parent))
+	for p, kids := range children {
+		sort.Slice(kids, func(i, j int) bool {
+			a, b := byID[kids[i]], byID[kids[j]]
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			if a.Col != b.Col {
+				return a.Col < b.Col
+			}
+			return a.ID < b.ID
+		})
+		counts := make(map[string]int)
+		for _, k := range kids {
+			seg := idSegment(byID[k])
+			siblingIndex[k] = counts[seg]
+			counts[seg]++
+		}
+		children[p] = kids
+	}
+
+	remap := make(map[string]string, len(cpg.Nodes))
+	used := make(map[string]bool, len(cpg.Nodes))
+	visiting := make(map[string]bool)
+
+	var stable func(id string) string
+	stable = func(id string) string {
+		if s, ok := remap[id]; ok {
+			return s
+		}
+		n := byID[id]
+		if n == nil || visiting[id] {
+			return id
+		}
+		visiting[id] = true
+		defer delete(visiting, id)
+
+		var s string
+		p, hasParent := parent[id]
+		switch {
+		case n.Kind == "package" || n.Kind == "file" || n.Kind == "meta_data" || strings.HasPrefix(id, "ext::") || strings.HasPrefix(id, "int::"):
+			s = id
+		case n.Kind == "basic_block" && n.ParentFunction != "":
+			s = fmt.Sprintf("%s::bb%v", stable(n.ParentFunction), n.Properties["index"])
+		case hasParent && strings.HasPrefix(p, "file::") && n.Kind == "function":
+			s = fmt.Sprintf("%s::%s#%s", n.Package, n.Name, sigHash(n.TypeInfo))
+		case hasParent && strings.HasPrefix(p, "file::") && n.Kind == "type_decl":
+			s = n.Package + "::type." + n.Name
+		case hasParent && strings.HasPrefix(p, "file::") && (n.Kind == "global_var" || n.Kind == "global_const"):
+			s = n.Package + "::var." + n.Name
+		case hasParent:
+			s = fmt.Sprintf("%s/%s[%d]", stable(p), idSegment(n), siblingIndex[id])
+		default:
+			s = id
+		}
+		// Disambiguate rare collisions (e.g. several init funcs) deterministically.
+		if used[s] {
+			base := s
+			for i := 2; used[s]; i++ {
+				s = fmt.Sprintf("%s~%d", base, i)
+			}
+		}
+		used[s] = true
+		remap[id] = s
+		return s
+	}
+
+	// Visit in (file, line, col) order so collision suffixes follow source order.
+	order := make([]*Node, 0, len(cpg.Nodes))
+	for i := range cpg.Nodes {
+		order = append(order, &cpg.Nodes[i])
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Col < b.Col
+	})
+	for _, n := range order {
+		stable(n.ID)
+	}
+
+	rewrite := func(id string) string {
+		if s, ok := remap[id]; ok {
+			return s
+		}
+		return id
+	}
+
+	changed := 0
+	cpg.nodeSeen = make(map[string]struct{}, len(cpg.Nodes))
+	for i := range cpg.Nodes {
+		n := &cpg.Nodes[i]
+		if s := rewrite(n.ID); s != n.ID {
+			n.ID = s
+			changed++
+		}
+		n.ParentFunction = rewrite(n.ParentFunction)
+		cpg.nodeSeen[n.ID] = struct{}{}
+	}
+	cpg.edgeSeen = make(map[edgeKey]struct{}, len(cpg.Edges))
+	for i := range cpg.Edges {
+		e := &cpg.Edges[i]
+		e.Source = rewrite(e.Source)
+		e.Target = rewrite(e.Target)
+		// Edge properties that carry node IDs
+		if m, ok := e.Properties["method"].(string); ok {
+			e.Properties["method"] = rewrite(m)
+		}
+		cpg.edgeSeen[edgeKey{e.Source, e.Target, e.Kind}] = struct{}{}
+	}
+	metrics := make(map[string]*Metrics, len(cpg.Metrics))
+	for id, m := range cpg.Metrics {
+		m.FunctionID = rewrite(id)
+		metrics[m.FunctionID] = m
+	}
+	cpg.Metrics = metrics
+
+	prog.Log("Stable IDs: rewrote %d of %d node IDs", changed, len(cpg.Nodes))
+}
+
+// idSegment names a node's step in a structural path.
+func idSegment(n *Node) string {
+	if n.Kind == "function" {
+		return "func_lit"
+	}
+	return n.Kind
+}
+
+// sigHash returns a short hash of a function's type signature so overloads
+// across receivers/build variants with the same name stay distinct.
+func sigHash(sig string) string {
+	h := fnv.New32a()
+	h.Write([]byte(sig))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
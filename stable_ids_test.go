@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const stableFixture = `package fixture
+
+type Store struct{ items map[string]int }
+
+func (s *Store) Get(k string) (int, bool) {
+	v, ok := s.items[k]
+	return v, ok
+}
+
+func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		if x > 0 {
+			total += x
+		}
+	}
+	f := func() int { return total }
+	return f()
+}
+`
+
+// stableIDSet returns the sorted node IDs and edge keys of a fixture after
+// StabilizeIDs.
+func stableIDSet(t *testing.T, src string) (nodes, edges []string) {
+	t.Helper()
+	cpg := buildTestCPG(t, src)
+	StabilizeIDs(cpg, NewProgress(false))
+	for _, n := range cpg.Nodes {
+		nodes = append(nodes, n.ID)
+	}
+	for _, e := range cpg.Edges {
+		edges = append(edges, e.Source+" -"+e.Kind+"-> "+e.Target)
+	}
+	sort.Strings(nodes)
+	sort.Strings(edges)
+	return nodes, edges
+}
+
+func TestStableIDsSurviveLineShift(t *testing.T) {
+	beforeNodes, beforeEdges := stableIDSet(t, stableFixture)
+	shifted := strings.Replace(stableFixture, "\nfunc Sum", "\n\nfunc Sum", 1)
+	afterNodes, afterEdges := stableIDSet(t, shifted)
+
+	if strings.Join(beforeNodes, "\n") != strings.Join(afterNodes, "\n") {
+		t.Errorf("node IDs changed after inserting a blank line:\nbefore %v\nafter  %v", beforeNodes, afterNodes)
+	}
+	if strings.Join(beforeEdges, "\n") != strings.Join(afterEdges, "\n") {
+		t.Errorf("edges changed after inserting a blank line")
+	}
+	for _, id := range beforeNodes {
+		if strings.Contains(id, "fixture.go:") {
+			t.Errorf("stable ID still carries a position: %q", id)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// caseBound is a case expression of a tagless switch comparing a plain
+// variable or field against a numeric constant: subject op value, with the
+// constant moved to the right.
+type caseBound struct {
+	subject string
+	op      token.Token // LSS, LEQ, GTR, GEQ or EQL
+	value   constant.Value
+}
+
+// contains reports whether every subject value satisfying c also satisfies b,
+// i.e. a case testing c after one testing b can never be taken.
+func (b caseBound) contains(c caseBound) bool {
+	if b.subject != c.subject {
+		return false
+	}
+	cmp := func(op token.Token) bool { return constant.Compare(c.value, op, b.value) }
+	switch b.op {
+	case token.GTR, token.GEQ:
+		inclusive := b.op == token.GEQ
+		switch c.op {
+		case token.GTR:
+			return cmp(token.GTR) || cmp(token.EQL)
+		case token.GEQ, token.EQL:
+			return cmp(token.GTR) || inclusive && cmp(token.EQL)
+		}
+	case token.LSS, token.LEQ:
+		inclusive := b.op == token.LEQ
+		switch c.op {
+		case token.LSS:
+			return cmp(token.LSS) || cmp(token.EQL)
+		case token.LEQ, token.EQL:
+			return cmp(token.LSS) || inclusive && cmp(token.EQL)
+		}
+	case token.EQL:
+		return c.op == token.EQL && cmp(token.EQL)
+	}
+	return false
+}
+
+// flipped mirrors a comparison operator for swapped operands (5 < x is x > 5).
+var flipped = map[token.Token]token.Token{
+	token.LSS: token.GTR, token.LEQ: token.GEQ,
+	token.GTR: token.LSS, token.GEQ: token.LEQ,
+	token.EQL: token.EQL,
+}
+
+// caseBoundOf returns e as a caseBound, if it is one.
+func caseBoundOf(info *types.Info, e ast.Expr) (caseBound, bool) {
+	bin, ok := ast.Unparen(e).(*ast.BinaryExpr)
+	if !ok {
+		return caseBound{}, false
+	}
+	if _, ok := flipped[bin.Op]; !ok {
+		return caseBound{}, false
+	}
+	numeric := func(e ast.Expr) constant.Value {
+		v := info.Types[e].Value
+		if v == nil || (v.Kind() != constant.Int && v.Kind() != constant.Float) {
+			return nil
+		}
+		return v
+	}
+	plain := func(e ast.Expr) bool {
+		for {
+			switch x := ast.Unparen(e).(type) {
+			case *ast.Ident:
+				return info.Types[x].Value == nil
+			case *ast.SelectorExpr:
+				e = x.X
+			default:
+				return false
+			}
+		}
+	}
+	switch {
+	case plain(bin.X) && numeric(bin.Y) != nil:
+		return caseBound{types.ExprString(bin.X), bin.Op, numeric(bin.Y)}, true
+	case plain(bin.Y) && numeric(bin.X) != nil:
+		return caseBound{types.ExprString(bin.Y), flipped[bin.Op], numeric(bin.X)}, true
+	}
+	return caseBound{}, false
+}
+
+// deadSwitchCases finds case clauses of s that can never be selected because
+// earlier clauses always match first: each of the clause's expressions is a
+// constant equal to an earlier clause's constant (in a tagless switch, e.g.
+// two bool constants that are both true) or, in a tagless switch, a range
+// comparison contained in an earlier one (n > 100 after n > 10). A clause
+// with one live expression can still be selected and is not reported. Each
+// dead clause is returned with the properties that mark its case node:
+// dead_case ("duplicate" or "subsumed"), dead_value (the expressions) and
+// covered_by_line (the earlier clause covering its first expression).
+func deadSwitchCases(info *types.Info, fset *token.FileSet, s *ast.SwitchStmt) map[*ast.CaseClause]map[string]any {
+	type seenCase struct {
+		line  int
+		value constant.Value
+		bound caseBound
+		isBnd bool
+	}
+	var seen []seenCase
+	var dead map[*ast.CaseClause]map[string]any
+	for _, stmt := range s.Body.List {
+		cc, ok := stmt.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		line := fset.Position(cc.Case).Line
+		var props map[string]any
+		var values []string
+		live := false
+		clause := make([]seenCase, 0, len(cc.List))
+		for _, e := range cc.List {
+			sc := seenCase{line: line, value: info.Types[e].Value}
+			if s.Tag == nil {
+				sc.bound, sc.isBnd = caseBoundOf(info, e)
+			}
+			clause = append(clause, sc)
+			values = append(values, types.ExprString(e))
+			// Only earlier clauses pre-empt: the clause's own expressions
+			// all select it.
+			kind, coveredBy := "", 0
+			for _, prev := range seen {
+				switch {
+				case sc.value != nil && prev.value != nil && sc.value.Kind() == prev.value.Kind() &&
+					constant.Compare(sc.value, token.EQL, prev.value):
+					kind = "duplicate"
+				case sc.isBnd && prev.isBnd && prev.bound.contains(sc.bound):
+					kind = "subsumed"
+				default:
+					continue
+				}
+				coveredBy = prev.line
+				break
+			}
+			if kind == "" {
+				live = true
+			} else if props == nil {
+				props = map[string]any{"dead_case": kind, "covered_by_line": coveredBy}
+			}
+		}
+		seen = append(seen, clause...)
+		if live || props == nil {
+			continue
+		}
+		props["dead_value"] = strings.Join(values, ", ")
+		if dead == nil {
+			dead = make(map[*ast.CaseClause]map[string]any)
+		}
+		dead[cc] = props
+	}
+	return dead
+}
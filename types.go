@@ -1,8 +1,10 @@
 package main
 
 import (
+	"go/ast"
 	"go/token"
 	"go/types"
+	"sort"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -15,6 +17,9 @@ func ExtractTypeRelationships(
 	cpg *CPG,
 	prog *Progress,
 ) {
+	if !emitFilter.AnyEdge(typePhaseEdges...) {
+		return
+	}
 	prog.Log("Extracting type relationships...")
 
 	// Collect all named types and interfaces from known module packages
@@ -165,7 +170,118 @@ func ExtractTypeRelationships(
 		}
 	}
 
-	prog.Log("Created %d implements, %d embeds, %d alias_of, %d satisfies_method edges", implementsCount, embedsCount, aliasCount, satisfiesCount)
+	// Generic instantiations: type argument → constraint interface
+	typeParamCount := emitTypeParamImplements(pkgs, fset, posLookup, cpg, &satisfiesCount)
+
+	prog.Log("Created %d implements (%d via type params), %d embeds, %d alias_of, %d satisfies_method edges",
+		implementsCount+typeParamCount, typeParamCount, embedsCount, aliasCount, satisfiesCount)
+}
+
+// emitTypeParamImplements records the implements relationships established by
+// generic instantiations: for F[T fmt.Stringer] instantiated with Celsius, it
+// emits Celsius → fmt.Stringer tagged {"via":"type_param"}. Only named
+// method-set constraints count (not any/comparable or type-set unions), and
+// only module types as type arguments. External constraint interfaces get an
+// ext:: type_decl stub. Returns the number of new implements edges.
+func emitTypeParamImplements(
+	pkgs []*packages.Package,
+	fset *token.FileSet,
+	posLookup *PosLookup,
+	cpg *CPG,
+	satisfiesCount *int,
+) int {
+	nodeOf := func(obj types.Object) string {
+		if obj == nil || !obj.Pos().IsValid() {
+			return ""
+		}
+		pos := fset.Position(obj.Pos())
+		relFile := modSet.RelFile(pos.Filename)
+		if relFile == "" {
+			return ""
+		}
+		return posLookup.Get(relFile, pos.Line, pos.Column)
+	}
+
+	count := 0
+	for _, pkg := range pkgs {
+		// Map order is random; walk instantiations in source order.
+		idents := make([]*ast.Ident, 0, len(pkg.TypesInfo.Instances))
+		for ident := range pkg.TypesInfo.Instances {
+			idents = append(idents, ident)
+		}
+		sort.Slice(idents, func(i, j int) bool { return idents[i].Pos() < idents[j].Pos() })
+		for _, ident := range idents {
+			inst := pkg.TypesInfo.Instances[ident]
+			var tparams *types.TypeParamList
+			switch t := inst.Type.(type) {
+			case *types.Signature:
+				if fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func); ok {
+					tparams = fn.Origin().Type().(*types.Signature).TypeParams()
+				}
+			case *types.Named:
+				tparams = t.Origin().TypeParams()
+			}
+			if tparams == nil || inst.TypeArgs == nil || tparams.Len() != inst.TypeArgs.Len() {
+				continue
+			}
+
+			for i := range tparams.Len() {
+				constraint, ok := types.Unalias(tparams.At(i).Constraint()).(*types.Named)
+				if !ok {
+					continue
+				}
+				iface, ok := constraint.Underlying().(*types.Interface)
+				if !ok || !iface.IsMethodSet() || iface.NumMethods() == 0 {
+					continue
+				}
+
+				arg := types.Unalias(inst.TypeArgs.At(i))
+				if ptr, ok := arg.(*types.Pointer); ok {
+					arg = types.Unalias(ptr.Elem())
+				}
+				named, ok := arg.(*types.Named)
+				if !ok || types.IsInterface(named) {
+					continue
+				}
+				concreteID := nodeOf(named.Origin().Obj())
+				if concreteID == "" {
+					continue
+				}
+
+				ifaceID := nodeOf(constraint.Obj())
+				if ifaceID == "" {
+					if constraint.Obj().Pkg() == nil {
+						continue
+					}
+					ifaceID = "ext::" + constraint.Obj().Pkg().Path() + "." + constraint.Obj().Name()
+					cpg.AddNode(Node{
+						ID:       ifaceID,
+						Kind:     "type_decl",
+						Name:     constraint.Obj().Name(),
+						Package:  modSet.RelPkg(constraint.Obj().Pkg().Path()),
+						TypeInfo: iface.String(),
+						Properties: map[string]any{
+							"external":  true,
+							"full_name": constraint.Obj().Pkg().Path() + "." + constraint.Obj().Name(),
+						},
+					})
+				}
+
+				if _, dup := cpg.edgeSeen[edgeKey{concreteID, ifaceID, "implements"}]; dup {
+					continue
+				}
+				cpg.AddEdge(Edge{
+					Source:     concreteID,
+					Target:     ifaceID,
+					Kind:       "implements",
+					Properties: map[string]any{"via": "type_param"},
+				})
+				count++
+				emitSatisfiesMethod(named.Origin(), iface, fset, posLookup, cpg, satisfiesCount)
+			}
+		}
+	}
+	return count
 }
 
 // emitSatisfiesMethod connects each method on concreteType to the interface method
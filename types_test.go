@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestTypeParamImplements(t *testing.T) {
+	conn := buildTestDB(t, `package fixture
+
+import "fmt"
+
+type Celsius float64
+
+func (c Celsius) String() string { return fmt.Sprintf("%.1fC", float64(c)) }
+
+type Plain int
+
+func Show[T fmt.Stringer](x T) string { return x.String() }
+
+func Keep[T any](x T) T { return x }
+
+func Use() string {
+	_ = Keep(Plain(1))
+	return Show(Celsius(21))
+}
+`)
+	got := queryStrings(t, conn,
+		`SELECT s.name || '->' || e.target FROM edges e JOIN nodes s ON s.id = e.source
+		 WHERE e.kind = 'implements' AND json_extract(e.properties, '$.via') = 'type_param'`)
+	if len(got) != 1 || got[0] != "Celsius->ext::fmt.Stringer" {
+		t.Errorf("type_param implements edges = %v, want [Celsius->ext::fmt.Stringer]", got)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long a burst of file events must stay quiet before
+// -watch regenerates: a save, gofmt-on-save and a branch switch each arrive
+// as several events.
+const watchDebounce = 500 * time.Millisecond
+
+// watchRelevant reports whether a change to the file named path can alter the
+// CPG: Go sources and module/workspace files.
+func watchRelevant(path string) bool {
+	switch filepath.Base(path) {
+	case "go.mod", "go.sum", "go.work":
+		return true
+	}
+	return strings.HasSuffix(path, ".go")
+}
+
+// watchSkipDir reports whether a directory is never analyzed and so is not
+// watched: VCS metadata, vendored and test data trees, hidden and _ dirs
+// (which the go tool ignores too), and node_modules.
+func watchSkipDir(name string) bool {
+	switch name {
+	case "vendor", "testdata", "node_modules":
+		return true
+	}
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// addWatchTree watches root and every analyzable directory below it.
+// fsnotify watches are per directory, not recursive.
+func addWatchTree(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && watchSkipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
+
+// Watch calls regenerate after each debounced burst of relevant changes under
+// dirs until ctx is cancelled. Directories are watched rather than files, so
+// an editor's atomic save (write a temp file, rename it over the original)
+// shows up as a create or rename of the .go name and still triggers; new
+// directories are watched as they appear. A failed regeneration is logged and
+// the watch continues.
+func Watch(ctx context.Context, dirs []string, debounce time.Duration, regenerate func() error, prog *Progress) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	defer w.Close()
+	for _, dir := range dirs {
+		if err := addWatchTree(w, dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+	prog.Log("Watching %d directories for changes (Ctrl-C to stop)", len(w.WatchList()))
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	var changed []string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			prog.Log("Warning: watch: %v", err)
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() && !watchSkipDir(info.Name()) {
+					if err := addWatchTree(w, ev.Name); err != nil {
+						prog.Log("Warning: watch %s: %v", ev.Name, err)
+					}
+					continue
+				}
+			}
+			if ev.Op == fsnotify.Chmod || !watchRelevant(ev.Name) {
+				continue
+			}
+			changed = append(changed, ev.Name)
+			timer.Reset(debounce)
+		case <-timer.C:
+			prog.Log("Change detected (%s), regenerating...", watchSummary(changed))
+			changed = changed[:0]
+			if err := regenerate(); err != nil {
+				prog.Log("Warning: regeneration failed: %v", err)
+			}
+		}
+	}
+}
+
+// watchSummary names the first changed file and how many more changed.
+func watchSummary(changed []string) string {
+	seen := map[string]bool{}
+	var uniq []string
+	for _, p := range changed {
+		if !seen[p] {
+			seen[p] = true
+			uniq = append(uniq, p)
+		}
+	}
+	if len(uniq) == 1 {
+		return filepath.Base(uniq[0])
+	}
+	return fmt.Sprintf("%s and %d more", filepath.Base(uniq[0]), len(uniq)-1)
+}
+
+// runWatch regenerates outputPath on source changes until ctx is cancelled.
+// Each rebuild writes a sibling temp file and renames it into place, so a
+// reader never sees a half-written database; with serveAddr set the server
+// keeps answering from the old file during the rebuild and is restarted on
+// the new one.
+func runWatch(ctx context.Context, goworkPath, outputPath, bundlePath, serveAddr string, opts generateOptions, prog *Progress) error {
+	var (
+		stopSrv context.CancelFunc
+		srvDone chan error
+	)
+	startServer := func() {
+		if serveAddr == "" {
+			return
+		}
+		sctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- serveDB(sctx, outputPath, serveAddr, prog) }()
+		stopSrv, srvDone = cancel, done
+	}
+	stopServer := func() {
+		if stopSrv == nil {
+			return
+		}
+		stopSrv()
+		if err := <-srvDone; err != nil {
+			prog.Log("Warning: %v", err)
+		}
+		stopSrv, srvDone = nil, nil
+	}
+	startServer()
+	defer stopServer()
+
+	regenerate := func() error {
+		tmp := outputPath + ".watch.tmp"
+		if err := generate(ctx, goworkPath, tmp, opts, prog); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		stopServer()
+		defer startServer()
+		if err := os.Rename(tmp, outputPath); err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+		if bundlePath != "" {
+			return WriteBundleFile(bundlePath, outputPath, prog)
+		}
+		return nil
+	}
+
+	var dirs []string
+	for _, m := range modSet.Dirs() {
+		dirs = append(dirs, m.Dir)
+	}
+	return Watch(ctx, dirs, watchDebounce, regenerate, prog)
+}
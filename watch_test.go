@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWatchRegenerates(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "fixture.go")
+	if err := os.WriteFile(src, []byte("package fixture\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	regens := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, []string{dir}, 50*time.Millisecond, func() error {
+			regens <- struct{}{}
+			return nil
+		}, NewProgress(false))
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watch: %v", err)
+		}
+	}()
+	// Let the watcher register its directories before changing anything.
+	time.Sleep(200 * time.Millisecond)
+
+	expect := func(what string, want bool) {
+		t.Helper()
+		select {
+		case <-regens:
+			if !want {
+				t.Errorf("%s: unexpected regeneration", what)
+			}
+		case <-time.After(time.Second):
+			if want {
+				t.Errorf("%s: no regeneration", what)
+			}
+		}
+	}
+
+	// A burst of writes is debounced into one regeneration.
+	for i := range 3 {
+		if err := os.WriteFile(src, []byte("package fixture\n\nvar X = "+strconv.Itoa(i)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect("write", true)
+	expect("debounced write burst", false)
+
+	// Atomic save: write a temp file and rename it over the original.
+	tmp := filepath.Join(dir, ".fixture.go.swp")
+	if err := os.WriteFile(tmp, []byte("package fixture\n\nvar Y = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, src); err != nil {
+		t.Fatal(err)
+	}
+	expect("atomic save", true)
+
+	// Non-Go files are ignored; Go files in subdirectories are not.
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expect("non-Go file", false)
+	if err := os.WriteFile(filepath.Join(dir, "sub", "sub.go"), []byte("package sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expect("subdirectory file", true)
+}